@@ -181,9 +181,9 @@ func demoMerkleTree() {
 	}
 
 	// 获取完整性证明
-	_, proof, err := merkleTree.GetProof(1)
+	proof, err := merkleTree.GetProof(1)
 	if err == nil {
-		fmt.Printf("✓ 完整性证明长度: %d\n", len(proof))
+		fmt.Printf("✓ 完整性证明长度: %d\n", len(proof.Steps))
 	}
 
 	fmt.Printf("✓ 数据块数量: %d\n", merkleTree.Size())
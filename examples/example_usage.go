@@ -248,13 +248,13 @@ func exampleMerkleTree() {
 
 	// 获取完整性证明
 	fmt.Println("\n获取完整性证明 (file2.txt):")
-	_, proof, err := merkleTree.GetProof(1)
+	proof, err := merkleTree.GetProof(1)
 	if err != nil {
 		log.Printf("获取证明失败: %v", err)
 	} else {
-		fmt.Printf("  证明长度: %d\n", len(proof))
-		for i, p := range proof {
-			fmt.Printf("  步骤 %d: %s\n", i+1, p)
+		fmt.Printf("  证明长度: %d\n", len(proof.Steps))
+		for i, step := range proof.Steps {
+			fmt.Printf("  步骤 %d: %s (%s)\n", i+1, step.Hash, step.Side)
 		}
 	}
 
@@ -412,7 +412,7 @@ func exampleRealWorldScenarios() {
 
 	// 验证数据完整性
 	data := []byte("chunk2:data_chunk_2")
-	if hashes, proof, _ := merkleTree.GetProof(1); len(proof) > 0 {
+	if proof, err := merkleTree.GetProof(1); err == nil {
 		isValid := datastructures.VerifyProof(data, proof, rootHash)
 		fmt.Printf("  数据块验证: %v\n", map[bool]string{true: "通过", false: "失败"}[isValid])
 	}
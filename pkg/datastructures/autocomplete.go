@@ -0,0 +1,359 @@
+package datastructures
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// radixNode 基数树（压缩前缀树）节点
+type radixNode struct {
+	label     string       // 从父节点到本节点的边标签
+	children  []*radixNode // 子节点，按标签首字节排序
+	terminal  bool         // 是否为一个完整词条的结尾
+	weight    float64      // 词条权重（仅terminal节点有效）
+	maxWeight float64      // 子树中最大的词条权重，用于Top-K剪枝
+}
+
+// childIndex 查找首字节为b的子节点位置
+func (n *radixNode) childIndex(b byte) (int, bool) {
+	i := sort.Search(len(n.children), func(i int) bool {
+		return n.children[i].label[0] >= b
+	})
+	return i, i < len(n.children) && n.children[i].label[0] == b
+}
+
+// addChild 按序插入子节点
+func (n *radixNode) addChild(child *radixNode) {
+	i, _ := n.childIndex(child.label[0])
+	n.children = append(n.children, nil)
+	copy(n.children[i+1:], n.children[i:])
+	n.children[i] = child
+}
+
+// recompute 根据自身和子节点重新计算子树最大权重
+func (n *radixNode) recompute() {
+	first := true
+	if n.terminal {
+		n.maxWeight = n.weight
+		first = false
+	}
+	for _, c := range n.children {
+		if first || c.maxWeight > n.maxWeight {
+			n.maxWeight = c.maxWeight
+			first = false
+		}
+	}
+}
+
+// Completion 自动补全结果
+type Completion struct {
+	Term   string  // 完整词条
+	Weight float64 // 词条权重
+}
+
+// Autocomplete 基于基数树的自动补全组件
+// 特点：
+// - 公共前缀压缩存储，节省内存
+// - 每个节点维护子树最大权重，按权重最优优先搜索Top-K补全
+// - 支持权重的增量更新（Insert覆盖、Increment累加、Delete删除）
+// - 更新只需沿路径重新计算，O(词条长度 × 分支数)
+type Autocomplete struct {
+	root  *radixNode   // 根节点（标签为空）
+	mu    sync.RWMutex // 读写锁
+	count int64        // 词条数量
+}
+
+// NewAutocomplete 创建新的自动补全组件
+func NewAutocomplete() *Autocomplete {
+	return &Autocomplete{root: &radixNode{}}
+}
+
+// commonPrefixLen 返回两个字符串的公共前缀长度
+func commonPrefixLen(a, b string) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	i := 0
+	for i < n && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// upsert 查找或创建词条节点，返回从根到该节点的路径
+func (a *Autocomplete) upsert(term string) []*radixNode {
+	path := []*radixNode{a.root}
+	node := a.root
+	rest := term
+
+	for rest != "" {
+		idx, ok := node.childIndex(rest[0])
+		if !ok {
+			leaf := &radixNode{label: rest}
+			node.addChild(leaf)
+			return append(path, leaf)
+		}
+
+		child := node.children[idx]
+		p := commonPrefixLen(child.label, rest)
+		if p == len(child.label) {
+			node = child
+			rest = rest[p:]
+			path = append(path, node)
+			continue
+		}
+
+		// 边标签部分匹配，需要分裂出中间节点
+		mid := &radixNode{label: child.label[:p], children: []*radixNode{child}}
+		child.label = child.label[p:]
+		node.children[idx] = mid
+		mid.recompute()
+		path = append(path, mid)
+
+		if p == len(rest) {
+			return path
+		}
+		leaf := &radixNode{label: rest[p:]}
+		mid.addChild(leaf)
+		return append(path, leaf)
+	}
+
+	return path
+}
+
+// lookup 精确查找词条节点，返回路径（未找到时返回nil）
+func (a *Autocomplete) lookup(term string) []*radixNode {
+	path := []*radixNode{a.root}
+	node := a.root
+	rest := term
+
+	for rest != "" {
+		idx, ok := node.childIndex(rest[0])
+		if !ok || !strings.HasPrefix(rest, node.children[idx].label) {
+			return nil
+		}
+		node = node.children[idx]
+		rest = rest[len(node.label):]
+		path = append(path, node)
+	}
+
+	if !node.terminal {
+		return nil
+	}
+	return path
+}
+
+// refreshPath 自底向上重新计算路径上的最大权重
+func refreshPath(path []*radixNode) {
+	for i := len(path) - 1; i >= 0; i-- {
+		path[i].recompute()
+	}
+}
+
+// Insert 插入词条或覆盖已有词条的权重
+func (a *Autocomplete) Insert(term string, weight float64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if term == "" {
+		return fmt.Errorf("term cannot be empty")
+	}
+
+	path := a.upsert(term)
+	node := path[len(path)-1]
+	if !node.terminal {
+		node.terminal = true
+		a.count++
+	}
+	node.weight = weight
+	refreshPath(path)
+
+	return nil
+}
+
+// Increment 增量更新词条权重，词条不存在时以delta为初始权重插入
+// 返回更新后的权重
+func (a *Autocomplete) Increment(term string, delta float64) (float64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if term == "" {
+		return 0, fmt.Errorf("term cannot be empty")
+	}
+
+	path := a.upsert(term)
+	node := path[len(path)-1]
+	if !node.terminal {
+		node.terminal = true
+		node.weight = 0
+		a.count++
+	}
+	node.weight += delta
+	refreshPath(path)
+
+	return node.weight, nil
+}
+
+// Weight 查询词条权重
+func (a *Autocomplete) Weight(term string) (float64, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	path := a.lookup(term)
+	if path == nil {
+		return 0, false
+	}
+	return path[len(path)-1].weight, true
+}
+
+// Delete 删除词条
+func (a *Autocomplete) Delete(term string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	path := a.lookup(term)
+	if path == nil || len(path) == 1 {
+		return false
+	}
+
+	node := path[len(path)-1]
+	node.terminal = false
+	node.weight = 0
+	a.count--
+
+	if len(node.children) == 0 {
+		// 删除空叶子节点
+		parent := path[len(path)-2]
+		idx, _ := parent.childIndex(node.label[0])
+		parent.children = append(parent.children[:idx], parent.children[idx+1:]...)
+		path = path[:len(path)-1]
+		node = parent
+	}
+
+	// 非词条节点只剩一个子节点时与子节点合并，保持路径压缩
+	if node != a.root && !node.terminal && len(node.children) == 1 {
+		child := node.children[0]
+		node.label += child.label
+		node.terminal = child.terminal
+		node.weight = child.weight
+		node.children = child.children
+	}
+
+	refreshPath(path)
+	return true
+}
+
+// completionItem 最优优先搜索中的候选项
+type completionItem struct {
+	node     *radixNode
+	term     string
+	priority float64
+	final    bool // true表示这是一个可直接输出的词条
+}
+
+// completionHeap 按优先级排序的最大堆
+type completionHeap []completionItem
+
+func (h completionHeap) Len() int { return len(h) }
+func (h completionHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	if h[i].final != h[j].final {
+		return h[i].final
+	}
+	return h[i].term < h[j].term
+}
+func (h completionHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *completionHeap) Push(x any)   { *h = append(*h, x.(completionItem)) }
+func (h *completionHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// Complete 返回以prefix为前缀、权重最高的k个词条（按权重降序）
+func (a *Autocomplete) Complete(prefix string, k int) []Completion {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if k <= 0 {
+		return nil
+	}
+
+	// 定位前缀所在的子树
+	node := a.root
+	term := ""
+	rest := prefix
+	for rest != "" {
+		idx, ok := node.childIndex(rest[0])
+		if !ok {
+			return nil
+		}
+		child := node.children[idx]
+		if strings.HasPrefix(rest, child.label) {
+			rest = rest[len(child.label):]
+		} else if strings.HasPrefix(child.label, rest) {
+			rest = ""
+		} else {
+			return nil
+		}
+		term += child.label
+		node = child
+	}
+
+	if node == a.root && len(node.children) == 0 {
+		return nil
+	}
+
+	// 最优优先搜索：子树最大权重作为上界，保证按权重降序输出
+	h := &completionHeap{{node: node, term: term, priority: node.maxWeight}}
+	result := make([]Completion, 0, k)
+
+	for h.Len() > 0 && len(result) < k {
+		item := heap.Pop(h).(completionItem)
+		if item.final {
+			result = append(result, Completion{Term: item.term, Weight: item.priority})
+			continue
+		}
+
+		if item.node.terminal {
+			heap.Push(h, completionItem{term: item.term, priority: item.node.weight, final: true})
+		}
+		for _, c := range item.node.children {
+			heap.Push(h, completionItem{node: c, term: item.term + c.label, priority: c.maxWeight})
+		}
+	}
+
+	return result
+}
+
+// Size 返回词条数量
+func (a *Autocomplete) Size() int64 {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.count
+}
+
+// Describe 返回自动补全组件的能力描述
+func (a *Autocomplete) Describe() Descriptor {
+	return Descriptor{
+		Name:           "Autocomplete",
+		Ordered:        true,
+		SupportsDelete: true,
+		SupportsRange:  true,
+		Concurrency:    ConcurrencyRWMutex,
+		Complexity: Complexity{
+			Insert: "O(m)",
+			Search: "O(m)",
+			Delete: "O(m)",
+			Range:  "O(m + k log k) 前缀Top-K",
+		},
+		Notes: "m为词条长度；范围查询指按前缀返回权重最高的k个补全",
+	}
+}
@@ -0,0 +1,93 @@
+package datastructures
+
+import (
+	"testing"
+)
+
+// TestAutocompleteTopK 测试按权重返回Top-K补全
+func TestAutocompleteTopK(t *testing.T) {
+	ac := NewAutocomplete()
+
+	terms := map[string]float64{
+		"go":        5,
+		"golang":    10,
+		"google":    8,
+		"gopher":    3,
+		"good":      7,
+		"graph":     9,
+		"gossip":    1,
+		"goroutine": 6,
+	}
+	for term, w := range terms {
+		if err := ac.Insert(term, w); err != nil {
+			t.Fatalf("Insert(%q) 错误 = %v", term, err)
+		}
+	}
+
+	if ac.Size() != int64(len(terms)) {
+		t.Errorf("Size() = %d, 期望 %d", ac.Size(), len(terms))
+	}
+
+	got := ac.Complete("go", 3)
+	want := []string{"golang", "google", "good"}
+	if len(got) != len(want) {
+		t.Fatalf("Complete(go, 3) 返回 %d 个结果, 期望 %d", len(got), len(want))
+	}
+	for i, c := range got {
+		if c.Term != want[i] {
+			t.Errorf("Complete(go, 3)[%d] = %q, 期望 %q", i, c.Term, want[i])
+		}
+	}
+
+	// 前缀落在边标签中间
+	got = ac.Complete("goo", 5)
+	if len(got) != 2 || got[0].Term != "google" || got[1].Term != "good" {
+		t.Errorf("Complete(goo, 5) = %v", got)
+	}
+
+	if got := ac.Complete("x", 5); len(got) != 0 {
+		t.Errorf("Complete(x, 5) = %v, 期望空结果", got)
+	}
+
+	if err := ac.Insert("", 1); err == nil {
+		t.Error("插入空词条应该返回错误")
+	}
+}
+
+// TestAutocompleteIncrementalUpdate 测试权重增量更新与删除
+func TestAutocompleteIncrementalUpdate(t *testing.T) {
+	ac := NewAutocomplete()
+	ac.Insert("car", 1)
+	ac.Insert("cart", 2)
+	ac.Insert("carbon", 3)
+
+	if w, _ := ac.Increment("car", 10); w != 11 {
+		t.Errorf("Increment(car, 10) = %v, 期望 11", w)
+	}
+	if got := ac.Complete("ca", 1); len(got) != 1 || got[0].Term != "car" {
+		t.Errorf("增量更新后 Complete(ca, 1) = %v, 期望 car", got)
+	}
+
+	if !ac.Delete("car") {
+		t.Fatal("Delete(car) 应该返回 true")
+	}
+	if ac.Delete("car") {
+		t.Error("重复删除应该返回 false")
+	}
+	if _, ok := ac.Weight("car"); ok {
+		t.Error("删除的词条仍然存在")
+	}
+
+	got := ac.Complete("car", 5)
+	if len(got) != 2 || got[0].Term != "carbon" || got[1].Term != "cart" {
+		t.Errorf("删除后 Complete(car, 5) = %v", got)
+	}
+
+	ac.Delete("cart")
+	if w, ok := ac.Weight("carbon"); !ok || w != 3 {
+		t.Errorf("Weight(carbon) = %v, %v, 期望 3, true", w, ok)
+	}
+	if ac.Size() != 1 {
+		t.Errorf("Size() = %d, 期望 1", ac.Size())
+	}
+}
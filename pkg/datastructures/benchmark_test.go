@@ -3,6 +3,7 @@ package datastructures
 import (
 	"fmt"
 	"math/rand"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -178,6 +179,75 @@ func BenchmarkSkipListDelete(b *testing.B) {
 	}
 }
 
+func BenchmarkGenericSkipListInsert(b *testing.B) {
+	data := generateTestData(benchmarkSize)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		skipList := NewOrderedSkipList[int, int]()
+		b.StopTimer()
+		keys := data[:b.N%benchmarkSize]
+		b.StartTimer()
+
+		for j, key := range keys {
+			skipList.Insert(key, j)
+		}
+	}
+}
+
+func BenchmarkGenericSkipListSearch(b *testing.B) {
+	skipList := NewOrderedSkipList[int, int]()
+	data := generateTestData(benchmarkSize)
+
+	// 预填充数据
+	for i, key := range data {
+		skipList.Insert(key, i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key := data[i%benchmarkSize]
+		skipList.Search(key)
+	}
+}
+
+// BenchmarkSkipListParallelMixed 全局锁跳表与无锁跳表的并发混合读写对比
+func BenchmarkSkipListParallelMixed(b *testing.B) {
+	data := generateTestData(smallSize)
+
+	b.Run("Mutex", func(b *testing.B) {
+		skipList := NewDefaultSkipList(intComparator)
+		b.RunParallel(func(pb *testing.PB) {
+			i := rand.Int()
+			for pb.Next() {
+				key := data[i%smallSize]
+				if i%4 == 0 {
+					skipList.Insert(key, i)
+				} else {
+					skipList.Search(key)
+				}
+				i++
+			}
+		})
+	})
+
+	b.Run("LockFree", func(b *testing.B) {
+		skipList := NewDefaultLockFreeSkipList(intComparator)
+		b.RunParallel(func(pb *testing.PB) {
+			i := rand.Int()
+			for pb.Next() {
+				key := data[i%smallSize]
+				if i%4 == 0 {
+					skipList.Insert(key, i)
+				} else {
+					skipList.Search(key)
+				}
+				i++
+			}
+		})
+	})
+}
+
 // =============== 可扩展哈希基准测试 ===============
 
 func BenchmarkExtendibleHashInsert(b *testing.B) {
@@ -229,6 +299,236 @@ func BenchmarkExtendibleHashDelete(b *testing.B) {
 	}
 }
 
+// BenchmarkExtendibleHashKeyEncoder 比较默认编码器与fmt格式化编码的查询开销
+func BenchmarkExtendibleHashKeyEncoder(b *testing.B) {
+	sprintfEncoder := KeyEncoderFunc(func(dst []byte, key any) ([]byte, error) {
+		return append(dst, fmt.Sprintf("%v", key)...), nil
+	})
+	data := generateTestData(smallSize)
+
+	for _, tc := range []struct {
+		name    string
+		encoder KeyEncoder
+	}{{"Default", DefaultKeyEncoder{}}, {"Sprintf", sprintfEncoder}} {
+		b.Run(tc.name, func(b *testing.B) {
+			hashTable := NewExtendibleHash(64, nil, WithKeyEncoder(tc.encoder))
+			for i, key := range data {
+				hashTable.Insert(key, i)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				hashTable.Search(data[i%smallSize])
+			}
+		})
+	}
+}
+
+// BenchmarkExtendibleHashBytesKey 比较[]byte键的通用接口与专用接口的查询开销
+func BenchmarkExtendibleHashBytesKey(b *testing.B) {
+	keys := make([][]byte, smallSize)
+	for i := range keys {
+		keys[i] = []byte(fmt.Sprintf("blob-key-%08d", i))
+	}
+	hashTable := NewExtendibleHash(64, nil)
+	for i, key := range keys {
+		hashTable.InsertBytes(key, i)
+	}
+
+	b.Run("Search", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			hashTable.Search(keys[i%smallSize])
+		}
+	})
+	b.Run("SearchBytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			hashTable.SearchBytes(keys[i%smallSize])
+		}
+	})
+}
+
+// BenchmarkStripedExtendibleHashParallel 比较分条与不分条时从空表开始并发写入的吞吐
+// 写入过程中不断发生目录加倍，不分条时每次加倍阻塞所有操作
+// 使用 go test -bench StripedExtendibleHashParallel -cpu 1,4,8 观察差异
+func BenchmarkStripedExtendibleHashParallel(b *testing.B) {
+	type inserter interface {
+		Insert(key, value any) error
+	}
+	for _, tc := range []struct {
+		name  string
+		table func() inserter
+	}{
+		{"Single", func() inserter { return NewExtendibleHash(16, nil) }},
+		{"Striped16", func() inserter { return NewStripedExtendibleHash(16, 16, nil) }},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			hashTable := tc.table()
+			var next atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					key := int(next.Add(1))
+					hashTable.Insert(key, key)
+				}
+			})
+		})
+	}
+}
+
+// hashFuncBenchCases 参与基准测试的哈希函数
+var hashFuncBenchCases = []struct {
+	name string
+	fn   HashFunc64
+}{
+	{"FNV32a", Hash64From32(defaultHash)},
+	{"FNV64a", FNV64aHash},
+	{"XXHash64", XXHash64},
+	{"Murmur3", Murmur3Hash64},
+	{"MapHash", NewMapHash()},
+}
+
+// BenchmarkHashFunc 比较内置哈希函数在不同键长下的吞吐
+func BenchmarkHashFunc(b *testing.B) {
+	for _, size := range []int{8, 64, 1024} {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i)
+		}
+		for _, tc := range hashFuncBenchCases {
+			b.Run(fmt.Sprintf("%s/%d", tc.name, size), func(b *testing.B) {
+				b.SetBytes(int64(size))
+				for i := 0; i < b.N; i++ {
+					tc.fn(data)
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkExtendibleHashHashFunc 比较不同哈希函数下可扩展哈希的查询开销
+func BenchmarkExtendibleHashHashFunc(b *testing.B) {
+	keys := make([]string, smallSize)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("user:%08d:profile", i)
+	}
+	for _, tc := range hashFuncBenchCases {
+		b.Run(tc.name, func(b *testing.B) {
+			hashTable := NewExtendibleHash(64, nil, WithHashFunc64(tc.fn))
+			for i, key := range keys {
+				hashTable.InsertString(key, i)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				hashTable.SearchString(keys[i%smallSize])
+			}
+		})
+	}
+}
+
+// BenchmarkExtendibleHashWithoutLocking 比较默认加锁与WithoutLocking在单goroutine下的插入和查询开销
+func BenchmarkExtendibleHashWithoutLocking(b *testing.B) {
+	for _, tc := range []struct {
+		name string
+		opts []ExtendibleHashOption
+	}{{"Locked", nil}, {"Unlocked", []ExtendibleHashOption{WithoutLocking()}}} {
+		b.Run(tc.name+"/Insert", func(b *testing.B) {
+			hashTable := NewExtendibleHash(64, nil, tc.opts...)
+			for i := 0; i < b.N; i++ {
+				hashTable.Insert(i%smallSize, i)
+			}
+		})
+		b.Run(tc.name+"/Search", func(b *testing.B) {
+			hashTable := NewExtendibleHash(64, nil, tc.opts...)
+			for i := 0; i < smallSize; i++ {
+				hashTable.Insert(i, i)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				hashTable.Search(i % smallSize)
+			}
+		})
+	}
+}
+
+// BenchmarkExtendibleHashParallel 桶级锁的扩展性：混合读写吞吐随并发度的变化
+// 使用 go test -bench ExtendibleHashParallel -cpu 1,2,4,8 观察扩展曲线
+func BenchmarkExtendibleHashParallel(b *testing.B) {
+	for _, mix := range []struct {
+		name       string
+		writeRatio int // 每10次操作中的写入次数，插入和删除各半
+	}{{"ReadMostly", 2}, {"WriteHeavy", 8}} {
+		b.Run(mix.name, func(b *testing.B) {
+			hashTable := NewExtendibleHash(16, nil)
+			for i := 0; i < smallSize; i++ {
+				hashTable.Insert(i, i)
+			}
+
+			var seed atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				rng := rand.New(rand.NewSource(seed.Add(1)))
+				for pb.Next() {
+					key := rng.Intn(smallSize)
+					switch op := rng.Intn(10); {
+					case op >= mix.writeRatio:
+						hashTable.Search(key)
+					case op%2 == 0:
+						hashTable.Insert(key, key)
+					default:
+						hashTable.Delete(key)
+					}
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkExtendibleHashOverflowChain 比较立即分裂与溢出链的插入开销和最坏延迟
+// 额外报告：单次插入的最大耗时、分裂次数和溢出页次数
+func BenchmarkExtendibleHashOverflowChain(b *testing.B) {
+	data := generateTestData(smallSize)
+	for _, maxPages := range []int{0, 2, 4} {
+		b.Run(fmt.Sprintf("MaxPages%d", maxPages), func(b *testing.B) {
+			var hashTable *ExtendibleHash
+			var worst time.Duration
+			for i := 0; i < b.N; i++ {
+				hashTable = NewExtendibleHash(16, nil, WithOverflowChain(maxPages))
+				for _, key := range data {
+					start := time.Now()
+					hashTable.Insert(key, key)
+					worst = max(worst, time.Since(start))
+				}
+			}
+			b.ReportMetric(float64(worst.Nanoseconds()), "max-ns")
+			b.ReportMetric(float64(hashTable.SplitCount()), "splits")
+			b.ReportMetric(float64(hashTable.OverflowCount()), "overflows")
+		})
+	}
+}
+
+// BenchmarkExtendibleHashDoubling 大表插入的最坏延迟，目录加倍是主要的延迟尖峰来源
+// 额外报告：单次插入的最大耗时、目录加倍的累计耗时和最终的目录大小
+func BenchmarkExtendibleHashDoubling(b *testing.B) {
+	const n = 1 << 17
+	var worst time.Duration
+	var stats ExtendibleHashStats
+	for i := 0; i < b.N; i++ {
+		hashTable := NewExtendibleHash(4, nil, WithHashFunc64(XXHash64))
+		for key := 0; key < n; key++ {
+			start := time.Now()
+			hashTable.Insert(key, key)
+			worst = max(worst, time.Since(start))
+		}
+		stats = hashTable.Stats()
+	}
+	b.ReportMetric(float64(worst.Nanoseconds()), "max-ns")
+	b.ReportMetric(float64(stats.DoublingTime.Nanoseconds())/float64(stats.DoublingCount), "ns/doubling")
+	b.ReportMetric(float64(stats.DirectorySize), "dir-size")
+}
+
 // =============== 布隆过滤器基准测试 ===============
 
 func BenchmarkBloomFilterInsert(b *testing.B) {
@@ -264,6 +564,94 @@ func BenchmarkBloomFilterSearch(b *testing.B) {
 	}
 }
 
+// BenchmarkBloomFilterParallelMixed 并发混合添加和查询，位数组按字原子操作，不经过锁
+func BenchmarkBloomFilterParallelMixed(b *testing.B) {
+	bloomFilter := NewDefaultBloomFilter()
+	data := generateTestData(benchmarkSize)
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := rand.Int()
+		for pb.Next() {
+			key := data[i%benchmarkSize]
+			if i%4 == 0 {
+				bloomFilter.AddInt(key)
+			} else {
+				bloomFilter.ContainsInt(key)
+			}
+			i++
+		}
+	})
+}
+
+// =============== 插入顺序基准测试 ===============
+
+// insertOrders 插入顺序维度：单调递增的ID是最常见的真实负载
+var insertOrders = []struct {
+	name string
+	keys func(n int) []int
+}{
+	{"Sequential", func(n int) []int {
+		keys := make([]int, n)
+		for i := range keys {
+			keys[i] = i
+		}
+		return keys
+	}},
+	{"Random", func(n int) []int {
+		return rand.Perm(n)
+	}},
+	{"Reverse", func(n int) []int {
+		keys := make([]int, n)
+		for i := range keys {
+			keys[i] = n - 1 - i
+		}
+		return keys
+	}},
+}
+
+// BenchmarkInsertOrder 比较不同插入顺序下的插入性能和结构形态
+// 额外报告：B+树高度和分裂次数、跳表层数、可扩展哈希的分裂次数和全局深度
+func BenchmarkInsertOrder(b *testing.B) {
+	for _, order := range insertOrders {
+		keys := order.keys(smallSize)
+
+		b.Run(order.name+"/BPlusTree", func(b *testing.B) {
+			var tree *BPlusTree
+			for i := 0; i < b.N; i++ {
+				tree = NewBPlusTree(64, intComparator)
+				for _, key := range keys {
+					tree.Insert(key, key)
+				}
+			}
+			b.ReportMetric(float64(tree.Height()), "height")
+			b.ReportMetric(float64(tree.SplitCount()), "splits")
+		})
+
+		b.Run(order.name+"/SkipList", func(b *testing.B) {
+			var skipList *SkipList
+			for i := 0; i < b.N; i++ {
+				skipList = NewDefaultSkipList(intComparator)
+				for _, key := range keys {
+					skipList.Insert(key, key)
+				}
+			}
+			b.ReportMetric(float64(skipList.Level()), "level")
+		})
+
+		b.Run(order.name+"/ExtendibleHash", func(b *testing.B) {
+			var hashTable *ExtendibleHash
+			for i := 0; i < b.N; i++ {
+				hashTable = NewExtendibleHashWithDefault()
+				for _, key := range keys {
+					hashTable.Insert(key, key)
+				}
+			}
+			b.ReportMetric(float64(hashTable.SplitCount()), "splits")
+			b.ReportMetric(float64(hashTable.GlobalDepth()), "depth")
+		})
+	}
+}
+
 // =============== 性能对比测试 ===============
 
 // TestPerformanceComparison 性能对比测试
@@ -578,3 +966,109 @@ func BenchmarkComprehensive(b *testing.B) {
 		})
 	}
 }
+
+// BenchmarkSkipListInsertBatch 对比逐个插入和批量插入
+func BenchmarkSkipListInsertBatch(b *testing.B) {
+	kvs := make([]KeyValue, 10000)
+	for i, k := range rand.Perm(len(kvs)) {
+		kvs[i] = KeyValue{Key: k, Value: k}
+	}
+
+	b.Run("Insert", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sl := NewDefaultSkipList(intComparator)
+			for _, kv := range kvs {
+				sl.Insert(kv.Key, kv.Value)
+			}
+		}
+	})
+
+	b.Run("InsertBatch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sl := NewDefaultSkipList(intComparator)
+			sl.InsertBatch(kvs)
+		}
+	})
+}
+
+// BenchmarkSkipListArena 对比堆分配和arena模式的插入性能
+func BenchmarkSkipListArena(b *testing.B) {
+	for _, bm := range []struct {
+		name string
+		opts []SkipListOption
+	}{
+		{"Heap", nil},
+		{"Arena", []SkipListOption{WithSkipListArena(4096)}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				sl := NewDefaultSkipList(intComparator, bm.opts...)
+				for j := 0; j < smallSize; j++ {
+					sl.Insert(j, j)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkSkipListParallelReadMostly 读多写少场景下的并发吞吐量（95%读，5%写）
+func BenchmarkSkipListParallelReadMostly(b *testing.B) {
+	sl := NewDefaultSkipList(intComparator)
+	for i := 0; i < smallSize; i++ {
+		sl.Insert(i, i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(time.Now().UnixNano()))
+		for pb.Next() {
+			k := r.Intn(smallSize)
+			if r.Intn(20) == 0 {
+				sl.Insert(k, k)
+			} else {
+				sl.Search(k)
+			}
+		}
+	})
+}
+
+// BenchmarkSkipListFinger 顺序追加场景下finger优化的效果
+func BenchmarkSkipListFinger(b *testing.B) {
+	for _, bm := range []struct {
+		name string
+		opts []SkipListOption
+	}{
+		{"Default", nil},
+		{"Finger", []SkipListOption{WithFinger()}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			sl := NewDefaultSkipList(intComparator, bm.opts...)
+			for i := 0; i < benchmarkSize; i++ {
+				sl.Insert(i, i)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				sl.Insert(benchmarkSize+i, i)
+			}
+		})
+	}
+}
+
+// BenchmarkMerkleTreeBuild 测试并行构建默克尔树的加速比（需要多核才能看到差别）
+func BenchmarkMerkleTreeBuild(b *testing.B) {
+	data := make([][]byte, 1<<16)
+	for i := range data {
+		data[i] = make([]byte, 1024)
+		rand.Read(data[i])
+	}
+
+	for _, workers := range []int{1, 2, 4, 8, 16} {
+		b.Run(fmt.Sprintf("Workers%d", workers), func(b *testing.B) {
+			b.SetBytes(int64(len(data) * 1024))
+			for i := 0; i < b.N; i++ {
+				NewMerkleTree(data, WithBuildWorkers(workers))
+			}
+		})
+	}
+}
@@ -4,10 +4,10 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
-	"hash"
-	"hash/fnv"
+	"hash/maphash"
 	"math"
 	"sync"
+	"sync/atomic"
 )
 
 // BloomFilter 布隆过滤器
@@ -16,91 +16,118 @@ import (
 // - 有假阳性（false positive），无假阴性（false negative）
 // - 用于快速判断元素是否在集合中
 // - 常用于数据库查询优化、缓存穿透防护
+// - 双重哈希：每个元素只计算两个哈希值，k个位置由g_i = h1 + i·h2 (mod m)得到，
+//   哈希计算无状态，在锁外进行
+// - 位数组按64位字原子读写，Add和Contains不加锁，可以在多个goroutine中并发执行，
+//   Merge、Clear等整体操作进行时也不会被阻塞
 type BloomFilter struct {
-	bitArray  []byte     // 位数组
+	bitArray  []uint64   // 位数组，第pos位在bitArray[pos/64]的第pos%64位
 	m         uint       // 位数组大小（位数）
 	k         uint       // 哈希函数数量
-	count     uint64     // 已插入元素数量
-	hashFuncs []hash.Hash32 // 哈希函数列表
-	mu        sync.RWMutex // 读写锁
+	count     atomic.Uint64 // 已插入元素数量
+	mu        sync.RWMutex // 读写锁，串行化Clear、Merge等整体操作，Add和Contains不获取
+	hash      BloomHash    // 哈希算法
+	seed      uint64       // FNV和xxhash的种子
+	mapSeed   maphash.Seed // hash为BloomHashMapHash时的种子
 }
 
+// bloomFilterVersion 序列化格式的版本，位置的计算方式改变时递增
+// 版本1：FNV-1a 64位双重哈希
+const bloomFilterVersion = 1
+
+// bloomHashSeed 第二个哈希的种子，使两次哈希计算相互独立
+const bloomHashSeed uint64 = 0x9e3779b97f4a7c15
+
 // NewBloomFilter 创建新的布隆过滤器
 // expectedElements: 期望插入的元素数量
 // falsePositiveRate: 期望的假阳性率 (0 < fpr < 1)
-func NewBloomFilter(expectedElements uint, falsePositiveRate float64) *BloomFilter {
-	if expectedElements == 0 {
+func NewBloomFilter(expectedElements uint, falsePositiveRate float64, opts ...BloomFilterOption) *BloomFilter {
+	m, k := EstimateParameters(expectedElements, falsePositiveRate)
+
+	bf := &BloomFilter{
+		bitArray:  make([]uint64, (m+63)/64),
+		m:         m,
+		k:         k,
+	}
+	for _, opt := range opts {
+		opt(bf)
+	}
+	return bf
+}
+
+// EstimateParameters 计算容纳n个元素、假阳性率为fpr时的最优位数m和哈希函数数量k
+// m = -n·ln(fpr) / (ln2)²，k = m/n·ln2（至少为1）；NewBloomFilter使用同样的公式
+func EstimateParameters(n uint, fpr float64) (m, k uint) {
+	if n == 0 {
 		panic("expectedElements must be > 0")
 	}
-	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+	if fpr <= 0 || fpr >= 1 {
 		panic("falsePositiveRate must be in (0, 1)")
 	}
 
 	// 计算最优的位数组大小
-	m := uint(-float64(expectedElements) * math.Log(falsePositiveRate) / (math.Log(2) * math.Log(2)))
+	m = uint(-float64(n) * math.Log(fpr) / (math.Log(2) * math.Log(2)))
 
 	// 计算最优的哈希函数数量
-	k := uint(float64(m) / float64(expectedElements) * math.Log(2))
+	k = uint(float64(m) / float64(n) * math.Log(2))
 
 	// 确保k至少为1
 	if k == 0 {
 		k = 1
 	}
+	return m, k
+}
 
-	// 初始化哈希函数
-	hashFuncs := make([]hash.Hash32, k)
-	for i := uint(0); i < k; i++ {
-		// 为每个哈希函数使用不同的盐值
-		h := fnv.New32a()
-		salt := make([]byte, 4)
-		binary.BigEndian.PutUint32(salt, uint32(i))
-		h.Write(salt)
-		hashFuncs[i] = h
-	}
+// ExpectedFPR 返回m位、k个哈希函数的过滤器插入n个不同元素后的理论假阳性率
+// FPR = (1 - e^(-kn/m))^k
+func ExpectedFPR(m, k, n uint) float64 {
+	return math.Pow(1-math.Exp(-float64(k)*float64(n)/float64(m)), float64(k))
+}
 
-	return &BloomFilter{
-		bitArray:  make([]byte, (m+7)/8),
-		m:         m,
-		k:         k,
-		hashFuncs: hashFuncs,
-		count:     0,
+// bloomHashes 计算元素的两个相互独立的64位哈希值，用于双重哈希
+// 两者都是带不同种子的FNV-1a，再经过murmur3的终结混合改善低位分布；h2强制为奇数，保证步长非零
+// seed混入两者的初始状态，seed为0时与未引入种子之前的结果相同
+func bloomHashes(data []byte, seed uint64) (h1, h2 uint64) {
+	const prime64 uint64 = 1099511628211
+	h1 = 14695981039346656037 ^ seed
+	h2 = 14695981039346656037 ^ bloomHashSeed ^ murmurFmix64(seed)
+	for _, b := range data {
+		h1 ^= uint64(b)
+		h1 *= prime64
+		h2 ^= uint64(b)
+		h2 *= prime64
 	}
+	return murmurFmix64(h1), murmurFmix64(h2) | 1
 }
 
-// getHashPositions 获取元素对应的哈希位置
-func (bf *BloomFilter) getHashPositions(data []byte) []uint {
-	positions := make([]uint, bf.k)
-
-	for i, hashFunc := range bf.hashFuncs {
-		hashFunc.Reset()
-		hashFunc.Write(data)
-		hashValue := hashFunc.Sum32()
+// position 返回第i个哈希位置：g_i = h1 + i·h2 (mod m)
+func (bf *BloomFilter) position(h1, h2 uint64, i uint) uint {
+	return uint((h1 + uint64(i)*h2) % uint64(bf.m))
+}
 
-		// 使用哈希值计算位置
-		position := hashValue % uint32(bf.m)
-		positions[i] = uint(position)
-	}
+// setBit 原子地设置第pos位
+func (bf *BloomFilter) setBit(pos uint) {
+	orUint64(&bf.bitArray[pos/64], 1<<(pos%64))
+}
 
-	return positions
+// testBit 原子地读取第pos位
+func (bf *BloomFilter) testBit(pos uint) bool {
+	return atomic.LoadUint64(&bf.bitArray[pos/64])&(1<<(pos%64)) != 0
 }
 
-// Add 添加元素
+// Add 添加元素，不加锁，可与其他Add和Contains并发执行
 func (bf *BloomFilter) Add(data []byte) {
-	bf.mu.Lock()
-	defer bf.mu.Unlock()
-
-	positions := bf.getHashPositions(data)
-
-	for _, pos := range positions {
-		// 计算字节和位的位置
-	_byte := pos / 8
-	_bit := pos % 8
+	h1, h2 := bf.hashes(data)
+	bf.addHashes(h1, h2)
+}
 
-		// 设置位
-		bf.bitArray[_byte] |= 1 << _bit
+// addHashes 按已计算的两个哈希值设置k个位
+func (bf *BloomFilter) addHashes(h1, h2 uint64) {
+	for i := uint(0); i < bf.k; i++ {
+		bf.setBit(bf.position(h1, h2, i))
 	}
 
-	bf.count++
+	bf.count.Add(1)
 }
 
 // AddString 添加字符串元素
@@ -122,7 +149,8 @@ func (bf *BloomFilter) AddFloat64(f float64) {
 	bf.Add(b)
 }
 
-// AddGeneric 添加泛型元素
+// AddGeneric 添加任意类型的元素，编码为fmt的%v格式
+// 不同类型的值可能格式化为相同的文本（如1和"1"），每次调用都有分配；元素类型已知时使用AddOf
 func (bf *BloomFilter) AddGeneric(item any) {
 	data := []byte(fmt.Sprintf("%v", item))
 	bf.Add(data)
@@ -130,18 +158,17 @@ func (bf *BloomFilter) AddGeneric(item any) {
 
 // Contains 检查元素是否存在
 // 返回true表示可能存在，返回false表示一定不存在
+// 不加锁；与并发的Add同时进行时，可能看到元素的部分位已设置而返回false
 func (bf *BloomFilter) Contains(data []byte) bool {
-	bf.mu.RLock()
-	defer bf.mu.RUnlock()
-
-	positions := bf.getHashPositions(data)
-
-	for _, pos := range positions {
-		_byte := pos / 8
-		_bit := pos % 8
+	h1, h2 := bf.hashes(data)
+	return bf.containsHashes(h1, h2)
+}
 
+// containsHashes 按已计算的两个哈希值检查k个位
+func (bf *BloomFilter) containsHashes(h1, h2 uint64) bool {
+	for i := uint(0); i < bf.k; i++ {
 		// 检查位是否被设置
-		if (bf.bitArray[_byte] & (1 << _bit)) == 0 {
+		if !bf.testBit(bf.position(h1, h2, i)) {
 			return false
 		}
 	}
@@ -168,7 +195,7 @@ func (bf *BloomFilter) ContainsFloat64(f float64) bool {
 	return bf.Contains(b)
 }
 
-// ContainsGeneric 检查泛型元素是否存在
+// ContainsGeneric 检查AddGeneric添加的元素是否存在
 func (bf *BloomFilter) ContainsGeneric(item any) bool {
 	data := []byte(fmt.Sprintf("%v", item))
 	return bf.Contains(data)
@@ -176,16 +203,23 @@ func (bf *BloomFilter) ContainsGeneric(item any) bool {
 
 // GetFalsePositiveRate 获取当前假阳性率
 func (bf *BloomFilter) GetFalsePositiveRate() float64 {
-	bf.mu.RLock()
-	defer bf.mu.RUnlock()
-
-	// 使用标准公式计算假阳性率
-	// FPR = (1 - e^(-kn/m))^k
-	// 其中 k 是哈希函数数量，n 是元素数量，m 是位数组大小
-	expValue := math.Exp(-float64(bf.k) * float64(bf.count) / float64(bf.m))
-	fpr := math.Pow(1-expValue, float64(bf.k))
+	// 使用标准公式计算假阳性率，n取Add的调用次数
+	return ExpectedFPR(bf.m, bf.k, uint(bf.count.Load()))
+}
 
-	return fpr
+// MeasureFPR 用一组确定不在过滤器中的元素实测假阳性率：被判定为存在的比例
+// 用于在集成测试中对照GetFalsePositiveRate等理论值；negatives为空时返回0
+func (bf *BloomFilter) MeasureFPR(negatives [][]byte) float64 {
+	if len(negatives) == 0 {
+		return 0
+	}
+	falsePositives := 0
+	for _, data := range negatives {
+		if bf.Contains(data) {
+			falsePositives++
+		}
+	}
+	return float64(falsePositives) / float64(len(negatives))
 }
 
 // Clear 清空布隆过滤器
@@ -194,71 +228,75 @@ func (bf *BloomFilter) Clear() {
 	defer bf.mu.Unlock()
 
 	for i := range bf.bitArray {
-		bf.bitArray[i] = 0
+		atomic.StoreUint64(&bf.bitArray[i], 0)
 	}
-	bf.count = 0
+	bf.count.Store(0)
 }
 
-// Size 返回已插入元素数量
+// Size 返回已插入元素数量，即Add的调用次数，重复添加的元素重复计数
+// 不同元素的数量用EstimateCardinality估计
 func (bf *BloomFilter) Size() uint64 {
-	bf.mu.RLock()
-	defer bf.mu.RUnlock()
-	return bf.count
+	return bf.count.Load()
 }
 
 // BitArraySize 返回位数组大小（字节数）
 func (bf *BloomFilter) BitArraySize() uint {
-	bf.mu.RLock()
-	defer bf.mu.RUnlock()
-	return uint(len(bf.bitArray))
+	return (bf.m + 7) / 8
 }
 
 // BitSize 返回位数组大小（位数）
+// m和k在创建后不再改变，读取无需加锁
 func (bf *BloomFilter) BitSize() uint {
-	bf.mu.RLock()
-	defer bf.mu.RUnlock()
 	return bf.m
 }
 
 // HashFuncCount 返回哈希函数数量
 func (bf *BloomFilter) HashFuncCount() uint {
-	bf.mu.RLock()
-	defer bf.mu.RUnlock()
 	return bf.k
 }
 
 // Merge 合并另一个布隆过滤器
+// other的位数可以是bf的整数倍（例如容量不同、位数取2的幂的分片），此时先把other折叠到bf的大小再合并，
+// 见Fold；哈希函数数量和哈希算法必须相同
 func (bf *BloomFilter) Merge(other *BloomFilter) error {
 	bf.mu.Lock()
 	defer bf.mu.Unlock()
 
-	if other.m != bf.m || other.k != bf.k {
-		return fmt.Errorf("cannot merge bloom filters with different parameters")
+	if other.m != bf.m && other.m%bf.m == 0 {
+		if err := bf.checkCompatible(other.emptyFolded(other.m / bf.m)); err != nil {
+			return err
+		}
+		other.foldInto(bf)
+		bf.count.Add(other.count.Load())
+		return nil
+	}
+	if err := bf.checkCompatible(other); err != nil {
+		return err
 	}
 
-	for i, b := range other.bitArray {
-		bf.bitArray[i] |= b
+	for i := range other.bitArray {
+		if w := atomic.LoadUint64(&other.bitArray[i]); w != 0 {
+			orUint64(&bf.bitArray[i], w)
+		}
 	}
 
-	bf.count += other.count
+	bf.count.Add(other.count.Load())
 	return nil
 }
 
 // Clone 克隆布隆过滤器
+// 哈希是无状态的函数，克隆只复制位数组和哈希配置（算法、种子），不共享任何可变状态：
+// 两个过滤器此后可以各自并发使用，互不影响，并且仍然可以相互Merge
 func (bf *BloomFilter) Clone() *BloomFilter {
 	bf.mu.RLock()
 	defer bf.mu.RUnlock()
 
-	newBf := &BloomFilter{
-		bitArray:  make([]byte, len(bf.bitArray)),
-		m:         bf.m,
-		k:         bf.k,
-		count:     bf.count,
-		hashFuncs: make([]hash.Hash32, len(bf.hashFuncs)),
-	}
+	newBf := bf.emptyLike()
+	newBf.count.Store(bf.count.Load())
 
-	copy(newBf.bitArray, bf.bitArray)
-	copy(newBf.hashFuncs, bf.hashFuncs)
+	for i := range bf.bitArray {
+		newBf.bitArray[i] = atomic.LoadUint64(&bf.bitArray[i])
+	}
 
 	return newBf
 }
@@ -268,60 +306,118 @@ func (bf *BloomFilter) Serialize() ([]byte, error) {
 	bf.mu.RLock()
 	defer bf.mu.RUnlock()
 
+	if bf.hash == BloomHashMapHash {
+		return nil, fmt.Errorf("bloom filters using maphash cannot be serialized")
+	}
+
 	data := struct {
+		Version  int
 		BitArray []byte
 		M        uint
 		K        uint
 		Count    uint64
+		Hash     BloomHash `json:",omitempty"`
+		Seed     uint64    `json:",omitempty"`
 	}{
-		BitArray: bf.bitArray,
+		Version:  bloomFilterVersion,
+		BitArray: bf.bytes(),
 		M:        bf.m,
 		K:        bf.k,
-		Count:    bf.count,
+		Count:    bf.count.Load(),
+		Hash:     bf.hash,
+		Seed:     bf.seed,
 	}
 
 	return json.Marshal(data)
 }
 
 // Deserialize 反序列化布隆过滤器
+// 位置计算方式不同的旧版本数据无法正确查询（会产生假阴性），因此拒绝加载
 func Deserialize(data []byte) (*BloomFilter, error) {
 	var bfData struct {
+		Version  int
 		BitArray []byte
 		M        uint
 		K        uint
 		Count    uint64
+		Hash     BloomHash // 缺省为BloomHashFNV
+		Seed     uint64    // 缺省为0
 	}
 
 	if err := json.Unmarshal(data, &bfData); err != nil {
 		return nil, err
 	}
-
-	// 重新初始化哈希函数
-	hashFuncs := make([]hash.Hash32, bfData.K)
-	for i := uint(0); i < bfData.K; i++ {
-		h := fnv.New32a()
-		salt := make([]byte, 4)
-		binary.BigEndian.PutUint32(salt, uint32(i))
-		h.Write(salt)
-		hashFuncs[i] = h
+	if bfData.Version != bloomFilterVersion {
+		return nil, fmt.Errorf("unsupported bloom filter version %d", bfData.Version)
+	}
+	if bfData.M == 0 || bfData.K == 0 || uint(len(bfData.BitArray)) != (bfData.M+7)/8 {
+		return nil, fmt.Errorf("invalid bloom filter parameters m=%d k=%d", bfData.M, bfData.K)
+	}
+	if bfData.Hash != BloomHashFNV && bfData.Hash != BloomHashXXHash {
+		return nil, fmt.Errorf("unsupported bloom filter hash %v", bfData.Hash)
 	}
 
-	return &BloomFilter{
-		bitArray:  bfData.BitArray,
+	bf := &BloomFilter{
+		bitArray:  make([]uint64, (bfData.M+63)/64),
 		m:         bfData.M,
 		k:         bfData.K,
-		count:     bfData.Count,
-		hashFuncs: hashFuncs,
-	}, nil
+		hash:      bfData.Hash,
+		seed:      bfData.Seed,
+	}
+	bf.count.Store(bfData.Count)
+	for i, b := range bfData.BitArray {
+		bf.bitArray[i/8] |= uint64(b) << (8 * (i % 8))
+	}
+	return bf, nil
+}
+
+// bytes 按字节返回位数组，第pos位在第pos/8字节的第pos%8位，与序列化格式一致
+func (bf *BloomFilter) bytes() []byte {
+	out := make([]byte, (bf.m+7)/8)
+	var buf [8]byte
+	for i := range bf.bitArray {
+		binary.LittleEndian.PutUint64(buf[:], atomic.LoadUint64(&bf.bitArray[i]))
+		copy(out[i*8:], buf[:])
+	}
+	return out
+}
+
+// orUint64 原子地把mask中的位并入*addr
+// 位已经全部设置时不写入，避免多核间无谓的缓存行失效；sync/atomic的OrUint64要求Go 1.23
+func orUint64(addr *uint64, mask uint64) {
+	for {
+		old := atomic.LoadUint64(addr)
+		if old|mask == old || atomic.CompareAndSwapUint64(addr, old, old|mask) {
+			return
+		}
+	}
 }
 
 // String 返回布隆过滤器的字符串表示
 func (bf *BloomFilter) String() string {
-	bf.mu.RLock()
-	defer bf.mu.RUnlock()
-
 	return fmt.Sprintf("BloomFilter(m=%d, k=%d, count=%d, fpr=%.6f)",
-		bf.m, bf.k, bf.count, bf.GetFalsePositiveRate())
+		bf.m, bf.k, bf.count.Load(), bf.GetFalsePositiveRate())
+}
+
+// NewBloomFilterWithSize 按指定的位数m和哈希函数数量k创建布隆过滤器
+// 需要合并容量不同的过滤器时，各方使用相同的k、位数取2的幂，较大的过滤器就能折叠到较小的大小
+func NewBloomFilterWithSize(m, k uint, opts ...BloomFilterOption) *BloomFilter {
+	if m == 0 {
+		panic("m must be > 0")
+	}
+	if k == 0 {
+		panic("k must be > 0")
+	}
+
+	bf := &BloomFilter{
+		bitArray: make([]uint64, (m+63)/64),
+		m:        m,
+		k:        k,
+	}
+	for _, opt := range opts {
+		opt(bf)
+	}
+	return bf
 }
 
 // NewOptimalBloomFilter 根据元素数量和假阳性率创建最优布隆过滤器
@@ -346,3 +442,18 @@ func NewSmallBloomFilter(expectedElements uint) *BloomFilter {
 func NewLargeBloomFilter(expectedElements uint) *BloomFilter {
 	return NewBloomFilter(expectedElements, 0.001) // 0.1%假阳性率
 }
+
+// Describe 返回布隆过滤器的能力描述
+func (bf *BloomFilter) Describe() Descriptor {
+	return Descriptor{
+		Name:          "BloomFilter",
+		Probabilistic: true,
+		Persistent:    true,
+		Concurrency:   ConcurrencyLockFree,
+		Complexity: Complexity{
+			Insert: "O(k)",
+			Search: "O(k)",
+		},
+		Notes: "有假阳性无假阴性，不存储元素本身，不支持删除",
+	}
+}
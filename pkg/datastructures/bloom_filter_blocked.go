@@ -0,0 +1,137 @@
+package datastructures
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"unsafe"
+)
+
+// blockWords 每个块的字数，8个uint64恰好是一个64字节的缓存行
+const blockWords = 8
+
+// blockBits 每个块的位数
+const blockBits = blockWords * 64
+
+// BlockedBloomFilter 分块布隆过滤器
+// 特点：
+// - 位数组划分为64字节的块，每个块对齐到缓存行
+// - 一个元素的k个位置都落在同一个块内：h1选块，h2在块内做双重哈希，查询最多访问一个缓存行
+// - 位数组远大于CPU缓存、以查询为主的负载下，缓存未命中从最多k次降为一次，吞吐通常高于BloomFilter
+// - 各块的负载不均匀，同样的位数下假阳性率略高于BloomFilter
+// - 与BloomFilter一样按字原子读写，Add和Contains不加锁
+type BlockedBloomFilter struct {
+	blocks    []uint64 // 位数组，按缓存行对齐，第b个块是blocks[b*8 : b*8+8]
+	numBlocks uint64   // 块数量
+	k         uint     // 每个元素在块内设置的位数
+	count     atomic.Uint64
+}
+
+// NewBlockedBloomFilter 创建分块布隆过滤器，参数与NewBloomFilter相同
+// 位数按标准公式计算后向上取整到块大小的整数倍
+func NewBlockedBloomFilter(expectedElements uint, falsePositiveRate float64) *BlockedBloomFilter {
+	m, _ := EstimateParameters(expectedElements, falsePositiveRate)
+	numBlocks := (uint64(m) + blockBits - 1) / blockBits
+	k := uint(math.Round(float64(numBlocks*blockBits) / float64(expectedElements) * math.Log(2)))
+	if k == 0 {
+		k = 1
+	}
+
+	return &BlockedBloomFilter{
+		blocks:    alignedWords(numBlocks * blockWords),
+		numBlocks: numBlocks,
+		k:         k,
+	}
+}
+
+// alignedWords 分配n个起始地址按64字节对齐的uint64
+func alignedWords(n uint64) []uint64 {
+	raw := make([]uint64, n+blockWords-1)
+	offset := uint64((64 - uintptr(unsafe.Pointer(&raw[0]))%64) % 64 / 8)
+	return raw[offset : offset+n]
+}
+
+// block 返回元素所在的块和块内双重哈希的起点与步长
+// 块号取h1乘以块数的128位积的高64位（乘法取模），避免除法；步长为奇数，块内的k个位置互不相同
+func (bf *BlockedBloomFilter) block(data []byte) (block []uint64, start, step uint32) {
+	h1, h2 := bloomHashes(data, 0)
+	b, _ := bits.Mul64(h1, bf.numBlocks)
+	return bf.blocks[b*blockWords : b*blockWords+blockWords], uint32(h2), uint32(h2>>32) | 1
+}
+
+// Add 添加元素，不加锁，可与其他Add和Contains并发执行
+func (bf *BlockedBloomFilter) Add(data []byte) {
+	block, start, step := bf.block(data)
+	for i := uint32(0); i < uint32(bf.k); i++ {
+		pos := (start + i*step) % blockBits
+		orUint64(&block[pos/64], 1<<(pos%64))
+	}
+	bf.count.Add(1)
+}
+
+// AddString 添加字符串元素
+func (bf *BlockedBloomFilter) AddString(s string) {
+	bf.Add([]byte(s))
+}
+
+// AddInt 添加整数元素，编码与BloomFilter.AddInt相同
+func (bf *BlockedBloomFilter) AddInt(n int) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(n))
+	bf.Add(b[:])
+}
+
+// Contains 检查元素是否存在
+// 返回true表示可能存在，返回false表示一定不存在
+func (bf *BlockedBloomFilter) Contains(data []byte) bool {
+	block, start, step := bf.block(data)
+	for i := uint32(0); i < uint32(bf.k); i++ {
+		pos := (start + i*step) % blockBits
+		if atomic.LoadUint64(&block[pos/64])&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsString 检查字符串元素是否存在
+func (bf *BlockedBloomFilter) ContainsString(s string) bool {
+	return bf.Contains([]byte(s))
+}
+
+// ContainsInt 检查整数元素是否存在
+func (bf *BlockedBloomFilter) ContainsInt(n int) bool {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(n))
+	return bf.Contains(b[:])
+}
+
+// Size 返回Add的调用次数
+func (bf *BlockedBloomFilter) Size() uint64 {
+	return bf.count.Load()
+}
+
+// BitSize 返回位数组大小（位数）
+func (bf *BlockedBloomFilter) BitSize() uint {
+	return uint(bf.numBlocks * blockBits)
+}
+
+// HashFuncCount 返回每个元素设置的位数
+func (bf *BlockedBloomFilter) HashFuncCount() uint {
+	return bf.k
+}
+
+// Describe 返回分块布隆过滤器的能力描述
+func (bf *BlockedBloomFilter) Describe() Descriptor {
+	return Descriptor{
+		Name:          "BlockedBloomFilter",
+		Probabilistic: true,
+		Concurrency:   ConcurrencyLockFree,
+		Complexity: Complexity{
+			Insert: "O(k)",
+			Search: "O(k)",
+		},
+		Notes: "k个位置落在同一缓存行，查询吞吐高于BloomFilter，假阳性率略高",
+	}
+}
@@ -0,0 +1,65 @@
+package datastructures
+
+import (
+	"fmt"
+	"testing"
+	"unsafe"
+)
+
+// TestBlockedBloomFilter 测试分块布隆过滤器没有假阴性，假阳性率接近目标值，且块按缓存行对齐
+func TestBlockedBloomFilter(t *testing.T) {
+	const n = 100000
+	bf := NewBlockedBloomFilter(n, 0.01)
+	if addr := uintptr(unsafe.Pointer(&bf.blocks[0])); addr%64 != 0 {
+		t.Fatalf("块的起始地址 %#x 没有按缓存行对齐", addr)
+	}
+	if bf.BitSize()%blockBits != 0 {
+		t.Fatalf("BitSize() = %d, 不是块大小的整数倍", bf.BitSize())
+	}
+
+	for i := 0; i < n; i++ {
+		bf.AddString(fmt.Sprintf("member-%d", i))
+	}
+	for i := 0; i < n; i++ {
+		if !bf.ContainsString(fmt.Sprintf("member-%d", i)) {
+			t.Fatalf("member-%d 出现假阴性", i)
+		}
+	}
+
+	falsePositives := 0
+	for i := 0; i < n; i++ {
+		if bf.ContainsString(fmt.Sprintf("other-%d", i)) {
+			falsePositives++
+		}
+	}
+	// 分块带来的假阳性率上升应在目标值的两倍以内
+	if rate := float64(falsePositives) / n; rate > 0.02 {
+		t.Fatalf("假阳性率 = %.4f, 期望 < 0.02", rate)
+	}
+	if bf.Size() != n {
+		t.Fatalf("Size() = %d, 期望 %d", bf.Size(), n)
+	}
+}
+
+// BenchmarkBlockedBloomFilterContains 位数组远大于CPU缓存时标准与分块布隆过滤器的查询对比
+// 查询的键一半是成员：标准布隆过滤器查询成员需要访问k个缓存行，分块只需一个
+func BenchmarkBlockedBloomFilterContains(b *testing.B) {
+	const n = 1 << 23
+	standard := NewBloomFilter(n, 0.01)
+	blocked := NewBlockedBloomFilter(n, 0.01)
+	for i := 0; i < n; i++ {
+		standard.AddInt(i)
+		blocked.AddInt(i)
+	}
+
+	b.Run("Standard", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			standard.ContainsInt(i * 7919 % (2 * n))
+		}
+	})
+	b.Run("Blocked", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			blocked.ContainsInt(i * 7919 % (2 * n))
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package datastructures
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+	"sync/atomic"
+)
+
+// bloomCompressedMagic 压缩格式的文件头，最后一个字节为格式版本
+// 位置的计算方式改变（bloomFilterVersion递增）时一并递增
+const bloomCompressedMagic = "BFG\x01"
+
+// maxCompressedBits 读取压缩格式时允许的最大位数（128GB），拒绝损坏的数据导致的超大分配
+const maxCompressedBits = 1 << 40
+
+// 压缩格式：
+//
+//	magic(4字节) | hash(uvarint) | seed(uvarint) | m(uvarint) | k(uvarint) | count(uvarint) |
+//	setBits(uvarint) | rice(uvarint) | payloadLen(uvarint) | payload
+//
+// payload按升序对setBits个置位位置的间隔做Golomb-Rice编码：间隔d = p_i - p_{i-1} - 1（p_{-1} = -1），
+// 先写d>>rice个1和一个0（一元编码的商），再写d的低rice位；位流从每个字节的最低位开始填充。
+// 每个置位约占rice+2位，与置位比例无关的原始位数组相比，稀疏的过滤器能缩小一个数量级以上；
+// 置位比例超过约1/4时压缩格式反而更大，此时应使用Serialize。
+
+// riceWriter 按最低位优先写入的位流
+type riceWriter struct {
+	buf   []byte
+	acc   uint64 // 尚未写出的位
+	nbits uint   // acc中的位数
+}
+
+// writeBits 写入v的低n位
+func (rw *riceWriter) writeBits(v uint64, n uint) {
+	// acc中最多留有7位，每次最多并入32位
+	for n > 32 {
+		rw.writeBits(v, 32)
+		v >>= 32
+		n -= 32
+	}
+	rw.acc |= (v & (1<<n - 1)) << rw.nbits
+	rw.nbits += n
+	for rw.nbits >= 8 {
+		rw.buf = append(rw.buf, byte(rw.acc))
+		rw.acc >>= 8
+		rw.nbits -= 8
+	}
+}
+
+// writeRice 写入d的Golomb-Rice编码
+func (rw *riceWriter) writeRice(d uint64, rice uint) {
+	for q := d >> rice; q > 0; q-- {
+		rw.writeBits(1, 1)
+	}
+	rw.writeBits(0, 1)
+	rw.writeBits(d, rice)
+}
+
+// bytes 写出剩余的位，返回完整的位流
+func (rw *riceWriter) bytes() []byte {
+	if rw.nbits > 0 {
+		rw.buf = append(rw.buf, byte(rw.acc))
+		rw.acc, rw.nbits = 0, 0
+	}
+	return rw.buf
+}
+
+// riceReader 读取riceWriter写入的位流
+type riceReader struct {
+	buf []byte
+	pos uint64 // 下一个要读取的位
+}
+
+// readBit 读取一位，位流结束时返回错误
+func (rr *riceReader) readBit() (uint64, error) {
+	if rr.pos>>3 >= uint64(len(rr.buf)) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	bit := uint64(rr.buf[rr.pos>>3]>>(rr.pos&7)) & 1
+	rr.pos++
+	return bit, nil
+}
+
+// readRice 读取一个Golomb-Rice编码的值，商超过maxQuotient时返回错误
+func (rr *riceReader) readRice(rice uint, maxQuotient uint64) (uint64, error) {
+	var q uint64
+	for {
+		bit, err := rr.readBit()
+		if err != nil {
+			return 0, err
+		}
+		if bit == 0 {
+			break
+		}
+		if q++; q > maxQuotient {
+			return 0, fmt.Errorf("rice quotient exceeds %d", maxQuotient)
+		}
+	}
+	var r uint64
+	for i := uint(0); i < rice; i++ {
+		bit, err := rr.readBit()
+		if err != nil {
+			return 0, err
+		}
+		r |= bit << i
+	}
+	return q<<rice | r, nil
+}
+
+// riceParameter 按置位的平均间隔选择Rice参数：floor(log2(平均间隔))
+func riceParameter(m, setBits uint64) uint {
+	if setBits == 0 || m <= setBits {
+		return 0
+	}
+	return uint(bits.Len64((m-setBits)/setBits)) - 1
+}
+
+// WriteCompressed 以Golomb-Rice压缩格式写入w，返回写入的字节数
+// 适合在服务间传输置位稀疏的大过滤器；使用maphash的过滤器无法序列化
+func (bf *BloomFilter) WriteCompressed(w io.Writer) (int64, error) {
+	if bf.hash == BloomHashMapHash {
+		return 0, fmt.Errorf("bloom filters using maphash cannot be serialized")
+	}
+
+	bf.mu.RLock()
+	defer bf.mu.RUnlock()
+
+	// 先取位数组的快照，保证置位数与编码的位置一致
+	words := make([]uint64, len(bf.bitArray))
+	var setBits uint64
+	for i := range words {
+		words[i] = atomic.LoadUint64(&bf.bitArray[i])
+		setBits += uint64(bits.OnesCount64(words[i]))
+	}
+	rice := riceParameter(uint64(bf.m), setBits)
+
+	var rw riceWriter
+	next := uint64(0) // 上一个置位位置+1
+	for i, word := range words {
+		for word != 0 {
+			pos := uint64(i)*64 + uint64(bits.TrailingZeros64(word))
+			rw.writeRice(pos-next, rice)
+			next = pos + 1
+			word &= word - 1
+		}
+	}
+
+	bw := &binaryWriter{w: w}
+	bw.write([]byte(bloomCompressedMagic))
+	bw.writeUvarint(uint64(bf.hash))
+	bw.writeUvarint(bf.seed)
+	bw.writeUvarint(uint64(bf.m))
+	bw.writeUvarint(uint64(bf.k))
+	bw.writeUvarint(bf.count.Load())
+	bw.writeUvarint(setBits)
+	bw.writeUvarint(uint64(rice))
+	bw.writeBytes(rw.bytes())
+	return bw.n, bw.err
+}
+
+// ReadCompressedBloomFilter 读取WriteCompressed写入的过滤器
+func ReadCompressedBloomFilter(r io.Reader) (*BloomFilter, error) {
+	br := &binaryReader{r: r}
+	magic := make([]byte, len(bloomCompressedMagic))
+	if _, err := br.read(magic); err != nil {
+		return nil, err
+	}
+	if string(magic) != bloomCompressedMagic {
+		return nil, fmt.Errorf("invalid compressed bloom filter header")
+	}
+
+	var fields [7]uint64 // hash, seed, m, k, count, setBits, rice
+	for i := range fields {
+		v, err := br.readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = v
+	}
+	hash, seed, m, k, count := fields[0], fields[1], fields[2], fields[3], fields[4]
+	setBits, rice := fields[5], fields[6]
+	if BloomHash(hash) != BloomHashFNV && BloomHash(hash) != BloomHashXXHash {
+		return nil, fmt.Errorf("unsupported bloom filter hash %v", BloomHash(hash))
+	}
+	if m == 0 || m > maxCompressedBits || k == 0 || setBits > m || rice > 63 {
+		return nil, fmt.Errorf("invalid bloom filter parameters m=%d k=%d setBits=%d rice=%d", m, k, setBits, rice)
+	}
+
+	// 一元编码的商合计不超过m>>rice，每个位置另占rice+1位
+	maxLen := ((m>>rice)+setBits*(rice+1))/8 + 1
+	payload, err := br.readBytes(maxLen)
+	if err != nil {
+		return nil, err
+	}
+
+	bf := NewBloomFilterWithSize(uint(m), uint(k), WithBloomHash(BloomHash(hash)), WithBloomSeed(seed))
+	bf.count.Store(count)
+	rr := riceReader{buf: payload}
+	next := uint64(0)
+	for i := uint64(0); i < setBits; i++ {
+		d, err := rr.readRice(uint(rice), m>>rice)
+		if err != nil {
+			return nil, err
+		}
+		pos := next + d
+		if pos >= m {
+			return nil, fmt.Errorf("bit position %d out of range for m=%d", pos, m)
+		}
+		bf.bitArray[pos/64] |= 1 << (pos % 64)
+		next = pos + 1
+	}
+	return bf, nil
+}
@@ -0,0 +1,92 @@
+package datastructures
+
+import (
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// BloomKey AddOf和ContainsOf支持的元素类型
+// 实现encoding.BinaryMarshaler的类型无法写进类型并集，使用AddMarshaled和ContainsMarshaled
+type BloomKey interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 | ~string | ~[]byte
+}
+
+// appendBloomKey 把元素编码为确定的字节序列
+// 编码与已有的专用方法保持一致：
+// - 整数符号扩展为8字节大端序，与AddInt相同，int8(-1)与int(-1)是同一个元素
+// - 浮点数转为float64后取8字节大端序的位模式，与AddFloat64相同
+// - 字符串和[]byte使用原始字节，与AddString和Add相同
+// - 基础类型走类型分支，命名类型（如type UserID int64）按底层类型经反射编码
+func appendBloomKey[T BloomKey](dst []byte, item T) []byte {
+	switch v := any(item).(type) {
+	case int:
+		return binary.BigEndian.AppendUint64(dst, uint64(v))
+	case int64:
+		return binary.BigEndian.AppendUint64(dst, uint64(v))
+	case int32:
+		return binary.BigEndian.AppendUint64(dst, uint64(v))
+	case uint64:
+		return binary.BigEndian.AppendUint64(dst, v)
+	case uint32:
+		return binary.BigEndian.AppendUint64(dst, uint64(v))
+	case float64:
+		return binary.BigEndian.AppendUint64(dst, math.Float64bits(v))
+	case string:
+		return append(dst, v...)
+	case []byte:
+		return append(dst, v...)
+	}
+
+	rv := reflect.ValueOf(item)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return binary.BigEndian.AppendUint64(dst, uint64(rv.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return binary.BigEndian.AppendUint64(dst, rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return binary.BigEndian.AppendUint64(dst, math.Float64bits(rv.Float()))
+	case reflect.String:
+		return append(dst, rv.String()...)
+	default: // ~[]byte
+		return append(dst, rv.Bytes()...)
+	}
+}
+
+// AddOf 按确定的二进制编码添加元素
+// 与AddGeneric相比不经过fmt格式化：不会因文本相同而把不同的值当作同一个元素，整数和浮点数也没有堆分配
+func AddOf[T BloomKey](bf *BloomFilter, item T) {
+	var buf [16]byte
+	bf.Add(appendBloomKey(buf[:0], item))
+}
+
+// ContainsOf 检查AddOf添加的元素是否存在
+// 返回true表示可能存在，返回false表示一定不存在
+func ContainsOf[T BloomKey](bf *BloomFilter, item T) bool {
+	var buf [16]byte
+	return bf.Contains(appendBloomKey(buf[:0], item))
+}
+
+// AddMarshaled 添加实现encoding.BinaryMarshaler的元素，编码为MarshalBinary的结果
+// MarshalBinary返回错误时不添加，返回该错误
+func AddMarshaled[T encoding.BinaryMarshaler](bf *BloomFilter, item T) error {
+	data, err := item.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("encode bloom filter element: %w", err)
+	}
+	bf.Add(data)
+	return nil
+}
+
+// ContainsMarshaled 检查AddMarshaled添加的元素是否存在
+func ContainsMarshaled[T encoding.BinaryMarshaler](bf *BloomFilter, item T) (bool, error) {
+	data, err := item.MarshalBinary()
+	if err != nil {
+		return false, fmt.Errorf("encode bloom filter element: %w", err)
+	}
+	return bf.Contains(data), nil
+}
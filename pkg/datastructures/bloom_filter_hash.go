@@ -0,0 +1,75 @@
+package datastructures
+
+import (
+	"fmt"
+	"hash/maphash"
+)
+
+// BloomHash 布隆过滤器的哈希算法
+type BloomHash uint8
+
+const (
+	// BloomHashFNV 两个不同种子的FNV-1a再经过murmur3终结混合（默认），结果确定，可以序列化
+	BloomHashFNV BloomHash = iota
+	// BloomHashXXHash XXHash64，长元素比FNV快得多，结果确定，可以序列化
+	BloomHashXXHash
+	// BloomHashMapHash hash/maphash，每个过滤器一个随机种子，无法预先构造冲突；
+	// 种子无法保存，使用它的过滤器不能序列化
+	BloomHashMapHash
+)
+
+// String 返回算法名称
+func (h BloomHash) String() string {
+	switch h {
+	case BloomHashFNV:
+		return "fnv"
+	case BloomHashXXHash:
+		return "xxhash"
+	case BloomHashMapHash:
+		return "maphash"
+	}
+	return fmt.Sprintf("BloomHash(%d)", uint8(h))
+}
+
+// BloomFilterOption 布隆过滤器的配置选项
+type BloomFilterOption func(*BloomFilter)
+
+// WithBloomHash 选择哈希算法，默认BloomHashFNV
+// 只有哈希算法和种子都相同（maphash的种子相同即由同一个过滤器Clone而来）的过滤器才能Merge或Intersect
+func WithBloomHash(hash BloomHash) BloomFilterOption {
+	return func(bf *BloomFilter) {
+		bf.hash = hash
+		if hash == BloomHashMapHash {
+			bf.mapSeed = maphash.MakeSeed()
+		}
+	}
+}
+
+// WithBloomSeed 设置FNV和xxhash的种子，默认为0
+// 种子保存在序列化数据中：相同种子构建的过滤器结果可复现；
+// 种子保密时外部难以预先构造在全部k个位置上冲突的输入（需要更强的保证时使用maphash）；maphash总是使用随机种子，不受此选项影响
+func WithBloomSeed(seed uint64) BloomFilterOption {
+	return func(bf *BloomFilter) {
+		bf.seed = seed
+	}
+}
+
+// Seed 返回过滤器的种子
+func (bf *BloomFilter) Seed() uint64 {
+	return bf.seed
+}
+
+// hashes 用选定的算法计算双重哈希的两个哈希值
+// 非默认算法只计算一个64位哈希，第二个哈希由第一个再混合得到：
+// 只有64位哈希完全相同的元素才会在全部k个位置上冲突
+func (bf *BloomFilter) hashes(data []byte) (h1, h2 uint64) {
+	switch bf.hash {
+	case BloomHashXXHash:
+		h1 = xxHash64(data, bf.seed)
+	case BloomHashMapHash:
+		h1 = maphash.Bytes(bf.mapSeed, data)
+	default:
+		return bloomHashes(data, bf.seed)
+	}
+	return h1, murmurFmix64(h1^bloomHashSeed) | 1
+}
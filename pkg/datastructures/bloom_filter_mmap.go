@@ -0,0 +1,240 @@
+package datastructures
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"sync"
+	"unsafe"
+)
+
+// 映射文件格式：
+//
+//	[0, 4096)      文件头页，包含两个文件头槽，分别位于偏移0和512
+//	[4096, 末尾)   位数组，⌈m/64⌉个小端序uint64，第pos位在第pos/64个字的第pos%64位
+//
+// 文件头槽: crc32(4字节) | magic(4字节) | version(uint32) | k(uint32) | m(uint64) | count(uint64) | seq(uint64) | hash(uint32) | seed(uint64)
+//
+// 文件头中的整数为大端序，crc32覆盖槽内除校验和之外的全部字节。
+// Flush先把位数组刷到磁盘，再把新的文件头写入较旧的槽；打开时选择校验通过且seq最大的槽，
+// 写文件头中途崩溃时另一个槽仍然完整。
+const (
+	mappedBloomMagic      = "BLM\x01"
+	mappedBloomHeaderSize = 4096
+	mappedBloomSlotSize   = 512
+	mappedBloomSlotLen    = 52
+)
+
+// MappedBloomFilter 以内存映射文件为位数组的持久化布隆过滤器
+// 特点：
+// - 位数组直接映射到文件，打开时不读取整个文件，适合数GB的过滤器
+// - 嵌入BloomFilter，Add、Contains等方法与纯内存版本完全相同，同样不加锁
+// - 修改在Flush之前不保证落盘；崩溃后恢复到最后一次Flush的状态，之后的部分修改可能也已保留
+// - 只读打开的过滤器可以被多个进程同时映射，共享同一份页缓存
+// - 哈希算法和种子保存在文件头中；maphash的种子无法保存，不能用于映射过滤器
+type MappedBloomFilter struct {
+	*BloomFilter
+	file     *os.File
+	data     []byte     // 整个文件的映射
+	readOnly bool       // 只读打开时修改只作用于进程私有的副本，不会写回文件
+	mu       sync.Mutex // 串行化Flush和Close
+	seq      uint64     // 最近一次写入的文件头序号
+}
+
+// OpenMappedBloomFilter 打开或创建映射到path的布隆过滤器
+// 文件不存在或为空时按expectedElements和falsePositiveRate创建；
+// 文件已存在时沿用文件中的参数、哈希算法和种子，忽略这两个参数和opts
+// opts: 创建时使用的WithBloomHash、WithBloomSeed等选项，BloomHashMapHash返回错误
+func OpenMappedBloomFilter(path string, expectedElements uint, falsePositiveRate float64, opts ...BloomFilterOption) (*MappedBloomFilter, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	var mbf *MappedBloomFilter
+	if info.Size() == 0 {
+		mbf, err = createMappedBloomFilter(file, NewBloomFilter(expectedElements, falsePositiveRate, opts...))
+	} else {
+		mbf, err = loadMappedBloomFilter(file, info.Size(), false)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return mbf, nil
+}
+
+// OpenMappedBloomFilterReadOnly 以只读方式打开已有的映射布隆过滤器
+// 多个进程可以同时只读打开同一个文件；Add等修改只作用于进程私有的副本，Flush返回错误
+func OpenMappedBloomFilterReadOnly(path string) (*MappedBloomFilter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	mbf, err := loadMappedBloomFilter(file, info.Size(), true)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return mbf, nil
+}
+
+// createMappedBloomFilter 按bf的参数初始化空文件并写入第一个文件头
+func createMappedBloomFilter(file *os.File, bf *BloomFilter) (*MappedBloomFilter, error) {
+	if bf.hash == BloomHashMapHash {
+		return nil, fmt.Errorf("bloom filters using maphash cannot be mapped to a file")
+	}
+	size := mappedBloomHeaderSize + int64(len(bf.bitArray))*8
+	if err := file.Truncate(size); err != nil {
+		return nil, err
+	}
+	data, err := mapFile(file, int(size), false)
+	if err != nil {
+		return nil, err
+	}
+	mbf := &MappedBloomFilter{file: file, data: data}
+	mbf.BloomFilter = &BloomFilter{bitArray: mbf.words(bf.m), m: bf.m, k: bf.k, hash: bf.hash, seed: bf.seed}
+	err = mbf.writeHeader(0)
+	if err == nil {
+		// 文件长度的变化也要落盘
+		err = file.Sync()
+	}
+	if err != nil {
+		unmapFile(data)
+		return nil, err
+	}
+	return mbf, nil
+}
+
+// loadMappedBloomFilter 映射已有文件，从较新的有效文件头恢复参数和计数
+func loadMappedBloomFilter(file *os.File, size int64, readOnly bool) (*MappedBloomFilter, error) {
+	if size < mappedBloomHeaderSize {
+		return nil, fmt.Errorf("mapped bloom filter file too short: %d bytes", size)
+	}
+	header := make([]byte, mappedBloomHeaderSize)
+	if _, err := file.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+
+	var m, k, count, seq, seed uint64
+	var hash BloomHash
+	valid := false
+	for slot := 0; slot < 2; slot++ {
+		b := header[slot*mappedBloomSlotSize : slot*mappedBloomSlotSize+mappedBloomSlotLen]
+		if binary.BigEndian.Uint32(b) != crc32.ChecksumIEEE(b[4:]) || string(b[4:8]) != mappedBloomMagic {
+			continue
+		}
+		if version := binary.BigEndian.Uint32(b[8:]); version != bloomFilterVersion {
+			return nil, fmt.Errorf("unsupported bloom filter version %d", version)
+		}
+		if s := binary.BigEndian.Uint64(b[32:]); !valid || s > seq {
+			k = uint64(binary.BigEndian.Uint32(b[12:]))
+			m = binary.BigEndian.Uint64(b[16:])
+			count = binary.BigEndian.Uint64(b[24:])
+			seq = s
+			hash = BloomHash(binary.BigEndian.Uint32(b[40:]))
+			seed = binary.BigEndian.Uint64(b[44:])
+			valid = true
+		}
+	}
+	if !valid {
+		return nil, fmt.Errorf("no valid mapped bloom filter header")
+	}
+	if m == 0 || k == 0 {
+		return nil, fmt.Errorf("invalid bloom filter parameters m=%d k=%d", m, k)
+	}
+	if hash != BloomHashFNV && hash != BloomHashXXHash {
+		return nil, fmt.Errorf("unsupported bloom filter hash %v", hash)
+	}
+	if expected := mappedBloomHeaderSize + int64((m+63)/64)*8; size != expected {
+		return nil, fmt.Errorf("mapped bloom filter file is %d bytes, expected %d for m=%d", size, expected, m)
+	}
+
+	data, err := mapFile(file, int(size), readOnly)
+	if err != nil {
+		return nil, err
+	}
+	mbf := &MappedBloomFilter{file: file, data: data, readOnly: readOnly, seq: seq}
+	mbf.BloomFilter = &BloomFilter{bitArray: mbf.words(uint(m)), m: uint(m), k: uint(k), hash: hash, seed: seed}
+	mbf.count.Store(count)
+	return mbf, nil
+}
+
+// words 把映射中文件头之后的部分解释为位数组
+// 映射按页对齐，文件头大小是8的倍数，字的原子操作满足对齐要求
+func (mbf *MappedBloomFilter) words(m uint) []uint64 {
+	return unsafe.Slice((*uint64)(unsafe.Pointer(&mbf.data[mappedBloomHeaderSize])), (m+63)/64)
+}
+
+// writeHeader 把参数、哈希配置和count写入较旧的文件头槽并刷盘
+func (mbf *MappedBloomFilter) writeHeader(count uint64) error {
+	mbf.seq++
+	slot := int(mbf.seq % 2)
+	b := mbf.data[slot*mappedBloomSlotSize : slot*mappedBloomSlotSize+mappedBloomSlotLen]
+	copy(b[4:], mappedBloomMagic)
+	binary.BigEndian.PutUint32(b[8:], bloomFilterVersion)
+	binary.BigEndian.PutUint32(b[12:], uint32(mbf.k))
+	binary.BigEndian.PutUint64(b[16:], uint64(mbf.m))
+	binary.BigEndian.PutUint64(b[24:], count)
+	binary.BigEndian.PutUint64(b[32:], mbf.seq)
+	binary.BigEndian.PutUint32(b[40:], uint32(mbf.hash))
+	binary.BigEndian.PutUint64(b[44:], mbf.seed)
+	binary.BigEndian.PutUint32(b, crc32.ChecksumIEEE(b[4:]))
+	return syncMapping(mbf.file, mbf.data, mappedBloomHeaderSize)
+}
+
+// Flush 把位数组和计数刷到磁盘
+// 先刷位数组再写文件头，文件头中的计数不会超前于磁盘上的位；只读打开时返回错误
+func (mbf *MappedBloomFilter) Flush() error {
+	if mbf.readOnly {
+		return fmt.Errorf("mapped bloom filter is read-only")
+	}
+	mbf.mu.Lock()
+	defer mbf.mu.Unlock()
+
+	// 计数在刷位数组之前读取，之后并发的Add可能已写入磁盘，但不会计入这次的文件头
+	count := mbf.count.Load()
+	if err := syncMapping(mbf.file, mbf.data, len(mbf.data)); err != nil {
+		return err
+	}
+	return mbf.writeHeader(count)
+}
+
+// Close 刷盘（只读打开时跳过）后解除映射并关闭文件，之后不能再使用该过滤器
+func (mbf *MappedBloomFilter) Close() error {
+	var err error
+	if !mbf.readOnly {
+		err = mbf.Flush()
+	}
+	mbf.mu.Lock()
+	defer mbf.mu.Unlock()
+
+	if uerr := unmapFile(mbf.data); err == nil {
+		err = uerr
+	}
+	if cerr := mbf.file.Close(); err == nil {
+		err = cerr
+	}
+	mbf.data = nil
+	mbf.BloomFilter.bitArray = nil
+	return err
+}
+
+// Describe 返回映射布隆过滤器的能力描述
+func (mbf *MappedBloomFilter) Describe() Descriptor {
+	d := mbf.BloomFilter.Describe()
+	d.Name = "MappedBloomFilter"
+	d.Notes = "位数组映射到文件（mmap），Flush后持久化，打开时不读取整个文件；只读打开时可被多个进程共享。" + d.Notes
+	return d
+}
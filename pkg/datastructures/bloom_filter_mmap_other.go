@@ -0,0 +1,27 @@
+//go:build !(linux || darwin || freebsd)
+
+package datastructures
+
+import "os"
+
+// mapFile 不支持内存映射的平台上把文件读入内存，Flush时整体写回
+func mapFile(file *os.File, size int, _ bool) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := file.ReadAt(data, 0); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// unmapFile 内存中的副本由GC回收
+func unmapFile([]byte) error {
+	return nil
+}
+
+// syncMapping 把内存副本的前n字节写回文件并刷盘
+func syncMapping(file *os.File, data []byte, n int) error {
+	if _, err := file.WriteAt(data[:n], 0); err != nil {
+		return err
+	}
+	return file.Sync()
+}
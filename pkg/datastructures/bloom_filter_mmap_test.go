@@ -0,0 +1,151 @@
+package datastructures
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMappedBloomFilterReopen 测试Flush后的内容在重新打开和只读打开后都能看到
+func TestMappedBloomFilterReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bloom.db")
+	mbf, err := OpenMappedBloomFilter(path, 10000, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5000; i++ {
+		mbf.AddString(fmt.Sprintf("key-%d", i))
+	}
+	if err := mbf.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 已有文件沿用文件中的参数
+	reopened, err := OpenMappedBloomFilter(path, 1, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	readOnly, err := OpenMappedBloomFilterReadOnly(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer readOnly.Close()
+
+	for _, f := range []*MappedBloomFilter{reopened, readOnly} {
+		if f.Size() != 5000 || f.BitSize() != mbf.BitSize() || f.HashFuncCount() != mbf.HashFuncCount() {
+			t.Fatalf("重新打开的过滤器 = %v, 期望 %v", f, mbf)
+		}
+		for i := 0; i < 5000; i++ {
+			if !f.ContainsString(fmt.Sprintf("key-%d", i)) {
+				t.Fatalf("重新打开后缺少 key-%d", i)
+			}
+		}
+	}
+
+	// 只读打开时的修改不写回文件
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		readOnly.AddString(fmt.Sprintf("private-%d", i))
+	}
+	if err := readOnly.Flush(); err == nil {
+		t.Fatal("只读过滤器的 Flush 应该返回错误")
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Fatal("只读模式下的修改被写入了文件")
+	}
+}
+
+// TestMappedBloomFilterTornHeader 测试较新的文件头损坏时回退到另一个文件头
+func TestMappedBloomFilterTornHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bloom.db")
+	mbf, err := OpenMappedBloomFilter(path, 1000, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mbf.AddString("first")
+	if err := mbf.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	mbf.AddString("second")
+	newest := int(mbf.seq+1) % 2
+	if err := mbf.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟写最新文件头时崩溃
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteAt([]byte{0xff, 0xff}, int64(newest*mappedBloomSlotSize+20)); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	reopened, err := OpenMappedBloomFilter(path, 1000, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Size() != 1 || !reopened.ContainsString("first") {
+		t.Fatalf("备用文件头: %v", reopened)
+	}
+	reopened.Close()
+
+	// 两个文件头都损坏时拒绝打开
+	file, _ = os.OpenFile(path, os.O_RDWR, 0)
+	file.WriteAt([]byte{0xff, 0xff}, 20)
+	file.WriteAt([]byte{0xff, 0xff}, mappedBloomSlotSize+20)
+	file.Close()
+	if _, err := OpenMappedBloomFilter(path, 1000, 0.01); err == nil {
+		t.Fatal("没有有效文件头的文件应该打开失败")
+	}
+}
+
+// TestMappedBloomFilterHashOptions 测试哈希算法和种子保存在文件头中，重新打开后位置计算不变
+func TestMappedBloomFilterHashOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bloom.db")
+	opts := []BloomFilterOption{WithBloomHash(BloomHashXXHash), WithBloomSeed(42)}
+	mbf, err := OpenMappedBloomFilter(path, 1000, 0.01, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := mbf.Describe(); d.Name != "MappedBloomFilter" || !d.Persistent {
+		t.Errorf("Describe() = %+v", d)
+	}
+	for i := 0; i < 500; i++ {
+		mbf.AddString(fmt.Sprintf("key-%d", i))
+	}
+	if err := mbf.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 与相同配置的内存过滤器位数组相同
+	want := NewBloomFilter(1000, 0.01, opts...)
+	for i := 0; i < 500; i++ {
+		want.AddString(fmt.Sprintf("key-%d", i))
+	}
+	reopened, err := OpenMappedBloomFilterReadOnly(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if reopened.hash != BloomHashXXHash || reopened.Seed() != 42 {
+		t.Fatalf("重新打开后 hash = %v, seed = %d, 期望 xxhash 和 42", reopened.hash, reopened.Seed())
+	}
+	if string(reopened.bytes()) != string(want.bytes()) {
+		t.Fatal("重新打开的过滤器与相同配置的内存过滤器位数组不同")
+	}
+
+	if _, err := OpenMappedBloomFilter(filepath.Join(t.TempDir(), "maphash.db"), 1000, 0.01, WithBloomHash(BloomHashMapHash)); err == nil {
+		t.Error("maphash 过滤器不应该能映射到文件")
+	}
+}
@@ -0,0 +1,33 @@
+//go:build linux || darwin || freebsd
+
+package datastructures
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// mapFile 把文件的前size字节映射到内存
+// 只读时使用私有映射，修改只作用于本进程的副本；否则使用共享映射，修改直接写入页缓存
+func mapFile(file *os.File, size int, readOnly bool) ([]byte, error) {
+	flags := syscall.MAP_SHARED
+	if readOnly {
+		flags = syscall.MAP_PRIVATE
+	}
+	return syscall.Mmap(int(file.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, flags)
+}
+
+// unmapFile 解除映射
+func unmapFile(data []byte) error {
+	return syscall.Munmap(data)
+}
+
+// syncMapping 把映射的前n字节同步写回文件
+func syncMapping(_ *os.File, data []byte, n int) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_MSYNC, uintptr(unsafe.Pointer(&data[0])), uintptr(n), syscall.MS_SYNC)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
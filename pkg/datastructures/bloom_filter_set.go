@@ -0,0 +1,181 @@
+package datastructures
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"sync/atomic"
+)
+
+// errBloomSaturated 位数组已全部置位，无法从置位数估计元素数量
+var errBloomSaturated = errors.New("bloom filter is saturated")
+
+// checkCompatible 检查两个过滤器能否按位组合：位数、哈希函数数量和哈希算法都必须相同
+func (bf *BloomFilter) checkCompatible(other *BloomFilter) error {
+	if other.m != bf.m || other.k != bf.k {
+		return fmt.Errorf("bloom filters have different parameters: m=%d k=%d vs m=%d k=%d",
+			bf.m, bf.k, other.m, other.k)
+	}
+	if other.hash != bf.hash || other.seed != bf.seed || other.mapSeed != bf.mapSeed {
+		return fmt.Errorf("bloom filters use different hash functions: %v seed %d vs %v seed %d",
+			bf.hash, bf.seed, other.hash, other.seed)
+	}
+	return nil
+}
+
+// emptyLike 返回参数和哈希配置与bf相同的空过滤器
+func (bf *BloomFilter) emptyLike() *BloomFilter {
+	return &BloomFilter{
+		bitArray: make([]uint64, len(bf.bitArray)),
+		m:        bf.m,
+		k:        bf.k,
+		hash:     bf.hash,
+		seed:     bf.seed,
+		mapSeed:  bf.mapSeed,
+	}
+}
+
+// estimateElements 由置位数估计插入过的不同元素数量（Swamidass-Baldi公式）
+// n ≈ -(m/k)·ln(1 - X/m)，X为置位数；全部置位时返回errBloomSaturated
+func (bf *BloomFilter) estimateElements(setBits uint64) (float64, error) {
+	if setBits >= uint64(bf.m) {
+		return 0, errBloomSaturated
+	}
+	m := float64(bf.m)
+	return -m / float64(bf.k) * math.Log(1-float64(setBits)/m), nil
+}
+
+// setBitCount 统计置位数
+func (bf *BloomFilter) setBitCount() uint64 {
+	var n uint64
+	for i := range bf.bitArray {
+		n += uint64(bits.OnesCount64(atomic.LoadUint64(&bf.bitArray[i])))
+	}
+	return n
+}
+
+// BitsSet 返回位数组中已置位的位数
+func (bf *BloomFilter) BitsSet() uint {
+	return uint(bf.setBitCount())
+}
+
+// FillRatio 返回已置位的比例，范围[0, 1]
+// 按最优参数构建的过滤器在达到预期元素数量时约为0.5，接近1时假阳性率迅速上升，可据此告警；
+// 与GetFalsePositiveRate不同，它不依赖Add的调用次数
+func (bf *BloomFilter) FillRatio() float64 {
+	return float64(bf.setBitCount()) / float64(bf.m)
+}
+
+// EstimateCardinality 由置位数估计插入过的不同元素数量
+// 与Size不同，重复添加的元素只计一次，Merge后也仍然准确；位数组全部置位时返回+Inf
+func (bf *BloomFilter) EstimateCardinality() float64 {
+	n, err := bf.estimateElements(bf.setBitCount())
+	if err != nil {
+		return math.Inf(1)
+	}
+	return n
+}
+
+// countSetBits 统计两个过滤器各自以及按位或之后的置位数
+func (bf *BloomFilter) countSetBits(other *BloomFilter) (a, b, union uint64) {
+	for i := range bf.bitArray {
+		x := atomic.LoadUint64(&bf.bitArray[i])
+		y := atomic.LoadUint64(&other.bitArray[i])
+		a += uint64(bits.OnesCount64(x))
+		b += uint64(bits.OnesCount64(y))
+		union += uint64(bits.OnesCount64(x | y))
+	}
+	return a, b, union
+}
+
+// EstimateOverlap 估计两个过滤器共同包含的元素数量，用于跨分片去重的规划
+// 分别由置位数估计|A|、|B|和|A∪B|，交集为|A|+|B|-|A∪B|，结果限制在[0, min(|A|,|B|)]；
+// 两个过滤器的参数必须相同，任一过滤器或其并集全部置位时无法估计，返回错误
+func (bf *BloomFilter) EstimateOverlap(other *BloomFilter) (float64, error) {
+	if err := bf.checkCompatible(other); err != nil {
+		return 0, err
+	}
+	a, b, union := bf.countSetBits(other)
+	na, err := bf.estimateElements(a)
+	if err != nil {
+		return 0, err
+	}
+	nb, err := bf.estimateElements(b)
+	if err != nil {
+		return 0, err
+	}
+	nu, err := bf.estimateElements(union)
+	if err != nil {
+		return 0, err
+	}
+	return math.Max(0, math.Min(na+nb-nu, math.Min(na, nb))), nil
+}
+
+// Intersect 返回两个过滤器按位与得到的新过滤器，原过滤器不变
+// 两者共同包含的元素一定被新过滤器判定为存在；但只在其中一个中的元素也可能恰好全部命中，
+// 假阳性率高于直接用交集元素构建的过滤器。新过滤器的元素数量取EstimateOverlap的估计值
+func (bf *BloomFilter) Intersect(other *BloomFilter) (*BloomFilter, error) {
+	if err := bf.checkCompatible(other); err != nil {
+		return nil, err
+	}
+	overlap, err := bf.EstimateOverlap(other)
+	if err != nil && err != errBloomSaturated {
+		return nil, err
+	}
+
+	result := bf.emptyLike()
+	for i := range result.bitArray {
+		result.bitArray[i] = atomic.LoadUint64(&bf.bitArray[i]) & atomic.LoadUint64(&other.bitArray[i])
+	}
+	if err == errBloomSaturated {
+		// 无法估计时保守地取较小一方的计数
+		overlap = float64(min(bf.count.Load(), other.count.Load()))
+	}
+	result.count.Store(uint64(math.Round(overlap)))
+	return result, nil
+}
+
+// emptyFolded 返回位数为bf的1/factor、其余配置相同的空过滤器，调用方保证m能被factor整除
+func (bf *BloomFilter) emptyFolded(factor uint) *BloomFilter {
+	folded := bf.emptyLike()
+	folded.m = bf.m / factor
+	folded.bitArray = make([]uint64, (folded.m+63)/64)
+	return folded
+}
+
+// foldInto 把bf的位按位置对dst.m取模并入dst，调用方保证bf.m是dst.m的整数倍
+// g_i mod m' = (g_i mod m) mod m'在m'整除m时成立，因此折叠后的位置与直接插入dst的位置相同
+func (bf *BloomFilter) foldInto(dst *BloomFilter) {
+	if dst.m%64 == 0 {
+		// 位数按字对齐时整字折叠
+		words := uint(len(dst.bitArray))
+		for i := range bf.bitArray {
+			if w := atomic.LoadUint64(&bf.bitArray[i]); w != 0 {
+				orUint64(&dst.bitArray[uint(i)%words], w)
+			}
+		}
+		return
+	}
+	for i := range bf.bitArray {
+		w := atomic.LoadUint64(&bf.bitArray[i])
+		for w != 0 {
+			pos := uint(i)*64 + uint(bits.TrailingZeros64(w))
+			dst.setBit(pos % dst.m)
+			w &= w - 1
+		}
+	}
+}
+
+// Fold 返回位数缩小为1/factor的新过滤器，原过滤器不变；m必须能被factor整除
+// 折叠后的过滤器与直接用同样的元素构建的m/factor位过滤器完全相同，
+// 假阳性率相应升高为(1 - e^(-k·n·factor/m))^k，适合合并前统一大小或压缩稀疏的过滤器
+func (bf *BloomFilter) Fold(factor uint) (*BloomFilter, error) {
+	if factor == 0 || bf.m%factor != 0 {
+		return nil, fmt.Errorf("cannot fold %d bits by factor %d", bf.m, factor)
+	}
+	folded := bf.emptyFolded(factor)
+	bf.foldInto(folded)
+	folded.count.Store(bf.count.Load())
+	return folded, nil
+}
@@ -0,0 +1,156 @@
+package datastructures
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// shardSeed 选择分片时与h1混合的常量，使分片号与分片内的位置相互独立
+const shardSeed uint64 = 0xc2b2ae3d27d4eb4f
+
+// ShardedBloomFilter 分片布隆过滤器
+// 特点：
+// - 按哈希把元素划分到N个独立的子过滤器，每个元素只访问一个分片
+// - 各分片的位数组是独立分配的内存，并发写入分散在不同的缓存行上
+// - Merge、Clear等整体操作按分片进行，各分片持有自己的锁，不会一次阻塞全部分片
+// - 每个分片按expectedElements/N构建，整体假阳性率与同样容量的BloomFilter相当
+// - 分片数和参数相同的分片过滤器之间可以Merge，适合各节点分别构建后汇总
+type ShardedBloomFilter struct {
+	shards []*BloomFilter
+}
+
+// NewShardedBloomFilter 创建分片布隆过滤器
+// expectedElements: 全部分片合计的期望元素数量
+// falsePositiveRate: 期望的假阳性率 (0 < fpr < 1)
+// shards: 分片数量，通常取并发写入的goroutine数量的若干倍
+// opts: 应用于每个分片的选项；WithBloomHash(BloomHashMapHash)时所有分片共享同一个种子
+func NewShardedBloomFilter(expectedElements uint, falsePositiveRate float64, shards int, opts ...BloomFilterOption) *ShardedBloomFilter {
+	if shards <= 0 {
+		panic("shards must be > 0")
+	}
+	if expectedElements == 0 {
+		panic("expectedElements must be > 0")
+	}
+
+	perShard := (expectedElements + uint(shards) - 1) / uint(shards)
+	first := NewBloomFilter(perShard, falsePositiveRate, opts...)
+	sbf := &ShardedBloomFilter{shards: make([]*BloomFilter, shards)}
+	sbf.shards[0] = first
+	for i := 1; i < shards; i++ {
+		sbf.shards[i] = first.emptyLike()
+	}
+	return sbf
+}
+
+// locate 计算元素的哈希值和所在的分片
+// 分片号取再混合后的h1乘以分片数的高64位，与分片内由h1、h2决定的位置相互独立
+func (sbf *ShardedBloomFilter) locate(data []byte) (shard *BloomFilter, h1, h2 uint64) {
+	h1, h2 = sbf.shards[0].hashes(data)
+	i, _ := bits.Mul64(murmurFmix64(h1^shardSeed), uint64(len(sbf.shards)))
+	return sbf.shards[i], h1, h2
+}
+
+// Add 添加元素
+func (sbf *ShardedBloomFilter) Add(data []byte) {
+	shard, h1, h2 := sbf.locate(data)
+	shard.addHashes(h1, h2)
+}
+
+// AddString 添加字符串元素
+func (sbf *ShardedBloomFilter) AddString(s string) {
+	sbf.Add([]byte(s))
+}
+
+// AddInt 添加整数元素，编码与BloomFilter.AddInt相同
+func (sbf *ShardedBloomFilter) AddInt(n int) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(n))
+	sbf.Add(b[:])
+}
+
+// Contains 检查元素是否存在
+// 返回true表示可能存在，返回false表示一定不存在
+func (sbf *ShardedBloomFilter) Contains(data []byte) bool {
+	shard, h1, h2 := sbf.locate(data)
+	return shard.containsHashes(h1, h2)
+}
+
+// ContainsString 检查字符串元素是否存在
+func (sbf *ShardedBloomFilter) ContainsString(s string) bool {
+	return sbf.Contains([]byte(s))
+}
+
+// ContainsInt 检查整数元素是否存在
+func (sbf *ShardedBloomFilter) ContainsInt(n int) bool {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(n))
+	return sbf.Contains(b[:])
+}
+
+// Merge 把另一个分片过滤器逐分片并入sbf
+// 两者的分片数、每个分片的参数和哈希算法都必须相同
+func (sbf *ShardedBloomFilter) Merge(other *ShardedBloomFilter) error {
+	if len(other.shards) != len(sbf.shards) {
+		return fmt.Errorf("cannot merge sharded bloom filters with %d and %d shards", len(sbf.shards), len(other.shards))
+	}
+	if err := sbf.shards[0].checkCompatible(other.shards[0]); err != nil {
+		return err
+	}
+	for i, shard := range sbf.shards {
+		if err := shard.Merge(other.shards[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clear 逐分片清空
+func (sbf *ShardedBloomFilter) Clear() {
+	for _, shard := range sbf.shards {
+		shard.Clear()
+	}
+}
+
+// Size 返回全部分片的Add调用次数之和
+func (sbf *ShardedBloomFilter) Size() uint64 {
+	var n uint64
+	for _, shard := range sbf.shards {
+		n += shard.Size()
+	}
+	return n
+}
+
+// EstimateCardinality 返回各分片不同元素数量估计值之和
+func (sbf *ShardedBloomFilter) EstimateCardinality() float64 {
+	var n float64
+	for _, shard := range sbf.shards {
+		n += shard.EstimateCardinality()
+	}
+	return n
+}
+
+// ShardCount 返回分片数量
+func (sbf *ShardedBloomFilter) ShardCount() int {
+	return len(sbf.shards)
+}
+
+// Shard 返回第i个分片
+// 分片与分片过滤器共享位数组；分片内的元素经过了选择，单独使用时只对落在该分片的元素有意义
+func (sbf *ShardedBloomFilter) Shard(i int) *BloomFilter {
+	return sbf.shards[i]
+}
+
+// Describe 返回分片布隆过滤器的能力描述
+func (sbf *ShardedBloomFilter) Describe() Descriptor {
+	return Descriptor{
+		Name:          "ShardedBloomFilter",
+		Probabilistic: true,
+		Concurrency:   ConcurrencyStriped,
+		Complexity: Complexity{
+			Insert: "O(k)",
+			Search: "O(k)",
+		},
+		Notes: "按哈希分片的布隆过滤器，写入分散在独立的子过滤器上，分片数相同的过滤器可以合并",
+	}
+}
@@ -0,0 +1,85 @@
+package datastructures
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestShardedBloomFilter 测试并发写入后没有假阴性、元素分布到全部分片，以及分片过滤器的合并
+func TestShardedBloomFilter(t *testing.T) {
+	const n = 80000
+	sbf := NewShardedBloomFilter(2*n, 0.01, 8)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < n; i += 8 {
+				sbf.AddInt(i)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		if !sbf.ContainsInt(i) {
+			t.Fatalf("%d 出现假阴性", i)
+		}
+	}
+	if sbf.Size() != n {
+		t.Fatalf("Size() = %d, 期望 %d", sbf.Size(), n)
+	}
+	for i := 0; i < sbf.ShardCount(); i++ {
+		// 均匀分布时每个分片约n/8个元素
+		if size := sbf.Shard(i).Size(); size < n/8*9/10 || size > n/8*11/10 {
+			t.Fatalf("分片 %d 有 %d 个元素, 期望约 %d", i, size, n/8)
+		}
+	}
+
+	other := NewShardedBloomFilter(2*n, 0.01, 8)
+	for i := n; i < 2*n; i++ {
+		other.AddInt(i)
+	}
+	if err := sbf.Merge(other); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2*n; i++ {
+		if !sbf.ContainsInt(i) {
+			t.Fatalf("合并后的过滤器缺少 %d", i)
+		}
+	}
+	falsePositives := 0
+	for i := 2 * n; i < 3*n; i++ {
+		if sbf.ContainsInt(i) {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / n; rate > 0.02 {
+		t.Fatalf("达到容量时假阳性率 = %.4f, 期望约 0.01", rate)
+	}
+
+	if err := sbf.Merge(NewShardedBloomFilter(2*n, 0.01, 4)); err == nil {
+		t.Fatal("分片数不同的过滤器不应该能合并")
+	}
+}
+
+// BenchmarkShardedBloomFilterParallelAdd 并发写入时单个过滤器与分片过滤器的对比
+func BenchmarkShardedBloomFilterParallelAdd(b *testing.B) {
+	b.Run("Single", func(b *testing.B) {
+		bf := NewBloomFilter(1<<20, 0.01)
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				bf.AddInt(i)
+			}
+		})
+	})
+	b.Run("Sharded", func(b *testing.B) {
+		sbf := NewShardedBloomFilter(1<<20, 0.01, 16)
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				sbf.AddInt(i)
+			}
+		})
+	})
+}
@@ -0,0 +1,168 @@
+package datastructures
+
+import (
+	"encoding/binary"
+	"math"
+	"sync/atomic"
+)
+
+// SpectralBloomFilter 谱布隆过滤器（Cohen & Matias, 2003），估计元素被添加的次数
+// 特点：
+// - 把BloomFilter的每个位换成一个32位计数器，Add把元素的k个计数器加1
+// - Count取k个计数器中的最小值（minimum selection），只会高估不会低估
+// - 高估的概率与同样参数的BloomFilter的假阳性率相同
+// - 与Count-Min Sketch相比，k个计数器共享同一个数组，接口与BloomFilter保持一致
+// - 计数器按原子操作读写，Add、Count和Remove不加锁
+// - 内存是同样参数的BloomFilter的32倍
+type SpectralBloomFilter struct {
+	counters []uint32      // 计数器数组
+	k        uint          // 哈希函数数量
+	count    atomic.Uint64 // 添加的总次数
+}
+
+// NewSpectralBloomFilter 创建谱布隆过滤器，参数与NewBloomFilter相同
+// falsePositiveRate既是Contains的假阳性率，也是Count高估的概率
+func NewSpectralBloomFilter(expectedElements uint, falsePositiveRate float64) *SpectralBloomFilter {
+	m, k := EstimateParameters(expectedElements, falsePositiveRate)
+	return &SpectralBloomFilter{
+		counters: make([]uint32, m),
+		k:        k,
+	}
+}
+
+// position 返回元素的第i个计数器，与BloomFilter相同的双重哈希
+func (sbf *SpectralBloomFilter) position(h1, h2 uint64, i uint) uint {
+	return uint((h1 + uint64(i)*h2) % uint64(len(sbf.counters)))
+}
+
+// Add 把元素的次数加1
+func (sbf *SpectralBloomFilter) Add(data []byte) {
+	sbf.AddN(data, 1)
+}
+
+// AddN 把元素的次数加n，计数器达到上限后不再增加
+func (sbf *SpectralBloomFilter) AddN(data []byte, n uint32) {
+	h1, h2 := bloomHashes(data, 0)
+	for i := uint(0); i < sbf.k; i++ {
+		addSaturating(&sbf.counters[sbf.position(h1, h2, i)], n)
+	}
+	sbf.count.Add(uint64(n))
+}
+
+// addSaturating 原子地把*addr加n，超过math.MaxUint32时停在上限
+func addSaturating(addr *uint32, n uint32) {
+	for {
+		old := atomic.LoadUint32(addr)
+		sum := old + n
+		if sum < old {
+			sum = math.MaxUint32
+		}
+		if sum == old || atomic.CompareAndSwapUint32(addr, old, sum) {
+			return
+		}
+	}
+}
+
+// AddString 把字符串元素的次数加1
+func (sbf *SpectralBloomFilter) AddString(s string) {
+	sbf.Add([]byte(s))
+}
+
+// AddInt 把整数元素的次数加1，编码与BloomFilter.AddInt相同
+func (sbf *SpectralBloomFilter) AddInt(n int) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(n))
+	sbf.Add(b[:])
+}
+
+// Count 返回元素被添加次数的估计值
+// 结果不小于真实次数；与BloomFilter假阳性率相同的概率下高估
+func (sbf *SpectralBloomFilter) Count(data []byte) uint32 {
+	h1, h2 := bloomHashes(data, 0)
+	minimum := uint32(math.MaxUint32)
+	for i := uint(0); i < sbf.k; i++ {
+		if c := atomic.LoadUint32(&sbf.counters[sbf.position(h1, h2, i)]); c < minimum {
+			minimum = c
+		}
+	}
+	return minimum
+}
+
+// CountString 返回字符串元素被添加次数的估计值
+func (sbf *SpectralBloomFilter) CountString(s string) uint32 {
+	return sbf.Count([]byte(s))
+}
+
+// CountInt 返回整数元素被添加次数的估计值
+func (sbf *SpectralBloomFilter) CountInt(n int) uint32 {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(n))
+	return sbf.Count(b[:])
+}
+
+// Contains 检查元素是否存在，即Count(data) > 0
+func (sbf *SpectralBloomFilter) Contains(data []byte) bool {
+	return sbf.Count(data) > 0
+}
+
+// ContainsString 检查字符串元素是否存在
+func (sbf *SpectralBloomFilter) ContainsString(s string) bool {
+	return sbf.Contains([]byte(s))
+}
+
+// Remove 把元素的次数减1，元素的估计次数为0时返回false且不做修改
+// 只能移除确实添加过的元素：移除因假阳性而"存在"的元素会让共享计数器的其他元素被低估
+func (sbf *SpectralBloomFilter) Remove(data []byte) bool {
+	if sbf.Count(data) == 0 {
+		return false
+	}
+	h1, h2 := bloomHashes(data, 0)
+	for i := uint(0); i < sbf.k; i++ {
+		addr := &sbf.counters[sbf.position(h1, h2, i)]
+		for {
+			old := atomic.LoadUint32(addr)
+			// 饱和的计数器已经丢失了真实值，保持不变
+			if old == 0 || old == math.MaxUint32 || atomic.CompareAndSwapUint32(addr, old, old-1) {
+				break
+			}
+		}
+	}
+	sbf.count.Add(^uint64(0))
+	return true
+}
+
+// RemoveString 把字符串元素的次数减1
+func (sbf *SpectralBloomFilter) RemoveString(s string) bool {
+	return sbf.Remove([]byte(s))
+}
+
+// Size 返回添加的总次数（减去移除的次数）
+func (sbf *SpectralBloomFilter) Size() uint64 {
+	return sbf.count.Load()
+}
+
+// Cells 返回计数器数量
+func (sbf *SpectralBloomFilter) Cells() uint {
+	return uint(len(sbf.counters))
+}
+
+// HashFuncCount 返回哈希函数数量
+func (sbf *SpectralBloomFilter) HashFuncCount() uint {
+	return sbf.k
+}
+
+// Describe 返回谱布隆过滤器的能力描述
+func (sbf *SpectralBloomFilter) Describe() Descriptor {
+	return Descriptor{
+		Name:           "SpectralBloomFilter",
+		Probabilistic:  true,
+		SupportsDelete: true,
+		Concurrency:    ConcurrencyLockFree,
+		Complexity: Complexity{
+			Insert: "O(k)",
+			Search: "O(k)",
+			Delete: "O(k)",
+		},
+		Notes: "计数器版布隆过滤器，估计元素出现次数（只高估），支持删除",
+	}
+}
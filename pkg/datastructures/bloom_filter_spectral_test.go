@@ -0,0 +1,54 @@
+package datastructures
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// TestSpectralBloomFilterCount 测试次数估计从不低估、高估比例接近假阳性率，以及移除和饱和
+func TestSpectralBloomFilterCount(t *testing.T) {
+	const n = 10000
+	sbf := NewSpectralBloomFilter(n, 0.01)
+	// 元素i被添加i%5+1次
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i%5; j++ {
+			sbf.AddInt(i)
+		}
+	}
+
+	overestimated := 0
+	for i := 0; i < n; i++ {
+		got, want := sbf.CountInt(i), uint32(i%5+1)
+		if got < want {
+			t.Fatalf("Count(%d) = %d, 低于实际次数 %d", i, got, want)
+		}
+		if got > want {
+			overestimated++
+		}
+	}
+	if rate := float64(overestimated) / n; rate > 0.02 {
+		t.Fatalf("高估率 = %.4f, 期望约 0.01", rate)
+	}
+
+	sbf.AddString("x")
+	sbf.AddString("x")
+	if !sbf.RemoveString("x") || sbf.CountString("x") != 1 {
+		t.Fatalf("Remove 后 Count() = %d, 期望 1", sbf.CountString("x"))
+	}
+	for i := 0; ; i++ {
+		// 找一个未添加且不是假阳性的元素
+		if key := fmt.Sprintf("absent-%d", i); !sbf.ContainsString(key) {
+			if sbf.RemoveString(key) {
+				t.Fatal("不应该能删除从未添加过的元素")
+			}
+			break
+		}
+	}
+
+	sbf.AddN([]byte("hot"), math.MaxUint32-1)
+	sbf.AddN([]byte("hot"), 10)
+	if c := sbf.Count([]byte("hot")); c != math.MaxUint32 {
+		t.Fatalf("饱和后 Count() = %d, 期望 %d", c, uint32(math.MaxUint32))
+	}
+}
@@ -0,0 +1,223 @@
+package datastructures
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// StableBloomFilter 稳定布隆过滤器（Deng & Rafiei, 2006），用于无界数据流的去重
+// 特点：
+// - 每个单元是一个d位的计数器；添加元素时先把P个随机单元减1，再把元素的k个单元置为最大值
+// - 旧元素的单元逐渐衰减到0而被"遗忘"，0单元的比例收敛到一个与数据流长度无关的稳定点
+// - 假阳性率因此保持在目标值附近，不会像经典布隆过滤器那样随插入持续上升直至饱和
+// - 代价是假阴性：很久以前添加的元素可能被判定为不存在，越近添加的元素越不容易被遗忘
+// - 所有操作由一个互斥锁保护
+type StableBloomFilter struct {
+	cells    []uint8    // 计数器单元
+	maxValue uint8      // 单元的最大值，2^d - 1
+	k        uint       // 哈希函数数量
+	p        uint       // 每次添加时衰减的单元数
+	count    uint64     // Add的调用次数
+	rng      *rand.Rand // 选择衰减起点
+	mu       sync.Mutex
+}
+
+// NewStableBloomFilter 创建稳定布隆过滤器
+// cells: 单元数量，越大越晚遗忘旧元素
+// cellBits: 每个单元的位数d（1~8），越大遗忘越平缓；1时每个单元就是一个位
+// falsePositiveRate: 稳定状态下的目标假阳性率 (0 < fpr < 1)
+func NewStableBloomFilter(cells uint, cellBits uint8, falsePositiveRate float64) *StableBloomFilter {
+	if cells == 0 {
+		panic("cells must be > 0")
+	}
+	if cellBits == 0 || cellBits > 8 {
+		panic("cellBits must be in [1, 8]")
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		panic("falsePositiveRate must be in (0, 1)")
+	}
+
+	k := uint(math.Ceil(math.Log2(1 / falsePositiveRate)))
+	if k > cells {
+		k = cells
+	}
+	maxValue := uint8(1<<cellBits - 1)
+	return &StableBloomFilter{
+		cells:    make([]uint8, cells),
+		maxValue: maxValue,
+		k:        k,
+		p:        stableDecrementCount(cells, k, maxValue, falsePositiveRate),
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// stableDecrementCount 计算使稳定点的假阳性率等于目标值的衰减单元数P
+// 稳定点0单元比例为 (1 / (1 + 1/(P·(1/k - 1/m))))^Max，令(1 - 稳定点)^k = fpr解出P
+func stableDecrementCount(m, k uint, maxValue uint8, falsePositiveRate float64) uint {
+	zeros := 1 - math.Pow(falsePositiveRate, 1/float64(k))
+	denom := (1/math.Pow(zeros, 1/float64(maxValue)) - 1) * (1/float64(k) - 1/float64(m))
+	p := uint(math.Round(1 / denom))
+	if p == 0 || denom <= 0 {
+		p = 1
+	}
+	if p > m {
+		p = m
+	}
+	return p
+}
+
+// position 返回元素的第i个单元，与BloomFilter相同的双重哈希
+func (sbf *StableBloomFilter) position(h1, h2 uint64, i uint) uint {
+	return uint((h1 + uint64(i)*h2) % uint64(len(sbf.cells)))
+}
+
+// decrement 从随机起点开始把连续的P个单元减1（到0为止）
+// 连续的单元与逐个随机选取在统计上等价，只需要一次随机数
+func (sbf *StableBloomFilter) decrement() {
+	m := uint(len(sbf.cells))
+	start := uint(sbf.rng.Int63n(int64(m)))
+	for i := uint(0); i < sbf.p; i++ {
+		j := start + i
+		if j >= m {
+			j -= m
+		}
+		if sbf.cells[j] > 0 {
+			sbf.cells[j]--
+		}
+	}
+}
+
+// Add 添加元素
+func (sbf *StableBloomFilter) Add(data []byte) {
+	sbf.TestAndAdd(data)
+}
+
+// TestAndAdd 检查元素是否存在并添加，返回添加之前的检查结果
+// 去重时代替先Contains再Add，两步在同一次加锁中完成
+func (sbf *StableBloomFilter) TestAndAdd(data []byte) bool {
+	h1, h2 := bloomHashes(data, 0)
+
+	sbf.mu.Lock()
+	defer sbf.mu.Unlock()
+
+	present := sbf.containsLocked(h1, h2)
+	sbf.decrement()
+	for i := uint(0); i < sbf.k; i++ {
+		sbf.cells[sbf.position(h1, h2, i)] = sbf.maxValue
+	}
+	sbf.count++
+	return present
+}
+
+// AddString 添加字符串元素
+func (sbf *StableBloomFilter) AddString(s string) {
+	sbf.Add([]byte(s))
+}
+
+// TestAndAddString 检查字符串元素是否存在并添加
+func (sbf *StableBloomFilter) TestAndAddString(s string) bool {
+	return sbf.TestAndAdd([]byte(s))
+}
+
+// AddInt 添加整数元素，编码与BloomFilter.AddInt相同
+func (sbf *StableBloomFilter) AddInt(n int) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(n))
+	sbf.Add(b[:])
+}
+
+// containsLocked 检查元素的k个单元是否都非0，调用方必须持有锁
+func (sbf *StableBloomFilter) containsLocked(h1, h2 uint64) bool {
+	for i := uint(0); i < sbf.k; i++ {
+		if sbf.cells[sbf.position(h1, h2, i)] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Contains 检查元素是否存在
+// 返回true表示可能存在；返回false表示从未添加或已被遗忘
+func (sbf *StableBloomFilter) Contains(data []byte) bool {
+	h1, h2 := bloomHashes(data, 0)
+
+	sbf.mu.Lock()
+	defer sbf.mu.Unlock()
+	return sbf.containsLocked(h1, h2)
+}
+
+// ContainsString 检查字符串元素是否存在
+func (sbf *StableBloomFilter) ContainsString(s string) bool {
+	return sbf.Contains([]byte(s))
+}
+
+// ContainsInt 检查整数元素是否存在
+func (sbf *StableBloomFilter) ContainsInt(n int) bool {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(n))
+	return sbf.Contains(b[:])
+}
+
+// Size 返回Add的调用次数
+func (sbf *StableBloomFilter) Size() uint64 {
+	sbf.mu.Lock()
+	defer sbf.mu.Unlock()
+	return sbf.count
+}
+
+// Cells 返回单元数量
+func (sbf *StableBloomFilter) Cells() uint {
+	return uint(len(sbf.cells))
+}
+
+// HashFuncCount 返回哈希函数数量
+func (sbf *StableBloomFilter) HashFuncCount() uint {
+	return sbf.k
+}
+
+// DecrementCount 返回每次添加时衰减的单元数P
+func (sbf *StableBloomFilter) DecrementCount() uint {
+	return sbf.p
+}
+
+// StablePoint 返回稳定状态下0单元比例的理论值
+func (sbf *StableBloomFilter) StablePoint() float64 {
+	m, k := float64(len(sbf.cells)), float64(sbf.k)
+	return math.Pow(1/(1+1/(float64(sbf.p)*(1/k-1/m))), float64(sbf.maxValue))
+}
+
+// FalsePositiveRate 返回稳定状态下假阳性率的理论值，(1 - StablePoint)^k
+func (sbf *StableBloomFilter) FalsePositiveRate() float64 {
+	return math.Pow(1-sbf.StablePoint(), float64(sbf.k))
+}
+
+// ZeroRatio 返回当前0单元的比例，随添加收敛到StablePoint
+func (sbf *StableBloomFilter) ZeroRatio() float64 {
+	sbf.mu.Lock()
+	defer sbf.mu.Unlock()
+
+	zeros := 0
+	for _, c := range sbf.cells {
+		if c == 0 {
+			zeros++
+		}
+	}
+	return float64(zeros) / float64(len(sbf.cells))
+}
+
+// Describe 返回稳定布隆过滤器的能力描述
+func (sbf *StableBloomFilter) Describe() Descriptor {
+	return Descriptor{
+		Name:          "StableBloomFilter",
+		Probabilistic: true,
+		Concurrency:   ConcurrencyMutex,
+		Complexity: Complexity{
+			Insert: "O(k+P)",
+			Search: "O(k)",
+		},
+		Notes: "用于无界数据流去重，假阳性率有界，旧元素会被遗忘（有假阴性）",
+	}
+}
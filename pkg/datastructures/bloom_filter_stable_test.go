@@ -0,0 +1,45 @@
+package datastructures
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// TestStableBloomFilterStream 测试无界数据流中假阳性率稳定在目标值附近，最近的元素不会被遗忘
+func TestStableBloomFilterStream(t *testing.T) {
+	const target = 0.01
+	sbf := NewStableBloomFilter(100000, 3, target)
+	if fpr := sbf.FalsePositiveRate(); math.Abs(fpr-target) > target/2 {
+		t.Fatalf("理论稳定假阳性率 = %f, 期望约 %f", fpr, target)
+	}
+
+	// 流的长度是单元数的10倍，经典布隆过滤器早已饱和
+	const stream = 1000000
+	duplicates := 0
+	var key [8]byte
+	for i := 0; i < stream; i++ {
+		binary.BigEndian.PutUint64(key[:], uint64(i))
+		if sbf.TestAndAdd(key[:]) {
+			duplicates++
+		}
+	}
+	// 全部元素都不相同，检测到的"重复"都是假阳性
+	if rate := float64(duplicates) / stream; rate > 2*target {
+		t.Fatalf("数据流上的假阳性率 = %.4f, 期望 < %.4f", rate, 2*target)
+	}
+	if z, want := sbf.ZeroRatio(), sbf.StablePoint(); math.Abs(z-want) > 0.05 {
+		t.Fatalf("零值比例 = %.3f, 期望约为稳定点 %.3f", z, want)
+	}
+
+	// 刚添加的元素一定存在
+	for i := 0; i < 100; i++ {
+		sbf.AddInt(i)
+		if !sbf.ContainsInt(i) {
+			t.Fatalf("刚添加的元素 %d 不存在", i)
+		}
+	}
+	if sbf.Size() != stream+100 {
+		t.Fatalf("Size() = %d, 期望 %d", sbf.Size(), stream+100)
+	}
+}
@@ -0,0 +1,571 @@
+package datastructures
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestBloomFilterFalsePositiveRate 测试没有假阴性，且实测假阳性率接近目标值
+func TestBloomFilterFalsePositiveRate(t *testing.T) {
+	const n = 20000
+	bf := NewBloomFilter(n, 0.01)
+	for i := 0; i < n; i++ {
+		bf.AddInt(i)
+	}
+	for i := 0; i < n; i++ {
+		if !bf.ContainsInt(i) {
+			t.Fatalf("ContainsInt(%d) = false, 布隆过滤器不应有假阴性", i)
+		}
+	}
+
+	falsePositives := 0
+	for i := n; i < 11*n; i++ {
+		if bf.ContainsInt(i) {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / (10 * n); rate > 0.02 {
+		t.Errorf("实测假阳性率 = %.4f, 目标 0.01", rate)
+	}
+}
+
+// TestBloomFilterConcurrent 测试并发添加和查询，哈希计算没有共享状态
+func TestBloomFilterConcurrent(t *testing.T) {
+	bf := NewBloomFilter(10000, 0.01)
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := fmt.Sprintf("%d-%d", w, i)
+				bf.AddString(key)
+				if !bf.ContainsString(key) {
+					t.Errorf("ContainsString(%s) 返回 false", key)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	if bf.Size() != 8000 {
+		t.Errorf("Size() = %d, 期望 8000", bf.Size())
+	}
+
+	data := []byte("no-alloc")
+	if allocs := testing.AllocsPerRun(100, func() { bf.Contains(data) }); allocs != 0 {
+		t.Errorf("Contains 分配 %.0f 次, 期望 0", allocs)
+	}
+}
+
+// TestBloomFilterSerialization 测试序列化往返以及拒绝版本不符或参数不一致的数据
+func TestBloomFilterSerialization(t *testing.T) {
+	bf := NewBloomFilter(1000, 0.01)
+	for i := 0; i < 500; i++ {
+		bf.AddInt(i)
+	}
+	data, err := bf.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize() 错误 = %v", err)
+	}
+	restored, err := Deserialize(data)
+	if err != nil {
+		t.Fatalf("Deserialize() 错误 = %v", err)
+	}
+	for i := 0; i < 500; i++ {
+		if !restored.ContainsInt(i) {
+			t.Fatalf("反序列化后 ContainsInt(%d) = false", i)
+		}
+	}
+	if restored.Size() != 500 || restored.BitSize() != bf.BitSize() || restored.HashFuncCount() != bf.HashFuncCount() {
+		t.Errorf("反序列化后参数不一致: %v, 原 %v", restored, bf)
+	}
+
+	for _, bad := range []string{
+		`{"BitArray":"AA==","M":8,"K":1,"Count":0}`,
+		`{"Version":1,"BitArray":"AA==","M":64,"K":1,"Count":0}`,
+		`{"Version":1,"BitArray":"AA==","M":8,"K":0,"Count":0}`,
+	} {
+		if _, err := Deserialize([]byte(bad)); err == nil {
+			t.Errorf("Deserialize(%s) 应返回错误", bad)
+		}
+	}
+}
+
+// TestBloomFilterIntersect 测试交集估计的误差以及交集过滤器不漏掉共同元素
+func TestBloomFilterIntersect(t *testing.T) {
+	a := NewBloomFilter(20000, 0.01)
+	b := NewBloomFilter(20000, 0.01)
+	// a包含[0, 10000)，b包含[7000, 17000)，共同元素3000个
+	for i := 0; i < 10000; i++ {
+		a.AddInt(i)
+		b.AddInt(i + 7000)
+	}
+
+	overlap, err := a.EstimateOverlap(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(overlap-3000) > 300 {
+		t.Fatalf("EstimateOverlap() = %.0f, 期望约 3000", overlap)
+	}
+
+	both, err := a.Intersect(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 7000; i < 10000; i++ {
+		if !both.ContainsInt(i) {
+			t.Fatalf("交集中缺少公共元素 %d", i)
+		}
+	}
+	if math.Abs(float64(both.Size())-overlap) > 1 {
+		t.Fatalf("交集大小 = %d, 期望 %.0f", both.Size(), overlap)
+	}
+	if !a.ContainsInt(0) || a.Size() != 10000 {
+		t.Fatal("Intersect 修改了接收者")
+	}
+
+	if _, err := a.Intersect(NewBloomFilter(100, 0.01)); err == nil {
+		t.Fatal("参数不同的过滤器不应该能求交集")
+	}
+	if _, err := a.EstimateOverlap(NewBloomFilter(100, 0.01)); err == nil {
+		t.Fatal("参数不同的过滤器不应该能估计重叠")
+	}
+}
+
+// TestBloomFilterEstimateCardinality 测试重复添加和合并后的基数估计
+func TestBloomFilterEstimateCardinality(t *testing.T) {
+	a := NewBloomFilter(20000, 0.01)
+	b := NewBloomFilter(20000, 0.01)
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 5000; i++ {
+			a.AddInt(i)
+			b.AddInt(i + 2500)
+		}
+	}
+	if a.Size() != 15000 {
+		t.Fatalf("Size() = %d, 期望 15000", a.Size())
+	}
+	if n := a.EstimateCardinality(); math.Abs(n-5000) > 250 {
+		t.Fatalf("EstimateCardinality() = %.0f, 期望约 5000", n)
+	}
+
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if n := a.EstimateCardinality(); math.Abs(n-7500) > 375 {
+		t.Fatalf("Merge 后 EstimateCardinality() = %.0f, 期望约 7500", n)
+	}
+
+	if n := NewBloomFilter(100, 0.01).EstimateCardinality(); n != 0 {
+		t.Fatalf("空过滤器 EstimateCardinality() = %f", n)
+	}
+}
+
+// TestBloomFilterFillRatio 测试置位数和填充率
+func TestBloomFilterFillRatio(t *testing.T) {
+	bf := NewBloomFilter(10000, 0.01)
+	if bf.BitsSet() != 0 || bf.FillRatio() != 0 {
+		t.Fatalf("空过滤器: BitsSet() = %d, FillRatio() = %f", bf.BitsSet(), bf.FillRatio())
+	}
+	bf.AddInt(1)
+	if bf.BitsSet() == 0 || bf.BitsSet() > bf.HashFuncCount() {
+		t.Fatalf("添加一个元素后 BitsSet() = %d, k = %d", bf.BitsSet(), bf.HashFuncCount())
+	}
+
+	for i := 0; i < 10000; i++ {
+		bf.AddInt(i)
+	}
+	// 按最优参数填满时约一半的位被置位
+	if r := bf.FillRatio(); math.Abs(r-0.5) > 0.05 {
+		t.Fatalf("达到容量时 FillRatio() = %f, 期望约 0.5", r)
+	}
+	if want := float64(bf.BitsSet()) / float64(bf.BitSize()); bf.FillRatio() != want {
+		t.Fatalf("FillRatio() = %f, 期望 %f", bf.FillRatio(), want)
+	}
+}
+
+// TestBloomFilterHashAlgorithms 测试各哈希算法在小整数键上的假阳性率，以及序列化和合并的限制
+func TestBloomFilterHashAlgorithms(t *testing.T) {
+	const n = 50000
+	for _, hash := range []BloomHash{BloomHashFNV, BloomHashXXHash, BloomHashMapHash} {
+		t.Run(hash.String(), func(t *testing.T) {
+			bf := NewBloomFilter(n, 0.01, WithBloomHash(hash))
+			for i := 0; i < n; i++ {
+				bf.AddInt(i)
+			}
+			falsePositives := 0
+			for i := n; i < 2*n; i++ {
+				if !bf.ContainsInt(i - n) {
+					t.Fatalf("%d 出现假阴性", i-n)
+				}
+				if bf.ContainsInt(i) {
+					falsePositives++
+				}
+			}
+			if rate := float64(falsePositives) / n; rate > 0.015 {
+				t.Fatalf("假阳性率 = %.4f, 期望约 0.01", rate)
+			}
+
+			data, err := bf.Serialize()
+			if hash == BloomHashMapHash {
+				if err == nil {
+					t.Fatal("maphash 过滤器不应该能序列化")
+				}
+				// 同一个过滤器的克隆共享种子，可以合并
+				if err := bf.Clone().Merge(bf); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			restored, err := Deserialize(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < n; i++ {
+				if !restored.ContainsInt(i) {
+					t.Fatalf("反序列化的过滤器缺少 %d", i)
+				}
+			}
+		})
+	}
+
+	fnv := NewBloomFilter(1000, 0.01)
+	if err := fnv.Merge(NewBloomFilter(1000, 0.01, WithBloomHash(BloomHashXXHash))); err == nil {
+		t.Fatal("哈希算法不同的过滤器不应该能合并")
+	}
+	if err := NewBloomFilter(1000, 0.01, WithBloomHash(BloomHashMapHash)).Merge(
+		NewBloomFilter(1000, 0.01, WithBloomHash(BloomHashMapHash))); err == nil {
+		t.Fatal("种子不同的 maphash 过滤器不应该能合并")
+	}
+}
+
+// TestBloomFilterFold 测试折叠后与直接构建的小过滤器相同，以及不同大小的过滤器合并
+func TestBloomFilterFold(t *testing.T) {
+	for _, m := range []uint{1 << 16, 3 * 1000} {
+		large := NewBloomFilterWithSize(4*m, 7)
+		small := NewBloomFilterWithSize(m, 7)
+		for i := 0; i < 500; i++ {
+			large.AddInt(i)
+			small.AddInt(i)
+		}
+		folded, err := large.Fold(4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if folded.BitSize() != m || folded.Size() != 500 {
+			t.Fatalf("折叠后的过滤器 = %v", folded)
+		}
+		if string(folded.bytes()) != string(small.bytes()) {
+			t.Fatalf("m=%d: 折叠后的位与按折叠后大小构建的过滤器不同", m)
+		}
+	}
+
+	shardA := NewBloomFilterWithSize(1<<14, 5)
+	shardB := NewBloomFilterWithSize(1<<16, 5)
+	for i := 0; i < 1000; i++ {
+		shardA.AddInt(i)
+		shardB.AddInt(i + 1000)
+	}
+	if err := shardA.Merge(shardB); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2000; i++ {
+		if !shardA.ContainsInt(i) {
+			t.Fatalf("合并后的过滤器缺少 %d", i)
+		}
+	}
+	if shardA.Size() != 2000 {
+		t.Fatalf("合并后 Size() = %d, 期望 2000", shardA.Size())
+	}
+
+	// 较小的过滤器不能并入较大的，k不同也不能合并
+	if err := shardB.Merge(shardA); err == nil {
+		t.Fatal("较小的过滤器不应该能合并到较大的过滤器中")
+	}
+	if err := shardA.Merge(NewBloomFilterWithSize(1<<15, 6)); err == nil {
+		t.Fatal("k 不同的过滤器不应该能合并")
+	}
+	if _, err := shardA.Fold(3); err == nil {
+		t.Fatal("不能整除 m 的因子不应该能折叠")
+	}
+}
+
+// TestBloomFilterReadsDoNotBlock 测试整体操作持有写锁时，查询、添加和只读访问器不被阻塞
+func TestBloomFilterReadsDoNotBlock(t *testing.T) {
+	bf := NewBloomFilter(1000, 0.01)
+	bf.AddString("present")
+
+	bf.mu.Lock()
+	defer bf.mu.Unlock()
+
+	done := make(chan bool)
+	go func() {
+		bf.AddString("added")
+		found := bf.ContainsString("present") && bf.ContainsString("added")
+		_ = bf.Size() + uint64(bf.BitSize()+bf.HashFuncCount())
+		_ = bf.GetFalsePositiveRate()
+		_ = bf.String()
+		done <- found
+	}()
+
+	select {
+	case found := <-done:
+		if !found {
+			t.Fatal("Contains 对已添加的元素返回 false")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Add 或 Contains 被过滤器的互斥锁阻塞")
+	}
+}
+
+// TestBloomFilterParameterEstimates 测试参数估计与构造函数一致，且理论假阳性率在容量处等于目标值
+func TestBloomFilterParameterEstimates(t *testing.T) {
+	for _, fpr := range []float64{0.1, 0.01, 0.001} {
+		m, k := EstimateParameters(10000, fpr)
+		bf := NewBloomFilter(10000, fpr)
+		if m != bf.BitSize() || k != bf.HashFuncCount() {
+			t.Fatalf("EstimateParameters() = (%d, %d), 过滤器为 (%d, %d)", m, k, bf.BitSize(), bf.HashFuncCount())
+		}
+		if got := ExpectedFPR(m, k, 10000); math.Abs(got-fpr)/fpr > 0.1 {
+			t.Fatalf("达到容量时 ExpectedFPR() = %f, 期望约 %f", got, fpr)
+		}
+		if ExpectedFPR(m, k, 0) != 0 || ExpectedFPR(m, k, 20000) <= fpr {
+			t.Fatal("ExpectedFPR() 在空过滤器上应该为 0, 超过容量后应该增大")
+		}
+	}
+}
+
+// TestBloomFilterCompressed 测试压缩格式的往返、稀疏时的压缩率以及拒绝损坏的数据
+func TestBloomFilterCompressed(t *testing.T) {
+	for _, n := range []int{0, 1000, 100000} {
+		bf := NewBloomFilter(100000, 0.01, WithBloomHash(BloomHashXXHash))
+		for i := 0; i < n; i++ {
+			bf.AddInt(i)
+		}
+
+		var buf bytes.Buffer
+		written, err := bf.WriteCompressed(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if written != int64(buf.Len()) {
+			t.Fatalf("WriteCompressed() 返回 %d, 实际写入 %d 字节", written, buf.Len())
+		}
+		// 只填充1%时压缩后不到原始位数组的1/10
+		if n == 1000 && buf.Len() > int(bf.BitArraySize())/10 {
+			t.Fatalf("稀疏过滤器压缩后为 %d 字节, 原始大小为 %d", buf.Len(), bf.BitArraySize())
+		}
+
+		restored, err := ReadCompressedBloomFilter(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(restored.bytes()) != string(bf.bytes()) || restored.Size() != bf.Size() ||
+			restored.HashFuncCount() != bf.HashFuncCount() || restored.hash != bf.hash {
+			t.Fatalf("n=%d: 恢复的过滤器不同: %v 与 %v", n, restored, bf)
+		}
+
+		if n > 0 {
+			data := buf.Bytes()
+			if _, err := ReadCompressedBloomFilter(bytes.NewReader(data[:len(data)-1])); err == nil {
+				t.Fatal("截断的压缩过滤器应该返回错误")
+			}
+			data[len(data)-1] ^= 0xff
+			if restored, err := ReadCompressedBloomFilter(bytes.NewReader(data)); err == nil &&
+				string(restored.bytes()) == string(bf.bytes()) {
+				t.Fatal("损坏的数据不应该解码为原来的过滤器")
+			}
+
+			// 其他版本的文件头被拒绝
+			other := append([]byte(nil), buf.Bytes()...)
+			other[len(bloomCompressedMagic)-1]++
+			if _, err := ReadCompressedBloomFilter(bytes.NewReader(other)); err == nil {
+				t.Fatal("版本不同的压缩过滤器应该返回错误")
+			}
+		}
+	}
+}
+
+// TestBloomFilterSeed 测试相同种子结果可复现、不同种子位置不同，以及种子在两种序列化格式中保留
+func TestBloomFilterSeed(t *testing.T) {
+	for _, hash := range []BloomHash{BloomHashFNV, BloomHashXXHash} {
+		build := func(seed uint64) *BloomFilter {
+			bf := NewBloomFilter(1000, 0.01, WithBloomHash(hash), WithBloomSeed(seed))
+			for i := 0; i < 100; i++ {
+				bf.AddInt(i)
+			}
+			return bf
+		}
+		a, b, other := build(42), build(42), build(43)
+		if string(a.bytes()) != string(b.bytes()) {
+			t.Fatalf("%v: 相同的种子得到了不同的位", hash)
+		}
+		if string(a.bytes()) == string(other.bytes()) {
+			t.Fatalf("%v: 不同的种子得到了相同的位", hash)
+		}
+		if err := a.Merge(other); err == nil {
+			t.Fatalf("%v: 种子不同的过滤器不应该能合并", hash)
+		}
+
+		data, err := a.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fromJSON, err := Deserialize(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if _, err := a.WriteCompressed(&buf); err != nil {
+			t.Fatal(err)
+		}
+		fromCompressed, err := ReadCompressedBloomFilter(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, restored := range []*BloomFilter{fromJSON, fromCompressed} {
+			if restored.Seed() != 42 || !restored.ContainsInt(99) {
+				t.Fatalf("%v: 恢复的过滤器丢失了种子: %d", hash, restored.Seed())
+			}
+			if err := restored.Merge(b); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// 种子为0时与未设置种子的结果相同，已有的序列化数据保持可用
+	zero, unseeded := NewBloomFilter(1000, 0.01, WithBloomSeed(0)), NewBloomFilter(1000, 0.01)
+	zero.AddString("x")
+	unseeded.AddString("x")
+	if string(zero.bytes()) != string(unseeded.bytes()) {
+		t.Fatal("种子为 0 时与默认过滤器不同")
+	}
+}
+
+// TestBloomFilterMeasureFPR 测试实测假阳性率与理论值接近
+func TestBloomFilterMeasureFPR(t *testing.T) {
+	bf := NewBloomFilter(10000, 0.01)
+	negatives := make([][]byte, 50000)
+	for i := range negatives {
+		bf.AddString(fmt.Sprintf("member-%d", i%10000))
+		negatives[i] = []byte(fmt.Sprintf("absent-%d", i))
+	}
+	// 每个成员添加了5次，理论值按不同元素数量计算
+	expected := ExpectedFPR(bf.BitSize(), bf.HashFuncCount(), 10000)
+	if got := bf.MeasureFPR(negatives); math.Abs(got-expected) > expected/2 {
+		t.Fatalf("MeasureFPR() = %.4f, 期望约 %.4f", got, expected)
+	}
+	if bf.MeasureFPR(nil) != 0 {
+		t.Fatal("没有样本时 MeasureFPR() 应该为 0")
+	}
+}
+
+type bloomTestID int64
+
+// TestBloomFilterAddOf 测试泛型的AddOf/ContainsOf与按类型的方法一致
+func TestBloomFilterAddOf(t *testing.T) {
+	bf := NewBloomFilter(1000, 0.001)
+
+	AddOf(bf, 42)
+	AddOf(bf, "hello")
+	AddOf(bf, bloomTestID(7))
+	AddOf(bf, 2.5)
+
+	// 编码与专用方法一致，命名类型按底层类型编码
+	if !bf.ContainsInt(42) || !ContainsOf(bf, int8(42)) || !ContainsOf(bf, uint64(42)) {
+		t.Error("AddOf(int) 应该与 ContainsInt 和其他宽度的整数一致")
+	}
+	if !bf.ContainsString("hello") || !ContainsOf(bf, []byte("hello")) {
+		t.Error("AddOf(string) 应该与 ContainsString 一致")
+	}
+	if !ContainsOf(bf, int64(7)) || !ContainsOf(bf, bloomTestID(7)) {
+		t.Error("命名整数类型的 AddOf 应该与其底层值一致")
+	}
+	if !bf.ContainsFloat64(2.5) || !ContainsOf(bf, float32(2.5)) {
+		t.Error("AddOf(float64) 应该与 ContainsFloat64 一致")
+	}
+
+	// AddGeneric把1和"1"格式化为同一个文本，AddOf区分它们
+	AddOf(bf, "1")
+	if ContainsOf(bf, 1) {
+		t.Error("AddOf(\"1\") 不应该使整数 1 存在")
+	}
+
+	if allocs := testing.AllocsPerRun(100, func() { AddOf(bf, 12345) }); allocs != 0 {
+		t.Errorf("AddOf(int) 分配了 %v 次内存, 期望 0", allocs)
+	}
+
+	if err := AddMarshaled(bf, testMarshalerKey{id: 300}); err != nil {
+		t.Fatalf("AddMarshaled() 错误 = %v", err)
+	}
+	if ok, err := ContainsMarshaled(bf, testMarshalerKey{id: 300}); err != nil || !ok {
+		t.Errorf("ContainsMarshaled() = %v, %v, 期望 true", ok, err)
+	}
+	if err := AddMarshaled(bf, testMarshalerKey{err: fmt.Errorf("boom")}); err == nil {
+		t.Error("AddMarshaled 应该返回 MarshalBinary 的错误")
+	}
+}
+
+// TestBloomFilterCloneIndependent 测试克隆后两个过滤器互不影响
+func TestBloomFilterCloneIndependent(t *testing.T) {
+	for _, hash := range []BloomHash{BloomHashFNV, BloomHashXXHash, BloomHashMapHash} {
+		t.Run(hash.String(), func(t *testing.T) {
+			bf := NewBloomFilter(10000, 0.001, WithBloomHash(hash), WithBloomSeed(99))
+			bf.AddString("shared")
+			clone := bf.Clone()
+
+			// 两个过滤器并发写入各自的元素，在-race下运行可以发现共享的可变状态
+			var wg sync.WaitGroup
+			for _, f := range []struct {
+				bf     *BloomFilter
+				prefix string
+			}{{bf, "orig"}, {clone, "clone"}} {
+				f := f
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 1000; i++ {
+						f.bf.AddString(fmt.Sprintf("%s-%d", f.prefix, i))
+					}
+				}()
+			}
+			wg.Wait()
+
+			if !bf.ContainsString("shared") || !clone.ContainsString("shared") {
+				t.Fatal("Clone 之前添加的元素应该同时在两个过滤器中")
+			}
+			leaked := 0
+			for i := 0; i < 1000; i++ {
+				if bf.ContainsString(fmt.Sprintf("clone-%d", i)) {
+					leaked++
+				}
+				if clone.ContainsString(fmt.Sprintf("orig-%d", i)) {
+					leaked++
+				}
+			}
+			if leaked > 20 {
+				t.Errorf("有 %d 个元素在过滤器与其副本之间串扰", leaked)
+			}
+			if bf.Size() != 1001 || clone.Size() != 1001 {
+				t.Errorf("Size() = %d 和 %d, 期望 1001", bf.Size(), clone.Size())
+			}
+
+			// 克隆保留哈希配置，可以合并回原过滤器
+			if err := bf.Merge(clone); err != nil {
+				t.Fatalf("Merge(clone) 错误 = %v", err)
+			}
+			if !bf.ContainsString("clone-500") {
+				t.Error("合并后的过滤器应该包含副本的元素")
+			}
+		})
+	}
+}
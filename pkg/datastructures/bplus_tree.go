@@ -30,6 +30,8 @@ type (
 		isLeaf   bool        // 是否为叶子节点
 		next     *TreeNode   // 叶子节点链表指针（仅叶子节点使用）
 		parent   *TreeNode   // 父节点指针
+		epoch    uint64      // 节点创建时的快照纪元（仅叶子节点使用）
+		hash     string      // 缓存的节点哈希，为空表示失效（RootHash）
 	}
 )
 
@@ -42,12 +44,31 @@ type BPlusTree struct {
 	comparator Comparator // 比较函数
 	mu         sync.RWMutex // 读写锁，支持并发访问
 	count      int64      // 总键数
+	epoch      uint64     // 当前快照纪元，每创建一个快照递增
+	snapshots  map[*bplusSnapshot]struct{} // 活跃的快照
+	allocator  NodeAllocator // 节点分配器
+	splits     int64      // 节点分裂次数（叶子节点和内部节点）
+	bloom      *bplusBloom // 附加的布隆过滤器（WithBloomFilter）
+	hashMu     sync.Mutex  // 持有读锁时保护节点哈希缓存的计算
+}
+
+// BPlusTreeOption B+树可选配置
+type BPlusTreeOption func(*BPlusTree)
+
+// WithNodeAllocator 使用指定的节点分配器（例如NodeArena）分配节点和键值切片
+func WithNodeAllocator(allocator NodeAllocator) BPlusTreeOption {
+	return func(t *BPlusTree) {
+		if allocator != nil {
+			t.allocator = allocator
+		}
+	}
 }
 
 // NewBPlusTree 创建新的B+树
 // order: 树的阶数，建议值：64-256（根据磁盘块大小调整）
 // comparator: 比较函数，用于键的排序
-func NewBPlusTree(order int, comparator Comparator) *BPlusTree {
+// opts: 可选配置
+func NewBPlusTree(order int, comparator Comparator, opts ...BPlusTreeOption) *BPlusTree {
 	if order < 3 {
 		panic("order must be >= 3")
 	}
@@ -55,14 +76,28 @@ func NewBPlusTree(order int, comparator Comparator) *BPlusTree {
 		panic("comparator is required")
 	}
 
-	return &BPlusTree{
-		root:       &TreeNode{isLeaf: true},
-		order:      order,
-		minKeys:    order/2 - 1,
+	t := &BPlusTree{
+		order:       order,
+		minKeys:     order/2 - 1,
 		minChildren: order / 2,
-		comparator: comparator,
-		count:      0,
+		comparator:  comparator,
+		count:       0,
+		allocator:   heapAllocator{},
 	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	t.root = t.newNode(true)
+
+	return t
+}
+
+// newNode 通过分配器创建新节点
+func (t *BPlusTree) newNode(isLeaf bool) *TreeNode {
+	node := t.allocator.NewNode(isLeaf, t.order)
+	node.isLeaf = isLeaf
+	node.epoch = t.epoch
+	return node
 }
 
 // Insert 插入键值对
@@ -74,14 +109,24 @@ func (t *BPlusTree) Insert(key any, value any) error {
 		return fmt.Errorf("key cannot be nil")
 	}
 
+	var enc []byte
+	if t.bloom != nil {
+		var err error
+		if enc, err = bloomKey(key); err != nil {
+			return err
+		}
+	}
+
 	// 查找叶子节点
 	leaf := t.findLeafNode(key)
+	leaf.invalidateHash()
 
 	// 检查是否已存在该键
 	for i, k := range leaf.keys {
 		cmp := t.comparator(k, key)
 		if cmp == 0 {
 			// 更新已存在的键
+			t.preserveLeaf(leaf)
 			leaf.values[i].Value = value
 			return nil
 		}
@@ -90,6 +135,9 @@ func (t *BPlusTree) Insert(key any, value any) error {
 	// 插入新键值对
 	t.insertIntoLeaf(leaf, key, value)
 	t.count++
+	if t.bloom != nil {
+		t.bloomInsert(enc)
+	}
 
 	return nil
 }
@@ -99,7 +147,7 @@ func (t *BPlusTree) Search(key any) (any, bool) {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
-	if key == nil {
+	if key == nil || !t.bloomMayContain(key) {
 		return nil, false
 	}
 
@@ -116,6 +164,13 @@ func (t *BPlusTree) Search(key any) (any, bool) {
 	return nil, false
 }
 
+// Exists 检查键是否存在
+// 附加了布隆过滤器时，一定不存在的键不遍历树
+func (t *BPlusTree) Exists(key any) bool {
+	_, found := t.Search(key)
+	return found
+}
+
 // Delete 删除键值对
 func (t *BPlusTree) Delete(key any) bool {
 	t.mu.Lock()
@@ -139,10 +194,14 @@ func (t *BPlusTree) Delete(key any) bool {
 	if idx == -1 {
 		return false // 键不存在
 	}
+	leaf.invalidateHash()
 
 	// 从叶子节点中删除
 	t.deleteFromLeaf(leaf, idx)
 	t.count--
+	if t.bloom != nil {
+		t.bloomDelete()
+	}
 
 	return true
 }
@@ -163,10 +222,13 @@ func (t *BPlusTree) RangeQuery(start, end any) ([]KeyValue, error) {
 	var result []KeyValue
 	leaf := t.findLeafNode(start)
 
-	// 遍历叶子节点链表
+	// 遍历叶子节点链表，遇到第一个不小于end的键即可停止
 	for leaf != nil {
 		for i, key := range leaf.keys {
-			if t.comparator(key, start) >= 0 && t.comparator(key, end) < 0 {
+			if t.comparator(key, end) >= 0 {
+				return result, nil
+			}
+			if t.comparator(key, start) >= 0 {
 				result = append(result, leaf.values[i])
 			}
 		}
@@ -198,6 +260,47 @@ func (t *BPlusTree) ScanAll() []KeyValue {
 	return result
 }
 
+// Keys 返回 [start, end) 中的所有键，不复制值
+func (t *BPlusTree) Keys(start, end any) ([]any, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if start == nil || end == nil {
+		return nil, fmt.Errorf("start and end cannot be nil")
+	}
+
+	if t.comparator(start, end) >= 0 {
+		return nil, fmt.Errorf("start must be less than end")
+	}
+
+	var result []any
+	for leaf := t.findLeafNode(start); leaf != nil; leaf = leaf.next {
+		for _, key := range leaf.keys {
+			if t.comparator(key, end) >= 0 {
+				return result, nil
+			}
+			if t.comparator(key, start) >= 0 {
+				result = append(result, key)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// KeysAll 按顺序返回所有键
+func (t *BPlusTree) KeysAll() []any {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	result := make([]any, 0, t.count)
+	for leaf := t.leftmostLeaf(); leaf != nil; leaf = leaf.next {
+		result = append(result, leaf.keys...)
+	}
+
+	return result
+}
+
 // Size 返回树中键值对数量
 func (t *BPlusTree) Size() int64 {
 	t.mu.RLock()
@@ -219,6 +322,13 @@ func (t *BPlusTree) Height() int {
 	return height
 }
 
+// SplitCount 返回节点分裂的累计次数
+func (t *BPlusTree) SplitCount() int64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.splits
+}
+
 // 内部方法：查找包含指定键的叶子节点
 func (t *BPlusTree) findLeafNode(key any) *TreeNode {
 	node := t.root
@@ -242,6 +352,8 @@ func (t *BPlusTree) findLeafNode(key any) *TreeNode {
 
 // 内部方法：将键值对插入叶子节点
 func (t *BPlusTree) insertIntoLeaf(leaf *TreeNode, key any, value any) {
+	t.preserveLeaf(leaf)
+
 	// 找到插入位置
 	insertPos := 0
 	for insertPos < len(leaf.keys) && t.comparator(leaf.keys[insertPos], key) < 0 {
@@ -260,38 +372,55 @@ func (t *BPlusTree) insertIntoLeaf(leaf *TreeNode, key any, value any) {
 
 	// 检查是否需要分裂
 	if len(leaf.keys) > t.order-1 {
-		t.splitLeafNode(leaf)
+		splitPos := len(leaf.keys) / 2
+
+		// 顺序插入优化：新键是最右叶子的最大键（或最左叶子的最小键）时不均匀分裂，
+		// 让旧叶子保持满载、新叶子只承接新键，单调递增/递减的键可获得接近100%的空间利用率
+		if insertPos == len(leaf.keys)-1 && leaf.next == nil {
+			splitPos = len(leaf.keys) - 1
+		} else if insertPos == 0 && leaf == t.leftmostLeaf() {
+			splitPos = 1
+		}
+
+		t.splitLeafNode(leaf, splitPos)
+	}
+}
+
+// 内部方法：返回最左叶子节点
+func (t *BPlusTree) leftmostLeaf() *TreeNode {
+	node := t.root
+	for !node.isLeaf {
+		node = node.children[0]
 	}
+	return node
 }
 
 // 内部方法：分裂叶子节点
-func (t *BPlusTree) splitLeafNode(leaf *TreeNode) {
-	// 找到分裂点
-	splitPos := len(leaf.keys) / 2
+// splitPos: 分裂点，[splitPos:]移动到新的右侧叶子
+func (t *BPlusTree) splitLeafNode(leaf *TreeNode, splitPos int) {
+	t.splits++
 
 	// 创建新叶子节点
-	newLeaf := &TreeNode{
-		isLeaf: true,
-		next:   leaf.next,
-		parent: leaf.parent,
-	}
+	newLeaf := t.newNode(true)
+	newLeaf.next = leaf.next
+	newLeaf.parent = leaf.parent
 
 	// 移动键值对到新节点
 	newLeaf.keys = append(newLeaf.keys, leaf.keys[splitPos:]...)
 	newLeaf.values = append(newLeaf.values, leaf.values[splitPos:]...)
 
-	// 更新原节点
+	// 更新原节点（清空尾部以释放引用）
+	clear(leaf.keys[splitPos:])
+	clear(leaf.values[splitPos:])
 	leaf.keys = leaf.keys[:splitPos]
 	leaf.values = leaf.values[:splitPos]
 	leaf.next = newLeaf
 
 	// 如果这是根节点，创建新根节点
 	if leaf.parent == nil {
-		newRoot := &TreeNode{
-			keys:     []any{newLeaf.keys[0]},
-			children: []*TreeNode{leaf, newLeaf},
-			isLeaf:   false,
-		}
+		newRoot := t.newNode(false)
+		newRoot.keys = append(newRoot.keys, newLeaf.keys[0])
+		newRoot.children = append(newRoot.children, leaf, newLeaf)
 		leaf.parent = newRoot
 		newLeaf.parent = newRoot
 		t.root = newRoot
@@ -331,14 +460,14 @@ func (t *BPlusTree) splitLeafNode(leaf *TreeNode) {
 
 // 内部方法：分裂内部节点
 func (t *BPlusTree) splitInternalNode(node *TreeNode) {
+	t.splits++
+
 	// 找到分裂点（注意：内部节点的键不会移动到新节点）
 	splitPos := len(node.keys) / 2
 
 	// 创建新内部节点
-	newNode := &TreeNode{
-		isLeaf:   false,
-		parent:   node.parent,
-	}
+	newNode := t.newNode(false)
+	newNode.parent = node.parent
 
 	// 移动键到新节点（不包括中间的键）
 	newNode.keys = append(newNode.keys, node.keys[splitPos+1:]...)
@@ -352,17 +481,17 @@ func (t *BPlusTree) splitInternalNode(node *TreeNode) {
 		child.parent = newNode
 	}
 
-	// 更新原节点
+	// 更新原节点（清空尾部以释放引用）
+	clear(node.keys[splitPos:])
+	clear(node.children[splitPos+1:])
 	node.keys = node.keys[:splitPos]
 	node.children = node.children[:splitPos+1]
 
 	// 如果这是根节点，创建新根节点
 	if node.parent == nil {
-		newRoot := &TreeNode{
-			keys:     []any{midKey},
-			children: []*TreeNode{node, newNode},
-			isLeaf:   false,
-		}
+		newRoot := t.newNode(false)
+		newRoot.keys = append(newRoot.keys, midKey)
+		newRoot.children = append(newRoot.children, node, newNode)
 		node.parent = newRoot
 		newNode.parent = newRoot
 		t.root = newRoot
@@ -402,6 +531,8 @@ func (t *BPlusTree) splitInternalNode(node *TreeNode) {
 
 // 内部方法：从叶子节点删除
 func (t *BPlusTree) deleteFromLeaf(leaf *TreeNode, idx int) {
+	t.preserveLeaf(leaf)
+
 	// 从叶子节点中删除键值对
 	leaf.keys = append(leaf.keys[:idx], leaf.keys[idx+1:]...)
 	leaf.values = append(leaf.values[:idx], leaf.values[idx+1:]...)
@@ -425,14 +556,16 @@ func (t *BPlusTree) rebalanceLeafNode(leaf *TreeNode) {
 	// 尝试从左兄弟节点借键
 	if pos > 0 && len(parent.children[pos-1].keys) > t.minKeys {
 		leftSibling := parent.children[pos-1]
+		t.preserveLeaf(leftSibling)
+		leftSibling.invalidateHash()
 
 		// 从左兄弟借最后一个键
 		borrowedKey := leftSibling.keys[len(leftSibling.keys)-1]
 		borrowedValue := leftSibling.values[len(leftSibling.values)-1]
 
 		// 在当前节点前插入借来的键
-		leaf.keys = append([]any{borrowedKey}, leaf.keys...)
-		leaf.values = append([]KeyValue{borrowedValue}, leaf.values...)
+		leaf.keys = insertAt(leaf.keys, 0, borrowedKey)
+		leaf.values = insertAt(leaf.values, 0, borrowedValue)
 
 		// 从左兄弟删除借出的键
 		leftSibling.keys = leftSibling.keys[:len(leftSibling.keys)-1]
@@ -446,6 +579,8 @@ func (t *BPlusTree) rebalanceLeafNode(leaf *TreeNode) {
 	// 尝试从右兄弟节点借键
 	if pos < len(parent.children)-1 && len(parent.children[pos+1].keys) > t.minKeys {
 		rightSibling := parent.children[pos+1]
+		t.preserveLeaf(rightSibling)
+		rightSibling.invalidateHash()
 
 		// 从右兄弟借第一个键
 		borrowedKey := rightSibling.keys[0]
@@ -456,8 +591,8 @@ func (t *BPlusTree) rebalanceLeafNode(leaf *TreeNode) {
 		leaf.values = append(leaf.values, borrowedValue)
 
 		// 从右兄弟删除借出的键
-		rightSibling.keys = rightSibling.keys[1:]
-		rightSibling.values = rightSibling.values[1:]
+		rightSibling.keys = removeAt(rightSibling.keys, 0)
+		rightSibling.values = removeAt(rightSibling.values, 0)
 
 		// 更新父节点中的键
 		parent.keys[pos] = rightSibling.keys[0]
@@ -468,6 +603,8 @@ func (t *BPlusTree) rebalanceLeafNode(leaf *TreeNode) {
 	if pos > 0 {
 		// 与左兄弟合并
 		leftSibling := parent.children[pos-1]
+		t.preserveLeaf(leftSibling)
+		leftSibling.invalidateHash()
 		leftSibling.keys = append(leftSibling.keys, leaf.keys...)
 		leftSibling.values = append(leftSibling.values, leaf.values...)
 		leftSibling.next = leaf.next
@@ -517,28 +654,30 @@ func (t *BPlusTree) rebalanceInternalNode(node *TreeNode) {
 	// 尝试从左兄弟节点借键
 	if pos > 0 && len(parent.children[pos-1].keys) > t.minKeys {
 		leftSibling := parent.children[pos-1]
+		leftSibling.invalidateHash()
 
 		// 从父节点借最后一个键到当前节点
 		borrowedKey := parent.keys[pos-1]
-		node.keys = append([]any{borrowedKey}, node.keys...)
+		node.keys = insertAt(node.keys, 0, borrowedKey)
 
 		// 从左兄弟借最后一个子节点
 		lastChild := leftSibling.children[len(leftSibling.children)-1]
 		lastChild.parent = node
-		node.children = append([]*TreeNode{lastChild}, node.children...)
+		node.children = insertAt(node.children, 0, lastChild)
 
-		// 从左兄弟删除借出的键和子节点
-		leftSibling.keys = leftSibling.keys[:len(leftSibling.keys)-1]
-		leftSibling.children = leftSibling.children[:len(leftSibling.children)-1]
-
-		// 更新父节点中的键
+		// 左兄弟的最后一个键上移到父节点
 		parent.keys[pos-1] = leftSibling.keys[len(leftSibling.keys)-1]
+
+		// 从左兄弟删除借出的键和子节点
+		leftSibling.keys = removeAt(leftSibling.keys, len(leftSibling.keys)-1)
+		leftSibling.children = removeAt(leftSibling.children, len(leftSibling.children)-1)
 		return
 	}
 
 	// 尝试从右兄弟节点借键
 	if pos < len(parent.children)-1 && len(parent.children[pos+1].keys) > t.minKeys {
 		rightSibling := parent.children[pos+1]
+		rightSibling.invalidateHash()
 
 		// 从父节点借第一个键到当前节点
 		borrowedKey := parent.keys[pos]
@@ -549,12 +688,12 @@ func (t *BPlusTree) rebalanceInternalNode(node *TreeNode) {
 		firstChild.parent = node
 		node.children = append(node.children, firstChild)
 
-		// 从右兄弟删除借出的键和子节点
-		rightSibling.keys = rightSibling.keys[1:]
-		rightSibling.children = rightSibling.children[1:]
-
-		// 更新父节点中的键
+		// 右兄弟的第一个键上移到父节点
 		parent.keys[pos] = rightSibling.keys[0]
+
+		// 从右兄弟删除借出的键和子节点
+		rightSibling.keys = removeAt(rightSibling.keys, 0)
+		rightSibling.children = removeAt(rightSibling.children, 0)
 		return
 	}
 
@@ -562,6 +701,7 @@ func (t *BPlusTree) rebalanceInternalNode(node *TreeNode) {
 	if pos > 0 {
 		// 与左兄弟合并
 		leftSibling := parent.children[pos-1]
+		leftSibling.invalidateHash()
 		parentKey := parent.keys[pos-1]
 
 		leftSibling.keys = append(leftSibling.keys, parentKey)
@@ -631,3 +771,21 @@ func (n *TreeNode) String(prefix string) string {
 
 	return result
 }
+
+// Describe 返回B+树的能力描述
+func (t *BPlusTree) Describe() Descriptor {
+	return Descriptor{
+		Name:           "BPlusTree",
+		Ordered:        true,
+		SupportsDelete: true,
+		SupportsRange:  true,
+		Concurrency:    ConcurrencyRWMutex,
+		Complexity: Complexity{
+			Insert: "O(log n)",
+			Search: "O(log n)",
+			Delete: "O(log n)",
+			Range:  "O(log n + k)",
+		},
+		Notes: "数据全部位于叶子节点，叶子链表支持顺序扫描；节点大小可适配磁盘块；维护默克尔式节点哈希，可为范围查询结果生成证明",
+	}
+}
@@ -0,0 +1,145 @@
+package datastructures
+
+import "sync"
+
+// NodeAllocator B+树节点分配器
+// 分配器负责创建节点，并可以为节点预分配键、值和子节点切片。
+// 预分配的切片容量不小于order+1时，节点在分裂前的追加操作不会触发额外的堆分配。
+type NodeAllocator interface {
+	// NewNode 分配一个空节点，isLeaf表示叶子节点，order为树的阶数
+	NewNode(isLeaf bool, order int) *TreeNode
+}
+
+// heapAllocator 默认分配器，直接从堆上分配节点
+type heapAllocator struct{}
+
+// NewNode 在堆上分配节点
+func (heapAllocator) NewNode(isLeaf bool, order int) *TreeNode {
+	return &TreeNode{isLeaf: isLeaf}
+}
+
+// slab 按块分配同类型元素的简单板分配器
+type slab[T any] struct {
+	chunks [][]T // 已分配的块
+	cur    int   // 当前使用的块
+	off    int   // 当前块内的偏移
+}
+
+// alloc 从板中切出长度为n、容量为n的切片，n超过块大小时直接堆分配
+func (s *slab[T]) alloc(n, chunkLen int) []T {
+	if n > chunkLen {
+		return make([]T, n)
+	}
+	if s.cur < len(s.chunks) && s.off+n > len(s.chunks[s.cur]) {
+		s.cur++
+		s.off = 0
+	}
+	if s.cur == len(s.chunks) {
+		s.chunks = append(s.chunks, make([]T, chunkLen))
+	}
+	r := s.chunks[s.cur][s.off : s.off+n : s.off+n]
+	s.off += n
+	return r
+}
+
+// reset 清空所有块并从头开始复用
+func (s *slab[T]) reset() {
+	for _, c := range s.chunks {
+		clear(c)
+	}
+	s.cur, s.off = 0, 0
+}
+
+// NodeArena 基于连续内存块的节点分配器
+// 特点：
+// - 节点和键值切片从大块内存中切分，百万级节点只对应少量堆对象
+// - 减少分配次数和GC需要跟踪的对象数量
+// - 树被丢弃后通过Reset整体回收并复用内存，或通过Release整体释放
+// - 被删除节点占用的空间不会单独回收，适合批量构建、整体丢弃的场景
+type NodeArena struct {
+	mu        sync.Mutex
+	chunkSize int // 每块容纳的节点数量
+	nodes     slab[TreeNode]
+	keys      slab[any]
+	values    slab[KeyValue]
+	children  slab[*TreeNode]
+	allocated int64 // 已分配的节点数量
+}
+
+// NewNodeArena 创建节点分配器
+// chunkSize: 每个内存块容纳的节点数量，建议值：1024-65536
+func NewNodeArena(chunkSize int) *NodeArena {
+	if chunkSize <= 0 {
+		panic("chunkSize must be > 0")
+	}
+	return &NodeArena{chunkSize: chunkSize}
+}
+
+// NewNode 从内存块中分配节点，并预分配容量为order+1的切片
+func (a *NodeArena) NewNode(isLeaf bool, order int) *TreeNode {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	capacity := order + 1
+	node := &a.nodes.alloc(1, a.chunkSize)[0]
+	node.isLeaf = isLeaf
+	node.keys = a.keys.alloc(capacity, a.chunkSize*capacity)[:0]
+	if isLeaf {
+		node.values = a.values.alloc(capacity, a.chunkSize*capacity)[:0]
+	} else {
+		node.children = a.children.alloc(capacity, a.chunkSize*capacity)[:0]
+	}
+	a.allocated++
+
+	return node
+}
+
+// Allocated 返回自上次Reset以来分配的节点数量
+func (a *NodeArena) Allocated() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.allocated
+}
+
+// Reset 整体回收所有节点并复用内存块
+// 调用前必须确保使用该分配器的树都已被丢弃
+func (a *NodeArena) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.nodes.reset()
+	a.keys.reset()
+	a.values.reset()
+	a.children.reset()
+	a.allocated = 0
+}
+
+// Release 释放所有内存块，交由GC整体回收
+// 调用前必须确保使用该分配器的树都已被丢弃
+func (a *NodeArena) Release() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.nodes = slab[TreeNode]{}
+	a.keys = slab[any]{}
+	a.values = slab[KeyValue]{}
+	a.children = slab[*TreeNode]{}
+	a.allocated = 0
+}
+
+// insertAt 在切片的指定位置原地插入元素
+func insertAt[T any](s []T, i int, v T) []T {
+	var zero T
+	s = append(s, zero)
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
+
+// removeAt 原地删除切片指定位置的元素
+func removeAt[T any](s []T, i int) []T {
+	copy(s[i:], s[i+1:])
+	var zero T
+	s[len(s)-1] = zero
+	return s[:len(s)-1]
+}
@@ -0,0 +1,248 @@
+package datastructures
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+)
+
+// B+树的节点哈希（默克尔式，按节点的扇出聚合），键和值都经DefaultKeyEncoder编码：
+//
+//	叶子：H(0x00 || n(uvarint) || n × (len(key)(uvarint) key len(value)(uvarint) value))
+//	内部节点：H(0x01 || n(uvarint) || n × (len(key)(uvarint) key) || (n+1) × 子节点哈希(32字节))
+//
+// 内部节点的分隔键参与哈希，第i个子节点只包含[keys[i-1], keys[i])中的键，
+// 验证方由此判断哪些子树与查询范围相交，从而确认范围查询的结果没有遗漏。
+// 哈希在第一次需要时计算并缓存在节点上；修改只把被修改的节点及其祖先标记为失效，
+// 下次计算时只重新哈希这些节点，代价为O(修改的节点数 × order)
+
+// invalidateHash 在修改节点之前调用，使节点及其祖先缓存的哈希失效，调用方必须持有写锁
+// 失效节点的祖先总是也已失效，遇到已失效的节点即可停止
+func (n *TreeNode) invalidateHash() {
+	for ; n != nil && n.hash != ""; n = n.parent {
+		n.hash = ""
+	}
+}
+
+// appendEncoded 把DefaultKeyEncoder的编码结果以长度前缀的形式追加到dst之后
+func appendEncoded(dst []byte, v any) ([]byte, error) {
+	enc, err := DefaultKeyEncoder{}.AppendKey(nil, v)
+	if err != nil {
+		return dst, err
+	}
+	dst = binary.AppendUvarint(dst, uint64(len(enc)))
+	return append(dst, enc...), nil
+}
+
+// bplusLeafHash 计算叶子节点的哈希
+func bplusLeafHash(entries []KeyValue) (string, error) {
+	buf := binary.AppendUvarint([]byte{merkleLeafPrefix}, uint64(len(entries)))
+	var err error
+	for _, kv := range entries {
+		if buf, err = appendEncoded(buf, kv.Key); err != nil {
+			return "", err
+		}
+		if buf, err = appendEncoded(buf, kv.Value); err != nil {
+			return "", err
+		}
+	}
+	hash := sha256.Sum256(buf)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// bplusInternalHash 计算内部节点的哈希，childHashes比keys多一个
+func bplusInternalHash(keys []any, childHashes []string) (string, error) {
+	buf := binary.AppendUvarint([]byte{merkleNodePrefix}, uint64(len(keys)))
+	var err error
+	for _, key := range keys {
+		if buf, err = appendEncoded(buf, key); err != nil {
+			return "", err
+		}
+	}
+	for _, h := range childHashes {
+		if len(h) != 2*sha256.Size {
+			return "", fmt.Errorf("invalid child hash length %d", len(h))
+		}
+		raw, err := hex.DecodeString(h)
+		if err != nil {
+			return "", err
+		}
+		buf = append(buf, raw...)
+	}
+	hash := sha256.Sum256(buf)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// nodeHash 返回节点的哈希，失效时重新计算并缓存
+// 调用方必须持有读锁和hashMu
+func (t *BPlusTree) nodeHash(n *TreeNode) (string, error) {
+	if n.hash != "" {
+		return n.hash, nil
+	}
+
+	var hash string
+	var err error
+	if n.isLeaf {
+		hash, err = bplusLeafHash(n.values)
+	} else {
+		childHashes := make([]string, len(n.children))
+		for i, child := range n.children {
+			if childHashes[i], err = t.nodeHash(child); err != nil {
+				return "", err
+			}
+		}
+		hash, err = bplusInternalHash(n.keys, childHashes)
+	}
+	if err != nil {
+		return "", err
+	}
+	n.hash = hash
+	return hash, nil
+}
+
+// RootHash 返回整棵树的根哈希，键值对完全相同且结构相同的两棵树根哈希相同
+// 键或值无法编码时返回错误
+func (t *BPlusTree) RootHash() (string, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	t.hashMu.Lock()
+	defer t.hashMu.Unlock()
+
+	return t.nodeHash(t.root)
+}
+
+// BPlusProofNode 范围证明中的一个节点
+// 与查询范围不相交的子树只给出Hash，其余字段为空；相交的节点给出完整内容，Hash为空
+type BPlusProofNode struct {
+	Hash     string            // 未展开子树的哈希
+	Leaf     bool              // 是否为叶子节点
+	Keys     []any             // 内部节点的分隔键
+	Entries  []KeyValue        // 叶子节点的全部键值对
+	Children []*BPlusProofNode // 内部节点的子节点，比Keys多一个
+}
+
+// BPlusRangeProof 范围查询[Start, End)结果的证明
+// 包含与范围相交的所有叶子的完整内容，以及从这些叶子到根的路径上其余子树的哈希，
+// 大小约为O(k + order·log n)，k为结果数量
+type BPlusRangeProof struct {
+	Start any
+	End   any
+	Root  *BPlusProofNode
+}
+
+// childIntersects 内部节点的第i个子节点覆盖的键区间是否与[start, end)相交
+func childIntersects(keys []any, i int, start, end any, comparator Comparator) bool {
+	return (i == 0 || comparator(keys[i-1], end) < 0) && (i == len(keys) || comparator(start, keys[i]) < 0)
+}
+
+// buildRangeProof 构建节点的证明，调用方必须持有读锁和hashMu
+func (t *BPlusTree) buildRangeProof(n *TreeNode, start, end any) (*BPlusProofNode, error) {
+	if n.isLeaf {
+		return &BPlusProofNode{Leaf: true, Entries: append([]KeyValue(nil), n.values...)}, nil
+	}
+
+	p := &BPlusProofNode{
+		Keys:     append([]any(nil), n.keys...),
+		Children: make([]*BPlusProofNode, len(n.children)),
+	}
+	for i, child := range n.children {
+		var err error
+		if childIntersects(n.keys, i, start, end, t.comparator) {
+			p.Children[i], err = t.buildRangeProof(child, start, end)
+		} else {
+			var hash string
+			hash, err = t.nodeHash(child)
+			p.Children[i] = &BPlusProofNode{Hash: hash}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// RangeQueryWithProof 范围查询 [start, end)，同时返回可以对RootHash验证的证明
+// 客户端只需持有可信的根哈希，用VerifyBPlusRangeProof即可确认结果既没有被篡改也没有遗漏
+func (t *BPlusTree) RangeQueryWithProof(start, end any) ([]KeyValue, *BPlusRangeProof, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	t.hashMu.Lock()
+	defer t.hashMu.Unlock()
+
+	if start == nil || end == nil {
+		return nil, nil, fmt.Errorf("start and end cannot be nil")
+	}
+	if t.comparator(start, end) >= 0 {
+		return nil, nil, fmt.Errorf("start must be less than end")
+	}
+
+	root, err := t.buildRangeProof(t.root, start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+	proof := &BPlusRangeProof{Start: start, End: end, Root: root}
+	results, _ := proof.collect(root, true, t.comparator)
+	return results, proof, nil
+}
+
+// collect 计算证明节点的哈希，并按树的顺序收集其中落在范围内的键值对
+// intersects表示节点覆盖的键区间与范围相交，这样的节点必须展开，
+// 否则证明无法说明结果没有遗漏，返回空哈希
+func (p *BPlusRangeProof) collect(n *BPlusProofNode, intersects bool, comparator Comparator) ([]KeyValue, string) {
+	if n == nil {
+		return nil, ""
+	}
+	if n.Hash != "" {
+		if intersects {
+			return nil, ""
+		}
+		return nil, n.Hash
+	}
+
+	if n.Leaf {
+		hash, err := bplusLeafHash(n.Entries)
+		if err != nil || !intersects {
+			return nil, hash
+		}
+		var results []KeyValue
+		for _, kv := range n.Entries {
+			if comparator(kv.Key, p.Start) >= 0 && comparator(kv.Key, p.End) < 0 {
+				results = append(results, kv)
+			}
+		}
+		return results, hash
+	}
+
+	if len(n.Children) != len(n.Keys)+1 {
+		return nil, ""
+	}
+	var results []KeyValue
+	childHashes := make([]string, len(n.Children))
+	for i, child := range n.Children {
+		var sub []KeyValue
+		sub, childHashes[i] = p.collect(child, intersects && childIntersects(n.Keys, i, p.Start, p.End, comparator), comparator)
+		if childHashes[i] == "" {
+			return nil, ""
+		}
+		results = append(results, sub...)
+	}
+	hash, err := bplusInternalHash(n.Keys, childHashes)
+	if err != nil {
+		return nil, ""
+	}
+	return results, hash
+}
+
+// VerifyBPlusRangeProof 验证范围证明，返回证明所证实的[proof.Start, proof.End)中的全部键值对
+// comparator必须与生成证明的树使用的比较器一致；证明无效或根哈希不一致时返回false
+func VerifyBPlusRangeProof(proof *BPlusRangeProof, rootHash string, comparator Comparator) ([]KeyValue, bool) {
+	if proof == nil || proof.Start == nil || proof.End == nil || comparator(proof.Start, proof.End) >= 0 {
+		return nil, false
+	}
+	results, hash := proof.collect(proof.Root, true, comparator)
+	if hash == "" || hash != rootHash {
+		return nil, false
+	}
+	return results, true
+}
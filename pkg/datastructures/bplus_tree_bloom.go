@@ -0,0 +1,103 @@
+package datastructures
+
+// bplusBloomInitialCapacity 附加布隆过滤器的初始容量（元素数量）
+const bplusBloomInitialCapacity = 1024
+
+// bplusBloom 附加在B+树上的布隆过滤器及其容量，由树的写锁保护
+type bplusBloom struct {
+	filter   *BloomFilter
+	fpr      float64 // 目标假阳性率
+	capacity int64   // 构建filter时的期望元素数量
+	stale    int64   // 上次重建以来删除的键数，这些键的位仍然置位
+}
+
+// WithBloomFilter 为B+树附加一个自动维护的布隆过滤器，Search和Exists先查过滤器，一定不存在的键不再遍历树
+// 特点：
+// - Insert新增键时同步写入过滤器；键经DefaultKeyEncoder编码，无法编码的键Insert返回错误
+// - 键数超过容量时按两倍键数从树中重建，假阳性率保持在fpr附近
+// - Delete不能从过滤器中移除键，删除的键数超过容量的一半时重建，清除残留的位
+// - 要求比较器判定相等的键编码也相同：int(1)和int64(1)这类跨类型相等的比较器不能使用过滤器
+// - 过滤器本身无锁，查询仍在树的读锁内完成，重建在写锁内完成
+func WithBloomFilter(falsePositiveRate float64) BPlusTreeOption {
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		panic("falsePositiveRate must be in (0, 1)")
+	}
+	return func(t *BPlusTree) {
+		t.bloom = &bplusBloom{
+			filter:   NewBloomFilter(bplusBloomInitialCapacity, falsePositiveRate),
+			fpr:      falsePositiveRate,
+			capacity: bplusBloomInitialCapacity,
+		}
+	}
+}
+
+// BloomFilter 返回附加的布隆过滤器，未使用WithBloomFilter时返回nil
+// 过滤器在重建时会被替换，返回值只反映调用时的状态，调用方不应修改它
+func (t *BPlusTree) BloomFilter() *BloomFilter {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.bloom == nil {
+		return nil
+	}
+	return t.bloom.filter
+}
+
+// bloomKey 编码过滤器使用的键
+func bloomKey(key any) ([]byte, error) {
+	var buf [32]byte
+	return DefaultKeyEncoder{}.AppendKey(buf[:0], key)
+}
+
+// bloomMayContain 检查键是否可能在树中，调用方必须持有读锁
+// 未附加过滤器或键无法编码时返回true，交由树判断
+func (t *BPlusTree) bloomMayContain(key any) bool {
+	if t.bloom == nil {
+		return true
+	}
+	enc, err := bloomKey(key)
+	if err != nil {
+		return true
+	}
+	return t.bloom.filter.Contains(enc)
+}
+
+// bloomInsert 把新插入的键写入过滤器，键数超过容量时重建，调用方必须持有写锁
+func (t *BPlusTree) bloomInsert(enc []byte) {
+	if t.count > t.bloom.capacity {
+		t.rebuildBloom()
+		return
+	}
+	t.bloom.filter.Add(enc)
+}
+
+// bloomDelete 记录一个删除的键，残留的位过多时重建，调用方必须持有写锁
+func (t *BPlusTree) bloomDelete() {
+	t.bloom.stale++
+	if t.bloom.stale*2 > t.bloom.capacity {
+		t.rebuildBloom()
+	}
+}
+
+// rebuildBloom 按当前键数的两倍重新构建过滤器，调用方必须持有写锁
+// 树中的键都在插入时编码成功过；万一编码失败则去掉过滤器，退化为直接查树
+func (t *BPlusTree) rebuildBloom() {
+	capacity := t.count * 2
+	if capacity < bplusBloomInitialCapacity {
+		capacity = bplusBloomInitialCapacity
+	}
+	filter := NewBloomFilter(uint(capacity), t.bloom.fpr)
+	for leaf := t.leftmostLeaf(); leaf != nil; leaf = leaf.next {
+		for _, key := range leaf.keys {
+			enc, err := bloomKey(key)
+			if err != nil {
+				t.bloom = nil
+				return
+			}
+			filter.Add(enc)
+		}
+	}
+	t.bloom.filter = filter
+	t.bloom.capacity = capacity
+	t.bloom.stale = 0
+}
@@ -0,0 +1,133 @@
+package datastructures
+
+// bplusSnapshot B+树的一个时间点快照
+// 创建快照时只记录当时的叶子节点列表（O(n/order)），不复制数据；
+// 之后写入者在修改快照中的叶子节点前，先把该叶子的旧内容保存到preserved中（写时复制）。
+type bplusSnapshot struct {
+	epoch     uint64                   // 快照纪元，纪元小于它的叶子属于该快照
+	leaves    []*TreeNode              // 快照时刻的叶子节点链表
+	preserved map[*TreeNode][]KeyValue // 快照之后被修改过的叶子的原始内容
+}
+
+// preserveLeaf 在修改叶子节点之前调用，为所有活跃快照保存该叶子的原始内容
+// 调用方必须持有写锁
+func (t *BPlusTree) preserveLeaf(leaf *TreeNode) {
+	for s := range t.snapshots {
+		if leaf.epoch >= s.epoch {
+			continue // 快照之后创建的叶子不属于该快照
+		}
+		if _, ok := s.preserved[leaf]; ok {
+			continue
+		}
+		s.preserved[leaf] = append([]KeyValue(nil), leaf.values...)
+	}
+}
+
+// BPlusTreeSnapshotIterator 快照一致性迭代器
+// 特点：
+// - 看到的是创建迭代器那一刻的数据，每个键恰好出现一次
+// - 不阻塞写入者，写入者只在首次修改快照中的叶子时复制该叶子
+// - 使用完毕后必须调用Close，否则写入者会持续为该快照复制叶子
+type BPlusTreeSnapshotIterator struct {
+	tree    *BPlusTree
+	snap    *bplusSnapshot
+	leafIdx int        // 当前叶子在快照叶子列表中的位置
+	entries []KeyValue // 当前叶子的快照内容
+	pos     int        // 当前叶子内的位置
+}
+
+// SnapshotIterator 创建快照一致性迭代器，迭代器初始位于第一个键值对
+func (t *BPlusTree) SnapshotIterator() *BPlusTreeSnapshotIterator {
+	t.mu.Lock()
+
+	t.epoch++
+	snap := &bplusSnapshot{
+		epoch:     t.epoch,
+		preserved: make(map[*TreeNode][]KeyValue),
+	}
+
+	leaf := t.root
+	for !leaf.isLeaf {
+		leaf = leaf.children[0]
+	}
+	for ; leaf != nil; leaf = leaf.next {
+		snap.leaves = append(snap.leaves, leaf)
+	}
+
+	if t.snapshots == nil {
+		t.snapshots = make(map[*bplusSnapshot]struct{})
+	}
+	t.snapshots[snap] = struct{}{}
+	t.mu.Unlock()
+
+	it := &BPlusTreeSnapshotIterator{tree: t, snap: snap, leafIdx: -1}
+	it.advanceLeaf()
+	return it
+}
+
+// advanceLeaf 加载下一个非空叶子的快照内容
+func (it *BPlusTreeSnapshotIterator) advanceLeaf() {
+	it.entries = nil
+	it.pos = 0
+
+	for it.snap != nil && len(it.entries) == 0 {
+		it.leafIdx++
+		if it.leafIdx >= len(it.snap.leaves) {
+			it.Close()
+			return
+		}
+
+		leaf := it.snap.leaves[it.leafIdx]
+		it.tree.mu.RLock()
+		if kvs, ok := it.snap.preserved[leaf]; ok {
+			it.entries = kvs
+		} else {
+			// 叶子自快照以来未被修改，复制当前内容（之后的修改不会影响副本）
+			it.entries = append([]KeyValue(nil), leaf.values...)
+		}
+		it.tree.mu.RUnlock()
+	}
+}
+
+// Valid 迭代器是否指向有效的键值对
+func (it *BPlusTreeSnapshotIterator) Valid() bool {
+	return it.pos < len(it.entries)
+}
+
+// Next 移动到下一个键值对
+func (it *BPlusTreeSnapshotIterator) Next() {
+	if !it.Valid() {
+		return
+	}
+	it.pos++
+	if it.pos >= len(it.entries) {
+		it.advanceLeaf()
+	}
+}
+
+// Key 返回当前键
+func (it *BPlusTreeSnapshotIterator) Key() any {
+	if !it.Valid() {
+		return nil
+	}
+	return it.entries[it.pos].Key
+}
+
+// Value 返回当前值
+func (it *BPlusTreeSnapshotIterator) Value() any {
+	if !it.Valid() {
+		return nil
+	}
+	return it.entries[it.pos].Value
+}
+
+// Close 释放快照，迭代结束时会自动调用，可重复调用
+func (it *BPlusTreeSnapshotIterator) Close() {
+	if it.snap == nil {
+		return
+	}
+	it.tree.mu.Lock()
+	delete(it.tree.snapshots, it.snap)
+	it.tree.mu.Unlock()
+	it.snap = nil
+}
@@ -2,6 +2,7 @@ package datastructures
 
 import (
 	"fmt"
+	"math/rand"
 	"sync"
 	"testing"
 )
@@ -505,3 +506,374 @@ func TestBPlusTreeStringRepresentation(t *testing.T) {
 	// 验证字符串包含一些预期的内容
 	t.Logf("Tree structure:\n%s", str)
 }
+
+// TestBPlusTreeSnapshotIterator 测试快照迭代器在并发写入下的一致性
+func TestBPlusTreeSnapshotIterator(t *testing.T) {
+	tree := NewBPlusTree(4, intComparator)
+	for i := 0; i < 1000; i++ {
+		tree.Insert(i, i)
+	}
+
+	it := tree.SnapshotIterator()
+	defer it.Close()
+
+	// 创建快照后并发修改：删除偶数键、更新奇数键、插入新键
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			if i%2 == 0 {
+				tree.Delete(i)
+			} else {
+				tree.Insert(i, -i)
+			}
+			tree.Insert(1000+i, i)
+		}
+	}()
+
+	expected := 0
+	for ; it.Valid(); it.Next() {
+		if it.Key() != expected || it.Value() != expected {
+			t.Fatalf("快照迭代得到 (%v, %v), 期望 (%d, %d)", it.Key(), it.Value(), expected, expected)
+		}
+		expected++
+	}
+	wg.Wait()
+
+	if expected != 1000 {
+		t.Errorf("快照迭代得到 %d 个键, 期望 1000", expected)
+	}
+	if len(tree.snapshots) != 0 {
+		t.Errorf("迭代结束后仍有 %d 个活跃快照", len(tree.snapshots))
+	}
+}
+
+// TestBPlusTreeNodeArena 测试使用内存块分配器的B+树
+func TestBPlusTreeNodeArena(t *testing.T) {
+	arena := NewNodeArena(64)
+	tree := NewBPlusTree(4, intComparator, WithNodeAllocator(arena))
+
+	for i := 0; i < 2000; i++ {
+		tree.Insert(i, i)
+	}
+	for i := 0; i < 2000; i += 3 {
+		tree.Delete(i)
+	}
+	for i := 0; i < 2000; i++ {
+		_, found := tree.Search(i)
+		if found != (i%3 != 0) {
+			t.Fatalf("Search(%d) found = %v", i, found)
+		}
+	}
+	if arena.Allocated() <= 1 {
+		t.Errorf("Allocated() = %d, 期望分配器被使用", arena.Allocated())
+	}
+
+	// 树丢弃后整体回收并复用
+	arena.Reset()
+	if arena.Allocated() != 0 {
+		t.Errorf("Reset() 后 Allocated() = %d, 期望 0", arena.Allocated())
+	}
+	tree = NewBPlusTree(4, intComparator, WithNodeAllocator(arena))
+	for i := 0; i < 100; i++ {
+		tree.Insert(i, i)
+	}
+	if len(tree.ScanAll()) != 100 {
+		t.Error("复用内存块后的树数据不正确")
+	}
+}
+
+// TestBPlusTreeSequentialSplit 测试顺序插入时的不均匀分裂
+func TestBPlusTreeSequentialSplit(t *testing.T) {
+	countLeaves := func(tree *BPlusTree) int {
+		n := 0
+		for leaf := tree.leftmostLeaf(); leaf != nil; leaf = leaf.next {
+			n++
+		}
+		return n
+	}
+
+	const size = 1000
+	ascending := NewBPlusTree(8, intComparator)
+	descending := NewBPlusTree(8, intComparator)
+	for i := 0; i < size; i++ {
+		ascending.Insert(i, i)
+		descending.Insert(size-1-i, i)
+	}
+
+	// 满载叶子容纳 order-1 = 7 个键
+	maxLeaves := (size+6)/7 + 1
+	for name, tree := range map[string]*BPlusTree{"递增": ascending, "递减": descending} {
+		if n := countLeaves(tree); n > maxLeaves {
+			t.Errorf("%s插入后叶子数量 = %d, 期望不超过 %d", name, n, maxLeaves)
+		}
+		result := tree.ScanAll()
+		if len(result) != size {
+			t.Fatalf("%s插入后 ScanAll() 返回 %d 个元素, 期望 %d", name, len(result), size)
+		}
+		for i, kv := range result {
+			if kv.Key != i {
+				t.Fatalf("%s插入后 ScanAll()[%d] = %v", name, i, kv.Key)
+			}
+		}
+	}
+
+	// 不均匀分裂产生的叶子在删除时仍能正确重新平衡
+	for i := 0; i < size; i += 2 {
+		if !ascending.Delete(i) {
+			t.Fatalf("Delete(%d) 应该返回 true", i)
+		}
+	}
+	for i := 1; i < size; i += 2 {
+		if _, found := ascending.Search(i); !found {
+			t.Fatalf("删除后找不到键 %d", i)
+		}
+	}
+}
+
+// TestBPlusTreeRandomOperations 随机插入删除与map对照，覆盖内部节点借键和合并
+func TestBPlusTreeRandomOperations(t *testing.T) {
+	for _, order := range []int{3, 4, 8} {
+		r := rand.New(rand.NewSource(int64(order)))
+		tree := NewBPlusTree(order, intComparator)
+		expected := make(map[int]int)
+
+		for i := 0; i < 20000; i++ {
+			key := r.Intn(500)
+			if r.Intn(3) == 0 {
+				key = i // 混入单调递增的键
+			}
+			if r.Intn(2) == 0 {
+				tree.Insert(key, i)
+				expected[key] = i
+				continue
+			}
+			_, exists := expected[key]
+			if deleted := tree.Delete(key); deleted != exists {
+				t.Fatalf("order=%d 第 %d 步 Delete(%d) = %v, 期望 %v", order, i, key, deleted, exists)
+			}
+			delete(expected, key)
+		}
+
+		for key, value := range expected {
+			if got, found := tree.Search(key); !found || got != value {
+				t.Fatalf("order=%d Search(%d) = %v, %v, 期望 %v, true", order, key, got, found, value)
+			}
+		}
+		if int(tree.Size()) != len(expected) || len(tree.ScanAll()) != len(expected) {
+			t.Errorf("order=%d Size() = %d, ScanAll() = %d, 期望 %d",
+				order, tree.Size(), len(tree.ScanAll()), len(expected))
+		}
+	}
+}
+
+// TestBPlusTreeKeys 测试B+树的只返回键的查询
+func TestBPlusTreeKeys(t *testing.T) {
+	tree := NewBPlusTree(4, intComparator)
+	for i := 0; i < 100; i++ {
+		tree.Insert(i, i)
+	}
+
+	all := tree.KeysAll()
+	if len(all) != 100 {
+		t.Fatalf("KeysAll() 返回 %d 个键, 期望 100", len(all))
+	}
+	for i, k := range all {
+		if k != i {
+			t.Fatalf("KeysAll()[%d] = %v", i, k)
+		}
+	}
+
+	keys, err := tree.Keys(10, 20)
+	if err != nil {
+		t.Fatalf("Keys() 错误 = %v", err)
+	}
+	if len(keys) != 10 || keys[0] != 10 || keys[9] != 19 {
+		t.Errorf("Keys(10, 20) = %v", keys)
+	}
+	if _, err := tree.Keys(20, 10); err == nil {
+		t.Error("start >= end 应该返回错误")
+	}
+}
+
+// TestBPlusTreeBloomFilter 测试附加布隆过滤器的B+树
+func TestBPlusTreeBloomFilter(t *testing.T) {
+	if NewBPlusTree(4, intComparator).BloomFilter() != nil {
+		t.Error("未使用WithBloomFilter时BloomFilter()应该返回nil")
+	}
+
+	tree := NewBPlusTree(16, intComparator, WithBloomFilter(0.01))
+	const n = 5000 // 超过初始容量，触发重建
+	for i := 0; i < n; i++ {
+		if err := tree.Insert(i*2, i); err != nil {
+			t.Fatalf("Insert(%d) 错误 = %v", i*2, err)
+		}
+	}
+	if c := tree.bloom.capacity; c < n {
+		t.Errorf("重建后容量 = %d, 期望 >= %d", c, n)
+	}
+
+	for i := 0; i < n; i++ {
+		if v, ok := tree.Search(i * 2); !ok || v != i {
+			t.Fatalf("Search(%d) = %v, %v", i*2, v, ok)
+		}
+		if tree.Exists(i*2 + 1) {
+			t.Fatalf("Exists(%d) = true, 期望 false", i*2+1)
+		}
+	}
+
+	// 奇数键从未插入，过滤器应该拦下绝大多数
+	filter := tree.BloomFilter()
+	misses := 0
+	for i := 0; i < n; i++ {
+		enc, _ := bloomKey(i*2 + 1)
+		if filter.Contains(enc) {
+			misses++
+		}
+	}
+	if rate := float64(misses) / n; rate > 0.05 {
+		t.Errorf("过滤器假阳性率 = %.4f, 期望接近 0.01", rate)
+	}
+
+	// 删除过半的键后重建，删除的键不再残留在过滤器中
+	for i := 0; i < n; i++ {
+		tree.Delete(i * 2)
+	}
+	if tree.Size() != 0 || tree.Exists(0) {
+		t.Errorf("删除全部键后 Size() = %d, Exists(0) = %v", tree.Size(), tree.Exists(0))
+	}
+	if s := tree.bloom.stale; s*2 > tree.bloom.capacity {
+		t.Errorf("残留的删除键 = %d, 容量 = %d, 应该已经重建", s, tree.bloom.capacity)
+	}
+
+	// 无法编码的键插入失败，树保持不变
+	if err := tree.Insert(testMarshalerKey{err: fmt.Errorf("marshal failed")}, 1); err == nil {
+		t.Error("无法编码的键应该返回错误")
+	}
+	if tree.Size() != 0 {
+		t.Errorf("插入失败后 Size() = %d, 期望 0", tree.Size())
+	}
+}
+
+// clearBPlusHashes 清除所有缓存的节点哈希，强制RootHash完整重新计算
+func clearBPlusHashes(n *TreeNode) {
+	n.hash = ""
+	for _, child := range n.children {
+		clearBPlusHashes(child)
+	}
+}
+
+// TestBPlusTreeRootHash 测试增量维护的根哈希与完整重新计算的结果一致
+func TestBPlusTreeRootHash(t *testing.T) {
+	for _, order := range []int{3, 4, 8} {
+		r := rand.New(rand.NewSource(int64(order)))
+		tree := NewBPlusTree(order, intComparator)
+		seen := make(map[string]bool)
+
+		for i := 0; i < 3000; i++ {
+			key := r.Intn(300)
+			if r.Intn(2) == 0 {
+				tree.Insert(key, i)
+			} else {
+				tree.Delete(key)
+			}
+			if i%7 != 0 {
+				continue
+			}
+
+			incremental, err := tree.RootHash()
+			if err != nil {
+				t.Fatal(err)
+			}
+			clearBPlusHashes(tree.root)
+			full, _ := tree.RootHash()
+			if incremental != full {
+				t.Fatalf("order=%d 第 %d 步增量根哈希 %s, 完整计算 %s", order, i, incremental, full)
+			}
+			seen[full] = true
+		}
+		if len(seen) < 100 {
+			t.Errorf("order=%d 只出现了 %d 个不同的根哈希", order, len(seen))
+		}
+	}
+
+	tree := NewBPlusTree(4, intComparator)
+	tree.Insert(1, "a")
+	before, _ := tree.RootHash()
+	tree.Insert(1, "b")
+	if after, _ := tree.RootHash(); after == before {
+		t.Error("更新值后根哈希应改变")
+	}
+}
+
+// TestBPlusTreeRangeProof 测试范围查询证明的生成和验证
+func TestBPlusTreeRangeProof(t *testing.T) {
+	tree := NewBPlusTree(4, intComparator)
+	for i := 0; i < 500; i += 2 {
+		tree.Insert(i, fmt.Sprintf("value_%d", i))
+	}
+	for i := 0; i < 500; i += 6 {
+		tree.Delete(i)
+	}
+	root, err := tree.RootHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range [][2]int{{-10, 5}, {100, 101}, {100, 102}, {37, 211}, {490, 600}, {-100, 1000}} {
+		results, proof, err := tree.RangeQueryWithProof(r[0], r[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := tree.RangeQuery(r[0], r[1])
+		if fmt.Sprint(results) != fmt.Sprint(want) {
+			t.Fatalf("RangeQueryWithProof(%d, %d) = %v, 期望 %v", r[0], r[1], results, want)
+		}
+		verified, ok := VerifyBPlusRangeProof(proof, root, intComparator)
+		if !ok || fmt.Sprint(verified) != fmt.Sprint(want) {
+			t.Fatalf("VerifyBPlusRangeProof(%d, %d) = %v, %v", r[0], r[1], verified, ok)
+		}
+	}
+
+	// 篡改值、删除结果、隐藏相交的子树或改变范围都无法通过验证
+	_, proof, _ := tree.RangeQueryWithProof(100, 140)
+	leaf := proof.Root
+	for !leaf.Leaf {
+		for _, child := range leaf.Children {
+			if child.Hash == "" {
+				leaf = child
+				break
+			}
+		}
+	}
+	leaf.Entries[0].Value = "forged"
+	if _, ok := VerifyBPlusRangeProof(proof, root, intComparator); ok {
+		t.Error("篡改值的证明不应通过验证")
+	}
+
+	_, proof, _ = tree.RangeQueryWithProof(100, 140)
+	for i, child := range proof.Root.Children {
+		if child.Hash == "" {
+			proof.Root.Children[i] = &BPlusProofNode{Hash: root}
+			break
+		}
+	}
+	if _, ok := VerifyBPlusRangeProof(proof, root, intComparator); ok {
+		t.Error("隐藏相交子树的证明不应通过验证")
+	}
+
+	_, proof, _ = tree.RangeQueryWithProof(100, 140)
+	proof.End = 400
+	if _, ok := VerifyBPlusRangeProof(proof, root, intComparator); ok {
+		t.Error("扩大范围后的证明不应通过验证")
+	}
+
+	// 修改树之后旧的根哈希不再匹配
+	_, proof, _ = tree.RangeQueryWithProof(100, 140)
+	tree.Insert(1001, "new")
+	newRoot, _ := tree.RootHash()
+	if _, ok := VerifyBPlusRangeProof(proof, newRoot, intComparator); ok {
+		t.Error("旧证明不应对新根哈希通过验证")
+	}
+}
@@ -0,0 +1,210 @@
+package datastructures
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// maxEncodedFieldLen 反序列化时单个键或值的最大长度，防止损坏的数据导致超大分配
+const maxEncodedFieldLen = 1 << 30
+
+// Codec 键或值的二进制编解码器，用于序列化数据结构
+type Codec interface {
+	// Encode 将值编码为字节序列
+	Encode(v any) ([]byte, error)
+	// Decode 将字节序列解码为值
+	Decode(data []byte) (any, error)
+}
+
+// IntCodec int类型编解码器（8字节大端序）
+type IntCodec struct{}
+
+// Encode 编码int
+func (IntCodec) Encode(v any) ([]byte, error) {
+	n, ok := v.(int)
+	if !ok {
+		return nil, fmt.Errorf("IntCodec: unexpected type %T", v)
+	}
+	return binary.BigEndian.AppendUint64(nil, uint64(n)), nil
+}
+
+// Decode 解码int
+func (IntCodec) Decode(data []byte) (any, error) {
+	if len(data) != 8 {
+		return nil, fmt.Errorf("IntCodec: invalid length %d", len(data))
+	}
+	return int(binary.BigEndian.Uint64(data)), nil
+}
+
+// Int64Codec int64类型编解码器（8字节大端序）
+type Int64Codec struct{}
+
+// Encode 编码int64
+func (Int64Codec) Encode(v any) ([]byte, error) {
+	n, ok := v.(int64)
+	if !ok {
+		return nil, fmt.Errorf("Int64Codec: unexpected type %T", v)
+	}
+	return binary.BigEndian.AppendUint64(nil, uint64(n)), nil
+}
+
+// Decode 解码int64
+func (Int64Codec) Decode(data []byte) (any, error) {
+	if len(data) != 8 {
+		return nil, fmt.Errorf("Int64Codec: invalid length %d", len(data))
+	}
+	return int64(binary.BigEndian.Uint64(data)), nil
+}
+
+// Float64Codec float64类型编解码器（IEEE 754，8字节大端序）
+type Float64Codec struct{}
+
+// Encode 编码float64
+func (Float64Codec) Encode(v any) ([]byte, error) {
+	f, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("Float64Codec: unexpected type %T", v)
+	}
+	return binary.BigEndian.AppendUint64(nil, math.Float64bits(f)), nil
+}
+
+// Decode 解码float64
+func (Float64Codec) Decode(data []byte) (any, error) {
+	if len(data) != 8 {
+		return nil, fmt.Errorf("Float64Codec: invalid length %d", len(data))
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(data)), nil
+}
+
+// StringCodec string类型编解码器
+type StringCodec struct{}
+
+// Encode 编码string
+func (StringCodec) Encode(v any) ([]byte, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("StringCodec: unexpected type %T", v)
+	}
+	return []byte(s), nil
+}
+
+// Decode 解码string
+func (StringCodec) Decode(data []byte) (any, error) {
+	return string(data), nil
+}
+
+// BytesCodec []byte类型编解码器，解码结果是输入的副本
+type BytesCodec struct{}
+
+// Encode 编码[]byte
+func (BytesCodec) Encode(v any) ([]byte, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("BytesCodec: unexpected type %T", v)
+	}
+	return b, nil
+}
+
+// Decode 解码[]byte
+func (BytesCodec) Decode(data []byte) (any, error) {
+	return append([]byte(nil), data...), nil
+}
+
+// binaryWriter 带字节计数和错误记录的二进制写入器
+// 第一次出错后后续写入都被忽略，调用方只需在最后检查err
+type binaryWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+	buf [binary.MaxVarintLen64]byte
+}
+
+// write 写入原始字节
+func (bw *binaryWriter) write(p []byte) {
+	if bw.err != nil {
+		return
+	}
+	n, err := bw.w.Write(p)
+	bw.n += int64(n)
+	bw.err = err
+}
+
+// writeUvarint 写入变长无符号整数
+func (bw *binaryWriter) writeUvarint(v uint64) {
+	n := binary.PutUvarint(bw.buf[:], v)
+	bw.write(bw.buf[:n])
+}
+
+// writeBytes 写入长度前缀的字节序列
+func (bw *binaryWriter) writeBytes(p []byte) {
+	bw.writeUvarint(uint64(len(p)))
+	bw.write(p)
+}
+
+// writeEncoded 用codec编码后写入长度前缀的字节序列
+func (bw *binaryWriter) writeEncoded(c Codec, v any) {
+	if bw.err != nil {
+		return
+	}
+	data, err := c.Encode(v)
+	if err != nil {
+		bw.err = err
+		return
+	}
+	bw.writeBytes(data)
+}
+
+// binaryReader 带字节计数的二进制读取器
+// 逐字节读取变长整数，不会读取超过数据结构末尾的内容
+type binaryReader struct {
+	r   io.Reader
+	n   int64
+	one [1]byte
+}
+
+// ReadByte 读取一个字节，实现io.ByteReader
+func (br *binaryReader) ReadByte() (byte, error) {
+	if _, err := br.read(br.one[:]); err != nil {
+		return 0, err
+	}
+	return br.one[0], nil
+}
+
+// read 读满p
+func (br *binaryReader) read(p []byte) (int, error) {
+	n, err := io.ReadFull(br.r, p)
+	br.n += int64(n)
+	return n, err
+}
+
+// readUvarint 读取变长无符号整数
+func (br *binaryReader) readUvarint() (uint64, error) {
+	return binary.ReadUvarint(br)
+}
+
+// readBytes 读取长度前缀的字节序列，maxLen限制单个字段的最大长度
+func (br *binaryReader) readBytes(maxLen uint64) ([]byte, error) {
+	n, err := br.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > maxLen {
+		return nil, fmt.Errorf("field length %d exceeds limit %d", n, maxLen)
+	}
+	p := make([]byte, n)
+	if _, err := br.read(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// readDecoded 读取长度前缀的字节序列并用codec解码
+func (br *binaryReader) readDecoded(c Codec, maxLen uint64) (any, error) {
+	data, err := br.readBytes(maxLen)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decode(data)
+}
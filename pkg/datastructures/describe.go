@@ -0,0 +1,84 @@
+package datastructures
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ConcurrencyModel 并发模型
+type ConcurrencyModel string
+
+const (
+	// ConcurrencyRWMutex 全局读写锁保护
+	ConcurrencyRWMutex ConcurrencyModel = "rwmutex"
+	// ConcurrencyMutex 全局互斥锁保护，读取也会修改内部状态或需要互斥
+	ConcurrencyMutex ConcurrencyModel = "mutex"
+	// ConcurrencyBucketLock 目录读写锁加桶级锁，不同桶上的写入可并发
+	ConcurrencyBucketLock ConcurrencyModel = "bucket-lock"
+	// ConcurrencyStriped 按哈希划分为多个独立加锁的分条，一个分条的结构调整不阻塞其他分条
+	ConcurrencyStriped ConcurrencyModel = "striped"
+	// ConcurrencyLockFree 基于CAS的无锁实现
+	ConcurrencyLockFree ConcurrencyModel = "lock-free"
+	// ConcurrencyLockFreeReads 读取不加锁，沿原子指针遍历；写入由互斥锁串行化，不阻塞读取
+	ConcurrencyLockFreeReads ConcurrencyModel = "lock-free-reads"
+	// ConcurrencyNone 不加锁，只能在单个goroutine中使用
+	ConcurrencyNone ConcurrencyModel = "none"
+)
+
+// Complexity 各操作的时间复杂度说明，不支持的操作为空字符串
+type Complexity struct {
+	Insert string `json:"insert"`
+	Search string `json:"search"`
+	Delete string `json:"delete"`
+	Range  string `json:"range"`
+}
+
+// Descriptor 数据结构的能力描述，供上层按能力路由操作和自动生成对比表
+type Descriptor struct {
+	Name           string           `json:"name"`           // 结构名称
+	Ordered        bool             `json:"ordered"`        // 是否按键有序
+	SupportsDelete bool             `json:"supportsDelete"` // 是否支持删除
+	SupportsRange  bool             `json:"supportsRange"`  // 是否支持范围查询
+	Probabilistic  bool             `json:"probabilistic"`  // 查询结果是否为概率性的
+	Persistent     bool             `json:"persistent"`     // 是否支持序列化持久化
+	Concurrency    ConcurrencyModel `json:"concurrency"`    // 并发模型
+	Complexity     Complexity       `json:"complexity"`     // 时间复杂度
+	Notes          string           `json:"notes"`          // 补充说明
+}
+
+// Describer 能够描述自身能力的数据结构
+type Describer interface {
+	Describe() Descriptor
+}
+
+// WriteCapabilityTable 以Markdown表格的形式输出多个数据结构的能力对比
+func WriteCapabilityTable(w io.Writer, structures ...Describer) error {
+	yesNo := func(b bool) string {
+		if b {
+			return "是"
+		}
+		return "否"
+	}
+	orDash := func(s string) string {
+		if s == "" {
+			return "不支持"
+		}
+		return s
+	}
+
+	var sb strings.Builder
+	sb.WriteString("| 结构 | 有序 | 删除 | 范围查询 | 概率性 | 持久化 | 并发模型 | 插入 | 查询 | 删除复杂度 | 范围复杂度 |\n")
+	sb.WriteString("|------|------|------|----------|--------|--------|----------|------|------|------------|------------|\n")
+	for _, s := range structures {
+		d := s.Describe()
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s | %s | %s | %s | %s | %s | %s |\n",
+			d.Name, yesNo(d.Ordered), yesNo(d.SupportsDelete), yesNo(d.SupportsRange),
+			yesNo(d.Probabilistic), yesNo(d.Persistent), d.Concurrency,
+			orDash(d.Complexity.Insert), orDash(d.Complexity.Search),
+			orDash(d.Complexity.Delete), orDash(d.Complexity.Range))
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
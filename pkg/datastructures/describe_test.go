@@ -0,0 +1,52 @@
+package datastructures
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDescribeCapabilityTable 测试能力描述和对比表输出
+func TestDescribeCapabilityTable(t *testing.T) {
+	structures := []Describer{
+		NewBPlusTree(4, intComparator),
+		NewDefaultSkipList(intComparator),
+		NewOrderedSkipList[int, int](),
+		NewDefaultLockFreeSkipList(intComparator),
+		NewExtendibleHashWithDefault(),
+		NewStripedExtendibleHash(4, 4, nil),
+		NewDefaultBloomFilter(),
+		NewBlockedBloomFilter(1000, 0.01),
+		NewStableBloomFilter(1000, 3, 0.01),
+		NewShardedBloomFilter(1000, 0.01, 4),
+		NewSpectralBloomFilter(1000, 0.01),
+		NewMerkleTree(nil),
+		NewMerklePatriciaTrie(),
+		&BinaryMerkleTree{},
+		NewAutocomplete(),
+		NewGeoIndex(16),
+		NewSortedSet(),
+	}
+
+	for _, s := range structures {
+		d := s.Describe()
+		if d.Name == "" || d.Concurrency == "" {
+			t.Errorf("%T 的能力描述不完整: %+v", s, d)
+		}
+		if d.SupportsRange && d.Complexity.Range == "" {
+			t.Errorf("%s 支持范围查询但缺少复杂度说明", d.Name)
+		}
+	}
+
+	if d := NewExtendibleHashWithDefault().Describe(); d.Ordered || d.SupportsRange {
+		t.Error("ExtendibleHash 不应声明有序或范围查询能力")
+	}
+
+	var sb strings.Builder
+	if err := WriteCapabilityTable(&sb, structures...); err != nil {
+		t.Fatalf("WriteCapabilityTable() 错误 = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	if len(lines) != len(structures)+2 {
+		t.Errorf("对比表有 %d 行, 期望 %d", len(lines), len(structures)+2)
+	}
+}
@@ -4,11 +4,39 @@ import (
 	"fmt"
 	"hash/fnv"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// maxGlobalDepth 全局深度上限，等于64位哈希值的位数
+// 到达上限或所有键哈希值完全相同的桶不再分裂，而是允许超出容量
+const maxGlobalDepth = 64
+
+// maxLoadDepth 加载快照或桶页文件时允许的最大全局深度
+// 目录有2^globalDepth项，损坏的输入不应导致无法分配的目录
+const maxLoadDepth = 40
+
 // HashFunc 哈希函数类型
 type HashFunc func(data []byte) uint32
 
+// HashFunc64 64位哈希函数类型
+// 32位哈希最多区分2^32个目录项，超大的表中完全相同的哈希值也更多；
+// 64位哈希让桶可以继续分裂，并减少需要逐字节比较编码的冲突
+type HashFunc64 func(data []byte) uint64
+
+// Hash64From32 把32位哈希函数适配为64位，高32位为0
+// 高位为0时桶最多分裂到局部深度32，行为与直接使用32位哈希函数相同
+func Hash64From32(fn HashFunc) HashFunc64 {
+	return func(data []byte) uint64 {
+		return uint64(fn(data))
+	}
+}
+
+// depthMask 返回低depth位全为1的掩码，depth为64时返回全1
+func depthMask(depth int) uint64 {
+	return uint64(1)<<depth - 1
+}
+
 // defaultHash 默认哈希函数
 func defaultHash(data []byte) uint32 {
 	h := fnv.New32a()
@@ -16,11 +44,62 @@ func defaultHash(data []byte) uint32 {
 	return h.Sum32()
 }
 
+// optionalRWMutex 可关闭的读写锁，关闭后不再同步，只记录读锁的持有数
+// 用于WithoutLocking：单goroutine使用时省去加锁解锁的开销
+type optionalRWMutex struct {
+	mu       sync.RWMutex
+	disabled bool
+	readers  int // 关闭时持有读锁的次数，供TryLock判断
+}
+
+func (m *optionalRWMutex) Lock() {
+	if !m.disabled {
+		m.mu.Lock()
+	}
+}
+
+func (m *optionalRWMutex) Unlock() {
+	if !m.disabled {
+		m.mu.Unlock()
+	}
+}
+
+func (m *optionalRWMutex) RLock() {
+	if m.disabled {
+		m.readers++
+		return
+	}
+	m.mu.RLock()
+}
+
+func (m *optionalRWMutex) RUnlock() {
+	if m.disabled {
+		m.readers--
+		return
+	}
+	m.mu.RUnlock()
+}
+
+// TryLock 关闭时在没有持有读锁时成功
+func (m *optionalRWMutex) TryLock() bool {
+	if m.disabled {
+		return m.readers == 0
+	}
+	return m.mu.TryLock()
+}
+
 // HashBucket 哈希桶
 type HashBucket struct {
 	keys    []any // 桶中的键
 	values  []any // 桶中的值
-	localDepth int       // 局部深度
+	encoded []string // 键的编码，用于等值比较
+	hashes  []uint64 // 键的哈希值，分裂时无需重新编码
+	stamps  []uint32 // 键最近一次被访问时的时钟值，只在开启LRU淘汰时更新
+	localDepth int       // 局部深度，只在持有目录写锁时修改
+	prefix  uint64       // 桶中所有键哈希值的低localDepth位
+	page    uint32       // 持久化模式下桶所在的页号，0表示未持久化
+	overflow int         // 溢出页数量，桶的实际容量为bucketCapacity×(1+overflow)
+	mu      optionalRWMutex // 桶锁，保护keys/values/encoded/hashes/stamps（持有读锁时stamps只能原子写入）
 }
 
 // NewHashBucket 创建新的哈希桶
@@ -28,13 +107,79 @@ func NewHashBucket() *HashBucket {
 	return &HashBucket{
 		keys:      make([]any, 0),
 		values:    make([]any, 0),
+		encoded:   make([]string, 0),
+		hashes:    make([]uint64, 0),
+		stamps:    make([]uint32, 0),
 		localDepth: 0,
 	}
 }
 
+// find 返回编码为enc、哈希为hash的键在桶中的位置，不存在时返回-1
+func (b *HashBucket) find(enc []byte, hash uint64) int {
+	for i, h := range b.hashes {
+		if h == hash && b.encoded[i] == string(enc) {
+			return i
+		}
+	}
+	return -1
+}
+
+// findKey 用equal比较键，返回与key相等、哈希为hash的键在桶中的位置，不存在时返回-1
+func (b *HashBucket) findKey(key any, hash uint64, equal EqualFunc) int {
+	for i, h := range b.hashes {
+		if h == hash && equal(b.keys[i], key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// append 向桶尾部追加一个键值对
+func (b *HashBucket) append(key, value any, encoded string, hash uint64) {
+	b.keys = append(b.keys, key)
+	b.values = append(b.values, value)
+	b.encoded = append(b.encoded, encoded)
+	b.hashes = append(b.hashes, hash)
+	b.stamps = append(b.stamps, 0)
+}
+
+// remove 删除桶中位置i的键值对
+func (b *HashBucket) remove(i int) {
+	b.keys = removeAt(b.keys, i)
+	b.values = removeAt(b.values, i)
+	b.encoded = removeAt(b.encoded, i)
+	b.hashes = removeAt(b.hashes, i)
+	b.stamps = removeAt(b.stamps, i)
+}
+
+// truncate 只保留桶中前n个键值对
+func (b *HashBucket) truncate(n int) {
+	b.keys = b.keys[:n]
+	b.values = b.values[:n]
+	b.encoded = b.encoded[:n]
+	b.hashes = b.hashes[:n]
+	b.stamps = b.stamps[:n]
+}
+
+// separable 分裂能否把桶中的键与哈希为hash的新键分开
+// 所有哈希值都相同时，无论分裂多少次它们都落在同一个桶中
+func (b *HashBucket) separable(hash uint64) bool {
+	for _, h := range b.hashes {
+		if h != hash {
+			return true
+		}
+	}
+	return false
+}
+
 // isFull 检查桶是否已满
 func (b *HashBucket) isFull(capacity int) bool {
-	return len(b.keys) >= capacity
+	return len(b.keys) >= capacity*(1+b.overflow)
+}
+
+// fitOverflow 把溢出页数量调整为容纳当前键所需的最小值
+func (b *HashBucket) fitOverflow(capacity int) {
+	b.overflow = max(0, (len(b.keys)+capacity-1)/capacity-1)
 }
 
 // isEmpty 检查桶是否为空
@@ -49,20 +194,170 @@ func (b *HashBucket) isEmpty() bool {
 // - 动态扩容，无需全量重新哈希
 // - 减少随机IO，适合磁盘存储
 // - 通过目录和桶的分离实现可扩展性
+// - 目录读写锁加桶锁：不同桶上的写入可以并发执行
+// - 删除使桶变得稀疏时自动合并兄弟桶并收缩目录
+//
+// 锁协议：
+// - 普通的Insert/Search/Delete持有目录读锁，再持有目标桶的桶锁
+// - 桶已满需要分裂时，释放全部锁后重新获取目录写锁；
+//   目录写锁排斥所有持有目录读锁的操作，因此分裂期间无需再获取桶锁
+// - 锁顺序始终是先目录后桶，不会死锁
 type ExtendibleHash struct {
 	buckets   []*HashBucket // 桶数组（目录）
-	directory []*HashBucket // 目录指针
+	directory hashDirectory // 目录，分段存储
 	globalDepth int        // 全局深度
 	bucketCapacity  int    // 桶容量
-	hashFunc        HashFunc // 哈希函数
-	mu              sync.RWMutex // 读写锁
-	count           int64   // 总键数
+	hashFunc        HashFunc64 // 哈希函数，32位哈希函数高位补0
+	keyEncoder      KeyEncoder // 键编码器，哈希和等值比较都基于编码结果
+	pager           *bucketPager // 桶页文件，nil表示纯内存模式
+	keyCodec        Codec        // 键编解码器，用于持久化
+	valueCodec      Codec        // 值编解码器，用于持久化
+	pageSize        int          // 桶页大小（字节）
+	mu              optionalRWMutex // 目录读写锁，保护directory和globalDepth
+	count           atomic.Int64 // 总键数
+	splits          atomic.Int64 // 桶分裂次数
+	merges          atomic.Int64 // 桶合并次数
+	overflows       atomic.Int64 // 追加溢出页的次数
+	maxOverflow     int          // 每个桶最多的溢出页数量，0表示桶满立即分裂
+	mergeThreshold  int          // 兄弟桶键数之和不超过该值时合并，负数表示不合并
+	depthLimit      int          // 全局深度上限，达到后桶满时不再分裂而是继续追加
+	splitLoadFactor float64      // 整体负载因子超过该值时提前分裂，0表示只在桶满时分裂
+	onDirectoryGrow func(oldDepth, newDepth int) // 目录加倍时的回调
+	onSplit         func(localDepth int)         // 桶分裂后的回调
+	onOverflow      func(overflowPages int)      // 追加溢出页后的回调
+	doublings       atomic.Int64 // 目录加倍次数
+	doublingNanos   atomic.Int64 // 目录加倍的累计耗时（纳秒）
+	numBuckets      int          // 不同桶的数量，受目录锁保护
+	maxEntries      int          // 键数上限，超出时淘汰最久未访问的键，0表示不限制
+	onEvict         func(key, value any) // 淘汰回调
+	clock           atomic.Uint32 // LRU时钟，每次插入加一，访问时记录当前值
+	evictions       atomic.Int64 // 淘汰的累计次数
+	keyHash         func(key any) uint64 // 自定义键哈希，与equal成对设置
+	equal           EqualFunc    // 自定义键相等判断，nil表示比较键的编码
+}
+
+// ExtendibleHashOption 可扩展哈希表的可选配置
+type ExtendibleHashOption func(*ExtendibleHash)
+
+// WithMergeThreshold 指定删除后合并兄弟桶的阈值
+// 两个兄弟桶的键数之和不超过threshold时合并为一个桶；默认为桶容量的一半，负数关闭自动合并
+func WithMergeThreshold(threshold int) ExtendibleHashOption {
+	return func(eh *ExtendibleHash) {
+		eh.mergeThreshold = min(threshold, eh.bucketCapacity)
+	}
+}
+
+// WithExtendibleHashCodec 指定键、值编解码器，持久化时必须设置
+func WithExtendibleHashCodec(keyCodec, valueCodec Codec) ExtendibleHashOption {
+	return func(eh *ExtendibleHash) {
+		eh.keyCodec = keyCodec
+		eh.valueCodec = valueCodec
+	}
+}
+
+// WithOverflowChain 桶满时先追加溢出页而不是立即分裂，溢出链达到maxPages页后才分裂
+// 追加溢出页只需桶锁，突发写入时可以推迟分裂和目录加倍带来的延迟尖峰，代价是桶内查找变长
+// 持久化模式不支持溢出链
+func WithOverflowChain(maxPages int) ExtendibleHashOption {
+	return func(eh *ExtendibleHash) {
+		eh.maxOverflow = max(0, maxPages)
+	}
+}
+
+// WithMaxGlobalDepth 限制全局深度，即目录最多2^depth项，用于限制目录的内存增长
+// 达到上限后局部深度等于depth的桶满了也不再分裂，新键直接追加到桶中，桶内查找随之变长
+// 只限制之后的增长，加载的快照或文件深度更大时保持原样
+func WithMaxGlobalDepth(depth int) ExtendibleHashOption {
+	return func(eh *ExtendibleHash) {
+		eh.depthLimit = min(max(0, depth), maxGlobalDepth)
+	}
+}
+
+// WithSplitLoadFactor 整体负载因子（键数 / (桶数 × 桶容量)）超过loadFactor时，
+// 插入后目标桶的键数也达到loadFactor × 桶容量的话就提前分裂该桶，不必等到桶满
+// 以更多的桶为代价缩短桶内查找，也把分裂分摊到更多次插入中；不大于0或不小于1时关闭
+func WithSplitLoadFactor(loadFactor float64) ExtendibleHashOption {
+	return func(eh *ExtendibleHash) {
+		if loadFactor <= 0 || loadFactor >= 1 {
+			loadFactor = 0
+		}
+		eh.splitLoadFactor = loadFactor
+	}
+}
+
+// WithDirectoryGrowHook 目录加倍时调用fn，参数为加倍前后的全局深度
+// 目录加倍需要复制整个目录，是最昂贵的事件，可用于监控和告警
+// fn在持有目录写锁时调用，不能访问该哈希表，应尽快返回
+func WithDirectoryGrowHook(fn func(oldDepth, newDepth int)) ExtendibleHashOption {
+	return func(eh *ExtendibleHash) {
+		eh.onDirectoryGrow = fn
+	}
+}
+
+// WithSplitHook 每次桶分裂后调用fn，参数为分裂后两个桶的局部深度
+// fn在持有目录写锁时调用，不能访问该哈希表，应尽快返回
+func WithSplitHook(fn func(localDepth int)) ExtendibleHashOption {
+	return func(eh *ExtendibleHash) {
+		eh.onSplit = fn
+	}
+}
+
+// WithOverflowHook 每次桶追加溢出页后调用fn，参数为该桶当前的溢出页数量
+// fn在持有桶锁时调用，不同桶上的调用可能并发执行；不能访问该哈希表，应尽快返回
+func WithOverflowHook(fn func(overflowPages int)) ExtendibleHashOption {
+	return func(eh *ExtendibleHash) {
+		eh.onOverflow = fn
+	}
+}
+
+// WithHashFunc64 使用64位哈希函数，覆盖NewExtendibleHash的hashFunc参数
+func WithHashFunc64(fn HashFunc64) ExtendibleHashOption {
+	return func(eh *ExtendibleHash) {
+		if fn != nil {
+			eh.hashFunc = fn
+		}
+	}
+}
+
+// EqualFunc 键相等判断函数
+type EqualFunc func(a, b any) bool
+
+// WithEqualFunc 使用自定义的键哈希和相等判断代替KeyEncoder，适用于无法规范编码的键，
+// 例如需要按字段比较的复合键或大小写不敏感的字符串
+// 两者必须一致：equal(a, b)为true时hash(a)必须等于hash(b)。设置后KeyEncoder和哈希函数不再使用，
+// 字节键和字符串键的专用接口也退化为通用路径；持久化时键的哈希同样由hash计算
+func WithEqualFunc(hash func(key any) uint64, equal EqualFunc) ExtendibleHashOption {
+	if hash == nil || equal == nil {
+		panic("hash and equal must both be set")
+	}
+	return func(eh *ExtendibleHash) {
+		eh.keyHash = hash
+		eh.equal = equal
+	}
+}
+
+// WithoutLocking 关闭目录锁和桶锁，适用于只在单个goroutine中使用的批处理场景
+// 此时哈希表不是并发安全的，所有调用必须来自同一goroutine；
+// 计数器仍使用原子操作，Stats等统计接口的结果不变
+func WithoutLocking() ExtendibleHashOption {
+	return func(eh *ExtendibleHash) {
+		eh.mu.disabled = true
+	}
+}
+
+// WithKeyEncoder 指定键编码器，默认使用DefaultKeyEncoder
+func WithKeyEncoder(encoder KeyEncoder) ExtendibleHashOption {
+	return func(eh *ExtendibleHash) {
+		if encoder != nil {
+			eh.keyEncoder = encoder
+		}
+	}
 }
 
 // NewExtendibleHash 创建新的可扩展哈希表
 // bucketCapacity: 桶容量，建议值：4-64（根据磁盘块大小调整）
-// hashFunc: 哈希函数
-func NewExtendibleHash(bucketCapacity int, hashFunc HashFunc) *ExtendibleHash {
+// hashFunc: 32位哈希函数，为nil时使用FNV-1a；需要64位哈希时使用WithHashFunc64
+func NewExtendibleHash(bucketCapacity int, hashFunc HashFunc, opts ...ExtendibleHashOption) *ExtendibleHash {
 	if bucketCapacity <= 0 {
 		panic("bucketCapacity must be > 0")
 	}
@@ -74,211 +369,671 @@ func NewExtendibleHash(bucketCapacity int, hashFunc HashFunc) *ExtendibleHash {
 	// 初始目录大小为2^globalDepth
 	initialSize := 1 // 初始globalDepth=0，大小为1
 	buckets := make([]*HashBucket, initialSize)
-	directory := make([]*HashBucket, initialSize)
+	directory := newHashDirectory(initialSize)
 
 	// 创建初始桶
 	bucket := NewHashBucket()
 	buckets[0] = bucket
-	directory[0] = bucket
+	directory.set(0, bucket)
 
-	return &ExtendibleHash{
+	eh := &ExtendibleHash{
 		buckets:      buckets,
 		directory:    directory,
 		globalDepth:  0,
 		bucketCapacity: bucketCapacity,
-		hashFunc:      hashFunc,
-		count:        0,
+		hashFunc:      Hash64From32(hashFunc),
+		keyEncoder:    DefaultKeyEncoder{},
+		mergeThreshold: bucketCapacity / 2,
+		depthLimit:    maxGlobalDepth,
+		numBuckets:    1,
+	}
+	for _, opt := range opts {
+		opt(eh)
 	}
+	bucket.mu.disabled = eh.mu.disabled
+	return eh
 }
 
-// getBucketIndex 获取键对应的桶索引
-func (eh *ExtendibleHash) getBucketIndex(key any) (uint32, uint32) {
-	if key == nil {
-		return 0, 0
+// newBucket 创建与哈希表加锁模式一致的空桶
+func (eh *ExtendibleHash) newBucket() *HashBucket {
+	bucket := NewHashBucket()
+	bucket.mu.disabled = eh.mu.disabled
+	return bucket
+}
+
+// encodeKey 编码键并计算其哈希值
+// 使用自定义相等判断时不编码，返回nil和自定义的哈希值
+func (eh *ExtendibleHash) encodeKey(key any) ([]byte, uint64, error) {
+	if eh.equal != nil {
+		return nil, eh.keyHash(key), nil
 	}
+	var buf [32]byte
+	enc, err := eh.keyEncoder.AppendKey(buf[:0], key)
+	if err != nil {
+		return nil, 0, err
+	}
+	return enc, eh.hashFunc(enc), nil
+}
 
-	// 将键转换为字节数组
-	keyBytes := []byte(fmt.Sprintf("%v", key))
+// lookup 返回键在桶中的位置，不存在时返回-1
+// 默认比较键的编码；设置了WithEqualFunc时用equal比较键本身
+func (eh *ExtendibleHash) lookup(bucket *HashBucket, key any, enc []byte, hashValue uint64) int {
+	if eh.equal != nil {
+		return bucket.findKey(key, hashValue, eh.equal)
+	}
+	return bucket.find(enc, hashValue)
+}
 
-	// 计算哈希值
-	hashValue := eh.hashFunc(keyBytes)
+// getBucketIndex 获取哈希值对应的桶索引（使用低globalDepth位）
+func (eh *ExtendibleHash) getBucketIndex(hashValue uint64) uint64 {
+	return hashValue & depthMask(eh.globalDepth)
+}
 
-	// 使用低globalDepth位作为索引
-	index := hashValue & uint32((1<<eh.globalDepth)-1)
-	fullHash := hashValue
+// canSplit 桶在插入哈希为hash的新键前能否通过分裂腾出空间
+// 深度达到上限，或者桶中所有键的哈希都与新键相同时，分裂无济于事
+func (eh *ExtendibleHash) canSplit(bucket *HashBucket, hash uint64) bool {
+	return bucket.localDepth < eh.depthLimit && bucket.separable(hash)
+}
 
-	return index, fullHash
+// overloaded 整体负载因子和桶的负载都超过splitLoadFactor时返回true
+// 调用方必须持有目录锁和桶锁
+func (eh *ExtendibleHash) overloaded(bucket *HashBucket) bool {
+	if eh.splitLoadFactor == 0 || bucket.localDepth >= eh.depthLimit {
+		return false
+	}
+	limit := eh.splitLoadFactor * float64(eh.bucketCapacity)
+	return float64(len(bucket.keys)) >= limit && float64(eh.count.Load()) > limit*float64(eh.numBuckets)
 }
 
-// Insert 插入键值对
-func (eh *ExtendibleHash) Insert(key any, value any) error {
+// presplit 在目录写锁下重新检查后提前分裂哈希值所在的桶
+func (eh *ExtendibleHash) presplit(hashValue uint64) {
 	eh.mu.Lock()
 	defer eh.mu.Unlock()
+	eh.presplitLocked(hashValue)
+}
 
+// presplitLocked 桶过载时提前分裂哈希值所在的桶
+// 插入已经成功，分裂失败不影响本次插入，写入错误已记录在桶页文件中，由Sync返回
+// 调用方必须持有目录写锁
+func (eh *ExtendibleHash) presplitLocked(hashValue uint64) {
+	bucket := eh.directory.get(eh.getBucketIndex(hashValue))
+	if eh.overloaded(bucket) && eh.canSplit(bucket, hashValue) {
+		eh.splitBucket(bucket)
+	}
+}
+
+// Insert 插入键值对
+func (eh *ExtendibleHash) Insert(key any, value any) error {
 	if key == nil {
 		return fmt.Errorf("key cannot be nil")
 	}
 
-	index, _ := eh.getBucketIndex(key)
-	bucket := eh.directory[index]
+	enc, hashValue, err := eh.encodeKey(key)
+	if err != nil {
+		return err
+	}
+	return eh.insertEncoded(key, value, enc, hashValue)
+}
 
-	// 检查桶中是否已存在该键
-	for i, k := range bucket.keys {
-		if fmt.Sprintf("%v", k) == fmt.Sprintf("%v", key) {
-			bucket.values[i] = value
-			return nil
-		}
+// insertEncoded 插入已编码的键，key是保存在桶中的原始键
+func (eh *ExtendibleHash) insertEncoded(key, value any, enc []byte, hashValue uint64) error {
+	// 快速路径：键已存在或桶未满时，只需目录读锁和桶锁
+	eh.mu.RLock()
+	bucket := eh.directory.get(eh.getBucketIndex(hashValue))
+	bucket.mu.Lock()
+	done, err := eh.insertIntoBucket(bucket, key, value, enc, hashValue, false)
+	presplit := done && err == nil && eh.overloaded(bucket)
+	var ev eviction
+	if done && err == nil {
+		ev = eh.evictInBucket(bucket, key, enc, hashValue)
+	}
+	bucket.mu.Unlock()
+	eh.mu.RUnlock()
+	eh.finishEviction(ev, key, enc, hashValue)
+	if presplit {
+		eh.presplit(hashValue)
+	}
+	if done || err != nil {
+		return err
 	}
 
-	// 如果桶未满，直接插入
-	if !bucket.isFull(eh.bucketCapacity) {
-		bucket.keys = append(bucket.keys, key)
-		bucket.values = append(bucket.values, value)
-		eh.count++
-		return nil
+	// 慢速路径：桶已满，在目录写锁下分裂直到目标桶有空位
+	// 释放读锁到获取写锁之间桶可能已被其他写入者分裂，因此每轮都重新定位
+	eh.mu.Lock()
+	err = eh.insertLocked(key, value, enc, hashValue)
+	eh.mu.Unlock()
+	if err == nil && eh.overCapacity() {
+		eh.evictGlobal(key, enc, hashValue)
 	}
+	return err
+}
 
-	// 桶已满，需要分裂
-	eh.splitBucket(bucket, index)
-	eh.count++
+// insertLocked 插入已编码的键，目标桶已满时分裂直到有空位
+// 调用方必须持有目录写锁
+func (eh *ExtendibleHash) insertLocked(key, value any, enc []byte, hashValue uint64) error {
+	for {
+		bucket := eh.directory.get(eh.getBucketIndex(hashValue))
+		force := !eh.canSplit(bucket, hashValue)
+		if done, err := eh.insertIntoBucket(bucket, key, value, enc, hashValue, force); done || err != nil {
+			return err
+		}
+		if err := eh.splitBucket(bucket); err != nil {
+			return err
+		}
+	}
+}
 
-	return nil
+// GetOrCompute 键已存在时返回已有的值和true，否则调用fn计算值并插入，返回计算结果和false
+// 查找、计算和插入在同一次加锁内完成，同一个键的并发调用中通常只有一次会执行fn，
+// 适合缓存填充这类计算代价高的场景。fn在持有锁时执行：不能访问该哈希表，
+// 并且会阻塞同一个桶上的其他操作（需要分裂时阻塞整个表）。
+// key为nil或无法编码时不调用fn，返回nil和false；持久化写入失败时值不会被保存
+func (eh *ExtendibleHash) GetOrCompute(key any, fn func() any) (any, bool) {
+	if key == nil {
+		return nil, false
+	}
+	enc, hashValue, err := eh.encodeKey(key)
+	if err != nil {
+		return nil, false
+	}
+	return eh.getOrComputeEncoded(key, fn, enc, hashValue)
 }
 
-// splitBucket 分裂桶
-func (eh *ExtendibleHash) splitBucket(bucket *HashBucket, index uint32) {
-	// 创建两个新桶
-	newBucket1 := NewHashBucket()
-	newBucket2 := NewHashBucket()
+// getOrComputeEncoded 对已编码的键执行GetOrCompute
+func (eh *ExtendibleHash) getOrComputeEncoded(key any, fn func() any, enc []byte, hashValue uint64) (any, bool) {
+	// 快速路径：键已存在，或桶中还有空位（含可追加的溢出页）时在桶锁内计算并插入
+	eh.mu.RLock()
+	bucket := eh.directory.get(eh.getBucketIndex(hashValue))
+	bucket.mu.Lock()
+	if i := eh.lookup(bucket, key, enc, hashValue); i >= 0 {
+		eh.touch(bucket, i)
+		value := bucket.values[i]
+		bucket.mu.Unlock()
+		eh.mu.RUnlock()
+		return value, true
+	}
+	var value any
+	computed := !bucket.isFull(eh.bucketCapacity) || bucket.overflow < eh.maxOverflow
+	done, presplit := false, false
+	var err error
+	var ev eviction
+	if computed {
+		value = fn()
+		done, err = eh.insertIntoBucket(bucket, key, value, enc, hashValue, false)
+		presplit = done && err == nil && eh.overloaded(bucket)
+		if done && err == nil {
+			ev = eh.evictInBucket(bucket, key, enc, hashValue)
+		}
+	}
+	bucket.mu.Unlock()
+	eh.mu.RUnlock()
+	eh.finishEviction(ev, key, enc, hashValue)
+	if presplit {
+		eh.presplit(hashValue)
+	}
+	if done || err != nil {
+		return value, false
+	}
 
-	// 设置新桶的局部深度
-	bucket.localDepth++
-	newBucket1.localDepth = bucket.localDepth
-	newBucket2.localDepth = bucket.localDepth
+	// 慢速路径：需要分裂，在目录写锁下重新检查后再计算
+	// 只有持久化模式下桶页放不下时才会带着已经计算好的值进入这里
+	eh.mu.Lock()
+	inserted := false
+	defer func() {
+		eh.mu.Unlock()
+		if inserted && eh.overCapacity() {
+			eh.evictGlobal(key, enc, hashValue)
+		}
+	}()
+	for {
+		bucket := eh.directory.get(eh.getBucketIndex(hashValue))
+		if i := eh.lookup(bucket, key, enc, hashValue); i >= 0 {
+			eh.touch(bucket, i)
+			return bucket.values[i], true
+		}
+		if !computed {
+			value = fn()
+			computed = true
+		}
+		force := !eh.canSplit(bucket, hashValue)
+		if done, err := eh.insertIntoBucket(bucket, key, value, enc, hashValue, force); done || err != nil {
+			inserted = done
+			return value, false
+		}
+		if err := eh.splitBucket(bucket); err != nil {
+			return value, false
+		}
+	}
+}
 
-	// 重新分配键值对
-	for i, key := range bucket.keys {
-		keyBytes := []byte(fmt.Sprintf("%v", key))
-		hashValue := eh.hashFunc(keyBytes)
-
-		// 使用新的局部深度确定桶位置
-		bit := (hashValue >> (bucket.localDepth - 1)) & 1
-		if bit == 0 {
-			newBucket1.keys = append(newBucket1.keys, key)
-			newBucket1.values = append(newBucket1.values, bucket.values[i])
-		} else {
-			newBucket2.keys = append(newBucket2.keys, key)
-			newBucket2.values = append(newBucket2.values, bucket.values[i])
+// CompareAndSwap 键存在且当前值等于old时把值替换为new并返回true
+// 比较和替换在桶锁内原子地完成，可用于乐观并发控制；与sync.Map相同，old必须是可比较的类型
+func (eh *ExtendibleHash) CompareAndSwap(key, old, new any) bool {
+	return eh.update(key, func(current any) (any, bool) {
+		if current != old {
+			return nil, false
 		}
+		return new, true
+	})
+}
+
+// UpdateIfExists 键存在时以当前值调用fn，把值替换为fn的返回值并返回true；键不存在时不调用fn
+// 读取和替换在桶锁内原子地完成。fn在持有锁时执行，不能访问该哈希表；
+// 持久化模式下新值放不进桶页时，fn可能在目录写锁下以最新的值再被调用一次
+func (eh *ExtendibleHash) UpdateIfExists(key any, fn func(old any) any) bool {
+	return eh.update(key, func(current any) (any, bool) {
+		return fn(current), true
+	})
+}
+
+// update 键存在时以当前值调用fn，fn返回true时把值替换为fn返回的新值
+// 持久化写入失败时不修改并返回false
+func (eh *ExtendibleHash) update(key any, fn func(current any) (any, bool)) bool {
+	if key == nil {
+		return false
+	}
+	enc, hashValue, err := eh.encodeKey(key)
+	if err != nil {
+		return false
 	}
 
-	// 如果局部深度超过全局深度，需要扩展目录
-	if bucket.localDepth > eh.globalDepth {
-		eh.expandDirectory(bucket.localDepth)
+	// 快速路径：只需目录读锁和桶锁
+	eh.mu.RLock()
+	bucket := eh.directory.get(eh.getBucketIndex(hashValue))
+	bucket.mu.Lock()
+	i := eh.lookup(bucket, key, enc, hashValue)
+	swapped, done := false, false
+	if i >= 0 {
+		var value any
+		if value, swapped = fn(bucket.values[i]); swapped {
+			done, err = eh.insertIntoBucket(bucket, bucket.keys[i], value, enc, hashValue, false)
+		}
+	}
+	bucket.mu.Unlock()
+	eh.mu.RUnlock()
+	if !swapped || done || err != nil {
+		return done
 	}
 
-	// 更新目录指针
-	bit := (index >> (bucket.localDepth - 1)) & 1
-	if bit == 0 {
-		eh.updateDirectoryPointers(index, newBucket1, newBucket2)
-	} else {
-		eh.updateDirectoryPointers(index, newBucket2, newBucket1)
+	// 慢速路径：持久化模式下新值放不进桶页，在目录写锁下重新读取后分裂
+	eh.mu.Lock()
+	defer eh.mu.Unlock()
+	bucket = eh.directory.get(eh.getBucketIndex(hashValue))
+	if i = eh.lookup(bucket, key, enc, hashValue); i < 0 {
+		return false
 	}
+	value, swapped := fn(bucket.values[i])
+	return swapped && eh.insertLocked(bucket.keys[i], value, enc, hashValue) == nil
 }
 
-// expandDirectory 扩展目录
-func (eh *ExtendibleHash) expandDirectory(newDepth int) {
-	oldSize := len(eh.directory)
-	newSize := oldSize * 2
-
-	// 创建新的目录
-	newDirectory := make([]*HashBucket, newSize)
-	copy(newDirectory, eh.directory)
-
-	// 添加新桶到buckets数组
-	for i := 0; i < oldSize; i++ {
-		bucketsUsed := make(map[*HashBucket]bool)
-		for _, bucket := range newDirectory[i:] {
-			if bucket != nil {
-				bucketsUsed[bucket] = true
+// insertIntoBucket 在桶中更新或追加键值对，桶已满且force为false时返回false
+// 持久化模式下修改会立即写入桶页，桶页放不下时撤销修改并视为桶已满
+// 调用方必须持有桶锁或目录写锁
+func (eh *ExtendibleHash) insertIntoBucket(bucket *HashBucket, key, value any, enc []byte, hashValue uint64, force bool) (bool, error) {
+	i := eh.lookup(bucket, key, enc, hashValue)
+	var old any
+	if i >= 0 {
+		old = bucket.values[i]
+		bucket.values[i] = value
+	} else {
+		if !force && bucket.isFull(eh.bucketCapacity) {
+			if bucket.overflow >= eh.maxOverflow {
+				return false, nil
+			}
+			// 追加一个溢出页推迟分裂，只需桶锁，不会阻塞其他桶上的操作
+			bucket.overflow++
+			eh.overflows.Add(1)
+			if eh.onOverflow != nil {
+				eh.onOverflow(bucket.overflow)
 			}
 		}
+		bucket.append(key, value, string(enc), hashValue)
 	}
 
-	eh.directory = newDirectory
-	eh.globalDepth = newDepth
-
-	// 重新分配目录指针
-	for i := 0; i < oldSize; i++ {
-		if eh.directory[i] != nil {
-			eh.directory[i+oldSize] = eh.directory[i]
+	if err := eh.persist(bucket); err != nil {
+		if i >= 0 {
+			bucket.values[i] = old
+		} else {
+			bucket.remove(len(bucket.keys) - 1)
+		}
+		if err == errPageOverflow && !force {
+			return false, nil
 		}
+		return false, err
+	}
+
+	if i < 0 {
+		eh.count.Add(1)
+		i = len(bucket.keys) - 1
 	}
+	eh.stampInserted(bucket, i)
+	return true, nil
 }
 
-// updateDirectoryPointers 更新目录指针
-func (eh *ExtendibleHash) updateDirectoryPointers(index uint32, bucket1, bucket2 *HashBucket) {
-	mask := uint32((1 << bucket1.localDepth) - 1)
-	prefix := index & ^mask
+// splitBucket 把桶分裂为自身和一个新的兄弟桶
+// 局部深度加一后，新增的那一位为1的键移入兄弟桶，对应的目录项指向兄弟桶
+// 持久化模式下先写入兄弟桶页再写回原桶页，中途崩溃时打开文件会修复两者的重叠
+// 调用方必须持有目录写锁
+func (eh *ExtendibleHash) splitBucket(bucket *HashBucket) error {
+	eh.splits.Add(1)
+	eh.numBuckets++
+
+	// 局部深度等于全局深度时，先把目录加倍
+	if bucket.localDepth == eh.globalDepth {
+		start := time.Now()
+		eh.expandDirectory(eh.globalDepth + 1)
+		eh.doublings.Add(1)
+		eh.doublingNanos.Add(int64(time.Since(start)))
+		if eh.onDirectoryGrow != nil {
+			eh.onDirectoryGrow(eh.globalDepth-1, eh.globalDepth)
+		}
+	}
 
-	// 更新所有匹配前缀的目录项
-	for i := uint32(0); i < uint32(len(eh.directory)); i++ {
-		if (i & mask) == prefix {
-			bit := (i >> (bucket1.localDepth - 1)) & 1
-			if bit == 0 {
-				eh.directory[i] = bucket1
-			} else {
-				eh.directory[i] = bucket2
-			}
+	bucket.localDepth++
+	sibling := eh.newBucket()
+	sibling.localDepth = bucket.localDepth
+	bit := uint64(1) << (bucket.localDepth - 1)
+	sibling.prefix = bucket.prefix | bit
+
+	// 重新分配键值对，原地过滤留在原桶中的部分
+	n := 0
+	for i, key := range bucket.keys {
+		if bucket.hashes[i]&bit != 0 {
+			sibling.append(key, bucket.values[i], bucket.encoded[i], bucket.hashes[i])
+			sibling.stamps[len(sibling.stamps)-1] = bucket.stamps[i]
+			continue
 		}
+		bucket.keys[n] = key
+		bucket.values[n] = bucket.values[i]
+		bucket.encoded[n] = bucket.encoded[i]
+		bucket.hashes[n] = bucket.hashes[i]
+		bucket.stamps[n] = bucket.stamps[i]
+		n++
+	}
+	clear(bucket.keys[n:])
+	clear(bucket.values[n:])
+	bucket.keys = bucket.keys[:n]
+	bucket.values = bucket.values[:n]
+	bucket.encoded = bucket.encoded[:n]
+	bucket.hashes = bucket.hashes[:n]
+	bucket.stamps = bucket.stamps[:n]
+	bucket.fitOverflow(eh.bucketCapacity)
+	sibling.fitOverflow(eh.bucketCapacity)
+
+	// 原先指向bucket、且索引中新增位为1的目录项改为指向sibling，按步长定位而不扫描整个目录
+	eh.directory.fill(sibling)
+	if eh.onSplit != nil {
+		eh.onSplit(bucket.localDepth)
+	}
+
+	if eh.pager == nil {
+		return nil
 	}
+	sibling.page = eh.pager.alloc()
+	if err := eh.persist(sibling); err != nil {
+		return err
+	}
+	return eh.persist(bucket)
+}
+
+// expandDirectory 扩展目录
+// 分段目录加倍时只复制段指针，段内容在之后第一次写入时才复制
+func (eh *ExtendibleHash) expandDirectory(newDepth int) {
+	eh.directory.double()
+	eh.globalDepth = newDepth
 }
 
 // Search 查找值
 func (eh *ExtendibleHash) Search(key any) (any, bool) {
-	eh.mu.RLock()
-	defer eh.mu.RUnlock()
-
 	if key == nil {
 		return nil, false
 	}
 
-	index, _ := eh.getBucketIndex(key)
-	bucket := eh.directory[index]
+	enc, hashValue, err := eh.encodeKey(key)
+	if err != nil {
+		return nil, false
+	}
+	return eh.searchEncoded(key, enc, hashValue)
+}
+
+// searchEncoded 查找已编码的键
+func (eh *ExtendibleHash) searchEncoded(key any, enc []byte, hashValue uint64) (any, bool) {
+	// 查询是最频繁的操作，显式解锁而不用defer
+	eh.mu.RLock()
+	bucket := eh.directory.get(eh.getBucketIndex(hashValue))
+	bucket.mu.RLock()
 
 	// 在桶中查找键
-	for i, k := range bucket.keys {
-		if fmt.Sprintf("%v", k) == fmt.Sprintf("%v", key) {
-			return bucket.values[i], true
-		}
+	var value any
+	i := eh.lookup(bucket, key, enc, hashValue)
+	if i >= 0 {
+		eh.touch(bucket, i)
+		value = bucket.values[i]
 	}
+	bucket.mu.RUnlock()
+	eh.mu.RUnlock()
 
-	return nil, false
+	return value, i >= 0
 }
 
 // Delete 删除键值对
 func (eh *ExtendibleHash) Delete(key any) bool {
-	eh.mu.Lock()
-	defer eh.mu.Unlock()
-
 	if key == nil {
 		return false
 	}
 
-	index, _ := eh.getBucketIndex(key)
-	bucket := eh.directory[index]
+	enc, hashValue, err := eh.encodeKey(key)
+	if err != nil {
+		return false
+	}
+	return eh.deleteEncoded(key, enc, hashValue)
+}
+
+// deleteEncoded 删除已编码的键
+func (eh *ExtendibleHash) deleteEncoded(key any, enc []byte, hashValue uint64) bool {
+	eh.mu.RLock()
+	bucket := eh.directory.get(eh.getBucketIndex(hashValue))
+	bucket.mu.Lock()
 
 	// 查找并删除键
-	for i, k := range bucket.keys {
-		if fmt.Sprintf("%v", k) == fmt.Sprintf("%v", key) {
-			bucket.keys = append(bucket.keys[:i], bucket.keys[i+1:]...)
-			bucket.values = append(bucket.values[:i], bucket.values[i+1:]...)
-			eh.count--
-			return true
+	i := eh.lookup(bucket, key, enc, hashValue)
+	if i >= 0 {
+		bucket.remove(i)
+		bucket.fitOverflow(eh.bucketCapacity)
+		eh.count.Add(-1)
+		eh.persist(bucket) // 写入失败记录在pager中，由Sync/Close返回
+	}
+	underfull := i >= 0 && bucket.localDepth > 0 && len(bucket.keys) <= eh.mergeThreshold
+	bucket.mu.Unlock()
+	eh.mu.RUnlock()
+
+	// 桶变得稀疏时尝试与兄弟桶合并；合并是尽力而为的，
+	// 目录写锁被占用时跳过，留给之后的删除完成
+	if underfull && eh.mu.TryLock() {
+		eh.mergeBuckets(hashValue)
+		eh.mu.Unlock()
+	}
+
+	return i >= 0
+}
+
+// mergeBuckets 把哈希值所在的桶与兄弟桶逐级合并，然后尽可能收缩目录
+// 兄弟桶是局部深度相同、索引只在第localDepth位不同的桶；
+// 两者的键数之和不超过mergeThreshold时才合并，合并后的桶至少还能再插入
+// bucketCapacity-mergeThreshold个键才会分裂，避免在阈值附近反复分裂合并
+// 调用方必须持有目录写锁
+func (eh *ExtendibleHash) mergeBuckets(hashValue uint64) {
+	for {
+		index := eh.getBucketIndex(hashValue)
+		bucket := eh.directory.get(index)
+		if bucket.localDepth == 0 {
+			break
+		}
+		bit := uint64(1) << (bucket.localDepth - 1)
+		buddy := eh.directory.get(index ^ bit)
+		if buddy.localDepth != bucket.localDepth || len(bucket.keys)+len(buddy.keys) > eh.mergeThreshold {
+			break
+		}
+
+		// 保留新增位为0的桶，另一个桶的内容并入其中
+		keep, gone := bucket, buddy
+		if index&bit != 0 {
+			keep, gone = buddy, bucket
+		}
+		n := len(keep.keys)
+		for i, key := range gone.keys {
+			keep.append(key, gone.values[i], gone.encoded[i], gone.hashes[i])
+			keep.stamps[len(keep.stamps)-1] = gone.stamps[i]
+		}
+		keep.localDepth--
+
+		// 先写入合并后的桶再释放另一个桶页，中途崩溃时打开文件会修复两者的重叠
+		// 键数满足条件但字节数放不下一页时撤销合并；写入失败时保留另一个桶页，
+		// 错误记录在pager中，由Sync/Close返回
+		err := eh.persist(keep)
+		if err == errPageOverflow {
+			keep.truncate(n)
+			keep.localDepth++
+			break
+		}
+		keep.fitOverflow(eh.bucketCapacity)
+		eh.directory.fill(keep)
+		eh.merges.Add(1)
+		eh.numBuckets--
+		if err != nil {
+			break
+		}
+		if eh.pager != nil {
+			eh.pager.release(gone.page)
 		}
 	}
 
-	return false
+	eh.shrinkDirectory()
+}
+
+// shrinkDirectory 所有桶的局部深度都小于全局深度时把目录减半，直到不能再减
+// 此时目录的前后两半完全相同，截断前一半即可
+// 调用方必须持有目录写锁
+func (eh *ExtendibleHash) shrinkDirectory() {
+	for eh.globalDepth > 0 {
+		reducible := true
+		eh.eachBucket(func(bucket *HashBucket) bool {
+			reducible = bucket.localDepth < eh.globalDepth
+			return reducible
+		})
+		if !reducible {
+			return
+		}
+		eh.globalDepth--
+		eh.directory.halve()
+	}
+}
+
+// eachBucket 对每个不同的桶恰好调用一次fn，fn返回false时停止
+// 局部深度为d的桶出现在所有低d位相同的目录项中，其中最小的索引小于2^d，
+// 因此只在索引i < 2^localDepth时访问，无需额外的去重集合
+// 调用方必须持有目录锁
+func (eh *ExtendibleHash) eachBucket(fn func(bucket *HashBucket) bool) {
+	eh.directory.each(func(i uint64, bucket *HashBucket) bool {
+		return i >= 1<<bucket.localDepth || fn(bucket)
+	})
+}
+
+// ForEach 对每个键值对调用fn，fn返回false时停止遍历，遍历顺序不确定
+// 先在目录读锁下逐桶复制全部键值对，释放锁之后再调用fn：
+// 看到的是复制时的内容，同一个桶内一致，不同桶之间可能观察到并发写入；
+// 调用fn时不持有任何锁，fn中可以查询、插入和删除
+func (eh *ExtendibleHash) ForEach(fn func(key, value any) bool) {
+	for _, p := range eh.pairs() {
+		if !fn(p.Key, p.Value) {
+			return
+		}
+	}
+}
+
+// pairs 在目录读锁下复制所有键值对
+func (eh *ExtendibleHash) pairs() []Pair[any, any] {
+	eh.mu.RLock()
+	defer eh.mu.RUnlock()
+
+	pairs := make([]Pair[any, any], 0, eh.count.Load())
+	eh.eachBucket(func(bucket *HashBucket) bool {
+		bucket.mu.RLock()
+		for i, key := range bucket.keys {
+			pairs = append(pairs, Pair[any, any]{Key: key, Value: bucket.values[i]})
+		}
+		bucket.mu.RUnlock()
+		return true
+	})
+	return pairs
+}
+
+// Keys 返回所有键，顺序不确定
+func (eh *ExtendibleHash) Keys() []any {
+	keys := make([]any, 0, eh.Size())
+	eh.ForEach(func(key, _ any) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Values 返回所有值，顺序不确定
+func (eh *ExtendibleHash) Values() []any {
+	values := make([]any, 0, eh.Size())
+	eh.ForEach(func(_, value any) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+// ToMap 把全部键值对复制到一个新的Go map中
+// []byte键不可比较，转换为string作为map的键；其他不可比较类型的键会导致panic，与直接写入map相同
+func (eh *ExtendibleHash) ToMap() map[any]any {
+	m := make(map[any]any, eh.Size())
+	eh.ForEach(func(key, value any) bool {
+		if b, ok := key.([]byte); ok {
+			key = string(b)
+		}
+		m[key] = value
+		return true
+	})
+	return m
+}
+
+// FromMap 把m中的全部键值对批量插入eh，已存在的键被覆盖
+// 整个加载过程只获取一次目录写锁，省去逐个Insert的加锁开销，期间其他操作会被阻塞；
+// 遇到第一个错误时停止并返回，此前插入的键值对保留；
+// 开启LRU淘汰时，加载过程中被淘汰的键值对在释放锁后统一回调
+func FromMap[K comparable, V any](eh *ExtendibleHash, m map[K]V) error {
+	var evicted []Pair[any, any]
+	defer func() {
+		for _, p := range evicted {
+			eh.evicted(p.Key, p.Value)
+		}
+	}()
+	eh.mu.Lock()
+	defer eh.mu.Unlock()
+
+	for k, v := range m {
+		key := any(k)
+		if key == nil {
+			return fmt.Errorf("key cannot be nil")
+		}
+		enc, hashValue, err := eh.encodeKey(key)
+		if err != nil {
+			return err
+		}
+		if err := eh.insertLocked(key, v, enc, hashValue); err != nil {
+			return err
+		}
+		if key, value, ok := eh.evictLocked(key, enc, hashValue); ok {
+			evicted = append(evicted, Pair[any, any]{Key: key, Value: value})
+		}
+		eh.presplitLocked(hashValue)
+	}
+	return nil
 }
 
 // GetBucketInfo 获取桶信息（用于调试和监控）
@@ -287,11 +1042,12 @@ func (eh *ExtendibleHash) GetBucketInfo() map[int]int {
 	defer eh.mu.RUnlock()
 
 	bucketCounts := make(map[int]int)
-	for _, bucket := range eh.directory {
+	eh.directory.each(func(_ uint64, bucket *HashBucket) bool {
 		if bucket != nil {
 			bucketCounts[bucket.localDepth]++
 		}
-	}
+		return true
+	})
 
 	return bucketCounts
 }
@@ -301,7 +1057,7 @@ func (eh *ExtendibleHash) GetBucketUsage() (avg float64, max int, min int, fullC
 	eh.mu.RLock()
 	defer eh.mu.RUnlock()
 
-	if len(eh.directory) == 0 {
+	if eh.directory.len() == 0 {
 		return 0, 0, 0, 0
 	}
 
@@ -310,9 +1066,11 @@ func (eh *ExtendibleHash) GetBucketUsage() (avg float64, max int, min int, fullC
 	min = eh.bucketCapacity
 	fullCount = 0
 
-	for _, bucket := range eh.directory {
+	eh.directory.each(func(_ uint64, bucket *HashBucket) bool {
 		if bucket != nil {
+			bucket.mu.RLock()
 			size := len(bucket.keys)
+			bucket.mu.RUnlock()
 			total += size
 			if size > max {
 				max = size
@@ -324,17 +1082,16 @@ func (eh *ExtendibleHash) GetBucketUsage() (avg float64, max int, min int, fullC
 				fullCount++
 			}
 		}
-	}
+		return true
+	})
 
-	avg = float64(total) / float64(len(eh.directory))
+	avg = float64(total) / float64(eh.directory.len())
 	return
 }
 
 // Size 返回键值对数量
 func (eh *ExtendibleHash) Size() int64 {
-	eh.mu.RLock()
-	defer eh.mu.RUnlock()
-	return eh.count
+	return eh.count.Load()
 }
 
 // GlobalDepth 返回全局深度
@@ -344,11 +1101,39 @@ func (eh *ExtendibleHash) GlobalDepth() int {
 	return eh.globalDepth
 }
 
-// BucketCount 返回桶数量
+// OverflowCount 返回追加溢出页的累计次数
+func (eh *ExtendibleHash) OverflowCount() int64 {
+	return eh.overflows.Load()
+}
+
+// MergeCount 返回桶合并的累计次数
+func (eh *ExtendibleHash) MergeCount() int64 {
+	return eh.merges.Load()
+}
+
+// SplitCount 返回桶分裂的累计次数
+func (eh *ExtendibleHash) SplitCount() int64 {
+	return eh.splits.Load()
+}
+
+// DoublingCount 返回目录加倍的累计次数
+func (eh *ExtendibleHash) DoublingCount() int64 {
+	return eh.doublings.Load()
+}
+
+// BucketCount 返回不同桶的数量（多个目录项可能指向同一个桶，目录大小见DirectorySize）
 func (eh *ExtendibleHash) BucketCount() int {
 	eh.mu.RLock()
 	defer eh.mu.RUnlock()
-	return len(eh.directory)
+
+	return eh.numBuckets
+}
+
+// DirectorySize 返回目录项数量，即2^globalDepth
+func (eh *ExtendibleHash) DirectorySize() int {
+	eh.mu.RLock()
+	defer eh.mu.RUnlock()
+	return eh.directory.len()
 }
 
 // String 返回哈希表的字符串表示（用于调试）
@@ -357,16 +1142,19 @@ func (eh *ExtendibleHash) String() string {
 	defer eh.mu.RUnlock()
 
 	result := fmt.Sprintf("ExtendibleHash(globalDepth=%d, bucketCount=%d, count=%d):\n",
-		eh.globalDepth, len(eh.directory), eh.count)
+		eh.globalDepth, eh.directory.len(), eh.count.Load())
 
 	bucketInfo := make(map[*HashBucket]int)
-	for _, bucket := range eh.directory {
+	eh.directory.each(func(_ uint64, bucket *HashBucket) bool {
 		if bucket != nil {
 			if _, ok := bucketInfo[bucket]; !ok {
+				bucket.mu.RLock()
 				bucketInfo[bucket] = len(bucket.keys)
+				bucket.mu.RUnlock()
 			}
 		}
-	}
+		return true
+	})
 
 	for bucket, size := range bucketInfo {
 		result += fmt.Sprintf("  Bucket(localDepth=%d, size=%d)\n", bucket.localDepth, size)
@@ -387,11 +1175,12 @@ func (eh *ExtendibleHash) Compact() {
 
 	// 统计目录使用情况
 	bucketsUsed := make(map[*HashBucket]bool)
-	for _, bucket := range eh.directory {
+	eh.directory.each(func(_ uint64, bucket *HashBucket) bool {
 		if bucket != nil {
 			bucketsUsed[bucket] = true
 		}
-	}
+		return true
+	})
 
 	// 如果所有桶的局部深度都小于全局深度，可以减少全局深度
 	if eh.globalDepth > 0 {
@@ -405,8 +1194,114 @@ func (eh *ExtendibleHash) Compact() {
 
 		if canReduce {
 			eh.globalDepth--
-			newSize := 1 << eh.globalDepth
-			eh.directory = eh.directory[:newSize]
+			eh.directory.halve()
+		}
+	}
+}
+
+// Rehash 以新的桶容量从头重建全部桶和目录，回收大量插入删除后残留的碎片
+// Compact每次最多把全局深度减一；Rehash中每个桶只在放不下时才按哈希的下一位继续划分，
+// 得到的局部深度和全局深度都是容纳现有键所需的最小值。重建复用已保存的哈希值，不重新编码键
+// 合并阈值按新旧容量的比例缩放；溢出页只保留给无法再划分的桶；LRU的访问记录保持不变
+// 持久化模式下新桶写入新分配的页，某个桶放不下一页且无法再划分时返回错误，哈希表保持不变；
+// 桶容量不记录在文件中，之后重新打开时应传入新的容量
+// 重建期间持有目录写锁，其他操作被阻塞
+func (eh *ExtendibleHash) Rehash(newBucketCapacity int) error {
+	if newBucketCapacity <= 0 {
+		return fmt.Errorf("bucket capacity must be > 0")
+	}
+
+	eh.mu.Lock()
+	defer eh.mu.Unlock()
+
+	all := eh.newBucket()
+	eh.eachBucket(func(bucket *HashBucket) bool {
+		for i, key := range bucket.keys {
+			all.append(key, bucket.values[i], bucket.encoded[i], bucket.hashes[i])
+			all.stamps[len(all.stamps)-1] = bucket.stamps[i]
+		}
+		return true
+	})
+
+	var buckets []*HashBucket
+	if err := eh.partition(all, newBucketCapacity, &buckets); err != nil {
+		return err
+	}
+	globalDepth := 0
+	for _, bucket := range buckets {
+		globalDepth = max(globalDepth, bucket.localDepth)
+	}
+	directory := newHashDirectory(1 << globalDepth)
+	for _, bucket := range buckets {
+		directory.fill(bucket)
+	}
+
+	if eh.pager != nil {
+		if err := eh.replacePages(&directory); err != nil {
+			return err
+		}
+	}
+	if eh.mergeThreshold > 0 {
+		eh.mergeThreshold = eh.mergeThreshold * newBucketCapacity / eh.bucketCapacity
+	}
+	eh.bucketCapacity = newBucketCapacity
+	eh.directory = directory
+	eh.globalDepth = globalDepth
+	eh.numBuckets = len(buckets)
+	return nil
+}
+
+// partition 按哈希的下一位递归划分src，直到每个桶放得下capacity个键（持久化模式下还要放得下一页），
+// 划分得到的桶追加到out；深度达到上限或所有键的哈希都相同时停止划分
+func (eh *ExtendibleHash) partition(src *HashBucket, capacity int, out *[]*HashBucket) error {
+	fits := len(src.keys) <= capacity
+	if fits && eh.pager != nil {
+		_, err := eh.encodeBucketPage(src)
+		if err != nil && err != errPageOverflow {
+			return err
 		}
+		fits = err == nil
+	}
+	if fits || src.localDepth >= eh.depthLimit || !src.separable(src.hashes[0]) {
+		src.fitOverflow(capacity)
+		*out = append(*out, src)
+		return nil
+	}
+
+	bit := uint64(1) << src.localDepth
+	low, high := eh.newBucket(), eh.newBucket()
+	low.localDepth, high.localDepth = src.localDepth+1, src.localDepth+1
+	low.prefix, high.prefix = src.prefix, src.prefix|bit
+	for i, h := range src.hashes {
+		dst := low
+		if h&bit != 0 {
+			dst = high
+		}
+		dst.append(src.keys[i], src.values[i], src.encoded[i], h)
+		dst.stamps[len(dst.stamps)-1] = src.stamps[i]
+	}
+	if err := eh.partition(low, capacity, out); err != nil {
+		return err
+	}
+	return eh.partition(high, capacity, out)
+}
+
+// Describe 返回可扩展哈希的能力描述
+func (eh *ExtendibleHash) Describe() Descriptor {
+	concurrency := ConcurrencyBucketLock
+	if eh.mu.disabled {
+		concurrency = ConcurrencyNone
+	}
+	return Descriptor{
+		Name:           "ExtendibleHash",
+		SupportsDelete: true,
+		Persistent:     true,
+		Concurrency:    concurrency,
+		Complexity: Complexity{
+			Insert: "O(1) 均摊",
+			Search: "O(1)",
+			Delete: "O(1)",
+		},
+		Notes: "目录加桶的两级结构，桶满时只分裂单个桶，目录按需加倍；可选桶页文件持久化",
 	}
 }
@@ -0,0 +1,84 @@
+package datastructures
+
+import "bytes"
+
+// appendRawKey 编码string或[]byte键
+// 使用DefaultKeyEncoder时直接拼接类型标签和原始字节，不经过接口装箱和类型判断，
+// 编码结果与通用路径完全相同，因此两套接口写入的键可以互相查找；自定义编码器时回退到通用路径
+func appendRawKey[K string | []byte](eh *ExtendibleHash, dst []byte, tag byte, key K) ([]byte, error) {
+	if _, ok := eh.keyEncoder.(DefaultKeyEncoder); ok {
+		return append(append(dst, tag), key...), nil
+	}
+	return eh.keyEncoder.AppendKey(dst, any(key))
+}
+
+// encodeRawKey 编码string或[]byte键并计算其哈希值
+// 第一个返回值是查找时使用的键：比较编码时不需要键本身，返回nil以免装箱；
+// 设置了WithEqualFunc时只能比较键本身，此时返回装箱后的键且不编码
+func encodeRawKey[K string | []byte](eh *ExtendibleHash, tag byte, key K) (any, []byte, uint64, error) {
+	if eh.equal != nil {
+		probe := any(key)
+		return probe, nil, eh.keyHash(probe), nil
+	}
+	var buf [64]byte
+	enc, err := appendRawKey(eh, buf[:0], tag, key)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return nil, enc, eh.hashFunc(enc), nil
+}
+
+// InsertBytes 以[]byte为键插入键值对，等价于Insert(key, value)但跳过通用键编码
+// 键会被复制，调用方之后可以修改key；nil与空切片是同一个键
+func (eh *ExtendibleHash) InsertBytes(key []byte, value any) error {
+	_, enc, hashValue, err := encodeRawKey(eh, keyTagBytes, key)
+	if err != nil {
+		return err
+	}
+	return eh.insertEncoded(bytes.Clone(key), value, enc, hashValue)
+}
+
+// SearchBytes 以[]byte为键查找值，键不需要装箱为any
+func (eh *ExtendibleHash) SearchBytes(key []byte) (any, bool) {
+	probe, enc, hashValue, err := encodeRawKey(eh, keyTagBytes, key)
+	if err != nil {
+		return nil, false
+	}
+	return eh.searchEncoded(probe, enc, hashValue)
+}
+
+// DeleteBytes 以[]byte为键删除键值对
+func (eh *ExtendibleHash) DeleteBytes(key []byte) bool {
+	probe, enc, hashValue, err := encodeRawKey(eh, keyTagBytes, key)
+	if err != nil {
+		return false
+	}
+	return eh.deleteEncoded(probe, enc, hashValue)
+}
+
+// InsertString 以string为键插入键值对，等价于Insert(key, value)但跳过通用键编码
+func (eh *ExtendibleHash) InsertString(key string, value any) error {
+	_, enc, hashValue, err := encodeRawKey(eh, keyTagString, key)
+	if err != nil {
+		return err
+	}
+	return eh.insertEncoded(key, value, enc, hashValue)
+}
+
+// SearchString 以string为键查找值，键不需要装箱为any
+func (eh *ExtendibleHash) SearchString(key string) (any, bool) {
+	probe, enc, hashValue, err := encodeRawKey(eh, keyTagString, key)
+	if err != nil {
+		return nil, false
+	}
+	return eh.searchEncoded(probe, enc, hashValue)
+}
+
+// DeleteString 以string为键删除键值对
+func (eh *ExtendibleHash) DeleteString(key string) bool {
+	probe, enc, hashValue, err := encodeRawKey(eh, keyTagString, key)
+	if err != nil {
+		return false
+	}
+	return eh.deleteEncoded(probe, enc, hashValue)
+}
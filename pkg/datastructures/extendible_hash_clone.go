@@ -0,0 +1,84 @@
+package datastructures
+
+import "slices"
+
+// clone 复制桶的内容和深度信息，返回的桶不关联任何页，unlocked指定桶锁是否关闭
+func (b *HashBucket) clone(unlocked bool) *HashBucket {
+	return &HashBucket{
+		keys:       slices.Clone(b.keys),
+		values:     slices.Clone(b.values),
+		encoded:    slices.Clone(b.encoded),
+		hashes:     slices.Clone(b.hashes),
+		stamps:     slices.Clone(b.stamps),
+		localDepth: b.localDepth,
+		prefix:     b.prefix,
+		overflow:   b.overflow,
+		mu:         optionalRWMutex{disabled: unlocked},
+	}
+}
+
+// newWithConfig 创建与eh配置相同、只有一个空桶的纯内存哈希表
+// 配置在构造后不再修改，无需持有锁
+func (eh *ExtendibleHash) newWithConfig(bucketCapacity int) *ExtendibleHash {
+	bucket := eh.newBucket()
+	directory := newHashDirectory(1)
+	directory.set(0, bucket)
+	other := &ExtendibleHash{
+		buckets:         []*HashBucket{bucket},
+		directory:       directory,
+		bucketCapacity:  bucketCapacity,
+		hashFunc:        eh.hashFunc,
+		keyEncoder:      eh.keyEncoder,
+		keyCodec:        eh.keyCodec,
+		valueCodec:      eh.valueCodec,
+		pageSize:        eh.pageSize,
+		maxOverflow:     eh.maxOverflow,
+		mergeThreshold:  eh.mergeThreshold,
+		depthLimit:      eh.depthLimit,
+		splitLoadFactor: eh.splitLoadFactor,
+		onDirectoryGrow: eh.onDirectoryGrow,
+		onSplit:         eh.onSplit,
+		onOverflow:      eh.onOverflow,
+		numBuckets:      1,
+		maxEntries:      eh.maxEntries,
+		onEvict:         eh.onEvict,
+		keyHash:         eh.keyHash,
+		equal:           eh.equal,
+	}
+	other.mu.disabled = eh.mu.disabled
+	return other
+}
+
+// Clone 返回哈希表的独立副本：目录和每个桶都重新分配，之后任何一方的修改都不影响另一方
+// 复制期间持有目录写锁，得到的是某一时刻的一致快照；复制是O(n)的，期间其他操作被阻塞
+// 副本沿用全部配置（包括淘汰回调和目录加倍回调）和统计计数，但总是纯内存模式，不关联桶页文件
+// 键和值本身按接口值复制，指针、切片等引用类型的值仍与原表共享底层数据
+func (eh *ExtendibleHash) Clone() *ExtendibleHash {
+	eh.mu.Lock()
+	defer eh.mu.Unlock()
+	return eh.cloneLocked(eh.mu.disabled)
+}
+
+// cloneLocked 复制整个哈希表，unlocked指定副本是否关闭加锁
+// 调用方必须持有目录写锁
+func (eh *ExtendibleHash) cloneLocked(unlocked bool) *ExtendibleHash {
+	clone := eh.newWithConfig(eh.bucketCapacity)
+	clone.mu.disabled = unlocked
+	clone.directory = newHashDirectory(eh.directory.len())
+	clone.globalDepth = eh.globalDepth
+	clone.numBuckets = eh.numBuckets
+	eh.eachBucket(func(bucket *HashBucket) bool {
+		clone.directory.fill(bucket.clone(unlocked))
+		return true
+	})
+
+	clone.count.Store(eh.count.Load())
+	clone.splits.Store(eh.splits.Load())
+	clone.merges.Store(eh.merges.Load())
+	clone.overflows.Store(eh.overflows.Load())
+	clone.evictions.Store(eh.evictions.Load())
+	clone.doublings.Store(eh.doublings.Load())
+	clone.doublingNanos.Store(eh.doublingNanos.Load())
+	clone.clock.Store(eh.clock.Load())
+	return clone
+}
@@ -0,0 +1,143 @@
+package datastructures
+
+import (
+	"slices"
+	"unsafe"
+)
+
+// dirSegmentBits 目录段大小的位数，每段1024个目录项（64位平台上8KB）
+const dirSegmentBits = 10
+
+const dirSegmentSize = 1 << dirSegmentBits
+
+// hashDirectory 分段的可扩展哈希目录
+// 目录由固定大小的段组成，加倍时后一半的段与前一半共享底层数组，只复制段指针，
+// 耗时是目录大小的1/1024；之后第一次写入共享的段时才复制该段（写时复制），
+// 复制的开销分摊到之后的分裂中，单次最多复制一段。
+// 目录不足一段时只有一个段，加倍时直接复制。
+// 读取需要目录读锁，修改需要目录写锁
+type hashDirectory struct {
+	segments [][]*HashBucket
+	shared   []bool // shared[s]为true时segments[s]可能与其他段共享底层数组，写入前需要复制
+}
+
+// newHashDirectory 创建size个目录项均为nil的目录，size必须是2的幂
+func newHashDirectory(size int) hashDirectory {
+	if size <= dirSegmentSize {
+		return hashDirectory{
+			segments: [][]*HashBucket{make([]*HashBucket, size)},
+			shared:   []bool{false},
+		}
+	}
+	d := hashDirectory{
+		segments: make([][]*HashBucket, size>>dirSegmentBits),
+		shared:   make([]bool, size>>dirSegmentBits),
+	}
+	for s := range d.segments {
+		d.segments[s] = make([]*HashBucket, dirSegmentSize)
+	}
+	return d
+}
+
+// len 返回目录项数量
+func (d *hashDirectory) len() int {
+	if len(d.segments) == 1 {
+		return len(d.segments[0])
+	}
+	return len(d.segments) << dirSegmentBits
+}
+
+// get 返回目录项i指向的桶
+func (d *hashDirectory) get(i uint64) *HashBucket {
+	return d.segments[i>>dirSegmentBits][i&(dirSegmentSize-1)]
+}
+
+// set 把目录项i指向bucket，段被共享时先复制
+func (d *hashDirectory) set(i uint64, bucket *HashBucket) {
+	s := i >> dirSegmentBits
+	if d.shared[s] {
+		d.segments[s] = slices.Clone(d.segments[s])
+		d.shared[s] = false
+	}
+	d.segments[s][i&(dirSegmentSize-1)] = bucket
+}
+
+// fill 把与bucket的前缀同余的全部目录项指向bucket，
+// 即索引低localDepth位等于prefix的目录项，步长为2^localDepth
+func (d *hashDirectory) fill(bucket *HashBucket) {
+	size := uint64(d.len())
+	for i := bucket.prefix; i < size; i += 1 << bucket.localDepth {
+		d.set(i, bucket)
+	}
+}
+
+// double 把目录加倍，新的后一半与前一半内容相同
+func (d *hashDirectory) double() {
+	if len(d.segments) == 1 && len(d.segments[0]) < dirSegmentSize {
+		old := d.segments[0]
+		grown := make([]*HashBucket, 2*len(old))
+		copy(grown, old)
+		copy(grown[len(old):], old)
+		d.segments[0] = grown
+		d.shared[0] = false
+		return
+	}
+	d.segments = append(d.segments, d.segments...)
+	d.shared = append(d.shared, d.shared...)
+	for s := range d.shared {
+		d.shared[s] = true
+	}
+}
+
+// halve 把目录减半，调用方保证前后两半内容相同
+func (d *hashDirectory) halve() {
+	if len(d.segments) == 1 {
+		half := len(d.segments[0]) / 2
+		if !d.shared[0] {
+			clear(d.segments[0][half:])
+		}
+		d.segments[0] = d.segments[0][:half]
+		return
+	}
+	half := len(d.segments) / 2
+	clear(d.segments[half:])
+	d.segments = d.segments[:half]
+	d.shared = d.shared[:half]
+}
+
+// bytes 返回目录占用的内存（字节），共享的段只计算一次
+func (d *hashDirectory) bytes() int64 {
+	seen := make(map[**HashBucket]bool, len(d.segments))
+	total := int64(cap(d.segments)) * int64(unsafe.Sizeof(d.segments[0]))
+	for _, segment := range d.segments {
+		if p := unsafe.SliceData(segment); !seen[p] {
+			seen[p] = true
+			total += int64(cap(segment)) * int64(unsafe.Sizeof(segment[0]))
+		}
+	}
+	return total
+}
+
+// each 按索引顺序对每个目录项调用fn，fn返回false时停止
+func (d *hashDirectory) each(fn func(i uint64, bucket *HashBucket) bool) {
+	var i uint64
+	for _, segment := range d.segments {
+		for _, bucket := range segment {
+			if !fn(i, bucket) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// firstNil 返回第一个为nil的目录项的索引，全部非nil时第二个返回值为false
+func (d *hashDirectory) firstNil() (uint64, bool) {
+	var index uint64
+	found := false
+	d.each(func(i uint64, bucket *HashBucket) bool {
+		index, found = i, bucket == nil
+		return !found
+	})
+	return index, found
+}
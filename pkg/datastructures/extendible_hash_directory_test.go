@@ -0,0 +1,74 @@
+package datastructures
+
+import "testing"
+
+// slots 按索引顺序返回全部目录项，供测试检查目录内容
+func (d *hashDirectory) slots() []*HashBucket {
+	slots := make([]*HashBucket, 0, d.len())
+	d.each(func(_ uint64, bucket *HashBucket) bool {
+		slots = append(slots, bucket)
+		return true
+	})
+	return slots
+}
+
+// TestHashDirectoryCopyOnWrite 测试加倍后共享的段在写入时才复制，写入不影响其他目录项
+func TestHashDirectoryCopyOnWrite(t *testing.T) {
+	buckets := make([]*HashBucket, 4*dirSegmentSize)
+	for i := range buckets {
+		buckets[i] = NewHashBucket()
+	}
+
+	d := newHashDirectory(1)
+	d.set(0, buckets[0])
+	for d.len() < 4*dirSegmentSize {
+		d.double()
+	}
+	if len(d.segments) != 4 {
+		t.Fatalf("段数 = %d, 期望 4", len(d.segments))
+	}
+	for i, bucket := range d.slots() {
+		if bucket != buckets[0] {
+			t.Fatalf("加倍后目录项%d = %p, 期望与目录项0相同", i, bucket)
+		}
+	}
+
+	// 写入一个共享的段只复制该段
+	d.set(dirSegmentSize+1, buckets[1])
+	for i, bucket := range d.slots() {
+		want := buckets[0]
+		if i == dirSegmentSize+1 {
+			want = buckets[1]
+		}
+		if bucket != want {
+			t.Fatalf("写入后目录项%d = %p, 期望 %p", i, bucket, want)
+		}
+	}
+	if d.shared[1] || !d.shared[0] {
+		t.Errorf("shared = %v, 只有被写入的段应取消共享", d.shared)
+	}
+
+	// 按步长填充覆盖所有同余的目录项
+	b := buckets[2]
+	b.localDepth, b.prefix = 3, 5
+	d.fill(b)
+	for i, bucket := range d.slots() {
+		if (i%8 == 5) != (bucket == b) {
+			t.Fatalf("fill后目录项%d = %p", i, bucket)
+		}
+	}
+
+	// 减半到一个段以内
+	for d.len() > 4 {
+		d.halve()
+	}
+	if got := d.slots(); len(got) != 4 || got[0] != buckets[0] {
+		t.Errorf("减半后目录 = %v", got)
+	}
+	if i, ok := d.firstNil(); ok {
+		t.Errorf("firstNil() = %d, 期望没有nil目录项", i)
+	}
+	if d.bytes() <= 0 {
+		t.Errorf("bytes() = %d", d.bytes())
+	}
+}
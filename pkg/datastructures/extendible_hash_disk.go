@@ -0,0 +1,373 @@
+package datastructures
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math/bits"
+	"os"
+	"sort"
+	"sync"
+)
+
+// 桶页文件格式：
+//
+//	页0（文件头）: crc32(4字节) | magic(4字节) | pageSize(uint32)
+//	页n（n >= 1）: crc32(4字节) | state(1字节) | localDepth(1字节) | prefix(uint64) |
+//	              count(uvarint) | count × (keyLen(uvarint) key valueLen(uvarint) value) | 零填充
+//
+// 所有整数为大端序，crc32覆盖页内除校验和之外的全部字节。
+// 目录不写入文件：打开时根据每个桶页的localDepth和prefix重建。
+const (
+	extendibleHashMagic      = "EHF\x02"
+	defaultPageSize          = 4096
+	minPageSize              = 64
+	pageHeaderSize           = 14
+	pageStateFree       byte = 0
+	pageStateBucket     byte = 1
+)
+
+// errPageOverflow 桶的内容超过了一页的大小
+var errPageOverflow = errors.New("bucket does not fit in a page")
+
+// bucketPager 固定大小的桶页文件
+// 页的分配和释放只在持有目录写锁时发生；不同桶页的写入可以并发执行
+type bucketPager struct {
+	file     *os.File
+	pageSize int
+	free     []uint32   // 空闲页号
+	next     uint32     // 文件末尾的下一个页号
+	mu       sync.Mutex // 保护err
+	err      error      // 第一次写入失败的错误
+}
+
+// alloc 分配一个桶页，优先复用空闲页
+func (p *bucketPager) alloc() uint32 {
+	if n := len(p.free); n > 0 {
+		id := p.free[n-1]
+		p.free = p.free[:n-1]
+		return id
+	}
+	p.next++
+	return p.next - 1
+}
+
+// release 把桶页标记为空闲并放回空闲列表
+func (p *bucketPager) release(id uint32) {
+	page := make([]byte, p.pageSize)
+	page[4] = pageStateFree
+	p.writePage(id, page)
+	p.free = append(p.free, id)
+}
+
+// writePage 计算校验和并写入一页，失败时记录错误
+func (p *bucketPager) writePage(id uint32, page []byte) error {
+	binary.BigEndian.PutUint32(page, crc32.ChecksumIEEE(page[4:]))
+	_, err := p.file.WriteAt(page, int64(id)*int64(p.pageSize))
+	if err != nil {
+		p.fail(err)
+	}
+	return err
+}
+
+// readPage 读取一页并校验
+func (p *bucketPager) readPage(id uint32, page []byte) error {
+	if _, err := p.file.ReadAt(page, int64(id)*int64(p.pageSize)); err != nil {
+		return err
+	}
+	if binary.BigEndian.Uint32(page) != crc32.ChecksumIEEE(page[4:]) {
+		return fmt.Errorf("page %d: checksum mismatch", id)
+	}
+	return nil
+}
+
+// fail 记录第一次写入失败的错误
+func (p *bucketPager) fail(err error) {
+	p.mu.Lock()
+	if p.err == nil {
+		p.err = err
+	}
+	p.mu.Unlock()
+}
+
+// WithPageSize 指定持久化模式下的桶页大小（字节），默认4096
+// 一个桶的全部键值对编码后必须能放进一页，放不下时桶会提前分裂
+func WithPageSize(pageSize int) ExtendibleHashOption {
+	return func(eh *ExtendibleHash) {
+		eh.pageSize = pageSize
+	}
+}
+
+// OpenExtendibleHash 打开或创建磁盘上的可扩展哈希表
+// 每个桶是文件中固定大小的一页，目录是内存中指向桶页的数组；
+// 每次修改都立即写入对应的桶页（write-through），读取由内存中的桶内容直接返回。
+// 打开已有文件时读取全部桶页并重建目录，同时修复分裂或合并中途崩溃留下的重叠桶页。
+// hashFunc、KeyEncoder和编解码器必须与创建文件时一致；需要通过WithExtendibleHashCodec设置编解码器。
+func OpenExtendibleHash(path string, bucketCapacity int, hashFunc HashFunc, opts ...ExtendibleHashOption) (*ExtendibleHash, error) {
+	eh := NewExtendibleHash(bucketCapacity, hashFunc, opts...)
+	if eh.keyCodec == nil || eh.valueCodec == nil {
+		return nil, fmt.Errorf("key and value codecs are required for persistence")
+	}
+	if eh.maxOverflow > 0 {
+		return nil, fmt.Errorf("overflow chains are not supported for disk-backed tables")
+	}
+	if eh.pageSize == 0 {
+		eh.pageSize = defaultPageSize
+	}
+	if eh.pageSize < minPageSize {
+		return nil, fmt.Errorf("page size must be >= %d", minPageSize)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if info.Size() == 0 {
+		err = eh.createPages(file)
+	} else {
+		err = eh.loadPages(file, info.Size())
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return eh, nil
+}
+
+// createPages 初始化空文件：写入文件头和唯一的初始桶
+func (eh *ExtendibleHash) createPages(file *os.File) error {
+	eh.pager = &bucketPager{file: file, pageSize: eh.pageSize, next: 1}
+
+	header := make([]byte, eh.pageSize)
+	copy(header[4:], extendibleHashMagic)
+	binary.BigEndian.PutUint32(header[8:], uint32(eh.pageSize))
+	if err := eh.pager.writePage(0, header); err != nil {
+		return err
+	}
+
+	bucket := eh.directory.get(0)
+	bucket.page = eh.pager.alloc()
+	if err := eh.persist(bucket); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// loadPages 读取全部桶页并重建目录
+func (eh *ExtendibleHash) loadPages(file *os.File, size int64) error {
+	header := make([]byte, minPageSize)
+	if _, err := file.ReadAt(header, 0); err != nil {
+		return err
+	}
+	if string(header[4:8]) != extendibleHashMagic {
+		return fmt.Errorf("invalid extendible hash file header")
+	}
+	eh.pageSize = int(binary.BigEndian.Uint32(header[8:]))
+	if eh.pageSize < minPageSize || size%int64(eh.pageSize) != 0 {
+		return fmt.Errorf("invalid page size %d for file of %d bytes", eh.pageSize, size)
+	}
+
+	pager := &bucketPager{file: file, pageSize: eh.pageSize, next: uint32(size / int64(eh.pageSize))}
+	page := make([]byte, eh.pageSize)
+	if err := pager.readPage(0, page); err != nil {
+		return err
+	}
+
+	var buckets []*HashBucket
+	for id := uint32(1); id < pager.next; id++ {
+		if err := pager.readPage(id, page); err != nil {
+			return err
+		}
+		if page[4] == pageStateFree {
+			pager.free = append(pager.free, id)
+			continue
+		}
+		bucket, err := eh.decodeBucketPage(page)
+		if err != nil {
+			return fmt.Errorf("page %d: %w", id, err)
+		}
+		bucket.page = id
+		buckets = append(buckets, bucket)
+	}
+	if len(buckets) == 0 {
+		return fmt.Errorf("no bucket pages found")
+	}
+
+	// 按局部深度升序填充目录，更深的桶覆盖较浅的桶。
+	// 分裂或合并中途崩溃时，较浅的旧桶页与较深的新桶页重叠，覆盖后旧桶只保留未被覆盖的部分
+	sort.SliceStable(buckets, func(i, j int) bool { return buckets[i].localDepth < buckets[j].localDepth })
+	globalDepth := buckets[len(buckets)-1].localDepth
+	if globalDepth > maxLoadDepth {
+		return fmt.Errorf("global depth %d exceeds limit %d", globalDepth, maxLoadDepth)
+	}
+	directory := newHashDirectory(1 << globalDepth)
+	for _, bucket := range buckets {
+		directory.fill(bucket)
+	}
+	if i, ok := directory.firstNil(); ok {
+		return fmt.Errorf("directory slot %d is not covered by any bucket page", i)
+	}
+
+	eh.pager = pager
+	eh.directory = directory
+	eh.globalDepth = globalDepth
+	var count int64
+	eh.numBuckets = 0
+	for _, bucket := range buckets {
+		repaired, err := eh.repairBucket(bucket)
+		if err != nil {
+			return err
+		}
+		if bucket.page == 0 {
+			continue // 被完全覆盖的旧桶
+		}
+		if repaired {
+			if err := eh.persist(bucket); err != nil {
+				return err
+			}
+		}
+		count += int64(len(bucket.keys))
+		eh.numBuckets++
+	}
+	eh.count.Store(count)
+	eh.shrinkDirectory()
+	return pager.err
+}
+
+// repairBucket 让桶与重建后的目录保持一致，返回桶是否被修改
+// 被完全覆盖的桶释放其页；被部分覆盖的桶丢弃不再指向它的键并加深局部深度
+func (eh *ExtendibleHash) repairBucket(bucket *HashBucket) (bool, error) {
+	covered := 0
+	for i := bucket.prefix; i < uint64(eh.directory.len()); i += 1 << bucket.localDepth {
+		if eh.directory.get(i) == bucket {
+			covered++
+		}
+	}
+	if covered == 0 {
+		eh.pager.release(bucket.page)
+		bucket.page = 0
+		return false, nil
+	}
+
+	expected := eh.directory.len() >> bucket.localDepth
+	if covered == expected {
+		return false, nil
+	}
+	if bits.OnesCount(uint(covered)) != 1 {
+		return false, fmt.Errorf("page %d: inconsistent bucket coverage", bucket.page)
+	}
+	// 剩余的目录项必须恰好是某个更深前缀的全部目录项
+	bucket.localDepth = eh.globalDepth - bits.TrailingZeros(uint(covered))
+	mask := depthMask(bucket.localDepth)
+	first, consistent := -1, true
+	eh.directory.each(func(i uint64, b *HashBucket) bool {
+		if b != bucket {
+			return true
+		}
+		if first < 0 {
+			first = int(i)
+		} else if i&mask != uint64(first)&mask {
+			consistent = false
+		}
+		return consistent
+	})
+	if !consistent {
+		return false, fmt.Errorf("page %d: inconsistent bucket coverage", bucket.page)
+	}
+	bucket.prefix = uint64(first) & mask
+
+	for i := len(bucket.keys) - 1; i >= 0; i-- {
+		if bucket.hashes[i]&mask != bucket.prefix {
+			bucket.remove(i)
+		}
+	}
+	return true, nil
+}
+
+// persist 持久化模式下把桶写入其所在的页，纯内存模式下什么也不做
+// 调用方必须持有桶锁或目录写锁
+func (eh *ExtendibleHash) persist(bucket *HashBucket) error {
+	if eh.pager == nil {
+		return nil
+	}
+	page, err := eh.encodeBucketPage(bucket)
+	if err != nil {
+		return err
+	}
+	return eh.pager.writePage(bucket.page, page)
+}
+
+// encodeBucketPage 把桶编码为一页
+func (eh *ExtendibleHash) encodeBucketPage(bucket *HashBucket) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, pageHeaderSize, eh.pageSize))
+	bw := &binaryWriter{w: buf}
+	eh.writeBucketEntries(bw, bucket)
+	if bw.err != nil {
+		return nil, bw.err
+	}
+	if buf.Len() > eh.pageSize {
+		return nil, errPageOverflow
+	}
+
+	page := buf.Bytes()[:eh.pageSize]
+	clear(page[buf.Len():])
+	page[4] = pageStateBucket
+	page[5] = byte(bucket.localDepth)
+	binary.BigEndian.PutUint64(page[6:], bucket.prefix)
+	return page, nil
+}
+
+// decodeBucketPage 从一页中解码桶，并重新计算每个键的编码和哈希值
+func (eh *ExtendibleHash) decodeBucketPage(page []byte) (*HashBucket, error) {
+	bucket := eh.newBucket()
+	bucket.localDepth = int(page[5])
+	bucket.prefix = binary.BigEndian.Uint64(page[6:])
+	if bucket.localDepth > maxGlobalDepth || bucket.prefix&^depthMask(bucket.localDepth) != 0 {
+		return nil, fmt.Errorf("invalid local depth %d or prefix %#x", bucket.localDepth, bucket.prefix)
+	}
+
+	br := &binaryReader{r: bytes.NewReader(page[pageHeaderSize:])}
+	if err := eh.readBucketEntries(br, bucket, uint64(len(page))); err != nil {
+		return nil, err
+	}
+	return bucket, nil
+}
+
+// Sync 把桶页文件刷到磁盘，返回之前写入桶页时发生的第一个错误
+// 纯内存模式下直接返回nil
+func (eh *ExtendibleHash) Sync() error {
+	if eh.pager == nil {
+		return nil
+	}
+	eh.pager.mu.Lock()
+	err := eh.pager.err
+	eh.pager.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return eh.pager.file.Sync()
+}
+
+// Close 刷盘并关闭桶页文件，之后不能再使用该哈希表
+// 纯内存模式下直接返回nil
+func (eh *ExtendibleHash) Close() error {
+	if eh.pager == nil {
+		return nil
+	}
+	eh.mu.Lock()
+	defer eh.mu.Unlock()
+
+	err := eh.Sync()
+	if cerr := eh.pager.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
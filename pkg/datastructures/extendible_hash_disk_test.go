@@ -0,0 +1,354 @@
+package datastructures
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// openTestDiskHash 在临时目录中打开int键、string值的磁盘哈希表
+func openTestDiskHash(t *testing.T, path string, opts ...ExtendibleHashOption) *ExtendibleHash {
+	t.Helper()
+	opts = append([]ExtendibleHashOption{WithExtendibleHashCodec(IntCodec{}, StringCodec{})}, opts...)
+	eh, err := OpenExtendibleHash(path, 8, nil, opts...)
+	if err != nil {
+		t.Fatalf("OpenExtendibleHash() 错误 = %v", err)
+	}
+	return eh
+}
+
+// TestExtendibleHashDiskPages 测试桶页文件的写入和重新打开
+func TestExtendibleHashDiskPages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hash.db")
+	eh := openTestDiskHash(t, path, WithPageSize(256))
+
+	const n = 3000
+	for i := 0; i < n; i++ {
+		if err := eh.Insert(i, fmt.Sprintf("value_%d", i)); err != nil {
+			t.Fatalf("Insert(%d) 错误 = %v", i, err)
+		}
+	}
+	for i := 0; i < n; i += 3 {
+		eh.Delete(i)
+	}
+	eh.Insert(1, "updated")
+	want := eh.Size()
+	if err := eh.Close(); err != nil {
+		t.Fatalf("Close() 错误 = %v", err)
+	}
+
+	eh = openTestDiskHash(t, path)
+	defer eh.Close()
+	if eh.Size() != want {
+		t.Fatalf("重新打开后 Size() = %d, 期望 %d", eh.Size(), want)
+	}
+	for i := 0; i < n; i++ {
+		v, ok := eh.Search(i)
+		switch {
+		case i%3 == 0:
+			if ok {
+				t.Fatalf("已删除的键%d仍然存在", i)
+			}
+		case i == 1:
+			if v != "updated" {
+				t.Fatalf("Search(1) = %v, 期望 updated", v)
+			}
+		case !ok || v != fmt.Sprintf("value_%d", i):
+			t.Fatalf("Search(%d) = %v, %v", i, v, ok)
+		}
+	}
+
+	// 重新打开后可以继续写入
+	if err := eh.Insert(n, "more"); err != nil {
+		t.Fatalf("Insert() 错误 = %v", err)
+	}
+}
+
+// TestExtendibleHashDiskPageOverflow 测试放不下一页的桶提前分裂，单个键值对放不下时返回错误
+func TestExtendibleHashDiskPageOverflow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hash.db")
+	eh := openTestDiskHash(t, path, WithPageSize(128))
+	defer eh.Close()
+
+	// 桶容量为8，但每页只能放下两三个这样的值
+	value := strings.Repeat("x", 40)
+	for i := 0; i < 100; i++ {
+		if err := eh.Insert(i, value); err != nil {
+			t.Fatalf("Insert(%d) 错误 = %v", i, err)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		if v, ok := eh.Search(i); !ok || v != value {
+			t.Fatalf("Search(%d) = %v, %v", i, v, ok)
+		}
+	}
+
+	if err := eh.Insert(1000, strings.Repeat("y", 200)); !errors.Is(err, errPageOverflow) {
+		t.Errorf("Insert(超大值) 错误 = %v, 期望 %v", err, errPageOverflow)
+	}
+	if _, ok := eh.Search(1000); ok {
+		t.Error("插入失败的键不应存在")
+	}
+}
+
+// TestExtendibleHashDiskMergeOverflow 测试键数满足合并条件但放不下一页的兄弟桶不合并，重新打开后数据完整
+func TestExtendibleHashDiskMergeOverflow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hash.db")
+	eh := openTestDiskHash(t, path, WithPageSize(256))
+
+	value := strings.Repeat("v", 100)
+	for i := 0; i < 200; i++ {
+		if err := eh.Insert(i, value); err != nil {
+			t.Fatalf("Insert(%d) 错误 = %v", i, err)
+		}
+	}
+	for i := 0; i < 200; i += 5 {
+		eh.Delete(i)
+	}
+	want := eh.Size()
+	if err := eh.Close(); err != nil {
+		t.Fatalf("Close() 错误 = %v", err)
+	}
+
+	eh = openTestDiskHash(t, path)
+	defer eh.Close()
+	if eh.Size() != want {
+		t.Fatalf("重新打开后 Size() = %d, 期望 %d", eh.Size(), want)
+	}
+	for i := 0; i < 200; i++ {
+		if _, ok := eh.Search(i); ok != (i%5 != 0) {
+			t.Fatalf("Search(%d) 存在 = %v", i, ok)
+		}
+	}
+}
+
+// TestExtendibleHashDiskRecovery 测试合并中途崩溃后重新打开能修复重叠的桶页
+func TestExtendibleHashDiskRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hash.db")
+	eh := openTestDiskHash(t, path, WithMergeThreshold(-1))
+	const n = 500
+	for i := 0; i < n; i++ {
+		eh.Insert(i, fmt.Sprint(i))
+	}
+
+	// 找一对兄弟桶，模拟合并只写入了保留的桶页、尚未释放另一个桶页时崩溃
+	var keep, gone *HashBucket
+	for i, bucket := range eh.directory.slots() {
+		if bucket.localDepth == 0 {
+			continue
+		}
+		bit := 1 << (bucket.localDepth - 1)
+		if buddy := eh.directory.get(uint64(i ^ bit)); i&bit == 0 && buddy.localDepth == bucket.localDepth {
+			keep, gone = bucket, buddy
+			break
+		}
+	}
+	if keep == nil {
+		t.Fatal("没有找到兄弟桶")
+	}
+	merged := NewHashBucket()
+	merged.localDepth = keep.localDepth - 1
+	merged.prefix = keep.prefix
+	merged.page = keep.page
+	for _, b := range []*HashBucket{keep, gone} {
+		for i, key := range b.keys {
+			merged.append(key, b.values[i], b.encoded[i], b.hashes[i])
+		}
+	}
+	if err := eh.persist(merged); err != nil {
+		t.Fatalf("persist() 错误 = %v", err)
+	}
+	eh.pager.file.Close()
+
+	eh = openTestDiskHash(t, path)
+	defer eh.Close()
+	if eh.Size() != n {
+		t.Fatalf("恢复后 Size() = %d, 期望 %d", eh.Size(), n)
+	}
+	for i := 0; i < n; i++ {
+		if v, ok := eh.Search(i); !ok || v != fmt.Sprint(i) {
+			t.Fatalf("恢复后 Search(%d) = %v, %v", i, v, ok)
+		}
+	}
+	seen := make(map[any]bool)
+	eh.ForEach(func(key, _ any) bool {
+		if seen[key] {
+			t.Fatalf("恢复后键%v出现了多次", key)
+		}
+		seen[key] = true
+		return true
+	})
+}
+
+// TestExtendibleHashDiskInvalidFile 测试打开损坏的文件
+func TestExtendibleHashDiskInvalidFile(t *testing.T) {
+	if _, err := OpenExtendibleHash(filepath.Join(t.TempDir(), "hash.db"), 8, nil); err == nil {
+		t.Error("未设置编解码器时应返回错误")
+	}
+
+	path := filepath.Join(t.TempDir(), "hash.db")
+	eh := openTestDiskHash(t, path)
+	for i := 0; i < 100; i++ {
+		eh.Insert(i, "v")
+	}
+	eh.Close()
+
+	// 篡改一个桶页的内容
+	eh = openTestDiskHash(t, path)
+	page := eh.directory.get(0).page
+	eh.pager.file.WriteAt([]byte{0xFF}, int64(page)*int64(eh.pageSize)+pageHeaderSize+1)
+	eh.pager.file.Close()
+
+	if _, err := OpenExtendibleHash(path, 8, nil, WithExtendibleHashCodec(IntCodec{}, StringCodec{})); err == nil {
+		t.Error("校验和不匹配时应返回错误")
+	}
+}
+
+// TestExtendibleHashDiskReadFrom 测试持久化模式下从快照恢复会写入桶页文件
+func TestExtendibleHashDiskReadFrom(t *testing.T) {
+	src := NewExtendibleHash(8, nil, WithExtendibleHashCodec(IntCodec{}, StringCodec{}))
+	for i := 0; i < 300; i++ {
+		src.Insert(i, fmt.Sprint(i))
+	}
+	var buf bytes.Buffer
+	if _, err := src.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() 错误 = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "hash.db")
+	eh := openTestDiskHash(t, path)
+	for i := 0; i < 100; i++ {
+		eh.Insert(-i-1, "old")
+	}
+	if _, err := eh.ReadFrom(&buf); err != nil {
+		t.Fatalf("ReadFrom() 错误 = %v", err)
+	}
+	eh.Close()
+
+	eh = openTestDiskHash(t, path)
+	defer eh.Close()
+	if eh.Size() != 300 {
+		t.Fatalf("重新打开后 Size() = %d, 期望 300", eh.Size())
+	}
+	for i := 0; i < 300; i++ {
+		if v, ok := eh.Search(i); !ok || v != fmt.Sprint(i) {
+			t.Fatalf("Search(%d) = %v, %v", i, v, ok)
+		}
+	}
+}
+
+// TestExtendibleHashDiskOverflowChain 测试持久化模式拒绝溢出链
+func TestExtendibleHashDiskOverflowChain(t *testing.T) {
+	_, err := OpenExtendibleHash(filepath.Join(t.TempDir(), "hash.db"), 8, nil,
+		WithExtendibleHashCodec(IntCodec{}, StringCodec{}), WithOverflowChain(2))
+	if err == nil {
+		t.Error("持久化模式下使用溢出链应返回错误")
+	}
+}
+
+// TestExtendibleHashDiskDeepPrefix 测试桶页保存超过32位的前缀
+func TestExtendibleHashDiskDeepPrefix(t *testing.T) {
+	const hash = uint64(1)<<35 | 5
+	eh := NewExtendibleHash(8, nil, WithPageSize(256), WithExtendibleHashCodec(IntCodec{}, StringCodec{}),
+		WithHashFunc64(func([]byte) uint64 { return hash }))
+
+	bucket := NewHashBucket()
+	bucket.localDepth = 36
+	bucket.prefix = hash
+	enc, h, _ := eh.encodeKey(7)
+	bucket.append(7, "seven", string(enc), h)
+
+	page, err := eh.encodeBucketPage(bucket)
+	if err != nil {
+		t.Fatalf("encodeBucketPage() 错误 = %v", err)
+	}
+	decoded, err := eh.decodeBucketPage(page)
+	if err != nil {
+		t.Fatalf("decodeBucketPage() 错误 = %v", err)
+	}
+	if decoded.localDepth != 36 || decoded.prefix != hash || len(decoded.keys) != 1 || decoded.hashes[0] != hash {
+		t.Errorf("解码后 localDepth=%d prefix=%#x keys=%v", decoded.localDepth, decoded.prefix, decoded.keys)
+	}
+
+	// 前缀超出局部深度的页被拒绝
+	page[5] = 35
+	if _, err := eh.decodeBucketPage(page); err == nil {
+		t.Error("前缀超出局部深度时应返回错误")
+	}
+}
+
+// TestExtendibleHashDiskUpdateIfExists 测试新值放不进桶页时更新会分裂桶并写入磁盘
+func TestExtendibleHashDiskUpdateIfExists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hash.db")
+	eh := openTestDiskHash(t, path, WithPageSize(128))
+	for i := 0; i < 4; i++ {
+		eh.Insert(i, "v")
+	}
+	long := strings.Repeat("x", 80)
+	for i := 0; i < 4; i++ {
+		if !eh.UpdateIfExists(i, func(old any) any { return old.(string) + long }) {
+			t.Fatalf("UpdateIfExists(%d) 失败", i)
+		}
+	}
+	if eh.SplitCount() == 0 {
+		t.Error("新值放不进桶页时应分裂")
+	}
+	if !eh.CompareAndSwap(0, "v"+long, "short") {
+		t.Error("CompareAndSwap(0) 失败")
+	}
+	if err := eh.Close(); err != nil {
+		t.Fatalf("Close() 错误 = %v", err)
+	}
+
+	eh = openTestDiskHash(t, path, WithPageSize(128))
+	defer eh.Close()
+	if v, _ := eh.Search(0); v != "short" {
+		t.Errorf("重新打开后 Search(0) = %v", v)
+	}
+	for i := 1; i < 4; i++ {
+		if v, _ := eh.Search(i); v != "v"+long {
+			t.Errorf("重新打开后 Search(%d) = %v", i, v)
+		}
+	}
+}
+
+// TestExtendibleHashDiskRehash 测试持久化模式下重建后桶页仍放得下且可以重新打开
+func TestExtendibleHashDiskRehash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hash.db")
+	eh := openTestDiskHash(t, path, WithPageSize(256))
+	for i := 0; i < 2000; i++ {
+		eh.Insert(i, fmt.Sprintf("value_%d", i))
+	}
+	for i := 0; i < 2000; i += 2 {
+		eh.Delete(i)
+	}
+
+	// 容量远大于一页能放下的键数，桶继续按页大小划分
+	if err := eh.Rehash(1000); err != nil {
+		t.Fatalf("Rehash() 错误 = %v", err)
+	}
+	if eh.BucketCount() < 2 {
+		t.Errorf("BucketCount() = %d, 放不下一页的桶应继续划分", eh.BucketCount())
+	}
+	eh.Insert(1, "updated")
+	if err := eh.Close(); err != nil {
+		t.Fatalf("Close() 错误 = %v", err)
+	}
+
+	eh = openTestDiskHash(t, path)
+	defer eh.Close()
+	if eh.Size() != 1000 {
+		t.Fatalf("重新打开后 Size() = %d, 期望 1000", eh.Size())
+	}
+	for i := 3; i < 2000; i += 2 {
+		if v, ok := eh.Search(i); !ok || v != fmt.Sprintf("value_%d", i) {
+			t.Fatalf("重新打开后 Search(%d) = %v, %v", i, v, ok)
+		}
+	}
+	if v, _ := eh.Search(1); v != "updated" {
+		t.Errorf("重新打开后 Search(1) = %v", v)
+	}
+}
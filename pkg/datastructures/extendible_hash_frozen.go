@@ -0,0 +1,102 @@
+package datastructures
+
+// FrozenExtendibleHash 可扩展哈希表的只读快照
+// 特点：
+// - 创建后内容不再改变，查询不获取任何锁，可以被任意多个goroutine并发读取
+// - 没有写入方法，需要修改时通过Thaw得到一个可写的副本
+// - 与原表完全独立，原表之后的修改不影响快照
+// 适合构建完成后发布给大量读者的查找表
+type FrozenExtendibleHash struct {
+	eh         *ExtendibleHash // 关闭加锁和LRU访问记录的独立副本，创建后不再修改
+	maxEntries int             // 原表的键数上限，Thaw时恢复
+	unlocked   bool            // 原表是否使用WithoutLocking，Thaw时恢复
+}
+
+// Freeze 返回哈希表当前内容的只读快照
+// 复制期间持有目录写锁，复制是O(n)的；快照总是纯内存的，沿用原表的键编码和哈希配置
+func (eh *ExtendibleHash) Freeze() *FrozenExtendibleHash {
+	eh.mu.Lock()
+	defer eh.mu.Unlock()
+
+	frozen := eh.cloneLocked(true)
+	// 只读快照不淘汰，查询时也不写入访问时间
+	frozen.maxEntries = 0
+	return &FrozenExtendibleHash{eh: frozen, maxEntries: eh.maxEntries, unlocked: eh.mu.disabled}
+}
+
+// searchEncoded 查找已编码的键，不加锁
+func (f *FrozenExtendibleHash) searchEncoded(key any, enc []byte, hashValue uint64) (any, bool) {
+	bucket := f.eh.directory.get(f.eh.getBucketIndex(hashValue))
+	if i := f.eh.lookup(bucket, key, enc, hashValue); i >= 0 {
+		return bucket.values[i], true
+	}
+	return nil, false
+}
+
+// Search 查找键对应的值
+func (f *FrozenExtendibleHash) Search(key any) (any, bool) {
+	if key == nil {
+		return nil, false
+	}
+	enc, hashValue, err := f.eh.encodeKey(key)
+	if err != nil {
+		return nil, false
+	}
+	return f.searchEncoded(key, enc, hashValue)
+}
+
+// SearchBytes 以[]byte为键查找，与ExtendibleHash.SearchBytes相同
+func (f *FrozenExtendibleHash) SearchBytes(key []byte) (any, bool) {
+	probe, enc, hashValue, err := encodeRawKey(f.eh, keyTagBytes, key)
+	if err != nil {
+		return nil, false
+	}
+	return f.searchEncoded(probe, enc, hashValue)
+}
+
+// SearchString 以string为键查找，与ExtendibleHash.SearchString相同
+func (f *FrozenExtendibleHash) SearchString(key string) (any, bool) {
+	probe, enc, hashValue, err := encodeRawKey(f.eh, keyTagString, key)
+	if err != nil {
+		return nil, false
+	}
+	return f.searchEncoded(probe, enc, hashValue)
+}
+
+// ForEach 对每个键值对调用fn，fn返回false时停止遍历，遍历顺序不确定
+func (f *FrozenExtendibleHash) ForEach(fn func(key, value any) bool) {
+	f.eh.eachBucket(func(bucket *HashBucket) bool {
+		for i, key := range bucket.keys {
+			if !fn(key, bucket.values[i]) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Size 返回键值对数量
+func (f *FrozenExtendibleHash) Size() int64 {
+	return f.eh.count.Load()
+}
+
+// Thaw 返回快照内容的可写副本，加锁模式和LRU淘汰配置与冻结前的原表相同
+// 快照本身保持不变，可以与读取并发调用
+func (f *FrozenExtendibleHash) Thaw() *ExtendibleHash {
+	// 快照不会被修改，复制时无需加锁
+	thawed := f.eh.cloneLocked(f.unlocked)
+	thawed.maxEntries = f.maxEntries
+	return thawed
+}
+
+// Describe 返回只读快照的能力描述
+func (f *FrozenExtendibleHash) Describe() Descriptor {
+	return Descriptor{
+		Name:        "FrozenExtendibleHash",
+		Concurrency: ConcurrencyLockFreeReads,
+		Complexity: Complexity{
+			Search: "O(1)",
+		},
+		Notes: "可扩展哈希的只读快照，查询不加锁；没有写入方法，通过Thaw得到可写副本",
+	}
+}
@@ -0,0 +1,131 @@
+package datastructures
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestExtendibleHashFreeze 测试只读快照的并发查询以及与原表、Thaw副本之间的独立性
+func TestExtendibleHashFreeze(t *testing.T) {
+	eh := NewExtendibleHash(4, nil)
+	for i := 0; i < 1000; i++ {
+		eh.Insert(i, i)
+	}
+	eh.InsertString("name", "frozen")
+	eh.InsertBytes([]byte{1, 2}, "bytes")
+	frozen := eh.Freeze()
+	if frozen.Size() != 1002 {
+		t.Fatalf("Size() = %d, 期望 1002", frozen.Size())
+	}
+
+	// 多个读者并发查询快照，同时原表继续被修改
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				if v, ok := frozen.Search(i); !ok || v != i {
+					t.Errorf("Search(%d) = %v, %v", i, v, ok)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 1000; i++ {
+		eh.Delete(i)
+		eh.Insert(i+1000, i)
+	}
+	wg.Wait()
+
+	if v, ok := frozen.SearchString("name"); !ok || v != "frozen" {
+		t.Errorf("SearchString(name) = %v, %v", v, ok)
+	}
+	if v, ok := frozen.SearchBytes([]byte{1, 2}); !ok || v != "bytes" {
+		t.Errorf("SearchBytes = %v, %v", v, ok)
+	}
+	if _, ok := frozen.Search(1500); ok {
+		t.Error("快照不应看到冻结之后的插入")
+	}
+	if _, ok := frozen.Search(nil); ok {
+		t.Error("Search(nil) 应返回false")
+	}
+	visited := 0
+	frozen.ForEach(func(_, _ any) bool {
+		visited++
+		return true
+	})
+	if visited != 1002 {
+		t.Errorf("ForEach 遍历 %d 个键, 期望 1002", visited)
+	}
+	if d := frozen.Describe(); d.Name != "FrozenExtendibleHash" || d.SupportsDelete || d.Persistent || d.Concurrency != ConcurrencyLockFreeReads {
+		t.Errorf("Describe() = %+v", d)
+	}
+
+	// Thaw得到可写副本，修改不影响快照
+	thawed := frozen.Thaw()
+	thawed.Insert(5, "changed")
+	thawed.Delete(6)
+	if v, _ := frozen.Search(5); v != 5 {
+		t.Errorf("Thaw副本的修改影响了快照, Search(5) = %v", v)
+	}
+	if _, ok := frozen.Search(6); !ok {
+		t.Error("Thaw副本的删除影响了快照")
+	}
+	if thawed.Describe().Concurrency != ConcurrencyBucketLock {
+		t.Errorf("Thaw副本应恢复加锁, Concurrency = %q", thawed.Describe().Concurrency)
+	}
+}
+
+// TestExtendibleHashFreezeLRU 测试LRU表的快照不淘汰，Thaw后恢复键数上限
+func TestExtendibleHashFreezeLRU(t *testing.T) {
+	eh := NewExtendibleHash(4, nil, WithLRUEviction(10, nil))
+	for i := 0; i < 10; i++ {
+		eh.Insert(i, i)
+	}
+	frozen := eh.Freeze()
+	for i := 0; i < 10; i++ {
+		frozen.Search(i)
+	}
+
+	thawed := frozen.Thaw()
+	for i := 10; i < 20; i++ {
+		thawed.Insert(i, i)
+	}
+	if thawed.Size() != 10 {
+		t.Errorf("Thaw副本 Size() = %d, 期望 10", thawed.Size())
+	}
+	if _, ok := thawed.Search(19); !ok {
+		t.Error("Thaw副本中最后插入的键被淘汰")
+	}
+	if frozen.Size() != 10 {
+		t.Errorf("快照 Size() = %d, 期望 10", frozen.Size())
+	}
+}
+
+// BenchmarkFrozenExtendibleHashParallel 比较并发读取时加锁的哈希表与只读快照
+func BenchmarkFrozenExtendibleHashParallel(b *testing.B) {
+	eh := NewExtendibleHash(16, nil)
+	keys := make([]string, smallSize)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key_%d", i)
+		eh.InsertString(keys[i], i)
+	}
+	frozen := eh.Freeze()
+
+	b.Run("Locked", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				eh.SearchString(keys[i%smallSize])
+			}
+		})
+	})
+	b.Run("Frozen", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				frozen.SearchString(keys[i%smallSize])
+			}
+		})
+	})
+}
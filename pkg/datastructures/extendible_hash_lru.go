@@ -0,0 +1,154 @@
+package datastructures
+
+import "sync/atomic"
+
+// WithLRUEviction 限制键数不超过maxEntries，插入新键超出上限时淘汰一个最久未访问的键，
+// 使哈希表可以直接用作有界缓存；onEvict不为nil时在不持有任何锁的情况下对被淘汰的键值对调用
+// 淘汰是近似LRU：优先在新键所在的桶内淘汰（以整个桶为样本的采样LRU），只需桶锁；
+// 桶内没有其他键时才在目录写锁下全局查找最久未访问的键。
+// 并发插入时键数可能短暂超出上限，超出的数量不超过并发写入者的数量。
+// 分条哈希表中每个分条分别计数
+func WithLRUEviction(maxEntries int, onEvict func(key, value any)) ExtendibleHashOption {
+	return func(eh *ExtendibleHash) {
+		eh.maxEntries = max(0, maxEntries)
+		eh.onEvict = onEvict
+	}
+}
+
+// overCapacity 开启LRU淘汰且键数超出上限时返回true
+func (eh *ExtendibleHash) overCapacity() bool {
+	return eh.maxEntries > 0 && eh.count.Load() > int64(eh.maxEntries)
+}
+
+// touch 记录桶中位置i的键刚被访问
+// 持有桶读锁时可能有其他读者同时记录，因此使用原子写入
+func (eh *ExtendibleHash) touch(bucket *HashBucket, i int) {
+	if eh.maxEntries > 0 {
+		atomic.StoreUint32(&bucket.stamps[i], eh.clock.Load())
+	}
+}
+
+// stampInserted 记录桶中位置i的键刚被插入或更新，并推进时钟
+// 调用方必须持有桶锁或目录写锁
+func (eh *ExtendibleHash) stampInserted(bucket *HashBucket, i int) {
+	if eh.maxEntries > 0 {
+		bucket.stamps[i] = eh.clock.Add(1)
+	}
+}
+
+// oldest 返回桶中除位置skip之外最久未访问的键的位置和年龄，不存在时返回-1
+// 时钟会回绕，因此比较与当前时钟的差值而不是时钟值本身
+func (eh *ExtendibleHash) oldest(bucket *HashBucket, skip int, now uint32) (int, uint32) {
+	victim, age := -1, uint32(0)
+	for i, stamp := range bucket.stamps {
+		if a := now - stamp; (victim < 0 || a > age) && i != skip {
+			victim, age = i, a
+		}
+	}
+	return victim, age
+}
+
+// evictAt 淘汰桶中位置i的键值对并返回它
+// 调用方必须持有桶锁或目录写锁
+func (eh *ExtendibleHash) evictAt(bucket *HashBucket, i int) (key, value any) {
+	key, value = bucket.keys[i], bucket.values[i]
+	bucket.remove(i)
+	bucket.fitOverflow(eh.bucketCapacity)
+	eh.count.Add(-1)
+	eh.evictions.Add(1)
+	eh.persist(bucket) // 写入失败记录在pager中，由Sync/Close返回
+	return key, value
+}
+
+// evictFromBucket 在桶内淘汰最久未访问的键，跳过刚插入的键newKey
+// 桶内没有其他键时返回false，由调用方在目录写锁下全局淘汰
+// 调用方必须持有桶锁或目录写锁
+func (eh *ExtendibleHash) evictFromBucket(bucket *HashBucket, newKey any, enc []byte, hashValue uint64) (key, value any, ok bool) {
+	i, _ := eh.oldest(bucket, eh.lookup(bucket, newKey, enc, hashValue), eh.clock.Load())
+	if i < 0 {
+		return nil, nil, false
+	}
+	key, value = eh.evictAt(bucket, i)
+	return key, value, true
+}
+
+// evictLocked 键数超出上限时淘汰一个键：先尝试哈希值所在的桶，再在所有桶中查找最久未访问的键
+// 调用方必须持有目录写锁
+func (eh *ExtendibleHash) evictLocked(newKey any, enc []byte, hashValue uint64) (key, value any, ok bool) {
+	if !eh.overCapacity() {
+		return nil, nil, false
+	}
+	target := eh.directory.get(eh.getBucketIndex(hashValue))
+	if key, value, ok = eh.evictFromBucket(target, newKey, enc, hashValue); ok {
+		return key, value, true
+	}
+
+	now := eh.clock.Load()
+	var victim *HashBucket
+	victimIndex, victimAge := -1, uint32(0)
+	eh.eachBucket(func(bucket *HashBucket) bool {
+		skip := -1
+		if bucket == target {
+			skip = eh.lookup(bucket, newKey, enc, hashValue)
+		}
+		if i, age := eh.oldest(bucket, skip, now); i >= 0 && (victim == nil || age > victimAge) {
+			victim, victimIndex, victimAge = bucket, i, age
+		}
+		return true
+	})
+	if victim == nil {
+		return nil, nil, false
+	}
+	key, value = eh.evictAt(victim, victimIndex)
+	return key, value, true
+}
+
+// evictGlobal 在目录写锁下执行evictLocked，释放锁后调用淘汰回调
+func (eh *ExtendibleHash) evictGlobal(newKey any, enc []byte, hashValue uint64) {
+	eh.mu.Lock()
+	key, value, ok := eh.evictLocked(newKey, enc, hashValue)
+	eh.mu.Unlock()
+	if ok {
+		eh.evicted(key, value)
+	}
+}
+
+// evicted 对被淘汰的键值对调用回调，调用时不持有任何锁
+func (eh *ExtendibleHash) evicted(key, value any) {
+	if eh.onEvict != nil {
+		eh.onEvict(key, value)
+	}
+}
+
+// EvictionCount 返回LRU淘汰的累计次数
+func (eh *ExtendibleHash) EvictionCount() int64 {
+	return eh.evictions.Load()
+}
+
+// eviction 快速路径在桶锁内淘汰的结果，留待释放锁后处理
+type eviction struct {
+	key, value any
+	ok         bool // 已在桶内淘汰了key
+	global     bool // 桶内没有可淘汰的键，需要全局淘汰
+}
+
+// evictInBucket 插入成功后键数超出上限时在桶内淘汰一个键
+// 调用方必须持有桶锁或目录写锁
+func (eh *ExtendibleHash) evictInBucket(bucket *HashBucket, newKey any, enc []byte, hashValue uint64) (ev eviction) {
+	if !eh.overCapacity() {
+		return ev
+	}
+	ev.key, ev.value, ev.ok = eh.evictFromBucket(bucket, newKey, enc, hashValue)
+	ev.global = !ev.ok
+	return ev
+}
+
+// finishEviction 释放锁后调用淘汰回调，桶内无法淘汰时转为全局淘汰
+func (eh *ExtendibleHash) finishEviction(ev eviction, newKey any, enc []byte, hashValue uint64) {
+	if ev.ok {
+		eh.evicted(ev.key, ev.value)
+	}
+	if ev.global {
+		eh.evictGlobal(newKey, enc, hashValue)
+	}
+}
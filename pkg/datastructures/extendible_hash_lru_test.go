@@ -0,0 +1,102 @@
+package datastructures
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestExtendibleHashLRUEviction 测试键数上限和淘汰回调
+func TestExtendibleHashLRUEviction(t *testing.T) {
+	var evicted []any
+	eh := NewExtendibleHash(8, nil, WithLRUEviction(100, func(key, value any) {
+		if key != value {
+			t.Errorf("淘汰回调 key=%v value=%v 不匹配", key, value)
+		}
+		evicted = append(evicted, key)
+	}))
+
+	// 热键0-9在插入过程中被反复访问，不应被淘汰
+	for i := 0; i < 1000; i++ {
+		eh.Insert(i, i)
+		for hot := 0; hot < 10 && hot <= i; hot++ {
+			eh.Search(hot)
+		}
+		if eh.Size() > 100 {
+			t.Fatalf("插入%d后 Size() = %d, 超出上限", i, eh.Size())
+		}
+	}
+	if eh.Size() != 100 {
+		t.Errorf("Size() = %d, 期望 100", eh.Size())
+	}
+	if len(evicted) != 900 || eh.EvictionCount() != 900 || eh.Stats().EvictionCount != 900 {
+		t.Errorf("淘汰 %d 次, EvictionCount() = %d, 期望 900", len(evicted), eh.EvictionCount())
+	}
+	for hot := 0; hot < 10; hot++ {
+		if _, ok := eh.Search(hot); !ok {
+			t.Errorf("热键%d被淘汰", hot)
+		}
+	}
+	if _, ok := eh.Search(999); !ok {
+		t.Error("最后插入的键被淘汰")
+	}
+
+	// 更新已有的键不触发淘汰
+	before := eh.EvictionCount()
+	eh.Insert(999, 999)
+	if eh.EvictionCount() != before {
+		t.Error("更新已有的键不应淘汰")
+	}
+
+	// 桶容量为1时桶内没有其他键，走全局淘汰
+	single := NewExtendibleHash(1, nil, WithLRUEviction(10, nil))
+	for i := 0; i < 100; i++ {
+		single.Insert(i, i)
+	}
+	if single.Size() != 10 {
+		t.Errorf("全局淘汰后 Size() = %d, 期望 10", single.Size())
+	}
+	for i := 90; i < 100; i++ {
+		if _, ok := single.Search(i); !ok {
+			t.Errorf("全局淘汰应保留最近插入的键%d", i)
+		}
+	}
+}
+
+// TestExtendibleHashLRUConcurrent 测试并发插入和GetOrCompute下键数最终不超过上限
+func TestExtendibleHashLRUConcurrent(t *testing.T) {
+	var mu sync.Mutex
+	evicted := 0
+	eh := NewExtendibleHash(4, nil, WithLRUEviction(200, func(key, value any) {
+		mu.Lock()
+		evicted++
+		mu.Unlock()
+	}))
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := w*500 + i
+				if i%2 == 0 {
+					eh.Insert(key, key)
+				} else {
+					eh.GetOrCompute(key, func() any { return key })
+				}
+				eh.Search(key - 1)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if eh.Size() != 200 {
+		t.Errorf("Size() = %d, 期望 200", eh.Size())
+	}
+	if int64(evicted) != eh.EvictionCount() || eh.Size()+eh.EvictionCount() != 4000 {
+		t.Errorf("回调 %d 次, EvictionCount() = %d, Size() = %d", evicted, eh.EvictionCount(), eh.Size())
+	}
+	if n := len(eh.Keys()); n != 200 {
+		t.Errorf("len(Keys()) = %d, 期望 200", n)
+	}
+}
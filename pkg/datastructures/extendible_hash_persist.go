@@ -0,0 +1,208 @@
+package datastructures
+
+import (
+	"fmt"
+	"io"
+)
+
+// extendibleHashSnapshotMagic 可扩展哈希快照格式的文件头
+const extendibleHashSnapshotMagic = "EHS\x01"
+
+// 快照格式：
+//
+//	magic(4字节) | globalDepth(uvarint) | bucketCount(uvarint) |
+//	bucketCount × (localDepth(uvarint) prefix(uvarint) count(uvarint) count × (keyLen key valueLen value))
+//
+// 每个不同的桶只写一次，目录由各桶的localDepth和prefix重建，加载时无需重新插入和分裂。
+
+// writeBucketEntries 写入桶中的键值对：count(uvarint) | count × (key value)
+func (eh *ExtendibleHash) writeBucketEntries(bw *binaryWriter, bucket *HashBucket) {
+	bw.writeUvarint(uint64(len(bucket.keys)))
+	for i, key := range bucket.keys {
+		if bw.err != nil {
+			return
+		}
+		bw.writeEncoded(eh.keyCodec, key)
+		bw.writeEncoded(eh.valueCodec, bucket.values[i])
+	}
+}
+
+// readBucketEntries 读取writeBucketEntries写入的键值对追加到桶中，并重新计算每个键的编码和哈希值
+func (eh *ExtendibleHash) readBucketEntries(br *binaryReader, bucket *HashBucket, maxLen uint64) error {
+	count, err := br.readUvarint()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < count; i++ {
+		key, err := br.readDecoded(eh.keyCodec, maxLen)
+		if err != nil {
+			return err
+		}
+		value, err := br.readDecoded(eh.valueCodec, maxLen)
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			return fmt.Errorf("key cannot be nil")
+		}
+		enc, hashValue, err := eh.encodeKey(key)
+		if err != nil {
+			return err
+		}
+		bucket.append(key, value, string(enc), hashValue)
+	}
+	return nil
+}
+
+// WriteTo 将目录元数据和全部桶内容写入w，实现io.WriterTo
+// 需要先通过WithExtendibleHashCodec设置键、值编解码器
+func (eh *ExtendibleHash) WriteTo(w io.Writer) (int64, error) {
+	if eh.keyCodec == nil || eh.valueCodec == nil {
+		return 0, fmt.Errorf("key and value codecs are required for serialization")
+	}
+
+	eh.mu.RLock()
+	defer eh.mu.RUnlock()
+
+	var buckets []*HashBucket
+	eh.eachBucket(func(bucket *HashBucket) bool {
+		buckets = append(buckets, bucket)
+		return true
+	})
+
+	bw := &binaryWriter{w: w}
+	bw.write([]byte(extendibleHashSnapshotMagic))
+	bw.writeUvarint(uint64(eh.globalDepth))
+	bw.writeUvarint(uint64(len(buckets)))
+	for _, bucket := range buckets {
+		if bw.err != nil {
+			break
+		}
+		bucket.mu.RLock()
+		bw.writeUvarint(uint64(bucket.localDepth))
+		bw.writeUvarint(uint64(bucket.prefix))
+		eh.writeBucketEntries(bw, bucket)
+		bucket.mu.RUnlock()
+	}
+
+	return bw.n, bw.err
+}
+
+// ReadFrom 从r读取WriteTo写入的数据并替换哈希表的全部内容，实现io.ReaderFrom
+// 读取失败时哈希表保持原有内容不变；持久化模式下新内容同时写入桶页文件
+// hashFunc和KeyEncoder必须与写入快照时一致，否则键会落在错误的桶中而被拒绝
+func (eh *ExtendibleHash) ReadFrom(r io.Reader) (int64, error) {
+	if eh.keyCodec == nil || eh.valueCodec == nil {
+		return 0, fmt.Errorf("key and value codecs are required for serialization")
+	}
+
+	br := &binaryReader{r: r}
+	magic := make([]byte, len(extendibleHashSnapshotMagic))
+	if _, err := br.read(magic); err != nil {
+		return br.n, err
+	}
+	if string(magic) != extendibleHashSnapshotMagic {
+		return br.n, fmt.Errorf("invalid extendible hash header")
+	}
+
+	globalDepth, err := br.readUvarint()
+	if err != nil {
+		return br.n, err
+	}
+	if globalDepth > maxLoadDepth {
+		return br.n, fmt.Errorf("global depth %d exceeds limit %d", globalDepth, maxLoadDepth)
+	}
+	bucketCount, err := br.readUvarint()
+	if err != nil {
+		return br.n, err
+	}
+	if bucketCount == 0 || bucketCount > 1<<globalDepth {
+		return br.n, fmt.Errorf("invalid bucket count %d for global depth %d", bucketCount, globalDepth)
+	}
+
+	// 先完整读取并校验，再替换内容
+	directory := newHashDirectory(1 << globalDepth)
+	var count int64
+	for b := uint64(0); b < bucketCount; b++ {
+		localDepth, err := br.readUvarint()
+		if err != nil {
+			return br.n, err
+		}
+		prefix, err := br.readUvarint()
+		if err != nil {
+			return br.n, err
+		}
+		if localDepth > globalDepth || prefix&^depthMask(int(localDepth)) != 0 {
+			return br.n, fmt.Errorf("invalid local depth %d or prefix %#x", localDepth, prefix)
+		}
+
+		bucket := eh.newBucket()
+		bucket.localDepth = int(localDepth)
+		bucket.prefix = prefix
+		if err := eh.readBucketEntries(br, bucket, maxEncodedFieldLen); err != nil {
+			return br.n, err
+		}
+		bucket.fitOverflow(eh.bucketCapacity)
+		mask := depthMask(int(localDepth))
+		for _, h := range bucket.hashes {
+			if h&mask != bucket.prefix {
+				return br.n, fmt.Errorf("key hash %#x does not belong to bucket with prefix %#x", h, prefix)
+			}
+		}
+
+		for i := prefix; i < uint64(directory.len()); i += 1 << localDepth {
+			if directory.get(i) != nil {
+				return br.n, fmt.Errorf("directory slot %d is covered by more than one bucket", i)
+			}
+			directory.set(i, bucket)
+		}
+		count += int64(len(bucket.keys))
+	}
+	if i, ok := directory.firstNil(); ok {
+		return br.n, fmt.Errorf("directory slot %d is not covered by any bucket", i)
+	}
+
+	eh.mu.Lock()
+	defer eh.mu.Unlock()
+
+	if eh.pager != nil {
+		if err := eh.replacePages(&directory); err != nil {
+			return br.n, err
+		}
+	}
+	eh.directory = directory
+	eh.globalDepth = int(globalDepth)
+	eh.count.Store(count)
+	eh.numBuckets = int(bucketCount)
+
+	return br.n, nil
+}
+
+// replacePages 持久化模式下释放旧桶页，并把新目录中的每个桶写入新分配的页
+// 写入前先检查每个桶都能放进一页；替换过程本身不是崩溃安全的
+// 调用方必须持有目录写锁
+func (eh *ExtendibleHash) replacePages(directory *hashDirectory) error {
+	var err error
+	directory.each(func(i uint64, bucket *HashBucket) bool {
+		if i < 1<<bucket.localDepth {
+			_, err = eh.encodeBucketPage(bucket)
+		}
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+
+	eh.eachBucket(func(bucket *HashBucket) bool {
+		eh.pager.release(bucket.page)
+		return true
+	})
+	directory.each(func(i uint64, bucket *HashBucket) bool {
+		if i < 1<<bucket.localDepth {
+			bucket.page = eh.pager.alloc()
+			err = eh.persist(bucket)
+		}
+		return err == nil
+	})
+	return err
+}
@@ -0,0 +1,60 @@
+package datastructures
+
+import "time"
+
+// ExtendibleHashStats 可扩展哈希的深度和负载统计，用于容量规划
+type ExtendibleHashStats struct {
+	Count               int64         // 键值对数量
+	GlobalDepth         int           // 全局深度
+	DirectorySize       int           // 目录项数量（2^GlobalDepth）
+	BucketCount         int           // 不同桶的数量
+	BucketCapacity      int           // 桶容量
+	LocalDepthHistogram []int         // LocalDepthHistogram[d]为局部深度为d的桶数
+	FullBuckets         int           // 已满的桶数（包括溢出页）
+	OverflowPages       int           // 当前的溢出页总数
+	LoadFactor          float64       // 键值对数量 / ((桶数 + 溢出页数) × 桶容量)
+	SplitCount          int64         // 桶分裂的累计次数
+	MergeCount          int64         // 桶合并的累计次数
+	OverflowCount       int64         // 追加溢出页的累计次数
+	EvictionCount       int64         // LRU淘汰的累计次数
+	DoublingCount       int64         // 目录加倍的累计次数
+	DoublingTime        time.Duration // 目录加倍的累计耗时
+	DirectoryBytes      int64         // 目录数组占用的内存（字节）
+}
+
+// Stats 返回可扩展哈希的深度和负载统计
+// 每个桶只统计一次，不受目录别名影响
+func (eh *ExtendibleHash) Stats() ExtendibleHashStats {
+	eh.mu.RLock()
+	defer eh.mu.RUnlock()
+
+	stats := ExtendibleHashStats{
+		Count:               eh.count.Load(),
+		GlobalDepth:         eh.globalDepth,
+		DirectorySize:       eh.directory.len(),
+		BucketCapacity:      eh.bucketCapacity,
+		LocalDepthHistogram: make([]int, eh.globalDepth+1),
+		SplitCount:          eh.splits.Load(),
+		MergeCount:          eh.merges.Load(),
+		OverflowCount:       eh.overflows.Load(),
+		EvictionCount:       eh.evictions.Load(),
+		DoublingCount:       eh.doublings.Load(),
+		DoublingTime:        time.Duration(eh.doublingNanos.Load()),
+		DirectoryBytes:      eh.directory.bytes(),
+	}
+
+	eh.eachBucket(func(bucket *HashBucket) bool {
+		stats.BucketCount++
+		stats.LocalDepthHistogram[bucket.localDepth]++
+		bucket.mu.RLock()
+		if bucket.isFull(eh.bucketCapacity) {
+			stats.FullBuckets++
+		}
+		stats.OverflowPages += bucket.overflow
+		bucket.mu.RUnlock()
+		return true
+	})
+
+	stats.LoadFactor = float64(stats.Count) / float64((stats.BucketCount+stats.OverflowPages)*eh.bucketCapacity)
+	return stats
+}
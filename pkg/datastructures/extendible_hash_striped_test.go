@@ -0,0 +1,117 @@
+package datastructures
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestStripedExtendibleHash 测试分条哈希表的基本操作和分条分布
+func TestStripedExtendibleHash(t *testing.T) {
+	sh := NewStripedExtendibleHash(5, 4, nil)
+	if sh.StripeCount() != 8 {
+		t.Fatalf("StripeCount() = %d, 期望向上取整为 8", sh.StripeCount())
+	}
+
+	const n = 5000
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < n; i += 4 {
+				sh.Insert(i, i)
+			}
+		}(w)
+	}
+	wg.Wait()
+	if sh.Size() != n {
+		t.Fatalf("Size() = %d, 期望 %d", sh.Size(), n)
+	}
+	for i := 0; i < n; i++ {
+		if v, ok := sh.Search(i); !ok || v != i {
+			t.Fatalf("Search(%d) = %v, %v", i, v, ok)
+		}
+	}
+
+	// 默认的32位哈希函数也能均匀分布到各个分条
+	for i, stats := range sh.StripeStats() {
+		if stats.Count < n/8/2 {
+			t.Errorf("分条%d只有%d个键，分布不均", i, stats.Count)
+		}
+	}
+
+	seen := 0
+	sh.ForEach(func(key, value any) bool {
+		seen++
+		return seen < 100
+	})
+	if seen != 100 {
+		t.Errorf("ForEach 提前停止后访问了%d个键, 期望 100", seen)
+	}
+
+	if v, loaded := sh.GetOrCompute(1, func() any { return -1 }); !loaded || v != 1 {
+		t.Errorf("GetOrCompute(1) = %v, %v", v, loaded)
+	}
+	for i := 0; i < n; i += 2 {
+		if !sh.Delete(i) {
+			t.Fatalf("Delete(%d) 失败", i)
+		}
+	}
+	if sh.Size() != n/2 {
+		t.Errorf("删除后 Size() = %d, 期望 %d", sh.Size(), n/2)
+	}
+}
+
+// TestStripedExtendibleHashIsolation 测试一个分条的目录加倍不阻塞其他分条
+func TestStripedExtendibleHashIsolation(t *testing.T) {
+	var blocking atomic.Bool
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	sh := NewStripedExtendibleHash(2, 2, nil, WithDirectoryGrowHook(func(oldDepth, newDepth int) {
+		if blocking.Load() {
+			select {
+			case entered <- struct{}{}:
+			default:
+			}
+			<-release
+		}
+	}))
+
+	// 找出分别落在两个分条中的键
+	var inA, inB []int
+	for i := 0; len(inA) < 100 || len(inB) == 0; i++ {
+		stripe, _, _, _ := sh.encodeKey(i)
+		if stripe == sh.stripes[0] {
+			inA = append(inA, i)
+		} else if len(inB) == 0 {
+			inB = append(inB, i)
+		}
+	}
+	sh.Insert(inB[0], "b")
+
+	blocking.Store(true)
+	go func() {
+		for _, k := range inA {
+			sh.Insert(k, k)
+		}
+	}()
+	<-entered
+
+	// 分条A在目录写锁内阻塞时，分条B仍可读写
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if v, ok := sh.Search(inB[0]); !ok || v != "b" {
+			t.Errorf("Search(%d) = %v, %v", inB[0], v, ok)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("其他分条上的查询被阻塞")
+	}
+	blocking.Store(false)
+	close(release)
+}
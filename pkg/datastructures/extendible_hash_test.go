@@ -0,0 +1,1177 @@
+package datastructures
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testMarshalerKey 实现encoding.BinaryMarshaler的测试键
+type testMarshalerKey struct {
+	id  uint16
+	err error
+}
+
+func (k testMarshalerKey) MarshalBinary() ([]byte, error) {
+	if k.err != nil {
+		return nil, k.err
+	}
+	return []byte{byte(k.id >> 8), byte(k.id)}, nil
+}
+
+// TestExtendibleHashKeyEncoder 测试键编码器用于哈希和等值比较
+func TestExtendibleHashKeyEncoder(t *testing.T) {
+	eh := NewExtendibleHash(64, nil)
+
+	// 不同类型的键互不冲突
+	eh.Insert(1, "int")
+	eh.Insert(int64(1), "int64")
+	eh.Insert("1", "string")
+	eh.Insert([]byte("1"), "bytes")
+	if eh.Size() != 4 {
+		t.Fatalf("Size() = %d, 期望 4", eh.Size())
+	}
+	for _, tc := range []struct {
+		key  any
+		want string
+	}{{1, "int"}, {int64(1), "int64"}, {"1", "string"}, {[]byte("1"), "bytes"}} {
+		if v, ok := eh.Search(tc.key); !ok || v != tc.want {
+			t.Errorf("Search(%#v) = %v, %v, 期望 %s", tc.key, v, ok, tc.want)
+		}
+	}
+
+	// []byte键按内容比较
+	eh.Insert([]byte("1"), "bytes2")
+	if v, _ := eh.Search([]byte("1")); v != "bytes2" || eh.Size() != 4 {
+		t.Errorf("更新[]byte键后 Search = %v, Size = %d", v, eh.Size())
+	}
+
+	// BinaryMarshaler按MarshalBinary的结果比较
+	eh.Insert(testMarshalerKey{id: 7}, "m7")
+	if v, ok := eh.Search(testMarshalerKey{id: 7}); !ok || v != "m7" {
+		t.Errorf("Search(marshaler) = %v, %v", v, ok)
+	}
+	if !eh.Delete(testMarshalerKey{id: 7}) || eh.Size() != 4 {
+		t.Errorf("Delete(marshaler) 失败，Size = %d", eh.Size())
+	}
+
+	// 编码失败时Insert返回错误，Search和Delete视为不存在
+	wantErr := errors.New("boom")
+	if err := eh.Insert(testMarshalerKey{err: wantErr}, "x"); !errors.Is(err, wantErr) {
+		t.Errorf("Insert() 错误 = %v, 期望包含 %v", err, wantErr)
+	}
+	if _, ok := eh.Search(testMarshalerKey{err: wantErr}); ok {
+		t.Error("编码失败的键不应被找到")
+	}
+
+	// 自定义编码器：大小写不敏感的字符串键
+	ci := NewExtendibleHash(64, nil, WithKeyEncoder(KeyEncoderFunc(func(dst []byte, key any) ([]byte, error) {
+		return append(dst, strings.ToLower(key.(string))...), nil
+	})))
+	ci.Insert("Hello", 1)
+	ci.Insert("HELLO", 2)
+	if v, ok := ci.Search("hello"); !ok || v != 2 || ci.Size() != 1 {
+		t.Errorf("大小写不敏感 Search = %v, %v, Size = %d", v, ok, ci.Size())
+	}
+}
+
+// TestExtendibleHashSplit 测试桶分裂后所有键仍可找到
+func TestExtendibleHashSplit(t *testing.T) {
+	eh := NewExtendibleHash(4, nil)
+	const n = 10000
+	for i := 0; i < n; i++ {
+		if err := eh.Insert(i, i*2); err != nil {
+			t.Fatalf("Insert(%d) 错误 = %v", i, err)
+		}
+	}
+	if eh.Size() != n {
+		t.Fatalf("Size() = %d, 期望 %d", eh.Size(), n)
+	}
+	if eh.SplitCount() == 0 {
+		t.Error("插入后应发生桶分裂")
+	}
+
+	// 每个目录项指向的桶都只包含低localDepth位与目录索引一致的键
+	eh.mu.RLock()
+	for i, bucket := range eh.directory.slots() {
+		mask := depthMask(bucket.localDepth)
+		for _, h := range bucket.hashes {
+			if h&mask != uint64(i)&mask {
+				t.Fatalf("目录项%d的桶（localDepth=%d）包含不属于它的哈希值%#x", i, bucket.localDepth, h)
+			}
+		}
+	}
+	eh.mu.RUnlock()
+
+	for i := 0; i < n; i++ {
+		if v, ok := eh.Search(i); !ok || v != i*2 {
+			t.Fatalf("Search(%d) = %v, %v", i, v, ok)
+		}
+	}
+	for i := 0; i < n; i += 2 {
+		if !eh.Delete(i) {
+			t.Fatalf("Delete(%d) 失败", i)
+		}
+	}
+	for i := 0; i < n; i++ {
+		if _, ok := eh.Search(i); ok != (i%2 == 1) {
+			t.Fatalf("删除后 Search(%d) 存在 = %v", i, ok)
+		}
+	}
+}
+
+// TestExtendibleHashConstantHash 测试所有键哈希值相同时不会无限分裂
+func TestExtendibleHashConstantHash(t *testing.T) {
+	eh := NewExtendibleHash(2, func([]byte) uint32 { return 0 })
+	for i := 0; i < 10; i++ {
+		eh.Insert(i, i)
+	}
+	for i := 0; i < 10; i++ {
+		if v, ok := eh.Search(i); !ok || v != i {
+			t.Fatalf("Search(%d) = %v, %v", i, v, ok)
+		}
+	}
+}
+
+// TestExtendibleHashConcurrent 测试桶级锁下的并发读写（配合-race运行）
+func TestExtendibleHashConcurrent(t *testing.T) {
+	eh := NewExtendibleHash(8, nil)
+	const workers, perWorker = 8, 2000
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			base := w * perWorker
+			for i := base; i < base+perWorker; i++ {
+				if err := eh.Insert(i, i); err != nil {
+					t.Errorf("Insert(%d) 错误 = %v", i, err)
+					return
+				}
+				if v, ok := eh.Search(i); !ok || v != i {
+					t.Errorf("Search(%d) = %v, %v", i, v, ok)
+					return
+				}
+				if i%3 == 0 && !eh.Delete(i) {
+					t.Errorf("Delete(%d) 失败", i)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	var want int64
+	for i := 0; i < workers*perWorker; i++ {
+		_, ok := eh.Search(i)
+		if ok != (i%3 != 0) {
+			t.Fatalf("Search(%d) 存在 = %v", i, ok)
+		}
+		if ok {
+			want++
+		}
+	}
+	if eh.Size() != want {
+		t.Errorf("Size() = %d, 期望 %d", eh.Size(), want)
+	}
+}
+
+// TestExtendibleHashForEach 测试遍历时每个键恰好出现一次
+func TestExtendibleHashForEach(t *testing.T) {
+	eh := NewExtendibleHash(4, nil)
+	const n = 1000
+	for i := 0; i < n; i++ {
+		eh.Insert(i, i*10)
+	}
+	if eh.directory.len() <= 1 {
+		t.Fatal("测试需要多个目录项共享同一个桶")
+	}
+
+	seen := make(map[int]bool, n)
+	eh.ForEach(func(key, value any) bool {
+		k := key.(int)
+		if seen[k] {
+			t.Fatalf("键%d被访问了多次", k)
+		}
+		if value != k*10 {
+			t.Errorf("键%d的值 = %v, 期望 %d", k, value, k*10)
+		}
+		seen[k] = true
+		return true
+	})
+	if len(seen) != n {
+		t.Errorf("ForEach 访问了%d个键, 期望 %d", len(seen), n)
+	}
+
+	// 提前停止
+	visited := 0
+	eh.ForEach(func(_, _ any) bool {
+		visited++
+		return visited < 10
+	})
+	if visited != 10 {
+		t.Errorf("提前停止后访问了%d个键, 期望 10", visited)
+	}
+
+	if keys, values := eh.Keys(), eh.Values(); len(keys) != n || len(values) != n {
+		t.Errorf("len(Keys()) = %d, len(Values()) = %d, 期望 %d", len(keys), len(values), n)
+	}
+
+	// 遍历中删除是安全的
+	eh.ForEach(func(key, _ any) bool {
+		eh.Delete(key)
+		return true
+	})
+	if eh.Size() != 0 || len(eh.Keys()) != 0 {
+		t.Errorf("遍历删除后 Size() = %d", eh.Size())
+	}
+}
+
+// TestExtendibleHashForEachConcurrentWriter 测试有并发写入时在ForEach回调中访问哈希表不会死锁
+func TestExtendibleHashForEachConcurrentWriter(t *testing.T) {
+	eh := NewExtendibleHash(4, nil)
+	for i := 0; i < 64; i++ {
+		eh.Insert(i, i)
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		// 不断插入新键触发分裂，使写入者在目录写锁上等待
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			eh.Insert(1000+i, i)
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for deadline := time.Now().Add(300 * time.Millisecond); time.Now().Before(deadline); {
+			eh.ForEach(func(key, value any) bool {
+				if k := key.(int); k < 64 {
+					if v, ok := eh.Search(k); !ok || v != k {
+						t.Errorf("回调中 Search(%d) = %v, %v", k, v, ok)
+					}
+					eh.Insert(k, k)
+				} else {
+					eh.Delete(k)
+				}
+				return true
+			})
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("ForEach 回调中访问哈希表发生死锁")
+	}
+	close(stop)
+	wg.Wait()
+
+	for i := 0; i < 64; i++ {
+		if v, ok := eh.Search(i); !ok || v != i {
+			t.Errorf("Search(%d) = %v, %v, 期望 %d", i, v, ok, i)
+		}
+	}
+}
+
+// TestExtendibleHashMerge 测试删除后兄弟桶合并和目录收缩
+func TestExtendibleHashMerge(t *testing.T) {
+	eh := NewExtendibleHash(4, nil)
+	const n = 2000
+	for i := 0; i < n; i++ {
+		eh.Insert(i, i)
+	}
+	if eh.GlobalDepth() == 0 {
+		t.Fatal("插入后全局深度应大于0")
+	}
+
+	for i := 0; i < n; i++ {
+		eh.Delete(i)
+		// 合并过程中不能丢失剩余的键
+		if i%100 == 0 {
+			for j := i + 1; j < n; j += 37 {
+				if v, ok := eh.Search(j); !ok || v != j {
+					t.Fatalf("删除%d后 Search(%d) = %v, %v", i, j, v, ok)
+				}
+			}
+		}
+	}
+	if eh.MergeCount() == 0 {
+		t.Error("删除后应发生桶合并")
+	}
+	if eh.GlobalDepth() != 0 || eh.BucketCount() != 1 {
+		t.Errorf("全部删除后 GlobalDepth() = %d, BucketCount() = %d, 期望 0 和 1",
+			eh.GlobalDepth(), eh.BucketCount())
+	}
+
+	// 表可以再次增长
+	for i := 0; i < 100; i++ {
+		eh.Insert(i, i)
+	}
+	if eh.Size() != 100 {
+		t.Errorf("重新插入后 Size() = %d, 期望 100", eh.Size())
+	}
+
+	// 关闭自动合并
+	noMerge := NewExtendibleHash(4, nil, WithMergeThreshold(-1))
+	for i := 0; i < n; i++ {
+		noMerge.Insert(i, i)
+	}
+	depth := noMerge.GlobalDepth()
+	for i := 0; i < n; i++ {
+		noMerge.Delete(i)
+	}
+	if noMerge.MergeCount() != 0 || noMerge.GlobalDepth() != depth {
+		t.Errorf("关闭合并后 MergeCount() = %d, GlobalDepth() = %d, 期望 0 和 %d",
+			noMerge.MergeCount(), noMerge.GlobalDepth(), depth)
+	}
+}
+
+// TestExtendibleHashMergeHysteresis 测试在合并阈值附近交替插入删除不会反复分裂合并
+func TestExtendibleHashMergeHysteresis(t *testing.T) {
+	eh := NewExtendibleHash(4, nil)
+	for i := 0; i < 64; i++ {
+		eh.Insert(i, i)
+	}
+	for i := 0; i < 64; i++ {
+		eh.Delete(i)
+	}
+	splits, merges := eh.SplitCount(), eh.MergeCount()
+
+	for round := 0; round < 100; round++ {
+		eh.Insert(0, 0)
+		eh.Delete(0)
+	}
+	if eh.SplitCount() != splits || eh.MergeCount() != merges {
+		t.Errorf("交替插入删除后 splits %d->%d, merges %d->%d",
+			splits, eh.SplitCount(), merges, eh.MergeCount())
+	}
+}
+
+// TestExtendibleHashSerialization 测试快照写入和恢复
+func TestExtendibleHashSerialization(t *testing.T) {
+	codec := WithExtendibleHashCodec(IntCodec{}, StringCodec{})
+	eh := NewExtendibleHash(4, nil, codec)
+	const n = 1000
+	for i := 0; i < n; i++ {
+		eh.Insert(i, fmt.Sprint(i))
+	}
+
+	var buf bytes.Buffer
+	written, err := eh.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo() 错误 = %v", err)
+	}
+	if written != int64(buf.Len()) {
+		t.Errorf("WriteTo() 返回 %d 字节, 实际写入 %d", written, buf.Len())
+	}
+	data := buf.Bytes()
+
+	restored := NewExtendibleHash(4, nil, codec)
+	restored.Insert(-1, "old")
+	read, err := restored.ReadFrom(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadFrom() 错误 = %v", err)
+	}
+	if read != written {
+		t.Errorf("ReadFrom() 读取 %d 字节, 期望 %d", read, written)
+	}
+	if restored.Size() != n || restored.GlobalDepth() != eh.GlobalDepth() || restored.SplitCount() != 0 {
+		t.Errorf("恢复后 Size() = %d, GlobalDepth() = %d, SplitCount() = %d",
+			restored.Size(), restored.GlobalDepth(), restored.SplitCount())
+	}
+	if _, ok := restored.Search(-1); ok {
+		t.Error("ReadFrom 应替换原有内容")
+	}
+	for i := 0; i < n; i++ {
+		if v, ok := restored.Search(i); !ok || v != fmt.Sprint(i) {
+			t.Fatalf("恢复后 Search(%d) = %v, %v", i, v, ok)
+		}
+	}
+
+	// 截断或损坏的数据返回错误，且不修改原有内容
+	for _, bad := range [][]byte{data[:len(data)/2], []byte("XXXX"), nil} {
+		if _, err := restored.ReadFrom(bytes.NewReader(bad)); err == nil {
+			t.Errorf("ReadFrom(%d字节) 应返回错误", len(bad))
+		}
+	}
+	if restored.Size() != n {
+		t.Errorf("读取失败后 Size() = %d, 期望 %d", restored.Size(), n)
+	}
+
+	// 哈希函数不一致时键落在错误的桶中
+	other := NewExtendibleHash(4, func(b []byte) uint32 { return defaultHash(b) + 1 }, codec)
+	if _, err := other.ReadFrom(bytes.NewReader(data)); err == nil {
+		t.Error("哈希函数不一致时 ReadFrom 应返回错误")
+	}
+
+	if _, err := NewExtendibleHash(4, nil).WriteTo(&buf); err == nil {
+		t.Error("未设置编解码器时 WriteTo 应返回错误")
+	}
+}
+
+// TestExtendibleHashStats 测试深度和负载统计
+func TestExtendibleHashStats(t *testing.T) {
+	eh := NewExtendibleHash(4, nil)
+	stats := eh.Stats()
+	if stats.BucketCount != 1 || stats.DirectorySize != 1 || stats.LoadFactor != 0 {
+		t.Errorf("空表 Stats() = %+v", stats)
+	}
+
+	const n = 1000
+	for i := 0; i < n; i++ {
+		eh.Insert(i, i)
+	}
+	stats = eh.Stats()
+
+	if stats.Count != n || stats.GlobalDepth != eh.GlobalDepth() || stats.SplitCount != eh.SplitCount() {
+		t.Errorf("Stats() = %+v", stats)
+	}
+	if stats.DirectorySize != 1<<stats.GlobalDepth || stats.DirectorySize != eh.DirectorySize() {
+		t.Errorf("DirectorySize = %d, GlobalDepth = %d", stats.DirectorySize, stats.GlobalDepth)
+	}
+	if stats.BucketCount != eh.BucketCount() || stats.BucketCount > stats.DirectorySize {
+		t.Errorf("BucketCount = %d, BucketCount() = %d, DirectorySize = %d",
+			stats.BucketCount, eh.BucketCount(), stats.DirectorySize)
+	}
+
+	// 直方图覆盖全部桶，且各桶覆盖的目录项之和等于目录大小
+	buckets, slots := 0, 0
+	for d, c := range stats.LocalDepthHistogram {
+		buckets += c
+		slots += c << (stats.GlobalDepth - d)
+	}
+	if buckets != stats.BucketCount || slots != stats.DirectorySize {
+		t.Errorf("直方图 %v 覆盖 %d 个桶、%d 个目录项", stats.LocalDepthHistogram, buckets, slots)
+	}
+
+	if want := float64(n) / float64(stats.BucketCount*4); stats.LoadFactor != want {
+		t.Errorf("LoadFactor = %v, 期望 %v", stats.LoadFactor, want)
+	}
+	if stats.DirectoryBytes <= 0 || stats.FullBuckets > stats.BucketCount {
+		t.Errorf("DirectoryBytes = %d, FullBuckets = %d", stats.DirectoryBytes, stats.FullBuckets)
+	}
+}
+
+// TestExtendibleHashOverflowChain 测试桶满时先追加溢出页再分裂
+func TestExtendibleHashOverflowChain(t *testing.T) {
+	const n = 2000
+	plain := NewExtendibleHash(4, nil)
+	chained := NewExtendibleHash(4, nil, WithOverflowChain(2))
+	for i := 0; i < n; i++ {
+		plain.Insert(i, i)
+		chained.Insert(i, i)
+	}
+
+	if chained.OverflowCount() == 0 {
+		t.Fatal("应追加过溢出页")
+	}
+	if chained.SplitCount() >= plain.SplitCount() {
+		t.Errorf("溢出链分裂次数 %d 应少于直接分裂的 %d", chained.SplitCount(), plain.SplitCount())
+	}
+	stats := chained.Stats()
+	if stats.OverflowPages == 0 || stats.LoadFactor > 1 {
+		t.Errorf("Stats() OverflowPages = %d, LoadFactor = %v", stats.OverflowPages, stats.LoadFactor)
+	}
+
+	// 每个桶不超过 容量×(1+最大溢出页数)
+	chained.mu.RLock()
+	for _, bucket := range chained.directory.slots() {
+		if bucket.overflow > 2 || len(bucket.keys) > 4*(1+bucket.overflow) {
+			t.Fatalf("桶 size = %d, overflow = %d", len(bucket.keys), bucket.overflow)
+		}
+	}
+	chained.mu.RUnlock()
+
+	for i := 0; i < n; i++ {
+		if v, ok := chained.Search(i); !ok || v != i {
+			t.Fatalf("Search(%d) = %v, %v", i, v, ok)
+		}
+	}
+
+	// 删除后释放溢出页
+	for i := 0; i < n; i++ {
+		chained.Delete(i)
+	}
+	if stats := chained.Stats(); stats.OverflowPages != 0 || stats.Count != 0 {
+		t.Errorf("全部删除后 OverflowPages = %d, Count = %d", stats.OverflowPages, stats.Count)
+	}
+}
+
+// TestExtendibleHashGetOrCompute 测试并发调用时每个键只计算一次
+func TestExtendibleHashGetOrCompute(t *testing.T) {
+	eh := NewExtendibleHash(4, nil)
+	eh.Insert("existing", 1)
+
+	if v, loaded := eh.GetOrCompute("existing", func() any {
+		t.Error("键已存在时不应调用fn")
+		return nil
+	}); !loaded || v != 1 {
+		t.Errorf("GetOrCompute(existing) = %v, %v", v, loaded)
+	}
+	if v, loaded := eh.GetOrCompute(nil, func() any { return 1 }); loaded || v != nil {
+		t.Errorf("GetOrCompute(nil) = %v, %v", v, loaded)
+	}
+
+	// 大量goroutine争抢同一批键，触发分裂时也只计算一次
+	const keys, workers = 500, 8
+	var calls [keys]atomic.Int32
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := 0; k < keys; k++ {
+				v, _ := eh.GetOrCompute(k, func() any {
+					calls[k].Add(1)
+					return k * 2
+				})
+				if v != k*2 {
+					t.Errorf("GetOrCompute(%d) = %v, 期望 %d", k, v, k*2)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for k := range calls {
+		if n := calls[k].Load(); n != 1 {
+			t.Fatalf("键%d的fn执行了%d次, 期望 1", k, n)
+		}
+	}
+	if eh.Size() != keys+1 {
+		t.Errorf("Size() = %d, 期望 %d", eh.Size(), keys+1)
+	}
+}
+
+// TestExtendibleHashGrowthPolicy 测试深度上限、提前分裂和目录加倍回调
+func TestExtendibleHashGrowthPolicy(t *testing.T) {
+	var grows [][2]int
+	eh := NewExtendibleHash(4, nil, WithMaxGlobalDepth(3), WithDirectoryGrowHook(func(oldDepth, newDepth int) {
+		grows = append(grows, [2]int{oldDepth, newDepth})
+	}))
+	for i := 0; i < 200; i++ {
+		eh.Insert(i, i)
+	}
+	if eh.GlobalDepth() != 3 {
+		t.Errorf("GlobalDepth() = %d, 期望 3", eh.GlobalDepth())
+	}
+	for i := 0; i < 200; i++ {
+		if v, ok := eh.Search(i); !ok || v != i {
+			t.Fatalf("Search(%d) = %v, %v", i, v, ok)
+		}
+	}
+	if len(grows) != 3 {
+		t.Fatalf("目录加倍回调次数 = %d, 期望 3", len(grows))
+	}
+	for i, g := range grows {
+		if g != [2]int{i, i + 1} {
+			t.Errorf("第%d次回调参数 = %v, 期望 [%d %d]", i, g, i, i+1)
+		}
+	}
+	if stats := eh.Stats(); stats.BucketCount != eh.BucketCount() || stats.BucketCount != 8 {
+		t.Errorf("Stats().BucketCount = %d, BucketCount() = %d, 期望 8", stats.BucketCount, eh.BucketCount())
+	}
+
+	// 提前分裂使整体负载因子保持在阈值附近
+	plain := NewExtendibleHash(16, nil)
+	eager := NewExtendibleHash(16, nil, WithSplitLoadFactor(0.5))
+	for i := 0; i < 5000; i++ {
+		plain.Insert(i, i)
+		eager.Insert(i, i)
+	}
+	p, e := plain.Stats(), eager.Stats()
+	if e.LoadFactor >= p.LoadFactor || e.LoadFactor > 0.6 {
+		t.Errorf("提前分裂后负载因子 = %.2f, 未提前分裂 = %.2f", e.LoadFactor, p.LoadFactor)
+	}
+	if e.BucketCount != eager.BucketCount() {
+		t.Errorf("Stats().BucketCount = %d, BucketCount() = %d", e.BucketCount, eager.BucketCount())
+	}
+	for i := 0; i < 5000; i++ {
+		if v, ok := eager.Search(i); !ok || v != i {
+			t.Fatalf("Search(%d) = %v, %v", i, v, ok)
+		}
+	}
+
+	// 删除合并后桶数仍与实际一致
+	for i := 0; i < 4900; i++ {
+		eager.Delete(i)
+	}
+	if e := eager.Stats(); e.BucketCount != eager.BucketCount() {
+		t.Errorf("删除后 Stats().BucketCount = %d, BucketCount() = %d", e.BucketCount, eager.BucketCount())
+	}
+}
+
+// TestExtendibleHashBytesKeys 测试[]byte和string专用接口与通用接口互通
+func TestExtendibleHashBytesKeys(t *testing.T) {
+	eh := NewExtendibleHash(4, nil)
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("blob-%d", i))
+		if err := eh.InsertBytes(key, i); err != nil {
+			t.Fatalf("InsertBytes(%s) 失败: %v", key, err)
+		}
+		key[0] = 'X' // 键已被复制，修改不影响表中的键
+	}
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("blob-%d", i))
+		if v, ok := eh.SearchBytes(key); !ok || v != i {
+			t.Fatalf("SearchBytes(%s) = %v, %v", key, v, ok)
+		}
+		if v, ok := eh.Search(key); !ok || v != i {
+			t.Fatalf("Search(%s) = %v, %v", key, v, ok)
+		}
+		if _, ok := eh.SearchString(string(key)); ok {
+			t.Fatalf("SearchString(%s) 不应找到[]byte键", key)
+		}
+	}
+
+	eh.InsertString("name", "a")
+	if v, ok := eh.Search("name"); !ok || v != "a" {
+		t.Errorf("Search(name) = %v, %v", v, ok)
+	}
+	eh.Insert("name", "b")
+	if v, ok := eh.SearchString("name"); !ok || v != "b" {
+		t.Errorf("SearchString(name) = %v, %v", v, ok)
+	}
+	if !eh.DeleteString("name") || eh.DeleteString("name") {
+		t.Error("DeleteString(name) 应只成功一次")
+	}
+	if !eh.DeleteBytes([]byte("blob-7")) || eh.DeleteBytes([]byte("blob-7")) {
+		t.Error("DeleteBytes(blob-7) 应只成功一次")
+	}
+	if eh.Size() != 99 {
+		t.Errorf("Size() = %d, 期望 99", eh.Size())
+	}
+
+	// 自定义编码器时回退到通用路径
+	custom := NewExtendibleHash(4, nil, WithKeyEncoder(KeyEncoderFunc(func(dst []byte, key any) ([]byte, error) {
+		return fmt.Appendf(dst, "%s", key), nil
+	})))
+	custom.InsertBytes([]byte("k"), 1)
+	if v, ok := custom.SearchString("k"); !ok || v != 1 {
+		t.Errorf("自定义编码器下 SearchString(k) = %v, %v", v, ok)
+	}
+}
+
+// TestExtendibleHashSplitReusesHashes 测试分裂与合并复用桶中保存的编码和哈希值，
+// 每次操作只编码、哈希一次，与分裂次数无关
+func TestExtendibleHashSplitReusesHashes(t *testing.T) {
+	var encodes, hashes int
+	encoder := KeyEncoderFunc(func(dst []byte, key any) ([]byte, error) {
+		encodes++
+		return DefaultKeyEncoder{}.AppendKey(dst, key)
+	})
+	hashFunc := func(data []byte) uint32 {
+		hashes++
+		return defaultHash(data)
+	}
+	eh := NewExtendibleHash(2, hashFunc, WithKeyEncoder(encoder))
+
+	const n = 1000
+	for i := 0; i < n; i++ {
+		eh.Insert(i, i)
+	}
+	if eh.SplitCount() == 0 {
+		t.Fatal("期望发生分裂")
+	}
+	if encodes != n || hashes != n {
+		t.Errorf("插入%d个键: 编码%d次, 哈希%d次", n, encodes, hashes)
+	}
+
+	for i := 0; i < n; i++ {
+		eh.Delete(i)
+	}
+	if eh.MergeCount() == 0 {
+		t.Fatal("期望发生合并")
+	}
+	if encodes != 2*n || hashes != 2*n {
+		t.Errorf("插入并删除%d个键: 编码%d次, 哈希%d次", n, encodes, hashes)
+	}
+}
+
+// TestExtendibleHash64BitHash 测试64位哈希函数与32位适配器
+func TestExtendibleHash64BitHash(t *testing.T) {
+	fnv64 := func(data []byte) uint64 {
+		h := fnv.New64a()
+		h.Write(data)
+		return h.Sum64()
+	}
+	eh := NewExtendibleHash(4, nil, WithHashFunc64(fnv64), WithExtendibleHashCodec(IntCodec{}, IntCodec{}))
+	const n = 2000
+	for i := 0; i < n; i++ {
+		eh.Insert(i, i)
+	}
+
+	// 桶中保存完整的64位哈希值
+	high := false
+	eh.mu.RLock()
+	for i, bucket := range eh.directory.slots() {
+		mask := depthMask(bucket.localDepth)
+		for _, h := range bucket.hashes {
+			high = high || h>>32 != 0
+			if h&mask != uint64(i)&mask {
+				t.Fatalf("目录项%d的桶（localDepth=%d）包含不属于它的哈希值%#x", i, bucket.localDepth, h)
+			}
+		}
+	}
+	eh.mu.RUnlock()
+	if !high {
+		t.Error("期望桶中保存64位哈希值")
+	}
+
+	var buf bytes.Buffer
+	if _, err := eh.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo 失败: %v", err)
+	}
+	restored := NewExtendibleHash(4, nil, WithHashFunc64(fnv64), WithExtendibleHashCodec(IntCodec{}, IntCodec{}))
+	if _, err := restored.ReadFrom(&buf); err != nil {
+		t.Fatalf("ReadFrom 失败: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		if v, ok := restored.Search(i); !ok || v != i {
+			t.Fatalf("恢复后 Search(%d) = %v, %v", i, v, ok)
+		}
+	}
+
+	// 适配的32位哈希函数与直接传入的行为一致
+	a := NewExtendibleHash(4, defaultHash)
+	b := NewExtendibleHash(4, nil, WithHashFunc64(Hash64From32(defaultHash)))
+	for i := 0; i < 500; i++ {
+		a.Insert(i, i)
+		b.Insert(i, i)
+	}
+	if a.GlobalDepth() != b.GlobalDepth() || a.BucketCount() != b.BucketCount() {
+		t.Errorf("适配后 深度/桶数 = %d/%d, 期望 %d/%d", b.GlobalDepth(), b.BucketCount(), a.GlobalDepth(), a.BucketCount())
+	}
+
+	// 全局深度为64时目录索引使用全部位
+	if depthMask(64) != ^uint64(0) || depthMask(0) != 0 {
+		t.Errorf("depthMask(64) = %#x, depthMask(0) = %#x", depthMask(64), depthMask(0))
+	}
+}
+
+// TestExtendibleHashMapConversion 测试与Go map之间的批量转换
+func TestExtendibleHashMapConversion(t *testing.T) {
+	src := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		src[fmt.Sprintf("key-%d", i)] = i
+	}
+
+	eh := NewExtendibleHash(8, nil)
+	eh.Insert("key-1", -1)
+	if err := FromMap(eh, src); err != nil {
+		t.Fatalf("FromMap 失败: %v", err)
+	}
+	if eh.Size() != int64(len(src)) {
+		t.Fatalf("Size() = %d, 期望 %d", eh.Size(), len(src))
+	}
+	if v, _ := eh.Search("key-1"); v != 1 {
+		t.Errorf("已存在的键应被覆盖, Search(key-1) = %v", v)
+	}
+
+	m := eh.ToMap()
+	if len(m) != len(src) {
+		t.Fatalf("len(ToMap()) = %d, 期望 %d", len(m), len(src))
+	}
+	for k, v := range src {
+		if m[k] != v {
+			t.Fatalf("ToMap()[%s] = %v, 期望 %d", k, m[k], v)
+		}
+	}
+
+	// []byte键转换为string
+	eh.InsertBytes([]byte("blob"), "b")
+	if m := eh.ToMap(); m["blob"] != "b" {
+		t.Errorf("ToMap()[blob] = %v", m["blob"])
+	}
+
+	// 接口类型的nil键被拒绝
+	if err := FromMap(NewExtendibleHash(8, nil), map[any]int{nil: 1}); err == nil {
+		t.Error("nil键应返回错误")
+	}
+}
+
+// TestExtendibleHashCompareAndSwap 测试CompareAndSwap和UpdateIfExists的原子性
+func TestExtendibleHashCompareAndSwap(t *testing.T) {
+	eh := NewExtendibleHash(4, nil)
+	if eh.CompareAndSwap("missing", nil, 1) {
+		t.Error("不存在的键不应CAS成功")
+	}
+	if eh.UpdateIfExists("missing", func(any) any { t.Error("键不存在时不应调用fn"); return nil }) {
+		t.Error("不存在的键不应更新成功")
+	}
+	if eh.Size() != 0 {
+		t.Errorf("Size() = %d, 期望 0", eh.Size())
+	}
+
+	eh.Insert("k", 1)
+	if eh.CompareAndSwap("k", 2, 3) {
+		t.Error("旧值不匹配时不应CAS成功")
+	}
+	if !eh.CompareAndSwap("k", 1, 2) {
+		t.Error("旧值匹配时CAS应成功")
+	}
+	if v, _ := eh.Search("k"); v != 2 {
+		t.Errorf("CAS后 Search(k) = %v, 期望 2", v)
+	}
+	// 不可比较的当前值与可比较的old比较时不会panic
+	eh.Insert("slice", []int{1})
+	if eh.CompareAndSwap("slice", 1, 2) {
+		t.Error("类型不同时不应CAS成功")
+	}
+
+	// 并发CAS自增与UpdateIfExists自增都不丢失更新
+	eh.Insert("counter", 0)
+	const workers, rounds = 8, 200
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				if w%2 == 0 {
+					eh.UpdateIfExists("counter", func(old any) any { return old.(int) + 1 })
+					continue
+				}
+				for {
+					old, _ := eh.Search("counter")
+					if eh.CompareAndSwap("counter", old, old.(int)+1) {
+						break
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	if v, _ := eh.Search("counter"); v != workers*rounds {
+		t.Errorf("counter = %v, 期望 %d", v, workers*rounds)
+	}
+}
+
+// TestExtendibleHashEqualFunc 测试自定义键哈希和相等判断
+func TestExtendibleHashEqualFunc(t *testing.T) {
+	foldHash := func(key any) uint64 {
+		return FNV64aHash([]byte(strings.ToLower(fmt.Sprint(key))))
+	}
+	foldEqual := func(a, b any) bool {
+		return strings.EqualFold(fmt.Sprint(a), fmt.Sprint(b))
+	}
+	codec := WithExtendibleHashCodec(StringCodec{}, IntCodec{})
+	eh := NewExtendibleHash(2, nil, WithEqualFunc(foldHash, foldEqual), codec)
+
+	// 大小写不敏感：不同写法是同一个键，分裂后仍能找到
+	for i := 0; i < 100; i++ {
+		eh.Insert(fmt.Sprintf("Key-%d", i), i)
+	}
+	for i := 0; i < 100; i++ {
+		eh.Insert(fmt.Sprintf("KEY-%d", i), i*10)
+	}
+	if eh.Size() != 100 {
+		t.Errorf("Size() = %d, 期望 100", eh.Size())
+	}
+	for i := 0; i < 100; i++ {
+		if v, ok := eh.Search(fmt.Sprintf("key-%d", i)); !ok || v != i*10 {
+			t.Errorf("Search(key-%d) = %v, %v, 期望 %d", i, v, ok, i*10)
+		}
+	}
+	if v, ok := eh.SearchString("kEy-7"); !ok || v != 70 {
+		t.Errorf("SearchString(kEy-7) = %v, %v, 期望 70", v, ok)
+	}
+	if !eh.Delete("KEY-0") || !eh.DeleteString("key-1") {
+		t.Error("不同大小写的Delete应成功")
+	}
+	if _, ok := eh.Search("Key-0"); ok {
+		t.Error("删除后不应找到Key-0")
+	}
+
+	// 快照加载时用自定义哈希重新定位键
+	var buf bytes.Buffer
+	if _, err := eh.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() 错误 = %v", err)
+	}
+	restored := NewExtendibleHash(2, nil, WithEqualFunc(foldHash, foldEqual), codec)
+	if _, err := restored.ReadFrom(&buf); err != nil {
+		t.Fatalf("ReadFrom() 错误 = %v", err)
+	}
+	if v, ok := restored.Search("key-50"); !ok || v != 500 || restored.Size() != 98 {
+		t.Errorf("加载后 Search(key-50) = %v, %v, Size() = %d", v, ok, restored.Size())
+	}
+
+	// 复合键只比较部分字段
+	type point struct {
+		X, Y int
+		Tag  string
+	}
+	pointHash := func(key any) uint64 {
+		p := key.(point)
+		return murmurFmix64(uint64(p.X)<<32 | uint64(uint32(p.Y)))
+	}
+	pointEqual := func(a, b any) bool {
+		pa, pb := a.(point), b.(point)
+		return pa.X == pb.X && pa.Y == pb.Y
+	}
+	points := NewExtendibleHash(4, nil, WithEqualFunc(pointHash, pointEqual))
+	for x := 0; x < 20; x++ {
+		for y := 0; y < 20; y++ {
+			points.Insert(point{x, y, "a"}, x*100+y)
+		}
+	}
+	if v, ok := points.Search(point{3, 4, "b"}); !ok || v != 304 {
+		t.Errorf("Search({3 4 b}) = %v, %v, 期望 304", v, ok)
+	}
+	if !points.CompareAndSwap(point{3, 4, "c"}, 304, -1) {
+		t.Error("Tag不同的复合键CAS应成功")
+	}
+	if points.Size() != 400 {
+		t.Errorf("Size() = %d, 期望 400", points.Size())
+	}
+
+	// 与LRU淘汰组合时新插入的键不会被当作淘汰对象
+	lru := NewExtendibleHash(4, nil, WithEqualFunc(foldHash, foldEqual), WithLRUEviction(10, nil))
+	for i := 0; i < 50; i++ {
+		lru.Insert(fmt.Sprintf("K%d", i), i)
+		if _, ok := lru.Search(fmt.Sprintf("k%d", i)); !ok {
+			t.Fatalf("刚插入的K%d被淘汰", i)
+		}
+	}
+	if lru.Size() != 10 {
+		t.Errorf("LRU Size() = %d, 期望 10", lru.Size())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("WithEqualFunc(nil, equal) 应panic")
+		}
+	}()
+	WithEqualFunc(nil, foldEqual)
+}
+
+// TestExtendibleHashWithoutLocking 测试无锁模式下的基本操作和ForEach中的删除
+func TestExtendibleHashWithoutLocking(t *testing.T) {
+	eh := NewExtendibleHash(4, nil, WithoutLocking())
+	if eh.Describe().Concurrency != ConcurrencyNone {
+		t.Errorf("Describe().Concurrency = %q, 期望 %q", eh.Describe().Concurrency, ConcurrencyNone)
+	}
+	for i := 0; i < 1000; i++ {
+		eh.Insert(i, i)
+	}
+	for i := 0; i < 1000; i++ {
+		if v, ok := eh.Search(i); !ok || v != i {
+			t.Fatalf("Search(%d) = %v, %v", i, v, ok)
+		}
+	}
+	bucketsBefore := eh.BucketCount()
+
+	// ForEach回调中删除时跳过合并，遍历仍然完整
+	visited := 0
+	eh.ForEach(func(key, _ any) bool {
+		visited++
+		if key.(int)%2 == 0 {
+			eh.Delete(key)
+		}
+		return true
+	})
+	if visited != 1000 || eh.Size() != 500 {
+		t.Errorf("遍历 %d 个键, Size() = %d, 期望 1000 和 500", visited, eh.Size())
+	}
+	if eh.MergeCount() != 0 {
+		t.Errorf("ForEach中的删除不应合并, MergeCount() = %d", eh.MergeCount())
+	}
+
+	// 回调之外的删除正常合并
+	for i := 1; i < 1000; i += 2 {
+		if !eh.Delete(i) {
+			t.Fatalf("Delete(%d) 失败", i)
+		}
+	}
+	if eh.Size() != 0 || eh.MergeCount() == 0 || eh.BucketCount() >= bucketsBefore {
+		t.Errorf("全部删除后 Size() = %d, MergeCount() = %d, BucketCount() = %d",
+			eh.Size(), eh.MergeCount(), eh.BucketCount())
+	}
+}
+
+// TestExtendibleHashClone 测试副本与原表相互独立
+func TestExtendibleHashClone(t *testing.T) {
+	eh := NewExtendibleHash(4, nil)
+	for i := 0; i < 500; i++ {
+		eh.Insert(i, i)
+	}
+	clone := eh.Clone()
+	if clone.Size() != 500 || clone.BucketCount() != eh.BucketCount() || clone.DirectorySize() != eh.DirectorySize() {
+		t.Errorf("副本 Size() = %d, BucketCount() = %d, DirectorySize() = %d, 与原表不一致",
+			clone.Size(), clone.BucketCount(), clone.DirectorySize())
+	}
+	if clone.SplitCount() != eh.SplitCount() {
+		t.Errorf("副本 SplitCount() = %d, 期望 %d", clone.SplitCount(), eh.SplitCount())
+	}
+
+	// 原表的修改（包括分裂与合并）不影响副本
+	for i := 0; i < 250; i++ {
+		eh.Delete(i)
+	}
+	for i := 500; i < 1000; i++ {
+		eh.Insert(i, i)
+	}
+	eh.Insert(300, "changed")
+	for i := 0; i < 500; i++ {
+		if v, ok := clone.Search(i); !ok || v != i {
+			t.Fatalf("副本 Search(%d) = %v, %v, 期望 %d", i, v, ok, i)
+		}
+	}
+	if _, ok := clone.Search(700); ok || clone.Size() != 500 {
+		t.Errorf("副本不应看到原表之后的插入, Size() = %d", clone.Size())
+	}
+
+	// 副本的修改不影响原表
+	clone.Insert(900, "clone")
+	clone.Delete(400)
+	if v, _ := eh.Search(900); v != 900 {
+		t.Errorf("原表 Search(900) = %v, 期望 900", v)
+	}
+	if _, ok := eh.Search(400); !ok {
+		t.Error("副本的删除影响了原表")
+	}
+
+	// 并发写入期间复制得到一致的快照
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				eh.Insert(1000+i%5000, i)
+			}
+		}
+	}()
+	for r := 0; r < 20; r++ {
+		snapshot := eh.Clone()
+		var n int64
+		snapshot.ForEach(func(_, _ any) bool {
+			n++
+			return true
+		})
+		if n != snapshot.Size() {
+			t.Errorf("快照遍历 %d 个键, Size() = %d", n, snapshot.Size())
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+// TestExtendibleHashRehash 测试以新容量重建后深度最小且内容不变
+func TestExtendibleHashRehash(t *testing.T) {
+	if err := NewExtendibleHash(4, nil).Rehash(0); err == nil {
+		t.Error("Rehash(0) 应返回错误")
+	}
+
+	// 关闭合并，大量删除后留下稀疏的桶和深目录
+	eh := NewExtendibleHash(4, nil, WithMergeThreshold(-1))
+	for i := 0; i < 4000; i++ {
+		eh.Insert(i, i)
+	}
+	for i := 0; i < 4000; i++ {
+		if i%100 != 0 {
+			eh.Delete(i)
+		}
+	}
+	bucketsBefore, dirBefore := eh.BucketCount(), eh.DirectorySize()
+
+	if err := eh.Rehash(4); err != nil {
+		t.Fatalf("Rehash(4) 错误 = %v", err)
+	}
+	if eh.BucketCount() >= bucketsBefore || eh.DirectorySize() >= dirBefore {
+		t.Errorf("重建后 BucketCount() = %d, DirectorySize() = %d, 重建前 %d, %d",
+			eh.BucketCount(), eh.DirectorySize(), bucketsBefore, dirBefore)
+	}
+	if eh.Size() != 40 {
+		t.Errorf("Size() = %d, 期望 40", eh.Size())
+	}
+	for i := 0; i < 4000; i += 100 {
+		if v, ok := eh.Search(i); !ok || v != i {
+			t.Fatalf("Search(%d) = %v, %v", i, v, ok)
+		}
+	}
+	// 全局深度恰好是最深的桶所需的深度
+	stats := eh.Stats()
+	if stats.LocalDepthHistogram[stats.GlobalDepth] == 0 || stats.OverflowPages != 0 {
+		t.Errorf("Stats() = %+v, 全局深度应等于最大局部深度且没有溢出页", stats)
+	}
+
+	// 容量足以容纳全部键时只剩一个桶
+	if err := eh.Rehash(64); err != nil {
+		t.Fatalf("Rehash(64) 错误 = %v", err)
+	}
+	if eh.BucketCount() != 1 || eh.DirectorySize() != 1 || eh.Stats().BucketCapacity != 64 {
+		t.Errorf("Rehash(64) 后 BucketCount() = %d, DirectorySize() = %d", eh.BucketCount(), eh.DirectorySize())
+	}
+
+	// 重建后继续插入和删除
+	for i := 0; i < 1000; i++ {
+		eh.Insert(i, -i)
+	}
+	for i := 0; i < 1000; i++ {
+		if v, _ := eh.Search(i); v != -i {
+			t.Fatalf("重建后插入 Search(%d) = %v, 期望 %d", i, v, -i)
+		}
+	}
+}
+
+// TestExtendibleHashEventHooks 测试分裂、目录加倍和溢出页的回调与计数
+func TestExtendibleHashEventHooks(t *testing.T) {
+	var splits, grows, overflows int64
+	maxDepth := 0
+	eh := NewExtendibleHash(4, nil,
+		WithOverflowChain(1),
+		WithSplitHook(func(localDepth int) {
+			splits++
+			maxDepth = max(maxDepth, localDepth)
+		}),
+		WithDirectoryGrowHook(func(oldDepth, newDepth int) { grows++ }),
+		WithOverflowHook(func(overflowPages int) {
+			if overflowPages != 1 {
+				t.Errorf("溢出页数量 = %d, 期望 1", overflowPages)
+			}
+			overflows++
+		}))
+	for i := 0; i < 2000; i++ {
+		eh.Insert(i, i)
+	}
+
+	stats := eh.Stats()
+	if splits == 0 || splits != eh.SplitCount() {
+		t.Errorf("分裂回调 %d 次, SplitCount() = %d", splits, eh.SplitCount())
+	}
+	if grows == 0 || grows != eh.DoublingCount() || grows != stats.DoublingCount {
+		t.Errorf("加倍回调 %d 次, DoublingCount() = %d, Stats().DoublingCount = %d", grows, eh.DoublingCount(), stats.DoublingCount)
+	}
+	if stats.DoublingCount != int64(stats.GlobalDepth) {
+		t.Errorf("DoublingCount = %d, 全局深度 %d", stats.DoublingCount, stats.GlobalDepth)
+	}
+	if overflows == 0 || overflows != eh.OverflowCount() {
+		t.Errorf("溢出回调 %d 次, OverflowCount() = %d", overflows, eh.OverflowCount())
+	}
+	if maxDepth != stats.GlobalDepth {
+		t.Errorf("分裂回调的最大局部深度 = %d, 全局深度 = %d", maxDepth, stats.GlobalDepth)
+	}
+}
@@ -0,0 +1,365 @@
+package datastructures
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// geohashBase32 Geohash使用的Base32字母表（去掉了a、i、l、o）
+const geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+const (
+	// GeohashMaxPrecision Geohash最大精度（字符数）
+	GeohashMaxPrecision = 12
+	// earthRadiusMeters 地球平均半径（米）
+	earthRadiusMeters = 6371008.8
+	// metersPerDegree 赤道处每度对应的米数
+	metersPerDegree = 111320.0
+)
+
+// geohashDecodeMap 字符到5位编码的映射
+var geohashDecodeMap = func() [256]int8 {
+	var m [256]int8
+	for i := range m {
+		m[i] = -1
+	}
+	for i := 0; i < len(geohashBase32); i++ {
+		m[geohashBase32[i]] = int8(i)
+	}
+	return m
+}()
+
+// GeoBox Geohash单元格对应的经纬度矩形
+type GeoBox struct {
+	MinLat, MaxLat float64
+	MinLon, MaxLon float64
+}
+
+// Center 返回矩形中心点
+func (b GeoBox) Center() (lat, lon float64) {
+	return (b.MinLat + b.MaxLat) / 2, (b.MinLon + b.MaxLon) / 2
+}
+
+// GeohashEncode 将经纬度编码为指定精度的Geohash
+// precision: 字符数，取值范围[1, 12]
+func GeohashEncode(lat, lon float64, precision int) (string, error) {
+	if precision < 1 || precision > GeohashMaxPrecision {
+		return "", fmt.Errorf("precision must be in [1, %d]", GeohashMaxPrecision)
+	}
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return "", fmt.Errorf("invalid coordinate (%v, %v)", lat, lon)
+	}
+
+	latLo, latHi := -90.0, 90.0
+	lonLo, lonHi := -180.0, 180.0
+	var sb strings.Builder
+	sb.Grow(precision)
+
+	even := true // 偶数位编码经度，奇数位编码纬度
+	bit, ch := 0, 0
+	for sb.Len() < precision {
+		if even {
+			mid := (lonLo + lonHi) / 2
+			if lon >= mid {
+				ch = ch<<1 | 1
+				lonLo = mid
+			} else {
+				ch <<= 1
+				lonHi = mid
+			}
+		} else {
+			mid := (latLo + latHi) / 2
+			if lat >= mid {
+				ch = ch<<1 | 1
+				latLo = mid
+			} else {
+				ch <<= 1
+				latHi = mid
+			}
+		}
+		even = !even
+
+		if bit++; bit == 5 {
+			sb.WriteByte(geohashBase32[ch])
+			bit, ch = 0, 0
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// GeohashDecodeBox 将Geohash解码为对应的经纬度矩形
+func GeohashDecodeBox(hash string) (GeoBox, error) {
+	if hash == "" {
+		return GeoBox{}, fmt.Errorf("geohash cannot be empty")
+	}
+
+	box := GeoBox{MinLat: -90, MaxLat: 90, MinLon: -180, MaxLon: 180}
+	even := true
+	for i := 0; i < len(hash); i++ {
+		v := geohashDecodeMap[hash[i]]
+		if v < 0 {
+			return GeoBox{}, fmt.Errorf("invalid geohash character %q", hash[i])
+		}
+		for mask := 16; mask > 0; mask >>= 1 {
+			if even {
+				mid := (box.MinLon + box.MaxLon) / 2
+				if int(v)&mask != 0 {
+					box.MinLon = mid
+				} else {
+					box.MaxLon = mid
+				}
+			} else {
+				mid := (box.MinLat + box.MaxLat) / 2
+				if int(v)&mask != 0 {
+					box.MinLat = mid
+				} else {
+					box.MaxLat = mid
+				}
+			}
+			even = !even
+		}
+	}
+
+	return box, nil
+}
+
+// GeohashDecode 将Geohash解码为单元格中心点的经纬度
+func GeohashDecode(hash string) (lat, lon float64, err error) {
+	box, err := GeohashDecodeBox(hash)
+	if err != nil {
+		return 0, 0, err
+	}
+	lat, lon = box.Center()
+	return lat, lon, nil
+}
+
+// GeohashNeighbors 返回Geohash单元格周围的8个相邻单元格
+// 经度方向跨越±180°时自动回绕，纬度方向超出两极的单元格被忽略
+func GeohashNeighbors(hash string) ([]string, error) {
+	box, err := GeohashDecodeBox(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	lat, lon := box.Center()
+	dLat := box.MaxLat - box.MinLat
+	dLon := box.MaxLon - box.MinLon
+
+	neighbors := make([]string, 0, 8)
+	for _, dy := range []float64{-1, 0, 1} {
+		for _, dx := range []float64{-1, 0, 1} {
+			if dx == 0 && dy == 0 {
+				continue
+			}
+			nLat := lat + dy*dLat
+			if nLat < -90 || nLat > 90 {
+				continue
+			}
+			nLon := lon + dx*dLon
+			if nLon < -180 {
+				nLon += 360
+			} else if nLon > 180 {
+				nLon -= 360
+			}
+			n, _ := GeohashEncode(nLat, nLon, len(hash))
+			neighbors = append(neighbors, n)
+		}
+	}
+
+	return neighbors, nil
+}
+
+// HaversineDistance 计算两个经纬度之间的大圆距离（米）
+func HaversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLon := (lon2 - lon1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// geohashPrecisionForRadius 选择单元格宽高均不小于radius的最大精度
+// 宽度按查询范围最靠近极点处的纬度计算：范围内的点可能比中心更靠近极点，那里每度经度更短，
+// 按中心纬度计算会使3×3邻域在东西方向上覆盖不到半径之内的点
+// 返回0表示半径超过最粗粒度单元格或查询范围包含极点，需要全表扫描
+func geohashPrecisionForRadius(lat, radius float64) int {
+	poleward := math.Abs(lat) + radius/metersPerDegree
+	if poleward >= 90 {
+		return 0
+	}
+	cosLat := math.Cos(poleward * math.Pi / 180)
+	for p := GeohashMaxPrecision; p >= 1; p-- {
+		bits := 5 * p
+		lonBits := (bits + 1) / 2
+		latBits := bits / 2
+		height := 180 / math.Exp2(float64(latBits)) * metersPerDegree
+		width := 360 / math.Exp2(float64(lonBits)) * metersPerDegree * cosLat
+		if height >= radius && width >= radius {
+			return p
+		}
+	}
+	return 0
+}
+
+// GeoPoint 地理位置点
+type GeoPoint struct {
+	ID       string  // 点的唯一标识
+	Lat      float64 // 纬度
+	Lon      float64 // 经度
+	Distance float64 // 距查询中心的距离（米），仅在查询结果中有效
+}
+
+// GeoIndex 基于Geohash键的B+树空间索引
+// 特点：
+// - 键为"geohash|id"，相邻位置在B+树中按前缀聚集
+// - 邻近查询展开为中心单元格及8个相邻单元格的前缀范围扫描
+// - 扫描结果再按大圆距离精确过滤
+type GeoIndex struct {
+	tree   *BPlusTree          // 以Geohash为键的有序索引
+	points map[string]GeoPoint // ID到位置的映射，用于更新和删除
+	mu     sync.RWMutex        // 读写锁
+}
+
+// NewGeoIndex 创建新的空间索引
+// order: 底层B+树的阶数
+func NewGeoIndex(order int) *GeoIndex {
+	return &GeoIndex{
+		tree:   NewBPlusTree(order, stringComparator),
+		points: make(map[string]GeoPoint),
+	}
+}
+
+// stringComparator 字符串比较函数
+func stringComparator(a, b any) int {
+	return strings.Compare(a.(string), b.(string))
+}
+
+// geoIndexKey 生成B+树中的复合键
+func geoIndexKey(hash, id string) string {
+	return hash + "|" + id
+}
+
+// Add 添加或更新一个位置点
+func (g *GeoIndex) Add(id string, lat, lon float64) error {
+	hash, err := GeohashEncode(lat, lon, GeohashMaxPrecision)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if old, ok := g.points[id]; ok {
+		oldHash, _ := GeohashEncode(old.Lat, old.Lon, GeohashMaxPrecision)
+		g.tree.Delete(geoIndexKey(oldHash, id))
+	}
+
+	p := GeoPoint{ID: id, Lat: lat, Lon: lon}
+	g.points[id] = p
+	return g.tree.Insert(geoIndexKey(hash, id), p)
+}
+
+// Remove 删除一个位置点
+func (g *GeoIndex) Remove(id string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p, ok := g.points[id]
+	if !ok {
+		return false
+	}
+	hash, _ := GeohashEncode(p.Lat, p.Lon, GeohashMaxPrecision)
+	delete(g.points, id)
+	return g.tree.Delete(geoIndexKey(hash, id))
+}
+
+// Get 获取位置点
+func (g *GeoIndex) Get(id string) (GeoPoint, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	p, ok := g.points[id]
+	return p, ok
+}
+
+// Size 返回位置点数量
+func (g *GeoIndex) Size() int64 {
+	return g.tree.Size()
+}
+
+// NearbyQuery 查询距(lat, lon)不超过radius米的所有点，按距离升序返回
+func (g *GeoIndex) NearbyQuery(lat, lon, radius float64) ([]GeoPoint, error) {
+	if radius < 0 {
+		return nil, fmt.Errorf("radius must be >= 0")
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var candidates []KeyValue
+	precision := geohashPrecisionForRadius(lat, radius)
+	if precision == 0 {
+		candidates = g.tree.ScanAll()
+	} else {
+		center, err := GeohashEncode(lat, lon, precision)
+		if err != nil {
+			return nil, err
+		}
+		neighbors, _ := GeohashNeighbors(center)
+
+		seen := make(map[string]bool, 9)
+		for _, prefix := range append([]string{center}, neighbors...) {
+			if seen[prefix] {
+				continue
+			}
+			seen[prefix] = true
+
+			// '~'大于所有Base32字符和分隔符'|'，[prefix, prefix~)覆盖该单元格内的全部键
+			kvs, err := g.tree.RangeQuery(prefix, prefix+"~")
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, kvs...)
+		}
+	}
+
+	var result []GeoPoint
+	for _, kv := range candidates {
+		p := kv.Value.(GeoPoint)
+		if d := HaversineDistance(lat, lon, p.Lat, p.Lon); d <= radius {
+			p.Distance = d
+			result = append(result, p)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Distance != result[j].Distance {
+			return result[i].Distance < result[j].Distance
+		}
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
+
+// Describe 返回空间索引的能力描述
+func (g *GeoIndex) Describe() Descriptor {
+	return Descriptor{
+		Name:           "GeoIndex",
+		Ordered:        true,
+		SupportsDelete: true,
+		SupportsRange:  true,
+		Concurrency:    ConcurrencyRWMutex,
+		Complexity: Complexity{
+			Insert: "O(log n)",
+			Search: "O(1) 按ID",
+			Delete: "O(log n)",
+			Range:  "O(log n + k) 邻近查询",
+		},
+		Notes: "以Geohash为键的B+树，邻近查询展开为9个前缀范围扫描",
+	}
+}
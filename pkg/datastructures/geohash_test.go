@@ -0,0 +1,109 @@
+package datastructures
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// TestGeohashEncodeDecode 测试Geohash编解码
+func TestGeohashEncodeDecode(t *testing.T) {
+	hash, err := GeohashEncode(57.64911, 10.40744, 11)
+	if err != nil {
+		t.Fatalf("GeohashEncode() 错误 = %v", err)
+	}
+	if hash != "u4pruydqqvj" {
+		t.Errorf("GeohashEncode() = %s, 期望 u4pruydqqvj", hash)
+	}
+
+	lat, lon, err := GeohashDecode(hash)
+	if err != nil {
+		t.Fatalf("GeohashDecode() 错误 = %v", err)
+	}
+	if math.Abs(lat-57.64911) > 1e-4 || math.Abs(lon-10.40744) > 1e-4 {
+		t.Errorf("GeohashDecode() = (%v, %v), 期望接近 (57.64911, 10.40744)", lat, lon)
+	}
+
+	if _, err := GeohashEncode(91, 0, 5); err == nil {
+		t.Error("非法纬度应该返回错误")
+	}
+	if _, err := GeohashDecodeBox("abc"); err == nil {
+		t.Error("包含非法字符的Geohash应该返回错误")
+	}
+
+	neighbors, _ := GeohashNeighbors("u4pru")
+	if len(neighbors) != 8 {
+		t.Errorf("GeohashNeighbors() 返回 %d 个单元格, 期望 8", len(neighbors))
+	}
+}
+
+// TestGeoIndexNearbyQuery 测试邻近查询
+func TestGeoIndexNearbyQuery(t *testing.T) {
+	idx := NewGeoIndex(16)
+
+	// 以(39.9, 116.4)为中心，沿纬度方向每隔约111米放置一个点
+	for i := 0; i < 50; i++ {
+		if err := idx.Add(fmt.Sprintf("p%02d", i), 39.9+float64(i)*0.001, 116.4); err != nil {
+			t.Fatalf("Add() 错误 = %v", err)
+		}
+	}
+	idx.Add("far", -33.86, 151.2)
+
+	result, err := idx.NearbyQuery(39.9, 116.4, 500)
+	if err != nil {
+		t.Fatalf("NearbyQuery() 错误 = %v", err)
+	}
+
+	// 0~4号点距离不超过500米
+	if len(result) != 5 {
+		t.Fatalf("NearbyQuery() 返回 %d 个点, 期望 5: %v", len(result), result)
+	}
+	for i, p := range result {
+		if p.ID != fmt.Sprintf("p%02d", i) {
+			t.Errorf("结果[%d] = %s, 期望按距离排序", i, p.ID)
+		}
+	}
+
+	// 移动点后旧位置不再命中
+	idx.Add("p01", 0, 0)
+	result, _ = idx.NearbyQuery(39.9, 116.4, 500)
+	if len(result) != 4 {
+		t.Errorf("移动后 NearbyQuery() 返回 %d 个点, 期望 4", len(result))
+	}
+
+	if !idx.Remove("p00") || idx.Remove("p00") {
+		t.Error("Remove() 返回值不正确")
+	}
+
+	// 超大半径退化为全表扫描
+	result, _ = idx.NearbyQuery(0, 0, 3e7)
+	if int64(len(result)) != idx.Size() {
+		t.Errorf("全球范围查询返回 %d 个点, 期望 %d", len(result), idx.Size())
+	}
+}
+
+// TestGeoIndexNearbyQueryHighLatitude 测试高纬度查询不会漏掉更靠近极点、经度差更大的点
+func TestGeoIndexNearbyQueryHighLatitude(t *testing.T) {
+	idx := NewGeoIndex(16)
+
+	// 从(70, 44.2)向东北偏东约1705千米，按中心纬度选出的单元格宽45°，该点落在3×3邻域之外
+	const lat, lon, radius = 70.0, 44.2, 1.71e6
+	idx.Add("ene", 70.1954, 90.3424)
+	idx.Add("far", 70.1954, 100)
+	if d := HaversineDistance(lat, lon, 70.1954, 90.3424); d > radius {
+		t.Fatalf("测试点距离 %.0f 米, 应在半径之内", d)
+	}
+
+	result, err := idx.NearbyQuery(lat, lon, radius)
+	if err != nil {
+		t.Fatalf("NearbyQuery() 错误 = %v", err)
+	}
+	if len(result) != 1 || result[0].ID != "ene" {
+		t.Errorf("NearbyQuery() = %v, 期望只有 ene", result)
+	}
+
+	// 查询范围包含极点时退化为全表扫描
+	if p := geohashPrecisionForRadius(89.5, 100000); p != 0 {
+		t.Errorf("geohashPrecisionForRadius(89.5, 100000) = %d, 期望 0", p)
+	}
+}
@@ -0,0 +1,178 @@
+package datastructures
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math/bits"
+)
+
+// 内置的64位哈希函数，通过WithHashFunc64选择：
+// - XXHash64: 纯Go实现，结果确定，长键的吞吐约为FNV的10倍，适合持久化的表
+// - Murmur3Hash64: MurmurHash3 x64_128的前64位，与其他语言的实现结果一致
+// - NewMapHash: 基于hash/maphash，有硬件AES指令时最快，带随机种子可抵御构造冲突的攻击；
+//   种子每个进程不同，不能用于持久化的表和快照
+// - FNV64aHash: 64位FNV-1a
+// FNV-1a（默认）逐字节处理，长键较慢，低位的分布也较差
+// 各函数的吞吐见BenchmarkHashFunc
+
+// xxhash64的常量
+const (
+	xxPrime1 uint64 = 11400714785074694791
+	xxPrime2 uint64 = 14029467366897019727
+	xxPrime3 uint64 = 1609587929392839161
+	xxPrime4 uint64 = 9650029242287828579
+	xxPrime5 uint64 = 2870177450012600261
+)
+
+// XXHash64 种子为0的xxHash64
+func XXHash64(data []byte) uint64 {
+	return xxHash64(data, 0)
+}
+
+// xxHash64 带种子的xxHash64
+func xxHash64(data []byte, seed uint64) uint64 {
+	n := len(data)
+	var h uint64
+	if n >= 32 {
+		// v1 = seed+prime1+prime2、v4 = seed-prime1，按uint64回绕计算
+		v1, v2, v3, v4 := seed+xxPrime1, seed+xxPrime2, seed, seed
+		v1 += xxPrime2
+		v4 -= xxPrime1
+		for ; len(data) >= 32; data = data[32:] {
+			v1 = xxRound(v1, binary.LittleEndian.Uint64(data[0:]))
+			v2 = xxRound(v2, binary.LittleEndian.Uint64(data[8:]))
+			v3 = xxRound(v3, binary.LittleEndian.Uint64(data[16:]))
+			v4 = xxRound(v4, binary.LittleEndian.Uint64(data[24:]))
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxMergeRound(h, v1)
+		h = xxMergeRound(h, v2)
+		h = xxMergeRound(h, v3)
+		h = xxMergeRound(h, v4)
+	} else {
+		h = seed + xxPrime5
+	}
+	h += uint64(n)
+
+	for ; len(data) >= 8; data = data[8:] {
+		h ^= xxRound(0, binary.LittleEndian.Uint64(data))
+		h = bits.RotateLeft64(h, 27)*xxPrime1 + xxPrime4
+	}
+	if len(data) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(data)) * xxPrime1
+		h = bits.RotateLeft64(h, 23)*xxPrime2 + xxPrime3
+		data = data[4:]
+	}
+	for _, b := range data {
+		h ^= uint64(b) * xxPrime5
+		h = bits.RotateLeft64(h, 11) * xxPrime1
+	}
+
+	h ^= h >> 33
+	h *= xxPrime2
+	h ^= h >> 29
+	h *= xxPrime3
+	h ^= h >> 32
+	return h
+}
+
+func xxRound(acc, input uint64) uint64 {
+	acc += input * xxPrime2
+	return bits.RotateLeft64(acc, 31) * xxPrime1
+}
+
+func xxMergeRound(acc, val uint64) uint64 {
+	acc ^= xxRound(0, val)
+	return acc*xxPrime1 + xxPrime4
+}
+
+// murmur3的常量
+const (
+	murmurC1 uint64 = 0x87c37b91114253d5
+	murmurC2 uint64 = 0x4cf5ad432745937f
+)
+
+// Murmur3Hash64 种子为0的MurmurHash3 x64_128，返回128位结果的前64位
+func Murmur3Hash64(data []byte) uint64 {
+	n := len(data)
+	var h1, h2 uint64
+	for ; len(data) >= 16; data = data[16:] {
+		k1 := binary.LittleEndian.Uint64(data[0:])
+		k2 := binary.LittleEndian.Uint64(data[8:])
+
+		h1 ^= murmurMixK1(k1)
+		h1 = bits.RotateLeft64(h1, 27) + h2
+		h1 = h1*5 + 0x52dce729
+
+		h2 ^= murmurMixK2(k2)
+		h2 = bits.RotateLeft64(h2, 31) + h1
+		h2 = h2*5 + 0x38495ab5
+	}
+
+	// 尾部不足16字节的部分按小端序拼成k1（前8字节）和k2（其余字节）
+	var k1, k2 uint64
+	for i := len(data) - 1; i >= 8; i-- {
+		k2 = k2<<8 | uint64(data[i])
+	}
+	for i := min(len(data), 8) - 1; i >= 0; i-- {
+		k1 = k1<<8 | uint64(data[i])
+	}
+	if len(data) > 8 {
+		h2 ^= murmurMixK2(k2)
+	}
+	if len(data) > 0 {
+		h1 ^= murmurMixK1(k1)
+	}
+
+	h1 ^= uint64(n)
+	h2 ^= uint64(n)
+	h1 += h2
+	h2 += h1
+	h1 = murmurFmix64(h1)
+	h2 = murmurFmix64(h2)
+	return h1 + h2
+}
+
+func murmurMixK1(k uint64) uint64 {
+	k *= murmurC1
+	k = bits.RotateLeft64(k, 31)
+	return k * murmurC2
+}
+
+func murmurMixK2(k uint64) uint64 {
+	k *= murmurC2
+	k = bits.RotateLeft64(k, 33)
+	return k * murmurC1
+}
+
+func murmurFmix64(k uint64) uint64 {
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return k
+}
+
+// NewMapHash 返回使用随机种子的hash/maphash哈希函数
+// 同一个返回值的结果是确定的，不同调用（以及不同进程）之间的种子不同
+func NewMapHash() HashFunc64 {
+	seed := maphash.MakeSeed()
+	return func(data []byte) uint64 {
+		return maphash.Bytes(seed, data)
+	}
+}
+
+// FNV64aHash 64位FNV-1a，与默认的32位FNV-1a同属一族
+func FNV64aHash(data []byte) uint64 {
+	const (
+		offset64 uint64 = 14695981039346656037
+		prime64  uint64 = 1099511628211
+	)
+	h := offset64
+	for _, b := range data {
+		h ^= uint64(b)
+		h *= prime64
+	}
+	return h
+}
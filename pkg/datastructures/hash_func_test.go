@@ -0,0 +1,61 @@
+package datastructures
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestHashFuncVectors 用参考实现的已知结果校验内置哈希函数
+func TestHashFuncVectors(t *testing.T) {
+	const fox = "The quick brown fox jumps over the lazy dog"
+	tests := []struct {
+		name string
+		fn   HashFunc64
+		in   string
+		want uint64
+	}{
+		{"XXHash64", XXHash64, "", 0xef46db3751d8e999},
+		{"XXHash64", XXHash64, "a", 0xd24ec4f1a98c6e5b},
+		{"XXHash64", XXHash64, "abc", 0x44bc2cf5ad770999},
+		{"XXHash64", XXHash64, fox, 0x0b242d361fda71bc},
+		{"Murmur3Hash64", Murmur3Hash64, "", 0},
+		{"Murmur3Hash64", Murmur3Hash64, "hello", 0xcbd8a7b341bd9b02},
+		{"Murmur3Hash64", Murmur3Hash64, fox, 0xe34bbc7bbc071b6c},
+		{"FNV64aHash", FNV64aHash, "", 0xcbf29ce484222325},
+		{"FNV64aHash", FNV64aHash, "a", 0xaf63dc4c8601ec8c},
+	}
+	for _, tt := range tests {
+		if got := tt.fn([]byte(tt.in)); got != tt.want {
+			t.Errorf("%s(%q) = %#x, 期望 %#x", tt.name, tt.in, got, tt.want)
+		}
+	}
+
+	// 同一个NewMapHash返回值的结果是确定的
+	mh := NewMapHash()
+	if mh([]byte("key")) != mh([]byte("key")) {
+		t.Error("NewMapHash 结果应确定")
+	}
+}
+
+// TestHashFuncExtendibleHash 测试每个内置哈希函数都能驱动可扩展哈希
+func TestHashFuncExtendibleHash(t *testing.T) {
+	for name, fn := range map[string]HashFunc64{
+		"XXHash64":      XXHash64,
+		"Murmur3Hash64": Murmur3Hash64,
+		"MapHash":       NewMapHash(),
+		"FNV64aHash":    FNV64aHash,
+	} {
+		eh := NewExtendibleHash(8, nil, WithHashFunc64(fn))
+		for i := 0; i < 2000; i++ {
+			eh.Insert(fmt.Sprintf("key-%d", i), i)
+		}
+		for i := 0; i < 2000; i++ {
+			if v, ok := eh.Search(fmt.Sprintf("key-%d", i)); !ok || v != i {
+				t.Fatalf("%s: Search(key-%d) = %v, %v", name, i, v, ok)
+			}
+		}
+		if stats := eh.Stats(); stats.LoadFactor < 0.3 {
+			t.Errorf("%s: 负载因子 = %.2f，哈希分布过差", name, stats.LoadFactor)
+		}
+	}
+}
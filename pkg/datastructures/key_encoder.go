@@ -0,0 +1,72 @@
+package datastructures
+
+import (
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// KeyEncoder 把键编码为字节序列，供哈希和等值比较使用
+// 两个键相等当且仅当编码结果逐字节相等，因此编码必须是确定性的
+type KeyEncoder interface {
+	// AppendKey 把key的编码追加到dst之后并返回新的切片
+	AppendKey(dst []byte, key any) ([]byte, error)
+}
+
+// KeyEncoderFunc 函数形式的KeyEncoder
+type KeyEncoderFunc func(dst []byte, key any) ([]byte, error)
+
+// AppendKey 实现KeyEncoder接口
+func (f KeyEncoderFunc) AppendKey(dst []byte, key any) ([]byte, error) {
+	return f(dst, key)
+}
+
+// 编码结果的类型标签，保证不同类型的键（如1和"1"）不会相等
+const (
+	keyTagInt     byte = 'i'
+	keyTagInt64   byte = 'l'
+	keyTagUint64  byte = 'u'
+	keyTagFloat64 byte = 'f'
+	keyTagString  byte = 's'
+	keyTagBytes   byte = 'b'
+	keyTagBinary  byte = 'm'
+	keyTagFormat  byte = 'v'
+)
+
+// DefaultKeyEncoder 默认键编码器
+// 特点：
+// - int/int64/uint64/float64/string/[]byte走快速路径，不经过反射和格式化
+// - 实现了encoding.BinaryMarshaler的类型使用MarshalBinary的结果
+// - 其余类型退化为fmt的"%T:%v"格式，与旧实现行为相近但区分类型
+// - 编码带类型标签，int(1)、int64(1)和"1"是三个不同的键
+type DefaultKeyEncoder struct{}
+
+// AppendKey 实现KeyEncoder接口
+func (DefaultKeyEncoder) AppendKey(dst []byte, key any) ([]byte, error) {
+	switch k := key.(type) {
+	case int:
+		return binary.BigEndian.AppendUint64(append(dst, keyTagInt), uint64(k)), nil
+	case int64:
+		return binary.BigEndian.AppendUint64(append(dst, keyTagInt64), uint64(k)), nil
+	case uint64:
+		return binary.BigEndian.AppendUint64(append(dst, keyTagUint64), k), nil
+	case float64:
+		if k == 0 {
+			k = 0 // -0和+0视为同一个键
+		}
+		return binary.BigEndian.AppendUint64(append(dst, keyTagFloat64), math.Float64bits(k)), nil
+	case string:
+		return append(append(dst, keyTagString), k...), nil
+	case []byte:
+		return append(append(dst, keyTagBytes), k...), nil
+	case encoding.BinaryMarshaler:
+		data, err := k.MarshalBinary()
+		if err != nil {
+			return dst, fmt.Errorf("encode key %v: %w", key, err)
+		}
+		return append(append(dst, keyTagBinary), data...), nil
+	default:
+		return fmt.Appendf(append(dst, keyTagFormat), "%T:%v", key, key), nil
+	}
+}
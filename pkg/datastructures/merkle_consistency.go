@@ -0,0 +1,132 @@
+package datastructures
+
+import "fmt"
+
+// ConsistencyProof 一致性证明：证明大小为NewSize的树是大小为OldSize的树追加数据块得到的
+// 类似RFC 6962的一致性证明，按本包的树形（奇数个节点时最后一个节点与自己配对）构造：
+// - 前OldSize个叶子唯一地分解为若干棵对齐的完整子树（对应OldSize的每个二进制1位）
+// - 这些子树同时是旧树和新树中的节点，哈希放在OldHashes中，验证方由它们算出旧根
+// - 新树中其余只覆盖新增叶子的节点哈希放在NewHashes中，与OldHashes一起算出新根
+// - 两个根都与验证方持有的根一致，说明旧树的全部叶子原样保留在新树的开头
+type ConsistencyProof struct {
+	Scheme    MerkleHashScheme // 节点哈希的计算方式
+	OldSize   int              // 旧树的数据块数量
+	NewSize   int              // 新树的数据块数量
+	OldHashes []string         // 旧树分解出的完整子树的哈希，从左到右
+	NewHashes []string         // 计算新根所需的其余节点哈希，深度优先、从左到右
+}
+
+// merkleWalk 自顶向下计算size个叶子的树中节点(level, i)的哈希
+// 完全落在前oldSize个叶子内的节点和完全落在其后的节点不再展开，由known给出哈希（old区分两者）；
+// 只有跨越oldSize边界的节点由子节点计算，这样的节点每层至多一个
+func merkleWalk(scheme MerkleHashScheme, level, i, size, oldSize int, known func(level, i int, old bool) (string, bool)) (string, bool) {
+	if (i+1)<<level <= oldSize {
+		return known(level, i, true)
+	}
+	if i<<level >= oldSize {
+		return known(level, i, false)
+	}
+
+	left, ok := merkleWalk(scheme, level-1, 2*i, size, oldSize, known)
+	if !ok {
+		return "", false
+	}
+	right := left
+	if (2*i+1)<<(level-1) < size {
+		if right, ok = merkleWalk(scheme, level-1, 2*i+1, size, oldSize, known); !ok {
+			return "", false
+		}
+	}
+	return merkleNodeHash(scheme, left, right), true
+}
+
+// prefixNodeHash 返回由前size个数据块构建的树中节点(level, i)的哈希，调用方必须持有锁
+// 完整的节点与当前树中的节点相同，直接读取；跨越size边界的节点按配对规则重新计算
+func (mt *MerkleTree) prefixNodeHash(level, i, size int) string {
+	if (i+1)<<level <= size {
+		return merkleNodeAt(mt.root, merkleHeight(len(mt.leaves)), level, i).hash
+	}
+	left := mt.prefixNodeHash(level-1, 2*i, size)
+	right := left
+	if (2*i+1)<<(level-1) < size {
+		right = mt.prefixNodeHash(level-1, 2*i+1, size)
+	}
+	return merkleNodeHash(mt.scheme, left, right)
+}
+
+// RootHashAt 返回由前size个数据块构建的树的根哈希，即树在只追加的历史中大小为size时的根
+func (mt *MerkleTree) RootHashAt(size int) (string, error) {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+	mt.flushHashes()
+
+	if size <= 0 || size > len(mt.leaves) {
+		return "", fmt.Errorf("size %d out of range", size)
+	}
+	return mt.prefixNodeHash(merkleHeight(size), 0, size), nil
+}
+
+// ConsistencyProof 生成大小为oldSize和newSize的两个历史版本之间的一致性证明
+// 把树当作只追加的日志：两个版本都是当前数据块序列的前缀，
+// 因此只有在oldSize之后一直只用AppendLeaf修改过树时，证明才对应真实的历史版本
+// 证明的大小为O(log n)
+func (mt *MerkleTree) ConsistencyProof(oldSize, newSize int) (*ConsistencyProof, error) {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+	mt.flushHashes()
+
+	if oldSize <= 0 || oldSize > newSize || newSize > len(mt.leaves) {
+		return nil, fmt.Errorf("invalid sizes old=%d new=%d for tree of size %d", oldSize, newSize, len(mt.leaves))
+	}
+
+	proof := &ConsistencyProof{Scheme: mt.scheme, OldSize: oldSize, NewSize: newSize}
+	merkleWalk(mt.scheme, merkleHeight(newSize), 0, newSize, oldSize, func(level, i int, old bool) (string, bool) {
+		hash := mt.prefixNodeHash(level, i, newSize)
+		if old {
+			proof.OldHashes = append(proof.OldHashes, hash)
+		} else {
+			proof.NewHashes = append(proof.NewHashes, hash)
+		}
+		return hash, true
+	})
+	return proof, nil
+}
+
+// VerifyConsistency 验证一致性证明：newRoot的树是oldRoot的树追加数据块得到的，按proof.Scheme计算哈希
+// 证明中的哈希必须恰好用完，多余或不足都视为无效
+func VerifyConsistency(oldRoot, newRoot string, proof *ConsistencyProof) bool {
+	if proof == nil || proof.OldSize <= 0 || proof.OldSize > proof.NewSize {
+		return false
+	}
+
+	// 由完整子树算出旧根，旧树中不存在完全落在oldSize之后的节点
+	oldHashes := proof.OldHashes
+	takeOld := func(level, i int, old bool) (string, bool) {
+		if !old || len(oldHashes) == 0 {
+			return "", false
+		}
+		hash := oldHashes[0]
+		oldHashes = oldHashes[1:]
+		return hash, true
+	}
+	computedOld, ok := merkleWalk(proof.Scheme, merkleHeight(proof.OldSize), 0, proof.OldSize, proof.OldSize, takeOld)
+	if !ok || len(oldHashes) != 0 || computedOld != oldRoot {
+		return false
+	}
+
+	// 同样的完整子树加上新增部分的节点算出新根
+	oldHashes = proof.OldHashes
+	newHashes := proof.NewHashes
+	computedNew, ok := merkleWalk(proof.Scheme, merkleHeight(proof.NewSize), 0, proof.NewSize, proof.OldSize, func(level, i int, old bool) (string, bool) {
+		if old {
+			return takeOld(level, i, old)
+		}
+		if len(newHashes) == 0 {
+			return "", false
+		}
+		hash := newHashes[0]
+		newHashes = newHashes[1:]
+		return hash, true
+	})
+	return ok && len(oldHashes) == 0 && len(newHashes) == 0 && computedNew == newRoot
+}
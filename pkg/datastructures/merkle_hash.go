@@ -0,0 +1,129 @@
+package datastructures
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// MerkleHashScheme 默克尔树计算节点哈希的方式
+// 哈希值总是SHA-256的十六进制表示，不同方式得到的根哈希不同
+type MerkleHashScheme uint8
+
+const (
+	// MerkleHashLegacy 最初的方式（默认）：叶子为H(data)，内部节点为H(左哈希的十六进制 + 右哈希的十六进制)
+	// 叶子与内部节点没有区分，可以把内部节点的两个子哈希拼接后冒充成一个叶子数据块（第二原像攻击）；
+	// 保留它是为了让已经发布的根哈希仍然能够验证
+	MerkleHashLegacy MerkleHashScheme = iota
+	// MerkleHashRFC6962 与RFC 6962相同的域分离：叶子为H(0x00 || data)，内部节点为H(0x01 || 左哈希 || 右哈希)，
+	// 子哈希按32字节原始值连接，叶子与内部节点的哈希输入不会相同
+	MerkleHashRFC6962
+)
+
+// String 返回方式名称
+func (s MerkleHashScheme) String() string {
+	switch s {
+	case MerkleHashLegacy:
+		return "legacy"
+	case MerkleHashRFC6962:
+		return "rfc6962"
+	}
+	return fmt.Sprintf("MerkleHashScheme(%d)", uint8(s))
+}
+
+// 域分离前缀
+const (
+	merkleLeafPrefix byte = 0x00
+	merkleNodePrefix byte = 0x01
+)
+
+// MerkleTreeOption 默克尔树的配置选项
+type MerkleTreeOption func(*MerkleTree)
+
+// WithMerkleHashScheme 选择节点哈希的计算方式，默认MerkleHashLegacy
+// 新的树应当使用MerkleHashRFC6962；证明中记录了方式，验证方应确认它是期望的方式，
+// 否则攻击者可以把证明降级到MerkleHashLegacy
+func WithMerkleHashScheme(scheme MerkleHashScheme) MerkleTreeOption {
+	return func(mt *MerkleTree) {
+		mt.scheme = scheme
+	}
+}
+
+// WithHashOnly 只保留叶子哈希，数据块在计算哈希后即丢弃
+// 树不再持有数据块的副本，内存只与数据块数量成正比，与数据块大小无关，适合对大文件构建树；
+// 证明、VerifyData和UpdateData等修改照常可用，RangeQuery返回错误，GetAllData返回空切片
+func WithHashOnly() MerkleTreeOption {
+	return func(mt *MerkleTree) {
+		mt.hashOnly = true
+	}
+}
+
+// HashOnly 返回树是否只保留叶子哈希
+func (mt *MerkleTree) HashOnly() bool {
+	return mt.hashOnly
+}
+
+// HashScheme 返回树的节点哈希计算方式
+func (mt *MerkleTree) HashScheme() MerkleHashScheme {
+	return mt.scheme
+}
+
+// merkleLeafHash 计算叶子节点的哈希值
+func merkleLeafHash(scheme MerkleHashScheme, data []byte) string {
+	var hash [sha256.Size]byte
+	if scheme == MerkleHashRFC6962 {
+		h := sha256.New()
+		h.Write([]byte{merkleLeafPrefix})
+		h.Write(data)
+		h.Sum(hash[:0])
+	} else {
+		hash = sha256.Sum256(data)
+	}
+	return hex.EncodeToString(hash[:])
+}
+
+// merkleNodeHash 计算内部节点的哈希值
+// MerkleHashRFC6962下子哈希不是32字节的十六进制时返回""，它不会与任何根哈希相等
+func merkleNodeHash(scheme MerkleHashScheme, left, right string) string {
+	if scheme != MerkleHashRFC6962 {
+		hash := sha256.Sum256([]byte(left + right))
+		return hex.EncodeToString(hash[:])
+	}
+
+	var buf [1 + 2*sha256.Size]byte
+	buf[0] = merkleNodePrefix
+	if len(left) != 2*sha256.Size || len(right) != 2*sha256.Size {
+		return ""
+	}
+	if _, err := hex.Decode(buf[1:1+sha256.Size], []byte(left)); err != nil {
+		return ""
+	}
+	if _, err := hex.Decode(buf[1+sha256.Size:], []byte(right)); err != nil {
+		return ""
+	}
+	hash := sha256.Sum256(buf[:])
+	return hex.EncodeToString(hash[:])
+}
+
+// newMerkleNode 创建使用指定哈希方式的节点
+func newMerkleNode(scheme MerkleHashScheme, data []byte, left, right *MerkleNode) *MerkleNode {
+	node := &MerkleNode{
+		data:     data,
+		children: make([]*MerkleNode, 0, 2),
+		isLeaf:   left == nil && right == nil,
+		scheme:   scheme,
+	}
+
+	if left != nil {
+		node.children = append(node.children, left)
+		left.parent = node
+	}
+
+	if right != nil {
+		node.children = append(node.children, right)
+		right.parent = node
+	}
+
+	node.hash = node.computeHash()
+	return node
+}
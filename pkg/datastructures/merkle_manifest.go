@@ -0,0 +1,116 @@
+package datastructures
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// ChunkInfo 清单中一个数据块的位置和哈希
+type ChunkInfo struct {
+	Offset int64  `json:"offset"` // 数据块在文件中的起始偏移
+	Size   int    `json:"size"`   // 数据块的字节数，只有最后一块可以小于ChunkSize
+	Hash   string `json:"hash"`   // 数据块的叶子哈希，与默克尔树中的叶子哈希相同
+}
+
+// FileManifest 文件的默克尔清单，可以直接用encoding/json编码
+// 记录切分方式、每个数据块的哈希和由它们构建的默克尔根，
+// 接收方只需可信的Root即可逐块校验下载的文件，并找出需要重新获取的数据块
+type FileManifest struct {
+	Scheme    MerkleHashScheme `json:"scheme"`    // 节点哈希的计算方式
+	ChunkSize int              `json:"chunkSize"` // 切分数据块的大小
+	FileSize  int64            `json:"fileSize"`  // 文件的总字节数
+	Root      string           `json:"root"`      // 默克尔根哈希，空文件为""
+	Chunks    []ChunkInfo      `json:"chunks"`    // 按顺序排列的数据块
+}
+
+// countingReader 记录已读取字节数的io.Reader
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read 实现io.Reader
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// BuildFileManifest 把path指向的文件按chunkSize切分，构建默克尔树并生成清单
+// opts: 可选配置，例如WithMerkleHashScheme；文件边读取边哈希，不保留数据块
+func BuildFileManifest(path string, chunkSize int, opts ...MerkleTreeOption) (*FileManifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cr := &countingReader{r: f}
+	mt, err := NewMerkleTreeFromReader(cr, chunkSize, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	m := &FileManifest{
+		Scheme:    mt.scheme,
+		ChunkSize: chunkSize,
+		FileSize:  cr.n,
+		Root:      mt.GetRootHash(),
+		Chunks:    make([]ChunkInfo, len(mt.leaves)),
+	}
+	for i, leaf := range mt.leaves {
+		offset := int64(i) * int64(chunkSize)
+		m.Chunks[i] = ChunkInfo{Offset: offset, Size: int(min(int64(chunkSize), cr.n-offset)), Hash: leaf.hash}
+	}
+	return m, nil
+}
+
+// Tree 由清单中的数据块哈希构建不保留数据块的默克尔树，不读取文件
+// 清单的偏移和大小必须与ChunkSize、FileSize描述的切分一致，重新计算的根必须等于Root，否则返回错误
+func (m *FileManifest) Tree() (*MerkleTree, error) {
+	if m.ChunkSize <= 0 || m.FileSize < 0 {
+		return nil, fmt.Errorf("invalid chunk size %d or file size %d", m.ChunkSize, m.FileSize)
+	}
+	if want := (m.FileSize + int64(m.ChunkSize) - 1) / int64(m.ChunkSize); int64(len(m.Chunks)) != want {
+		return nil, fmt.Errorf("manifest has %d chunks, want %d", len(m.Chunks), want)
+	}
+
+	hashes := make([]string, len(m.Chunks))
+	for i, c := range m.Chunks {
+		offset := int64(i) * int64(m.ChunkSize)
+		if c.Offset != offset || int64(c.Size) != min(int64(m.ChunkSize), m.FileSize-offset) {
+			return nil, fmt.Errorf("chunk %d has offset %d size %d, inconsistent with chunk size %d", i, c.Offset, c.Size, m.ChunkSize)
+		}
+		hashes[i] = c.Hash
+	}
+	mt := newMerkleTreeFromLeafHashes(m.Scheme, hashes)
+	if got := mt.GetRootHash(); got != m.Root {
+		return nil, fmt.Errorf("manifest root %s does not match its chunks (computed %s)", m.Root, got)
+	}
+	return mt, nil
+}
+
+// VerifyFileManifest 按清单重新检查path指向的文件，返回内容与清单不符的数据块下标（升序）
+// 文件比清单记录的长或短时，多出或缺少的数据块都算作不符；文件与清单完全一致时返回nil
+// 清单本身不一致或读取文件失败时返回错误
+func VerifyFileManifest(path string, m *FileManifest) ([]int, error) {
+	expected, err := m.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	actual, err := NewMerkleTreeFromReader(f, m.ChunkSize, WithMerkleHashScheme(m.Scheme))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// 最后一个数据块长度不同时哈希必然不同，Diff会把它算作不符
+	return expected.Diff(actual), nil
+}
@@ -0,0 +1,566 @@
+package datastructures
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/bits"
+	"sync"
+)
+
+// mptKind 默克尔帕特里夏树的节点类型
+type mptKind uint8
+
+const (
+	mptLeaf      mptKind = iota // 叶子：剩余路径和值
+	mptExtension                // 扩展：共享的路径段和唯一的子节点
+	mptBranch                   // 分支：16个子节点，可以带一个值
+)
+
+// mptNode 默克尔帕特里夏树节点
+// 节点创建后不再修改，修改操作沿路径复制节点，节点的哈希在创建时计算
+type mptNode struct {
+	kind     mptKind
+	path     []byte       // 路径段，每个元素是一个半字节（叶子、扩展）
+	value    []byte       // 值（叶子、带值的分支）
+	hasValue bool         // 分支是否带值
+	child    *mptNode     // 子节点（扩展）
+	children [16]*mptNode // 子节点（分支）
+	digest   [32]byte     // 节点编码的SHA-256
+}
+
+// MerklePatriciaTrie 默克尔帕特里夏树（以太坊风格）
+// 特点：
+// - 按键的半字节路径寻址，兼具前缀树的键查找和默克尔树的认证
+// - 叶子、扩展、分支三种节点，删除后自动合并，根哈希只由键值集合决定，与操作顺序无关
+// - 证明是从根到键所在位置的节点编码，既能证明键存在及其值，也能证明键不存在
+// - 使用SHA-256和本包自己的节点编码，结构与以太坊相同但根哈希与以太坊（Keccak-256、RLP）不兼容
+// - 节点不可变，修改沿路径复制O(len(key))个节点
+type MerklePatriciaTrie struct {
+	root  *mptNode
+	count int
+	mu    sync.RWMutex
+}
+
+// NewMerklePatriciaTrie 创建空的默克尔帕特里夏树
+func NewMerklePatriciaTrie() *MerklePatriciaTrie {
+	return &MerklePatriciaTrie{}
+}
+
+// keyNibbles 把键拆成半字节，高半字节在前
+func keyNibbles(key []byte) []byte {
+	nibbles := make([]byte, len(key)*2)
+	for i, b := range key {
+		nibbles[2*i] = b >> 4
+		nibbles[2*i+1] = b & 0x0f
+	}
+	return nibbles
+}
+
+// nibblePrefixLen 返回两个半字节序列的公共前缀长度
+func nibblePrefixLen(a, b []byte) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// concatNibbles 返回a、b连接后的新切片，不与a、b共享底层数组
+func concatNibbles(a ...[]byte) []byte {
+	var out []byte
+	for _, p := range a {
+		out = append(out, p...)
+	}
+	return out
+}
+
+// appendMPTPath 编码路径段：半字节数量后跟两两打包的半字节，奇数个时最后一个字节的低半字节为0
+func appendMPTPath(dst, path []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(path)))
+	for i := 0; i < len(path); i += 2 {
+		b := path[i] << 4
+		if i+1 < len(path) {
+			b |= path[i+1]
+		}
+		dst = append(dst, b)
+	}
+	return dst
+}
+
+// encode 返回节点的编码，节点哈希和证明都基于它
+//
+//	叶子：0 | 路径 | uvarint(len(value)) | value
+//	扩展：1 | 路径 | 子节点哈希(32字节)
+//	分支：2 | 子节点位图(uint16大端) | 存在的子节点哈希 | 是否带值(1字节) | [uvarint(len(value)) | value]
+func (n *mptNode) encode() []byte {
+	buf := []byte{byte(n.kind)}
+	switch n.kind {
+	case mptLeaf:
+		buf = appendMPTPath(buf, n.path)
+		buf = binary.AppendUvarint(buf, uint64(len(n.value)))
+		buf = append(buf, n.value...)
+	case mptExtension:
+		buf = appendMPTPath(buf, n.path)
+		buf = append(buf, n.child.digest[:]...)
+	case mptBranch:
+		var bitmap uint16
+		for i, c := range n.children {
+			if c != nil {
+				bitmap |= 1 << i
+			}
+		}
+		buf = binary.BigEndian.AppendUint16(buf, bitmap)
+		for _, c := range n.children {
+			if c != nil {
+				buf = append(buf, c.digest[:]...)
+			}
+		}
+		if n.hasValue {
+			buf = append(buf, 1)
+			buf = binary.AppendUvarint(buf, uint64(len(n.value)))
+			buf = append(buf, n.value...)
+		} else {
+			buf = append(buf, 0)
+		}
+	}
+	return buf
+}
+
+// sealed 计算节点哈希后返回节点
+func (n *mptNode) sealed() *mptNode {
+	n.digest = sha256.Sum256(n.encode())
+	return n
+}
+
+func newMPTLeaf(path, value []byte) *mptNode {
+	return (&mptNode{kind: mptLeaf, path: path, value: value}).sealed()
+}
+
+func newMPTExtension(path []byte, child *mptNode) *mptNode {
+	return (&mptNode{kind: mptExtension, path: path, child: child}).sealed()
+}
+
+// withPrefix 在节点前加上路径段prefix，合并相邻的路径段，保持规范形式
+func withPrefix(prefix []byte, n *mptNode) *mptNode {
+	if len(prefix) == 0 {
+		return n
+	}
+	switch n.kind {
+	case mptLeaf:
+		return newMPTLeaf(concatNibbles(prefix, n.path), n.value)
+	case mptExtension:
+		return newMPTExtension(concatNibbles(prefix, n.path), n.child)
+	default:
+		return newMPTExtension(prefix, n)
+	}
+}
+
+// normalizeBranch 检查修改后的分支：只剩一个值或一个子节点时收缩为叶子或扩展
+func normalizeBranch(b *mptNode) *mptNode {
+	only, entries := -1, 0
+	for i, c := range b.children {
+		if c != nil {
+			only = i
+			entries++
+		}
+	}
+	switch {
+	case entries == 0 && b.hasValue:
+		return newMPTLeaf(nil, b.value)
+	case entries == 1 && !b.hasValue:
+		return withPrefix([]byte{byte(only)}, b.children[only])
+	}
+	return b.sealed()
+}
+
+// branchOf 构建包含两个条目的分支：每个条目是剩余路径和对应的子节点（或值）
+// 剩余路径为空的条目成为分支的值；否则第一个半字节选择子节点，其余路径加在子节点前
+func branchOf(pathA []byte, nodeA *mptNode, pathB []byte, nodeB *mptNode) *mptNode {
+	b := &mptNode{kind: mptBranch}
+	for _, e := range []struct {
+		path []byte
+		node *mptNode
+	}{{pathA, nodeA}, {pathB, nodeB}} {
+		if len(e.path) == 0 {
+			// 只有叶子会以空的剩余路径出现在这里
+			b.value, b.hasValue = e.node.value, true
+		} else {
+			b.children[e.path[0]] = withPrefix(e.path[1:], e.node)
+		}
+	}
+	return b.sealed()
+}
+
+// mptInsert 把键值写入以n为根的子树，返回新的子树根以及是否新增了键
+func mptInsert(n *mptNode, path, value []byte) (*mptNode, bool) {
+	if n == nil {
+		return newMPTLeaf(path, value), true
+	}
+
+	switch n.kind {
+	case mptLeaf:
+		p := nibblePrefixLen(n.path, path)
+		if p == len(n.path) && p == len(path) {
+			return newMPTLeaf(n.path, value), false
+		}
+		b := branchOf(n.path[p:], newMPTLeaf(nil, n.value), path[p:], newMPTLeaf(nil, value))
+		return withPrefix(path[:p:p], b), true
+
+	case mptExtension:
+		p := nibblePrefixLen(n.path, path)
+		if p == len(n.path) {
+			child, added := mptInsert(n.child, path[p:], value)
+			return newMPTExtension(n.path, child), added
+		}
+		// 在公共前缀之后分裂：扩展剩余的路径和新键各占分支的一个位置
+		b := &mptNode{kind: mptBranch}
+		b.children[n.path[p]] = withPrefix(n.path[p+1:], n.child)
+		if rest := path[p:]; len(rest) == 0 {
+			b.value, b.hasValue = value, true
+		} else {
+			b.children[rest[0]] = newMPTLeaf(rest[1:], value)
+		}
+		return withPrefix(path[:p:p], b.sealed()), true
+
+	default:
+		b := *n
+		added := false
+		if len(path) == 0 {
+			added = !n.hasValue
+			b.value, b.hasValue = value, true
+		} else {
+			b.children[path[0]], added = mptInsert(n.children[path[0]], path[1:], value)
+		}
+		return b.sealed(), added
+	}
+}
+
+// mptDelete 从以n为根的子树中删除键，返回新的子树根以及键是否存在
+func mptDelete(n *mptNode, path []byte) (*mptNode, bool) {
+	if n == nil {
+		return nil, false
+	}
+
+	switch n.kind {
+	case mptLeaf:
+		if bytes.Equal(n.path, path) {
+			return nil, true
+		}
+		return n, false
+
+	case mptExtension:
+		if !bytes.HasPrefix(path, n.path) {
+			return n, false
+		}
+		child, ok := mptDelete(n.child, path[len(n.path):])
+		if !ok {
+			return n, false
+		}
+		// 扩展的子节点是分支，删除一个条目后至少还剩一个，child不为nil
+		return withPrefix(n.path, child), true
+
+	default:
+		b := *n
+		if len(path) == 0 {
+			if !n.hasValue {
+				return n, false
+			}
+			b.value, b.hasValue = nil, false
+		} else {
+			child, ok := mptDelete(n.children[path[0]], path[1:])
+			if !ok {
+				return n, false
+			}
+			b.children[path[0]] = child
+		}
+		return normalizeBranch(&b), true
+	}
+}
+
+// Put 写入键值对，键已存在时覆盖值
+// 值不会被复制，调用方之后不应修改它
+func (t *MerklePatriciaTrie) Put(key, value []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	root, added := mptInsert(t.root, keyNibbles(key), value)
+	t.root = root
+	if added {
+		t.count++
+	}
+}
+
+// Get 查找键对应的值
+func (t *MerklePatriciaTrie) Get(key []byte) ([]byte, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	path := keyNibbles(key)
+	n := t.root
+	for n != nil {
+		switch n.kind {
+		case mptLeaf:
+			if bytes.Equal(n.path, path) {
+				return n.value, true
+			}
+			return nil, false
+		case mptExtension:
+			if !bytes.HasPrefix(path, n.path) {
+				return nil, false
+			}
+			path, n = path[len(n.path):], n.child
+		default:
+			if len(path) == 0 {
+				return n.value, n.hasValue
+			}
+			path, n = path[1:], n.children[path[0]]
+		}
+	}
+	return nil, false
+}
+
+// Delete 删除键，返回键是否存在
+func (t *MerklePatriciaTrie) Delete(key []byte) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	root, ok := mptDelete(t.root, keyNibbles(key))
+	if ok {
+		t.root = root
+		t.count--
+	}
+	return ok
+}
+
+// Size 返回键的数量
+func (t *MerklePatriciaTrie) Size() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.count
+}
+
+// RootHash 返回根哈希的十六进制表示，空树返回""
+func (t *MerklePatriciaTrie) RootHash() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.root == nil {
+		return ""
+	}
+	return hex.EncodeToString(t.root.digest[:])
+}
+
+// Prove 生成键的证明：从根开始沿键的路径经过的每个节点的编码
+// 键不存在时证明到查找终止的节点为止，可以用来证明键不存在；空树的证明为空
+func (t *MerklePatriciaTrie) Prove(key []byte) [][]byte {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var proof [][]byte
+	path := keyNibbles(key)
+	n := t.root
+	for n != nil {
+		proof = append(proof, n.encode())
+		switch n.kind {
+		case mptLeaf:
+			return proof
+		case mptExtension:
+			if !bytes.HasPrefix(path, n.path) {
+				return proof
+			}
+			path, n = path[len(n.path):], n.child
+		default:
+			if len(path) == 0 {
+				return proof
+			}
+			path, n = path[1:], n.children[path[0]]
+		}
+	}
+	return proof
+}
+
+// mptDecoded 证明中解码出的节点，子节点只有哈希
+type mptDecoded struct {
+	kind     mptKind
+	path     []byte
+	value    []byte
+	hasValue bool
+	children [16][]byte // 子节点哈希，扩展的子节点放在children[0]
+}
+
+// mptDecoder 按encode的格式读取字节
+type mptDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *mptDecoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *mptDecoder) take(n uint64) []byte {
+	if d.err != nil || n > uint64(len(d.buf)) {
+		d.fail()
+		return nil
+	}
+	p := d.buf[:n]
+	d.buf = d.buf[n:]
+	return p
+}
+
+func (d *mptDecoder) fail() {
+	if d.err == nil {
+		d.err = fmt.Errorf("malformed trie node")
+	}
+}
+
+func (d *mptDecoder) path() []byte {
+	count := d.uvarint()
+	if count > uint64(len(d.buf))*2 {
+		d.fail()
+		return nil
+	}
+	packed := d.take((count + 1) / 2)
+	if d.err != nil {
+		return nil
+	}
+	path := make([]byte, count)
+	for i := range path {
+		if i%2 == 0 {
+			path[i] = packed[i/2] >> 4
+		} else {
+			path[i] = packed[i/2] & 0x0f
+		}
+	}
+	return path
+}
+
+// decodeMPTNode 解码节点编码，多余的字节视为错误
+func decodeMPTNode(data []byte) (*mptDecoded, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("malformed trie node")
+	}
+	n := &mptDecoded{kind: mptKind(data[0])}
+	d := &mptDecoder{buf: data[1:]}
+	switch n.kind {
+	case mptLeaf:
+		n.path = d.path()
+		n.value = d.take(d.uvarint())
+	case mptExtension:
+		n.path = d.path()
+		n.children[0] = d.take(32)
+	case mptBranch:
+		bm := d.take(2)
+		if d.err != nil {
+			break
+		}
+		bitmap := binary.BigEndian.Uint16(bm)
+		for i := range n.children {
+			if bitmap&(1<<i) != 0 {
+				n.children[i] = d.take(32)
+			}
+		}
+		switch flag := d.take(1); {
+		case d.err != nil:
+		case flag[0] == 1:
+			n.hasValue = true
+			n.value = d.take(d.uvarint())
+		case flag[0] != 0:
+			d.fail()
+		}
+		if bits.OnesCount16(bitmap)+boolToInt(n.hasValue) < 2 {
+			d.fail()
+		}
+	default:
+		d.fail()
+	}
+	if d.err == nil && len(d.buf) != 0 {
+		d.fail()
+	}
+	return n, d.err
+}
+
+// boolToInt 把布尔值转为0或1
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// VerifyMPTProof 用根哈希验证Prove生成的证明
+// 返回键的值和键是否存在；证明与根哈希不符、不完整或带有多余节点时返回错误
+func VerifyMPTProof(rootHash string, key []byte, proof [][]byte) ([]byte, bool, error) {
+	if rootHash == "" {
+		if len(proof) != 0 {
+			return nil, false, fmt.Errorf("proof for empty trie must be empty")
+		}
+		return nil, false, nil
+	}
+	expected, err := hex.DecodeString(rootHash)
+	if err != nil || len(expected) != sha256.Size {
+		return nil, false, fmt.Errorf("invalid root hash %q", rootHash)
+	}
+
+	path := keyNibbles(key)
+	for i, enc := range proof {
+		if digest := sha256.Sum256(enc); !bytes.Equal(digest[:], expected) {
+			return nil, false, fmt.Errorf("proof node %d does not match its hash", i)
+		}
+		n, err := decodeMPTNode(enc)
+		if err != nil {
+			return nil, false, fmt.Errorf("proof node %d: %w", i, err)
+		}
+
+		var value []byte
+		found, done := false, true
+		switch n.kind {
+		case mptLeaf:
+			if bytes.Equal(n.path, path) {
+				value, found = n.value, true
+			}
+		case mptExtension:
+			if bytes.HasPrefix(path, n.path) {
+				path, expected, done = path[len(n.path):], n.children[0], false
+			}
+		default:
+			if len(path) == 0 {
+				value, found = n.value, n.hasValue
+			} else if n.children[path[0]] != nil {
+				path, expected, done = path[1:], n.children[path[0]], false
+			}
+		}
+
+		if done {
+			if i != len(proof)-1 {
+				return nil, false, fmt.Errorf("proof has %d extra nodes", len(proof)-1-i)
+			}
+			return value, found, nil
+		}
+	}
+	return nil, false, fmt.Errorf("proof is incomplete")
+}
+
+// Describe 返回默克尔帕特里夏树的能力描述
+func (t *MerklePatriciaTrie) Describe() Descriptor {
+	return Descriptor{
+		Name:           "MerklePatriciaTrie",
+		SupportsDelete: true,
+		Concurrency:    ConcurrencyRWMutex,
+		Complexity: Complexity{
+			Insert: "O(len(key))",
+			Search: "O(len(key))",
+			Delete: "O(len(key))",
+		},
+		Notes: "按键寻址的认证字典，根哈希与操作顺序无关，支持键存在和不存在的证明",
+	}
+}
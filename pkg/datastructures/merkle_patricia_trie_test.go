@@ -0,0 +1,154 @@
+package datastructures
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// TestMerklePatriciaTrieBasic 测试默克尔帕特里夏树的插入、查找、覆盖和删除
+func TestMerklePatriciaTrieBasic(t *testing.T) {
+	trie := NewMerklePatriciaTrie()
+	if trie.RootHash() != "" {
+		t.Fatal("空树的根哈希应该为空")
+	}
+
+	// 互为前缀的键覆盖叶子、扩展、分支和带值的分支
+	kvs := map[string]string{
+		"do":    "verb",
+		"dog":   "puppy",
+		"doge":  "coin",
+		"horse": "stallion",
+		"":      "empty key",
+		"d":     "letter",
+	}
+	for k, v := range kvs {
+		trie.Put([]byte(k), []byte(v))
+	}
+	if trie.Size() != len(kvs) {
+		t.Fatalf("Size() = %d, 期望 %d", trie.Size(), len(kvs))
+	}
+	for k, v := range kvs {
+		got, ok := trie.Get([]byte(k))
+		if !ok || string(got) != v {
+			t.Errorf("Get(%q) = %q, %v, 期望 %q", k, got, ok, v)
+		}
+	}
+	for _, k := range []string{"dogs", "h", "cat", "doe"} {
+		if _, ok := trie.Get([]byte(k)); ok {
+			t.Errorf("Get(%q) 找到了不存在的键", k)
+		}
+	}
+
+	root := trie.RootHash()
+	trie.Put([]byte("dog"), []byte("hound"))
+	if trie.RootHash() == root || trie.Size() != len(kvs) {
+		t.Error("覆盖值应该改变根哈希但不改变大小")
+	}
+	trie.Put([]byte("dog"), []byte("puppy"))
+	if trie.RootHash() != root {
+		t.Error("恢复原值后根哈希应该恢复")
+	}
+
+	if trie.Delete([]byte("cat")) {
+		t.Error("删除不存在的键应该返回 false")
+	}
+	for k := range kvs {
+		if !trie.Delete([]byte(k)) {
+			t.Fatalf("Delete(%q) 返回 false", k)
+		}
+	}
+	if trie.Size() != 0 || trie.RootHash() != "" {
+		t.Errorf("全部删除后 Size() = %d, RootHash() = %q", trie.Size(), trie.RootHash())
+	}
+}
+
+// TestMerklePatriciaTrieCanonicalRoot 测试根哈希与插入顺序和中间操作无关
+func TestMerklePatriciaTrieCanonicalRoot(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	keys := make([][]byte, 300)
+	for i := range keys {
+		keys[i] = []byte(fmt.Sprintf("key-%d", rng.Intn(1000)))
+	}
+
+	// 根哈希只由最终的键值集合决定
+	a := NewMerklePatriciaTrie()
+	for _, k := range keys {
+		a.Put(k, k)
+	}
+	b := NewMerklePatriciaTrie()
+	for _, i := range rng.Perm(len(keys)) {
+		b.Put(keys[i], keys[i])
+	}
+	if a.RootHash() != b.RootHash() || a.Size() != b.Size() {
+		t.Fatal("插入顺序改变了根哈希")
+	}
+
+	// 插入再删除额外的键后回到原来的根
+	for i := 0; i < 200; i++ {
+		b.Put([]byte(fmt.Sprintf("extra-%d", i)), []byte("x"))
+		b.Put([]byte(fmt.Sprintf("key-%d", 1000+i)), []byte("y"))
+	}
+	for i := 0; i < 200; i++ {
+		b.Delete([]byte(fmt.Sprintf("extra-%d", i)))
+		b.Delete([]byte(fmt.Sprintf("key-%d", 1000+i)))
+	}
+	if a.RootHash() != b.RootHash() || a.Size() != b.Size() {
+		t.Fatal("插入再删除后没有回到原来的根哈希")
+	}
+}
+
+// TestMerklePatriciaTrieProof 测试存在性和不存在性证明
+func TestMerklePatriciaTrieProof(t *testing.T) {
+	trie := NewMerklePatriciaTrie()
+	if v, ok, err := VerifyMPTProof("", []byte("k"), trie.Prove([]byte("k"))); err != nil || ok || v != nil {
+		t.Fatalf("空树的证明 = %q, %v, %v", v, ok, err)
+	}
+
+	for i := 0; i < 500; i++ {
+		trie.Put([]byte(fmt.Sprintf("account-%d", i)), []byte(fmt.Sprintf("balance-%d", i*10)))
+	}
+	trie.Put([]byte("account-1"), []byte("prefix of others"))
+	root := trie.RootHash()
+
+	// 存在性证明
+	for _, k := range []string{"account-0", "account-1", "account-42", "account-499"} {
+		want, _ := trie.Get([]byte(k))
+		got, ok, err := VerifyMPTProof(root, []byte(k), trie.Prove([]byte(k)))
+		if err != nil || !ok || !bytes.Equal(got, want) {
+			t.Fatalf("%q 的证明 = %q, %v, %v, 期望 %q", k, got, ok, err, want)
+		}
+	}
+
+	// 不存在性证明
+	for _, k := range []string{"account-500", "account-", "acc", "zzz", ""} {
+		got, ok, err := VerifyMPTProof(root, []byte(k), trie.Prove([]byte(k)))
+		if err != nil || ok || got != nil {
+			t.Fatalf("%q 的不存在性证明 = %q, %v, %v", k, got, ok, err)
+		}
+	}
+
+	// 证明与键或根不符
+	proof := trie.Prove([]byte("account-7"))
+	if _, ok, err := VerifyMPTProof(root, []byte("account-8"), proof); err == nil && ok {
+		t.Error("一个键的证明验证通过了另一个键")
+	}
+	tampered := append([][]byte(nil), proof...)
+	last := append([]byte(nil), tampered[len(tampered)-1]...)
+	last[len(last)-1] ^= 1
+	tampered[len(tampered)-1] = last
+	if _, _, err := VerifyMPTProof(root, []byte("account-7"), tampered); err == nil {
+		t.Error("篡改的证明应该返回错误")
+	}
+	if _, _, err := VerifyMPTProof(root, []byte("account-7"), proof[:len(proof)-1]); err == nil {
+		t.Error("截断的证明应该返回错误")
+	}
+	if _, _, err := VerifyMPTProof(root, []byte("account-7"), append(proof, proof[0])); err == nil {
+		t.Error("带有多余节点的证明应该返回错误")
+	}
+	trie.Put([]byte("account-7"), []byte("changed"))
+	if _, _, err := VerifyMPTProof(trie.RootHash(), []byte("account-7"), proof); err == nil {
+		t.Error("过期的证明对新的根哈希应该返回错误")
+	}
+}
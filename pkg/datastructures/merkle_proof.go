@@ -0,0 +1,206 @@
+package datastructures
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ProofSide 兄弟节点相对于路径上节点的位置
+type ProofSide uint8
+
+const (
+	// SiblingRight 兄弟节点在右侧：父节点哈希 = H(当前哈希 + 兄弟哈希)
+	SiblingRight ProofSide = iota
+	// SiblingLeft 兄弟节点在左侧：父节点哈希 = H(兄弟哈希 + 当前哈希)
+	SiblingLeft
+)
+
+// String 返回位置名称
+func (s ProofSide) String() string {
+	switch s {
+	case SiblingRight:
+		return "right"
+	case SiblingLeft:
+		return "left"
+	}
+	return fmt.Sprintf("ProofSide(%d)", uint8(s))
+}
+
+// ProofStep 证明中的一层：兄弟节点的哈希值和它所在的一侧
+type ProofStep struct {
+	Hash string    `json:"hash"`
+	Side ProofSide `json:"side"`
+}
+
+// Proof 单个数据块的完整性证明
+// Steps按从叶子到根的顺序排列；奇数个节点时最后一个节点与自己配对，
+// 这一层的兄弟哈希就是当前哈希，位于右侧
+type Proof struct {
+	Scheme    MerkleHashScheme `json:"scheme"`    // 节点哈希的计算方式
+	LeafIndex int              `json:"leafIndex"` // 数据块的下标
+	TreeSize  int              `json:"treeSize"`  // 生成证明时的数据块数量
+	Steps     []ProofStep      `json:"steps"`     // 从叶子到根的兄弟节点
+}
+
+// sideAt 返回下标为index的节点在所在层的兄弟位置
+func sideAt(index int) ProofSide {
+	if index&1 == 1 {
+		return SiblingLeft
+	}
+	return SiblingRight
+}
+
+// RootFromLeafHash 从叶子哈希沿证明向上计算根哈希
+// 证明的层数和每层的位置必须与LeafIndex、TreeSize描述的形状一致，否则返回false：
+// 位置由下标的二进制位决定，证明因此同时证实了数据块的下标
+// 自配对的层兄弟哈希就是当前哈希，Hash可以为空（紧凑格式解码的证明即是如此）
+func (p *Proof) RootFromLeafHash(leafHash string) (string, bool) {
+	if p.LeafIndex < 0 || p.LeafIndex >= p.TreeSize || len(p.Steps) != merkleHeight(p.TreeSize) {
+		return "", false
+	}
+
+	current := leafHash
+	count := p.TreeSize
+	for level, step := range p.Steps {
+		index := p.LeafIndex >> level
+		if step.Side != sideAt(index) {
+			return "", false
+		}
+		sibling := step.Hash
+		if sibling == "" && selfPaired(index, count) {
+			sibling = current
+		}
+		if step.Side == SiblingLeft {
+			current = merkleNodeHash(p.Scheme, sibling, current)
+		} else {
+			current = merkleNodeHash(p.Scheme, current, sibling)
+		}
+		count = (count + 1) / 2
+	}
+	return current, true
+}
+
+// MultiProof 多个数据块的批量完整性证明
+// 只包含验证方无法由给定数据块自行算出的节点哈希：多个叶子共享的祖先只计算一次，
+// 相邻叶子互为兄弟时不需要兄弟哈希，连续k个数据块的证明约为O(k + log n)个哈希，
+// 远小于k个独立证明的O(k·log n)
+type MultiProof struct {
+	Scheme   MerkleHashScheme // 节点哈希的计算方式
+	TreeSize int              // 生成证明时的数据块数量
+	Indices  []int            // 被证明的数据块下标，严格升序
+	Hashes   []string         // 补充的节点哈希，按自底向上、每层从左到右的顺序排列
+}
+
+// multiProofNode 批量证明计算过程中一层里已知的节点
+type multiProofNode struct {
+	index int
+	hash  string
+	node  *MerkleNode // 生成证明时使用，验证时为nil
+}
+
+// normalizeIndices 复制下标并排序去重，检查范围
+func normalizeIndices(indices []int, size int) ([]int, error) {
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("no indices given")
+	}
+	sorted := append([]int(nil), indices...)
+	sort.Ints(sorted)
+	out := sorted[:0]
+	for i, idx := range sorted {
+		if idx < 0 || idx >= size {
+			return nil, fmt.Errorf("index %d out of range", idx)
+		}
+		if i == 0 || idx != sorted[i-1] {
+			out = append(out, idx)
+		}
+	}
+	return out, nil
+}
+
+// GetMultiProof 获取多个数据块的批量证明，下标可以无序或重复
+func (mt *MerkleTree) GetMultiProof(indices []int) (*MultiProof, error) {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+	mt.flushHashes()
+
+	sorted, err := normalizeIndices(indices, len(mt.leaves))
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &MultiProof{Scheme: mt.scheme, TreeSize: len(mt.leaves), Indices: sorted}
+	level := make([]multiProofNode, len(sorted))
+	for i, idx := range sorted {
+		level[i] = multiProofNode{index: idx, node: mt.leaves[idx]}
+	}
+
+	for count := len(mt.leaves); count > 1; count = (count + 1) / 2 {
+		next := make([]multiProofNode, 0, len(level))
+		for i := 0; i < len(level); i++ {
+			cur := level[i]
+			parent := cur.node.parent
+			switch {
+			case cur.index&1 == 0 && i+1 < len(level) && level[i+1].index == cur.index+1:
+				i++ // 兄弟也是已知节点
+			case cur.index&1 == 0 && cur.index+1 == count:
+				// 最后一个节点与自己配对
+			case cur.index&1 == 0:
+				proof.Hashes = append(proof.Hashes, parent.children[1].hash)
+			default:
+				proof.Hashes = append(proof.Hashes, parent.children[0].hash)
+			}
+			next = append(next, multiProofNode{index: cur.index >> 1, node: parent})
+		}
+		level = next
+	}
+
+	return proof, nil
+}
+
+// VerifyMultiProof 验证批量证明，按proof.Scheme计算哈希
+// data: 与proof.Indices一一对应的数据块
+// rootHash: 期望的根哈希值
+// 补充哈希必须恰好用完，多余或不足都视为无效
+func VerifyMultiProof(data [][]byte, proof *MultiProof, rootHash string) bool {
+	if proof == nil || len(proof.Indices) == 0 || len(data) != len(proof.Indices) {
+		return false
+	}
+
+	level := make([]multiProofNode, len(data))
+	for i, idx := range proof.Indices {
+		if idx < 0 || idx >= proof.TreeSize || (i > 0 && idx <= proof.Indices[i-1]) {
+			return false
+		}
+		level[i] = multiProofNode{index: idx, hash: merkleLeafHash(proof.Scheme, data[i])}
+	}
+
+	hashes := proof.Hashes
+	for count := proof.TreeSize; count > 1; count = (count + 1) / 2 {
+		next := make([]multiProofNode, 0, len(level))
+		for i := 0; i < len(level); i++ {
+			cur := level[i]
+			var parentHash string
+			switch {
+			case cur.index&1 == 0 && i+1 < len(level) && level[i+1].index == cur.index+1:
+				parentHash = merkleNodeHash(proof.Scheme, cur.hash, level[i+1].hash)
+				i++
+			case cur.index&1 == 0 && cur.index+1 == count:
+				parentHash = merkleNodeHash(proof.Scheme, cur.hash, cur.hash)
+			default:
+				if len(hashes) == 0 {
+					return false
+				}
+				if cur.index&1 == 0 {
+					parentHash = merkleNodeHash(proof.Scheme, cur.hash, hashes[0])
+				} else {
+					parentHash = merkleNodeHash(proof.Scheme, hashes[0], cur.hash)
+				}
+				hashes = hashes[1:]
+			}
+			next = append(next, multiProofNode{index: cur.index >> 1, hash: parentHash})
+		}
+		level = next
+	}
+
+	return len(hashes) == 0 && level[0].hash == rootHash
+}
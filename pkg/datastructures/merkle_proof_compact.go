@@ -0,0 +1,162 @@
+package datastructures
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+)
+
+// merkleProofCompactMagic 紧凑证明格式的文件头
+const merkleProofCompactMagic = "MKP\x02"
+
+// 紧凑证明格式（MarshalCompact）：
+//
+//	magic(4字节，"MKP\x02") | scheme(uvarint) | leafIndex(uvarint) | treeSize(uvarint) | k × hash(32字节)
+//
+// 与MarshalBinary的格式相比省去了验证方能够自行推出的内容：
+// - side由leafIndex的二进制位决定，不再写入
+// - 奇数个节点时最后一个节点与自己配对，这一层的兄弟哈希就是当前哈希，不再写入；树的右边缘上连续多层自配对时整段都不占空间
+// 层数和哪些层自配对都由leafIndex、treeSize决定，k为其余层的数量，哈希按从叶子到根的顺序排列。
+// UnmarshalBinary同时接受两种格式；由紧凑格式解码的证明中自配对层的Hash为空，只能用于验证，不能再用MarshalBinary编码。
+
+// selfPaired 下标为index的节点是否为所在层（共count个节点）的最后一个且与自己配对
+func selfPaired(index, count int) bool {
+	return index&1 == 0 && index+1 == count
+}
+
+// MarshalCompact 按紧凑格式编码证明，大小约为MarshalBinary的33分之32，
+// 树的大小不是2的幂时对右边缘的数据块更小
+// 证明的形状必须与LeafIndex、TreeSize一致，否则返回错误
+func (p *Proof) MarshalCompact() ([]byte, error) {
+	if _, err := p.Scheme.MarshalText(); err != nil {
+		return nil, err
+	}
+	if p.LeafIndex < 0 || p.LeafIndex >= p.TreeSize || len(p.Steps) != merkleHeight(p.TreeSize) {
+		return nil, fmt.Errorf("proof shape does not match leaf index %d and tree size %d", p.LeafIndex, p.TreeSize)
+	}
+
+	var buf bytes.Buffer
+	bw := &binaryWriter{w: &buf}
+	bw.write([]byte(merkleProofCompactMagic))
+	bw.writeUvarint(uint64(p.Scheme))
+	bw.writeUvarint(uint64(p.LeafIndex))
+	bw.writeUvarint(uint64(p.TreeSize))
+	count := p.TreeSize
+	for level, step := range p.Steps {
+		index := p.LeafIndex >> level
+		if step.Side != sideAt(index) {
+			return nil, fmt.Errorf("step %d has side %v, want %v", level, step.Side, sideAt(index))
+		}
+		if !selfPaired(index, count) {
+			bw.writeHash(step.Hash)
+		}
+		count = (count + 1) / 2
+	}
+	if bw.err != nil {
+		return nil, bw.err
+	}
+	return buf.Bytes(), nil
+}
+
+// unmarshalCompact 解码紧凑格式，br已经读过文件头
+func (p *Proof) unmarshalCompact(br *binaryReader, size int) error {
+	var fields [3]uint64 // scheme, leafIndex, treeSize
+	for i := range fields {
+		v, err := br.readUvarint()
+		if err != nil {
+			return err
+		}
+		fields[i] = v
+	}
+	scheme := MerkleHashScheme(fields[0])
+	if scheme != MerkleHashLegacy && scheme != MerkleHashRFC6962 || fields[0] > 0xff {
+		return fmt.Errorf("unknown hash scheme %d", fields[0])
+	}
+	if fields[2] > math.MaxInt || fields[1] >= fields[2] {
+		return fmt.Errorf("leaf index %d out of range for tree size %d", fields[1], fields[2])
+	}
+
+	leafIndex, treeSize := int(fields[1]), int(fields[2])
+	steps := make([]ProofStep, merkleHeight(treeSize))
+	count := treeSize
+	for level := range steps {
+		index := leafIndex >> level
+		steps[level].Side = sideAt(index)
+		if !selfPaired(index, count) {
+			hash, err := br.readHash()
+			if err != nil {
+				return err
+			}
+			steps[level].Hash = hash
+		}
+		count = (count + 1) / 2
+	}
+	if br.n != int64(size) {
+		return fmt.Errorf("%d trailing bytes after proof", int64(size)-br.n)
+	}
+
+	// 自配对层的兄弟哈希是当前哈希，要由叶子哈希逐层算出，这里留空，RootFromLeafHash验证时补齐
+	*p = Proof{Scheme: scheme, LeafIndex: leafIndex, TreeSize: treeSize, Steps: steps}
+	return nil
+}
+
+// MergeProofs 把同一棵树的多个单独证明合并为一个批量证明，去掉验证方能由其他数据块自行算出的哈希
+// 相邻数据块互为兄弟或共享祖先时，各自证明中重复或可推出的哈希只保留一次，
+// 结果与对同一组下标调用GetMultiProof相同，用VerifyMultiProof验证，
+// 数据块按下标升序传入；同一下标出现多次时只保留一个
+func MergeProofs(proofs []*Proof) (*MultiProof, error) {
+	if len(proofs) == 0 {
+		return nil, fmt.Errorf("no proofs given")
+	}
+	first := proofs[0]
+	if first == nil {
+		return nil, fmt.Errorf("nil proof")
+	}
+
+	// known[level][index]为证明中给出的第level层节点的哈希
+	height := merkleHeight(first.TreeSize)
+	known := make([]map[int]string, height)
+	for l := range known {
+		known[l] = make(map[int]string)
+	}
+	indices := make([]int, 0, len(proofs))
+	for _, p := range proofs {
+		if p == nil || p.Scheme != first.Scheme || p.TreeSize != first.TreeSize {
+			return nil, fmt.Errorf("proofs are not for the same tree")
+		}
+		if p.LeafIndex < 0 || p.LeafIndex >= p.TreeSize || len(p.Steps) != height {
+			return nil, fmt.Errorf("proof for leaf %d does not match tree size %d", p.LeafIndex, p.TreeSize)
+		}
+		for l, step := range p.Steps {
+			known[l][(p.LeafIndex>>l)^1] = step.Hash
+		}
+		indices = append(indices, p.LeafIndex)
+	}
+
+	sorted, err := normalizeIndices(indices, first.TreeSize)
+	if err != nil {
+		return nil, err
+	}
+	proof := &MultiProof{Scheme: first.Scheme, TreeSize: first.TreeSize, Indices: sorted}
+
+	// 与GetMultiProof相同的逐层遍历，只是兄弟哈希取自各个证明
+	level := append([]int(nil), sorted...)
+	for l, count := 0, first.TreeSize; count > 1; l, count = l+1, (count+1)/2 {
+		next := make([]int, 0, len(level))
+		for i := 0; i < len(level); i++ {
+			cur := level[i]
+			switch {
+			case cur&1 == 0 && i+1 < len(level) && level[i+1] == cur+1:
+				i++ // 兄弟也是已知节点
+			case selfPaired(cur, count):
+				// 最后一个节点与自己配对
+			default:
+				proof.Hashes = append(proof.Hashes, known[l][cur^1])
+			}
+			next = append(next, cur>>1)
+		}
+		level = next
+	}
+
+	return proof, nil
+}
@@ -0,0 +1,162 @@
+package datastructures
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+)
+
+// merkleProofMagic 证明二进制格式的文件头
+const merkleProofMagic = "MKP\x01"
+
+// maxProofSteps 反序列化时证明的最大层数，防止损坏的数据导致超大分配
+const maxProofSteps = 64
+
+// 证明的二进制格式（MarshalBinary），供其他服务和语言实现验证：
+//
+//	magic(4字节，"MKP\x01") | scheme(uvarint) | leafIndex(uvarint) | treeSize(uvarint) |
+//	stepCount(uvarint) | stepCount × (side(1字节) hash(32字节))
+//
+// - uvarint与Go的encoding/binary相同：每字节低7位为数据，最高位为1表示后面还有字节，低位在前
+// - scheme：0为MerkleHashLegacy，1为MerkleHashRFC6962
+// - side：0为兄弟在右侧（父 = H(当前, 兄弟)），1为兄弟在左侧（父 = H(兄弟, 当前)）
+// - hash为SHA-256原始字节，steps按从叶子到根的顺序排列
+//
+// JSON格式（encoding/json）与之一一对应，哈希为小写十六进制，scheme和side为名称：
+//
+//	{"scheme":"rfc6962","leafIndex":2,"treeSize":5,"steps":[{"hash":"…","side":"right"}]}
+//
+// 验证方按RootFromLeafHash的规则由叶子哈希计算根：
+// - MerkleHashLegacy：叶子为H(data)，父为H(左哈希十六进制 || 右哈希十六进制)
+// - MerkleHashRFC6962：叶子为H(0x00 || data)，父为H(0x01 || 左哈希 || 右哈希)
+// 层数必须等于ceil(log2(treeSize))，第l层的side必须等于leafIndex的第l位
+
+// MarshalText 返回方式名称，实现encoding.TextMarshaler
+func (s MerkleHashScheme) MarshalText() ([]byte, error) {
+	switch s {
+	case MerkleHashLegacy, MerkleHashRFC6962:
+		return []byte(s.String()), nil
+	}
+	return nil, fmt.Errorf("unknown hash scheme %d", uint8(s))
+}
+
+// UnmarshalText 解析方式名称，实现encoding.TextUnmarshaler
+func (s *MerkleHashScheme) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "legacy":
+		*s = MerkleHashLegacy
+	case "rfc6962":
+		*s = MerkleHashRFC6962
+	default:
+		return fmt.Errorf("unknown hash scheme %q", text)
+	}
+	return nil
+}
+
+// MarshalText 返回位置名称，实现encoding.TextMarshaler
+func (s ProofSide) MarshalText() ([]byte, error) {
+	switch s {
+	case SiblingRight, SiblingLeft:
+		return []byte(s.String()), nil
+	}
+	return nil, fmt.Errorf("unknown proof side %d", uint8(s))
+}
+
+// UnmarshalText 解析位置名称，实现encoding.TextUnmarshaler
+func (s *ProofSide) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "right":
+		*s = SiblingRight
+	case "left":
+		*s = SiblingLeft
+	default:
+		return fmt.Errorf("unknown proof side %q", text)
+	}
+	return nil
+}
+
+// MarshalBinary 按上面描述的二进制格式编码证明，实现encoding.BinaryMarshaler
+func (p *Proof) MarshalBinary() ([]byte, error) {
+	if _, err := p.Scheme.MarshalText(); err != nil {
+		return nil, err
+	}
+	if p.LeafIndex < 0 || p.TreeSize < 0 {
+		return nil, fmt.Errorf("negative leaf index %d or tree size %d", p.LeafIndex, p.TreeSize)
+	}
+
+	var buf bytes.Buffer
+	bw := &binaryWriter{w: &buf}
+	bw.write([]byte(merkleProofMagic))
+	bw.writeUvarint(uint64(p.Scheme))
+	bw.writeUvarint(uint64(p.LeafIndex))
+	bw.writeUvarint(uint64(p.TreeSize))
+	bw.writeUvarint(uint64(len(p.Steps)))
+	for _, step := range p.Steps {
+		if step.Side != SiblingRight && step.Side != SiblingLeft {
+			return nil, fmt.Errorf("unknown proof side %d", uint8(step.Side))
+		}
+		bw.write([]byte{byte(step.Side)})
+		bw.writeHash(step.Hash)
+	}
+	if bw.err != nil {
+		return nil, bw.err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary 解码MarshalBinary或MarshalCompact的结果，实现encoding.BinaryUnmarshaler
+// 数据必须恰好用完；只检查格式，证明是否有效由VerifyProof判断
+func (p *Proof) UnmarshalBinary(data []byte) error {
+	br := &binaryReader{r: bytes.NewReader(data)}
+	magic := make([]byte, len(merkleProofMagic))
+	if _, err := br.read(magic); err != nil {
+		return err
+	}
+	if string(magic) == merkleProofCompactMagic {
+		return p.unmarshalCompact(br, len(data))
+	}
+	if string(magic) != merkleProofMagic {
+		return fmt.Errorf("invalid merkle proof header")
+	}
+
+	var fields [4]uint64 // scheme, leafIndex, treeSize, stepCount
+	for i := range fields {
+		v, err := br.readUvarint()
+		if err != nil {
+			return err
+		}
+		fields[i] = v
+	}
+	scheme := MerkleHashScheme(fields[0])
+	if scheme != MerkleHashLegacy && scheme != MerkleHashRFC6962 || fields[0] > 0xff {
+		return fmt.Errorf("unknown hash scheme %d", fields[0])
+	}
+	if fields[1] > math.MaxInt || fields[2] > math.MaxInt {
+		return fmt.Errorf("leaf index %d or tree size %d too large", fields[1], fields[2])
+	}
+	if fields[3] > maxProofSteps {
+		return fmt.Errorf("step count %d exceeds limit %d", fields[3], maxProofSteps)
+	}
+
+	steps := make([]ProofStep, fields[3])
+	for i := range steps {
+		side, err := br.ReadByte()
+		if err != nil {
+			return err
+		}
+		if side != byte(SiblingRight) && side != byte(SiblingLeft) {
+			return fmt.Errorf("unknown proof side %d", side)
+		}
+		hash, err := br.readHash()
+		if err != nil {
+			return err
+		}
+		steps[i] = ProofStep{Hash: hash, Side: ProofSide(side)}
+	}
+	if br.n != int64(len(data)) {
+		return fmt.Errorf("%d trailing bytes after proof", int64(len(data))-br.n)
+	}
+
+	*p = Proof{Scheme: scheme, LeafIndex: int(fields[1]), TreeSize: int(fields[2]), Steps: steps}
+	return nil
+}
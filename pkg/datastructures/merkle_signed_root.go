@@ -0,0 +1,129 @@
+package datastructures
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// merkleSignedRootMagic 签名根序列化格式的文件头
+const merkleSignedRootMagic = "MKS\x01"
+
+// merkleSignedRootDomain 签名消息的域分隔前缀，防止签名被挪用到其他用途的消息上
+const merkleSignedRootDomain = "merkle-root-v1\x00"
+
+// 签名的消息与序列化格式（MarshalBinary）：
+//
+//	消息：   domain("merkle-root-v1\x00") | root(32字节) | timestamp(8字节，大端Unix纳秒)
+//	序列化： magic(4字节，"MKS\x01") | root(32字节) | timestamp(8字节) | signature(64字节)
+//
+// 根哈希按SHA-256原始字节写入；时间戳只保留到纳秒，不保留时区
+
+// SignedRoot 用ed25519签名的默克尔根，可以直接用encoding/json编码
+// 发布方签名后分发，客户端持有发布方的公钥即可确认根哈希的来源和时间，
+// 再用VerifyProof等函数对这个根验证数据块
+type SignedRoot struct {
+	Root      string    `json:"root"`      // 根哈希（十六进制）
+	Timestamp time.Time `json:"timestamp"` // 签名时间
+	Signature []byte    `json:"signature"` // 对消息的ed25519签名
+}
+
+// signedRootMessage 返回被签名的消息，根哈希不是32字节的十六进制时返回错误
+func signedRootMessage(root string, ts time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	bw := &binaryWriter{w: &buf}
+	bw.write([]byte(merkleSignedRootDomain))
+	bw.writeHash(root)
+	bw.write(binary.BigEndian.AppendUint64(nil, uint64(ts.UnixNano())))
+	if bw.err != nil {
+		return nil, bw.err
+	}
+	return buf.Bytes(), nil
+}
+
+// SignRoot 用私钥对根哈希和时间戳签名
+// 空树的根哈希为""，不能签名；时间戳转换为UTC并只保留到纳秒，与序列化后的结果相同
+func SignRoot(priv ed25519.PrivateKey, root string, ts time.Time) (*SignedRoot, error) {
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid private key length %d", len(priv))
+	}
+	ts = time.Unix(0, ts.UnixNano()).UTC()
+	msg, err := signedRootMessage(root, ts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot sign root %q: %w", root, err)
+	}
+	return &SignedRoot{Root: root, Timestamp: ts, Signature: ed25519.Sign(priv, msg)}, nil
+}
+
+// SignRoot 用私钥对树当前的根哈希签名，时间戳为当前时间；空树返回错误
+func (mt *MerkleTree) SignRoot(priv ed25519.PrivateKey) (*SignedRoot, error) {
+	return SignRoot(priv, mt.GetRootHash(), time.Now())
+}
+
+// VerifySignedRoot 用公钥验证签名根，签名无效、公钥长度不对或根哈希格式错误时返回false
+// 只证明根哈希和时间戳出自私钥持有者，时间戳是否足够新由调用方判断
+func VerifySignedRoot(pub ed25519.PublicKey, sr *SignedRoot) bool {
+	if sr == nil || len(pub) != ed25519.PublicKeySize {
+		return false
+	}
+	msg, err := signedRootMessage(sr.Root, sr.Timestamp)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(pub, msg, sr.Signature)
+}
+
+// MarshalBinary 实现encoding.BinaryMarshaler
+func (sr *SignedRoot) MarshalBinary() ([]byte, error) {
+	if len(sr.Signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("invalid signature length %d", len(sr.Signature))
+	}
+	var buf bytes.Buffer
+	bw := &binaryWriter{w: &buf}
+	bw.write([]byte(merkleSignedRootMagic))
+	bw.writeHash(sr.Root)
+	bw.write(binary.BigEndian.AppendUint64(nil, uint64(sr.Timestamp.UnixNano())))
+	bw.write(sr.Signature)
+	if bw.err != nil {
+		return nil, bw.err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary 实现encoding.BinaryUnmarshaler，只解码不验证签名
+func (sr *SignedRoot) UnmarshalBinary(data []byte) error {
+	const size = len(merkleSignedRootMagic) + sha256.Size + 8 + ed25519.SignatureSize
+	if len(data) != size {
+		return fmt.Errorf("signed root is %d bytes, want %d", len(data), size)
+	}
+	br := &binaryReader{r: bytes.NewReader(data)}
+	magic := make([]byte, len(merkleSignedRootMagic))
+	if _, err := br.read(magic); err != nil {
+		return err
+	}
+	if string(magic) != merkleSignedRootMagic {
+		return fmt.Errorf("invalid signed root header")
+	}
+	root, err := br.readHash()
+	if err != nil {
+		return err
+	}
+	var ts [8]byte
+	if _, err := br.read(ts[:]); err != nil {
+		return err
+	}
+	sig := make([]byte, ed25519.SignatureSize)
+	if _, err := br.read(sig); err != nil {
+		return err
+	}
+
+	*sr = SignedRoot{
+		Root:      root,
+		Timestamp: time.Unix(0, int64(binary.BigEndian.Uint64(ts[:]))).UTC(),
+		Signature: sig,
+	}
+	return nil
+}
@@ -14,39 +14,25 @@ type MerkleNode struct {
 	children []*MerkleNode // 子节点（最多2个）
 	parent   *MerkleNode   // 父节点指针
 	isLeaf   bool          // 是否为叶子节点
+	scheme   MerkleHashScheme // 哈希计算方式
 }
 
-// NewMerkleNode 创建新的默克尔树节点
+// NewMerkleNode 创建新的默克尔树节点，使用MerkleHashLegacy
 func NewMerkleNode(data []byte, left, right *MerkleNode) *MerkleNode {
-	node := &MerkleNode{
-		data:     data,
-		children: make([]*MerkleNode, 0, 2),
-		isLeaf:   left == nil && right == nil,
-	}
-
-	if left != nil {
-		node.children = append(node.children, left)
-		left.parent = node
-	}
-
-	if right != nil {
-		node.children = append(node.children, right)
-		right.parent = node
-	}
-
-	node.hash = node.computeHash()
-	return node
+	return newMerkleNode(MerkleHashLegacy, data, left, right)
 }
 
 // computeHash 计算节点哈希值
 func (n *MerkleNode) computeHash() string {
 	if n.isLeaf {
 		// 叶子节点：直接对数据哈希
-		hash := sha256.Sum256(n.data)
-		return hex.EncodeToString(hash[:])
+		return merkleLeafHash(n.scheme, n.data)
+	}
+	if len(n.children) == 2 {
+		return merkleNodeHash(n.scheme, n.children[0].hash, n.children[1].hash)
 	}
 
-	// 内部节点：对子节点哈希连接后哈希
+	// 只有一个子节点（只有直接调用NewMerkleNode才会出现）：对子节点哈希连接后哈希
 	hashData := ""
 	for _, child := range n.children {
 		hashData += child.hash
@@ -69,43 +55,71 @@ type MerkleTree struct {
 	data     [][]byte     // 原始数据
 	mu       sync.RWMutex // 读写锁
 	count    int64        // 数据块数量
+	scheme   MerkleHashScheme // 哈希计算方式
+	buildWorkers int          // 构建时并行的goroutine数量（WithBuildWorkers）
+	hashOnly bool             // 只保留叶子哈希，不保留数据块
+	keyIndex map[string]int   // NewMerkleTreeFromKV构建时键的编码到叶子下标的映射
+	deferred bool             // UpdateData只标记失效路径，读取哈希时再重新计算（WithDeferredHashing）
+	hashMu   sync.Mutex       // 持有读锁时重新计算失效哈希的互斥锁
 }
 
 // NewMerkleTree 从数据块创建默克尔树
-func NewMerkleTree(data [][]byte) *MerkleTree {
+// opts: 可选配置，例如WithMerkleHashScheme、WithHashOnly
+func NewMerkleTree(data [][]byte, opts ...MerkleTreeOption) *MerkleTree {
 	if len(data) == 0 {
-		return &MerkleTree{
+		mt := &MerkleTree{
 			root:   nil,
 			leaves: make([]*MerkleNode, 0),
 			data:   make([][]byte, 0),
 			count:  0,
 		}
+		for _, opt := range opts {
+			opt(mt)
+		}
+		return mt
 	}
 
 	mt := &MerkleTree{
-		data:   make([][]byte, len(data)),
 		leaves: make([]*MerkleNode, 0, len(data)),
 		count:  int64(len(data)),
 	}
+	for _, opt := range opts {
+		opt(mt)
+	}
 
 	// 复制数据
-	copy(mt.data, data)
+	if mt.hashOnly {
+		mt.data = make([][]byte, 0)
+	} else {
+		mt.data = make([][]byte, len(data))
+		copy(mt.data, data)
+	}
+
+	if mt.buildWorkers > 1 {
+		mt.leaves, mt.root = buildMerkleTreeParallel(mt.scheme, data, mt.buildWorkers)
+		if mt.hashOnly {
+			for _, leaf := range mt.leaves {
+				leaf.data = nil
+			}
+		}
+		return mt
+	}
 
 	// 构建叶子节点
 	leaves := make([]*MerkleNode, len(data))
 	for i, d := range data {
-		leaves[i] = NewMerkleNode(d, nil, nil)
+		leaves[i] = mt.newLeaf(d)
 		mt.leaves = append(mt.leaves, leaves[i])
 	}
 
 	// 递归构建树
-	mt.root = buildMerkleTree(leaves)
+	mt.root = buildMerkleTree(mt.scheme, leaves)
 
 	return mt
 }
 
 // buildMerkleTree 递归构建默克尔树
-func buildMerkleTree(nodes []*MerkleNode) *MerkleNode {
+func buildMerkleTree(scheme MerkleHashScheme, nodes []*MerkleNode) *MerkleNode {
 	if len(nodes) == 1 {
 		return nodes[0]
 	}
@@ -119,11 +133,11 @@ func buildMerkleTree(nodes []*MerkleNode) *MerkleNode {
 			right = i
 		}
 
-		parent := NewMerkleNode(nil, nodes[i], nodes[right])
+		parent := newMerkleNode(scheme, nil, nodes[i], nodes[right])
 		nextLevel = append(nextLevel, parent)
 	}
 
-	return buildMerkleTree(nextLevel)
+	return buildMerkleTree(scheme, nextLevel)
 }
 
 // VerifyData 验证单个数据块的完整性
@@ -135,90 +149,75 @@ func (mt *MerkleTree) VerifyData(index int, data []byte) bool {
 		return false
 	}
 
-	expectedHash := mt.leaves[index].hash
-	actualHash := sha256.Sum256(data)
-	actualHashStr := hex.EncodeToString(actualHash[:])
-
-	return expectedHash == actualHashStr
+	return mt.leaves[index].hash == merkleLeafHash(mt.scheme, data)
 }
 
 // VerifyRoot 验证根哈希
 func (mt *MerkleTree) VerifyRoot(expectedRootHash string) bool {
 	mt.mu.RLock()
 	defer mt.mu.RUnlock()
+	mt.flushHashes()
 	return mt.root != nil && mt.root.hash == expectedRootHash
 }
 
 // GetProof 获取数据块的完整性证明
-// 返回从该叶子节点到根的所有兄弟节点的哈希值
-func (mt *MerkleTree) GetProof(index int) ([][]byte, []string, error) {
+// 返回从该叶子节点到根的每一层兄弟节点的哈希值及其所在的一侧
+func (mt *MerkleTree) GetProof(index int) (*Proof, error) {
 	mt.mu.RLock()
 	defer mt.mu.RUnlock()
+	return mt.getProofLocked(index)
+}
+
+// getProofLocked 获取数据块的完整性证明，调用方必须持有读锁
+func (mt *MerkleTree) getProofLocked(index int) (*Proof, error) {
+	mt.flushHashes()
 
 	if index < 0 || index >= len(mt.leaves) {
-		return nil, nil, fmt.Errorf("index out of range")
+		return nil, fmt.Errorf("index out of range")
 	}
 
-	var proof []string
-	var hashes [][]byte
-
-	node := mt.leaves[index]
+	proof := &Proof{
+		Scheme:    mt.scheme,
+		LeafIndex: index,
+		TreeSize:  len(mt.leaves),
+		Steps:     make([]ProofStep, 0, merkleHeight(len(mt.leaves))),
+	}
 
 	// 从叶子节点向上遍历到根节点
+	node := mt.leaves[index]
 	for node.parent != nil {
 		parent := node.parent
-		var sibling *MerkleNode
-
-		// 找到兄弟节点
-		if len(parent.children) == 2 {
-			if parent.children[0] == node {
-				sibling = parent.children[1]
-			} else {
-				sibling = parent.children[0]
-			}
 
-			proof = append(proof, sibling.hash)
-			hashes = append(hashes, []byte(sibling.hash))
+		// 奇数个节点时最后一个节点与自己配对，两个子节点相同，兄弟在右侧
+		if parent.children[0] == node {
+			proof.Steps = append(proof.Steps, ProofStep{Hash: parent.children[1].hash, Side: SiblingRight})
+		} else {
+			proof.Steps = append(proof.Steps, ProofStep{Hash: parent.children[0].hash, Side: SiblingLeft})
 		}
 
 		node = parent
 	}
 
-	// 反转数组（从叶子到根改为从根到叶子）
-	for i, j := 0, len(proof)-1; i < j; i, j = i+1, j-1 {
-		proof[i], proof[j] = proof[j], proof[i]
-		hashes[i], hashes[j] = hashes[j], hashes[i]
-	}
-
-	return hashes, proof, nil
+	return proof, nil
 }
 
-// VerifyProof 验证完整性证明
-// proof: 兄弟节点哈希值数组（从根到叶子）
-// targetHash: 目标数据的哈希值
+// VerifyProof 验证完整性证明，按proof.Scheme计算哈希
+// data: 数据块
+// proof: GetProof返回的证明
 // rootHash: 期望的根哈希值
-func VerifyProof(data []byte, proof []string, rootHash string) bool {
-	// 计算数据块的哈希值
-	hash := sha256.Sum256(data)
-	hashStr := hex.EncodeToString(hash[:])
-
-	// 从叶子开始向上验证
-	currentHash := hashStr
-
-	for _, siblingHash := range proof {
-		// 连接当前哈希和兄弟节点哈希
-		combined := currentHash + siblingHash
-		combinedHash := sha256.Sum256([]byte(combined))
-		currentHash = hex.EncodeToString(combinedHash[:])
+func VerifyProof(data []byte, proof *Proof, rootHash string) bool {
+	if proof == nil {
+		return false
 	}
-
-	return currentHash == rootHash
+	root, ok := proof.RootFromLeafHash(merkleLeafHash(proof.Scheme, data))
+	return ok && root == rootHash
 }
 
 // GetRootHash 获取根哈希值
 func (mt *MerkleTree) GetRootHash() string {
 	mt.mu.RLock()
 	defer mt.mu.RUnlock()
+	mt.flushHashes()
 	if mt.root == nil {
 		return ""
 	}
@@ -230,6 +229,9 @@ func (mt *MerkleTree) RangeQuery(start, end int) ([][]byte, error) {
 	mt.mu.RLock()
 	defer mt.mu.RUnlock()
 
+	if mt.hashOnly {
+		return nil, fmt.Errorf("data blocks are not retained")
+	}
 	if start < 0 || end > len(mt.data) || start >= end {
 		return nil, fmt.Errorf("invalid range")
 	}
@@ -242,7 +244,7 @@ func (mt *MerkleTree) RangeQuery(start, end int) ([][]byte, error) {
 	return result, nil
 }
 
-// GetAllData 获取所有数据
+// GetAllData 获取所有数据，不保留数据块的树返回空切片
 func (mt *MerkleTree) GetAllData() [][]byte {
 	mt.mu.RLock()
 	defer mt.mu.RUnlock()
@@ -262,12 +264,22 @@ func (mt *MerkleTree) UpdateData(index int, newData []byte) error {
 	}
 
 	// 更新数据
-	mt.data[index] = newData
+	if !mt.hashOnly {
+		mt.data[index] = newData
+	}
 
 	// 重新计算从叶子节点到根节点的哈希值
 	node := mt.leaves[index]
 	node.data = newData
 	node.hash = node.computeHash()
+	if mt.hashOnly {
+		node.data = nil
+	}
+
+	if mt.deferred {
+		invalidatePath(node.parent)
+		return nil
+	}
 
 	// 向上更新父节点
 	for node.parent != nil {
@@ -313,6 +325,7 @@ func (mt *MerkleTree) Height() int {
 func (mt *MerkleTree) String() string {
 	mt.mu.RLock()
 	defer mt.mu.RUnlock()
+	mt.flushHashes()
 
 	var result string
 	result += fmt.Sprintf("MerkleTree(count=%d, root=%s):\n", mt.count, mt.root.hash)
@@ -336,68 +349,22 @@ func (n *MerkleNode) String(prefix string) string {
 	return result
 }
 
-// NewMerkleTreeFromKV 从键值对创建默克尔树
-func NewMerkleTreeFromKV(kvs []KeyValue) *MerkleTree {
-	data := make([][]byte, len(kvs))
-	for i, kv := range kvs {
-		// 将键值对序列化为字节数组
-		kvBytes := []byte(fmt.Sprintf("%v:%v", kv.Key, kv.Value))
-		data[i] = kvBytes
-	}
-	return NewMerkleTree(data)
-}
-
-// BinaryMerkleTree 二进制默克尔树版本
-// 优化的实现，要求数据块数量为2的幂次方
-type BinaryMerkleTree struct {
-	root     *MerkleNode
-	leaves   []*MerkleNode
-	data     [][]byte
-	mu       sync.RWMutex
-	count    int64
-}
 
-// NewBinaryMerkleTree 创建二进制默克尔树
-func NewBinaryMerkleTree(data [][]byte) (*BinaryMerkleTree, error) {
-	if len(data) == 0 {
-		return &BinaryMerkleTree{}, nil
-	}
-
-	// 检查是否为2的幂次方
-	if len(data)&(len(data)-1) != 0 {
-		return nil, fmt.Errorf("data length must be power of 2")
-	}
-
-	mt := &BinaryMerkleTree{
-		data:   make([][]byte, len(data)),
-		leaves: make([]*MerkleNode, 0, len(data)),
-		count:  int64(len(data)),
+// Describe 返回默克尔树的能力描述
+func (mt *MerkleTree) Describe() Descriptor {
+	return Descriptor{
+		Name:           "MerkleTree",
+		Ordered:        true,
+		SupportsRange:  true,
+		SupportsDelete: true,
+		Persistent:     true,
+		Concurrency:    ConcurrencyRWMutex,
+		Complexity: Complexity{
+			Insert: "O(log n) 追加，O(n-i) 在位置i插入",
+			Search: "O(1) 按索引",
+			Delete: "O(n-i) 删除位置i",
+			Range:  "O(k) 按索引",
+		},
+		Notes: "按位置寻址，支持数据完整性验证和O(log n)大小的证明；更新和追加数据块为O(log n)",
 	}
-
-	copy(mt.data, data)
-
-	// 构建叶子节点
-	leaves := make([]*MerkleNode, len(data))
-	for i, d := range data {
-		leaves[i] = NewMerkleNode(d, nil, nil)
-		mt.leaves = append(mt.leaves, leaves[i])
-	}
-
-	// 使用二进制方式构建树
-	mt.root = mt.buildBinaryTree(leaves, 0, len(leaves))
-
-	return mt, nil
-}
-
-// buildBinaryTree 二进制方式构建树
-func (mt *BinaryMerkleTree) buildBinaryTree(nodes []*MerkleNode, start, end int) *MerkleNode {
-	if end-start == 1 {
-		return nodes[start]
-	}
-
-	mid := (start + end) / 2
-	left := mt.buildBinaryTree(nodes, start, mid)
-	right := mt.buildBinaryTree(nodes, mid, end)
-
-	return NewMerkleNode(nil, left, right)
 }
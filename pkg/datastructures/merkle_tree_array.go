@@ -0,0 +1,265 @@
+package datastructures
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/bits"
+	"sync"
+)
+
+// merkleArrayMagic 二进制默克尔树序列化格式的文件头
+const merkleArrayMagic = "MKB\x01"
+
+// BinaryMerkleTree 二进制默克尔树版本
+// 数据块数量为2的幂次方的完全二叉树，用隐式数组表示，不为节点分配指针：
+// - nodes[1]为根，nodes[i]的子节点为nodes[2i]和nodes[2i+1]，父节点为nodes[i/2]
+// - 第j个叶子位于nodes[n+j]，nodes[0]不使用
+// - 哈希按SHA-256原始字节保存，每个节点32字节，约为MerkleTree的指针节点的五分之一
+// 树的大小在创建后固定，只支持原地UpdateData；需要追加、插入或删除数据块时使用MerkleTree。
+// 根哈希和证明与相同数据块、相同哈希方式的MerkleTree完全一致，可以用VerifyProof验证
+type BinaryMerkleTree struct {
+	nodes  [][sha256.Size]byte
+	mu     sync.RWMutex
+	count  int64
+	scheme MerkleHashScheme
+}
+
+// NewBinaryMerkleTree 创建二进制默克尔树，数据块数量必须为2的幂次方
+// opts: 可选配置，只使用WithMerkleHashScheme；树只保存哈希，不保留数据块
+func NewBinaryMerkleTree(data [][]byte, opts ...MerkleTreeOption) (*BinaryMerkleTree, error) {
+	var cfg MerkleTree
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if len(data) == 0 {
+		return &BinaryMerkleTree{scheme: cfg.scheme}, nil
+	}
+
+	// 检查是否为2的幂次方
+	if len(data)&(len(data)-1) != 0 {
+		return nil, fmt.Errorf("data length must be power of 2")
+	}
+
+	n := len(data)
+	mt := &BinaryMerkleTree{
+		nodes:  make([][sha256.Size]byte, 2*n),
+		count:  int64(n),
+		scheme: cfg.scheme,
+	}
+	for i, d := range data {
+		mt.nodes[n+i] = binaryLeafHash(mt.scheme, d)
+	}
+	// 从后往前计算，子节点总是先于父节点
+	for i := n - 1; i >= 1; i-- {
+		mt.nodes[i] = binaryNodeHash(mt.scheme, mt.nodes[2*i], mt.nodes[2*i+1])
+	}
+	return mt, nil
+}
+
+// binaryLeafHash 计算叶子哈希的原始字节，与merkleLeafHash一致
+func binaryLeafHash(scheme MerkleHashScheme, data []byte) [sha256.Size]byte {
+	if scheme != MerkleHashRFC6962 {
+		return sha256.Sum256(data)
+	}
+	var hash [sha256.Size]byte
+	h := sha256.New()
+	h.Write([]byte{merkleLeafPrefix})
+	h.Write(data)
+	h.Sum(hash[:0])
+	return hash
+}
+
+// binaryNodeHash 计算内部节点哈希的原始字节，与merkleNodeHash一致
+func binaryNodeHash(scheme MerkleHashScheme, left, right [sha256.Size]byte) [sha256.Size]byte {
+	var buf [1 + 4*sha256.Size]byte
+	if scheme != MerkleHashRFC6962 {
+		// MerkleHashLegacy对两个子哈希的十六进制连接后哈希
+		hex.Encode(buf[:2*sha256.Size], left[:])
+		hex.Encode(buf[2*sha256.Size:4*sha256.Size], right[:])
+		return sha256.Sum256(buf[:4*sha256.Size])
+	}
+	buf[0] = merkleNodePrefix
+	copy(buf[1:], left[:])
+	copy(buf[1+sha256.Size:], right[:])
+	return sha256.Sum256(buf[:1+2*sha256.Size])
+}
+
+// leafCount 返回数据块数量，调用方必须持有读锁
+func (mt *BinaryMerkleTree) leafCount() int {
+	return len(mt.nodes) / 2
+}
+
+// GetRootHash 获取根哈希值，空树为""
+func (mt *BinaryMerkleTree) GetRootHash() string {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+	if len(mt.nodes) == 0 {
+		return ""
+	}
+	return hex.EncodeToString(mt.nodes[1][:])
+}
+
+// Size 返回数据块数量
+func (mt *BinaryMerkleTree) Size() int64 {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+	return mt.count
+}
+
+// Height 返回树的高度，与MerkleTree.Height相同，只有一个数据块时为1
+func (mt *BinaryMerkleTree) Height() int {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+	if len(mt.nodes) == 0 {
+		return 0
+	}
+	return bits.TrailingZeros(uint(mt.leafCount())) + 1
+}
+
+// VerifyData 验证指定索引的数据块
+func (mt *BinaryMerkleTree) VerifyData(index int, data []byte) bool {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+
+	n := mt.leafCount()
+	if index < 0 || index >= n {
+		return false
+	}
+	return mt.nodes[n+index] == binaryLeafHash(mt.scheme, data)
+}
+
+// UpdateData 更新指定索引的数据块，沿父节点下标重新计算到根的哈希，O(log n)
+func (mt *BinaryMerkleTree) UpdateData(index int, data []byte) error {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+
+	n := mt.leafCount()
+	if index < 0 || index >= n {
+		return fmt.Errorf("index out of range")
+	}
+	i := n + index
+	mt.nodes[i] = binaryLeafHash(mt.scheme, data)
+	for i >>= 1; i >= 1; i >>= 1 {
+		mt.nodes[i] = binaryNodeHash(mt.scheme, mt.nodes[2*i], mt.nodes[2*i+1])
+	}
+	return nil
+}
+
+// GetProof 获取数据块的完整性证明，兄弟节点为nodes[i^1]
+func (mt *BinaryMerkleTree) GetProof(index int) (*Proof, error) {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+
+	n := mt.leafCount()
+	if index < 0 || index >= n {
+		return nil, fmt.Errorf("index out of range")
+	}
+
+	proof := &Proof{
+		Scheme:    mt.scheme,
+		LeafIndex: index,
+		TreeSize:  n,
+		Steps:     make([]ProofStep, 0, merkleHeight(n)),
+	}
+	for i := n + index; i > 1; i >>= 1 {
+		proof.Steps = append(proof.Steps, ProofStep{Hash: hex.EncodeToString(mt.nodes[i^1][:]), Side: sideAt(i)})
+	}
+	return proof, nil
+}
+
+// 序列化格式：
+//
+//	magic(4字节，"MKB\x01") | scheme(uvarint) | count(uvarint) | (2·count-1) × hash(32字节)
+//
+// 按数组顺序写入nodes[1:]，加载时直接作为数组使用；每个内部节点都会对照子节点重新验证
+
+// MarshalBinary 实现encoding.BinaryMarshaler
+func (mt *BinaryMerkleTree) MarshalBinary() ([]byte, error) {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+
+	var buf bytes.Buffer
+	bw := &binaryWriter{w: &buf}
+	bw.write([]byte(merkleArrayMagic))
+	bw.writeUvarint(uint64(mt.scheme))
+	bw.writeUvarint(uint64(mt.leafCount()))
+	for i := 1; i < len(mt.nodes); i++ {
+		bw.write(mt.nodes[i][:])
+	}
+	if bw.err != nil {
+		return nil, bw.err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary 实现encoding.BinaryUnmarshaler
+// 数据块数量不是2的幂次方、长度不符或内部节点哈希与子节点不一致时返回错误
+func (mt *BinaryMerkleTree) UnmarshalBinary(data []byte) error {
+	br := &binaryReader{r: bytes.NewReader(data)}
+	magic := make([]byte, len(merkleArrayMagic))
+	if _, err := br.read(magic); err != nil {
+		return err
+	}
+	if string(magic) != merkleArrayMagic {
+		return fmt.Errorf("invalid binary merkle tree header")
+	}
+	scheme, err := br.readUvarint()
+	if err != nil {
+		return err
+	}
+	if scheme != uint64(MerkleHashLegacy) && scheme != uint64(MerkleHashRFC6962) {
+		return fmt.Errorf("unknown hash scheme %d", scheme)
+	}
+	count, err := br.readUvarint()
+	if err != nil {
+		return err
+	}
+	if count&(count-1) != 0 {
+		return fmt.Errorf("data length %d is not a power of 2", count)
+	}
+	// 先检查长度，避免按伪造的count分配内存
+	if rest := uint64(len(data)) - uint64(br.n); count > 0 && rest != (2*count-1)*sha256.Size {
+		return fmt.Errorf("%d bytes of hashes for %d leaves", rest, count)
+	}
+
+	var nodes [][sha256.Size]byte
+	if count > 0 {
+		nodes = make([][sha256.Size]byte, 2*count)
+		for i := 1; i < len(nodes); i++ {
+			if _, err := br.read(nodes[i][:]); err != nil {
+				return err
+			}
+		}
+		for i := 1; i < int(count); i++ {
+			if nodes[i] != binaryNodeHash(MerkleHashScheme(scheme), nodes[2*i], nodes[2*i+1]) {
+				return fmt.Errorf("node %d does not match its children", i)
+			}
+		}
+	}
+	if br.n != int64(len(data)) {
+		return fmt.Errorf("%d trailing bytes after tree", int64(len(data))-br.n)
+	}
+
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	mt.nodes = nodes
+	mt.count = int64(count)
+	mt.scheme = MerkleHashScheme(scheme)
+	return nil
+}
+
+// Describe 返回二进制默克尔树的能力描述
+func (mt *BinaryMerkleTree) Describe() Descriptor {
+	return Descriptor{
+		Name:        "BinaryMerkleTree",
+		Ordered:     true,
+		Persistent:  true,
+		Concurrency: ConcurrencyRWMutex,
+		Complexity: Complexity{
+			Search: "O(1) 按索引验证",
+		},
+		Notes: "数据块数量必须为2的幂次方，隐式数组布局（父节点在i/2），只保存哈希，大小固定，支持O(log n)原地更新",
+	}
+}
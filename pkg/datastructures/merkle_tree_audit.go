@@ -0,0 +1,85 @@
+package datastructures
+
+import "fmt"
+
+// Audit 由叶子重新计算树中每个节点的哈希，检查内存中的树是否完整一致
+// 按从左到右、先子节点后父节点的顺序检查，返回第一个出错节点的描述，包括从根出发的路径（L/R序列）：
+// - 保留数据块时由数据块重新计算叶子哈希；只保留哈希时叶子哈希无从验证，只检查内部节点
+// - 内部节点的哈希必须等于由两个子节点计算的哈希，子节点的父指针必须指向它
+// - 树的形状必须与数据块数量一致：第l层的第i个节点覆盖叶子[i·2^l, (i+1)·2^l)，奇数个节点时最后一个节点与自己配对
+// 用于发现内存损坏或增量更新逻辑中的错误，代价为O(n)次哈希；一致时返回nil
+// 启用WithDeferredHashing时先重新计算失效的哈希，再检查
+func (mt *MerkleTree) Audit() error {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+	mt.flushHashes()
+
+	n := len(mt.leaves)
+	if int64(n) != mt.count {
+		return fmt.Errorf("count %d does not match %d leaves", mt.count, n)
+	}
+	if !mt.hashOnly && len(mt.data) != n {
+		return fmt.Errorf("%d data blocks for %d leaves", len(mt.data), n)
+	}
+	if n == 0 {
+		if mt.root != nil {
+			return fmt.Errorf("empty tree has a root")
+		}
+		return nil
+	}
+	if mt.root == nil || mt.root.parent != nil {
+		return fmt.Errorf("missing root or root has a parent")
+	}
+	return mt.auditNode(mt.root, merkleHeight(n), 0, "")
+}
+
+// auditNode 检查第level层的第i个节点及其子树，path为从根到它的路径，调用方必须持有读锁
+func (mt *MerkleTree) auditNode(node *MerkleNode, level, i int, path string) error {
+	where := func() string {
+		if path == "" {
+			return fmt.Sprintf("node at level %d index %d (root)", level, i)
+		}
+		return fmt.Sprintf("node at level %d index %d (path %s)", level, i, path)
+	}
+
+	if level == 0 {
+		if node != mt.leaves[i] || !node.isLeaf {
+			return fmt.Errorf("%s is not leaf %d", where(), i)
+		}
+		if mt.hashOnly {
+			return nil
+		}
+		if computed := merkleLeafHash(mt.scheme, mt.data[i]); node.hash != computed {
+			return fmt.Errorf("%s: stored hash %s, computed %s from data block", where(), node.hash, computed)
+		}
+		return nil
+	}
+
+	if node.isLeaf || len(node.children) != 2 {
+		return fmt.Errorf("%s: expected an internal node with 2 children, got %d", where(), len(node.children))
+	}
+	left, right := node.children[0], node.children[1]
+	if left.parent != node || right.parent != node {
+		return fmt.Errorf("%s: child parent pointer does not point back", where())
+	}
+	if err := mt.auditNode(left, level-1, 2*i, path+"L"); err != nil {
+		return err
+	}
+	// 下一层的节点数；右孩子超出时必须与左孩子是同一个节点
+	below := (len(mt.leaves) + 1<<(level-1) - 1) >> (level - 1)
+	if 2*i+1 < below {
+		if right == left {
+			return fmt.Errorf("%s: right child duplicates the left child", where())
+		}
+		if err := mt.auditNode(right, level-1, 2*i+1, path+"R"); err != nil {
+			return err
+		}
+	} else if right != left {
+		return fmt.Errorf("%s: last node at its level must pair with itself", where())
+	}
+
+	if computed := merkleNodeHash(mt.scheme, left.hash, right.hash); node.hash != computed {
+		return fmt.Errorf("%s: stored hash %s, computed %s from children", where(), node.hash, computed)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package datastructures
+
+// WithDeferredHashing 延迟重新计算内部节点的哈希
+// UpdateData只更新叶子哈希，并把从叶子到根的路径标记为失效（哈希置为""），
+// 到第一次需要内部节点哈希时（GetRootHash、GetProof、Diff、WriteTo等）或调用Recompute时才统一重新计算：
+// - 遇到已失效的祖先即停止标记，连续k次更新的标记代价之和不超过O(k + 失效节点数)
+// - 重新计算只访问失效节点，共享祖先只哈希一次，k次更新的代价为O(失效节点数)而不是O(k·log n)
+// - VerifyData和VerifyKV只比较叶子哈希，不触发重新计算
+// 适合成批更新大量数据块、之后才读取根哈希的场景
+func WithDeferredHashing() MerkleTreeOption {
+	return func(mt *MerkleTree) {
+		mt.deferred = true
+	}
+}
+
+// invalidatePath 把节点及其祖先的哈希标记为失效，调用方必须持有写锁
+// 失效节点的祖先总是也已失效，遇到已失效的节点即可停止
+func invalidatePath(n *MerkleNode) {
+	for ; n != nil && n.hash != ""; n = n.parent {
+		n.hash = ""
+	}
+}
+
+// recomputeNode 重新计算子树中所有失效节点的哈希
+func recomputeNode(n *MerkleNode) {
+	if n.hash != "" || n.isLeaf {
+		return
+	}
+	for _, child := range n.children {
+		recomputeNode(child)
+	}
+	n.hash = n.computeHash()
+}
+
+// flushHashes 重新计算失效的哈希，调用方必须持有读锁或写锁
+// 读取内部节点哈希之前必须调用；多个读者由hashMu互斥，之后读到的都是最新的哈希
+func (mt *MerkleTree) flushHashes() {
+	if !mt.deferred {
+		return
+	}
+	mt.hashMu.Lock()
+	defer mt.hashMu.Unlock()
+	if mt.root != nil {
+		recomputeNode(mt.root)
+	}
+}
+
+// Recompute 立即重新计算所有失效的哈希，未启用WithDeferredHashing时不做任何事
+// 可以在一批更新之后、并发读取之前调用，把重新计算的代价移出读取路径
+func (mt *MerkleTree) Recompute() {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+	mt.flushHashes()
+}
+
+// Deferred 返回树是否延迟重新计算内部节点的哈希
+func (mt *MerkleTree) Deferred() bool {
+	return mt.deferred
+}
@@ -0,0 +1,73 @@
+package datastructures
+
+import "unsafe"
+
+// Diff 返回两棵树中内容不同的数据块下标（升序）
+// 自顶向下比较子树哈希，哈希相同的子树整体跳过，只有d个数据块不同时代价约为O(d·log² n)，
+// 副本之间同步时只需传输这些数据块
+// 两棵树的大小不同时，较长的树多出的数据块都算作不同；哈希方式不同时哈希无法比较，全部数据块都算作不同
+func (mt *MerkleTree) Diff(other *MerkleTree) []int {
+	if other == mt {
+		return nil
+	}
+
+	// 按地址顺序加锁：a.Diff(b)与b.Diff(a)并发且两棵树都有等待中的写者时，
+	// 各自的第二个RLock会排在对方的写者之后，顺序不固定就会死锁
+	first, second := mt, other
+	if uintptr(unsafe.Pointer(second)) < uintptr(unsafe.Pointer(first)) {
+		first, second = second, first
+	}
+	first.mu.RLock()
+	defer first.mu.RUnlock()
+	second.mu.RLock()
+	defer second.mu.RUnlock()
+	mt.flushHashes()
+	other.flushHashes()
+
+	n1, n2 := len(mt.leaves), len(other.leaves)
+	common := n1
+	if n2 < common {
+		common = n2
+	}
+	longest := n1
+	if n2 > longest {
+		longest = n2
+	}
+
+	var diff []int
+	if mt.scheme != other.scheme {
+		for i := 0; i < longest; i++ {
+			diff = append(diff, i)
+		}
+		return diff
+	}
+
+	h1, h2 := merkleHeight(n1), merkleHeight(n2)
+	var walk func(level, i int)
+	walk = func(level, i int) {
+		if i<<level >= common {
+			return
+		}
+		// 完全落在公共部分的子树在两棵树中覆盖相同的数据块，可以直接比较；
+		// 两棵树大小相同时形状也相同，跨越边界的子树同样可以比较
+		if (i+1)<<level <= common || n1 == n2 {
+			if merkleNodeAt(mt.root, h1, level, i).hash == merkleNodeAt(other.root, h2, level, i).hash {
+				return
+			}
+		}
+		if level == 0 {
+			diff = append(diff, i)
+			return
+		}
+		walk(level-1, 2*i)
+		walk(level-1, 2*i+1)
+	}
+	if common > 0 {
+		walk(merkleHeight(common), 0)
+	}
+
+	for i := common; i < longest; i++ {
+		diff = append(diff, i)
+	}
+	return diff
+}
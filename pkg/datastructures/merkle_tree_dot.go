@@ -0,0 +1,60 @@
+package datastructures
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// merkleDOTHashLen DOT图中显示的哈希前缀长度（十六进制字符数）
+const merkleDOTHashLen = 8
+
+// ExportDOT 把树写成Graphviz的DOT格式，用于调试证明生成和验证失败的问题
+// 每个节点显示所在的层、层内下标和哈希的前缀，叶子另外显示数据块下标；
+// 奇数个节点时与自己配对的节点只画一条虚线边。节点名为n<level>_<index>，
+// 与Audit报告的位置一致，可以直接在图中找到出错的节点
+// 示例：dot -Tsvg tree.dot -o tree.svg
+func (mt *MerkleTree) ExportDOT(w io.Writer) error {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+	mt.flushHashes()
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph MerkleTree {")
+	fmt.Fprintln(bw, "\tnode [shape=box, fontname=\"monospace\"];")
+	if mt.root != nil {
+		height := merkleHeight(len(mt.leaves))
+		mt.writeDOTNode(bw, mt.root, height, 0)
+		// 叶子放在同一行，按下标从左到右排列
+		fmt.Fprint(bw, "\t{ rank=same;")
+		for i := range mt.leaves {
+			fmt.Fprintf(bw, " n0_%d;", i)
+		}
+		fmt.Fprintln(bw, " }")
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// writeDOTNode 写出第level层第i个节点及其子树，调用方必须持有读锁
+func (mt *MerkleTree) writeDOTNode(w io.Writer, node *MerkleNode, level, i int) {
+	hash := node.hash
+	if len(hash) > merkleDOTHashLen {
+		hash = hash[:merkleDOTHashLen] + "…"
+	}
+	if level == 0 {
+		fmt.Fprintf(w, "\tn0_%d [label=\"leaf #%d\\n%s\", style=filled, fillcolor=lightgrey];\n", i, i, hash)
+		return
+	}
+	fmt.Fprintf(w, "\tn%d_%d [label=\"L%d #%d\\n%s\"];\n", level, i, level, i, hash)
+
+	left, right := node.children[0], node.children[1]
+	mt.writeDOTNode(w, left, level-1, 2*i)
+	if right == left {
+		fmt.Fprintf(w, "\tn%d_%d -> n%d_%d [style=dashed, label=\"self\"];\n", level, i, level-1, 2*i)
+		return
+	}
+	mt.writeDOTNode(w, right, level-1, 2*i+1)
+	fmt.Fprintf(w, "\tn%d_%d -> n%d_%d;\n", level, i, level-1, 2*i)
+	fmt.Fprintf(w, "\tn%d_%d -> n%d_%d;\n", level, i, level-1, 2*i+1)
+}
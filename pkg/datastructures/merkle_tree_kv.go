@@ -0,0 +1,91 @@
+package datastructures
+
+import "fmt"
+
+// kvLeafData 将键值对序列化为叶子数据块
+func kvLeafData(key, value any) []byte {
+	return []byte(fmt.Sprintf("%v:%v", key, value))
+}
+
+// NewMerkleTreeFromKV 从键值对创建默克尔树
+// 同时记录每个键所在的叶子下标，可以用GetProofForKey和VerifyKV按键获取证明和验证，
+// 调用方不需要自己维护下标；键经DefaultKeyEncoder编码后比较，重复的键以最后一个为准
+func NewMerkleTreeFromKV(kvs []KeyValue, opts ...MerkleTreeOption) *MerkleTree {
+	data := make([][]byte, len(kvs))
+	keyIndex := make(map[string]int, len(kvs))
+	for i, kv := range kvs {
+		data[i] = kvLeafData(kv.Key, kv.Value)
+		// 无法编码的键仍然作为叶子参与构建，只是不能按键查找
+		if enc, err := (DefaultKeyEncoder{}).AppendKey(nil, kv.Key); err == nil {
+			keyIndex[string(enc)] = i
+		}
+	}
+	mt := NewMerkleTree(data, opts...)
+	mt.keyIndex = keyIndex
+	return mt
+}
+
+// indexOfKey 返回键所在的叶子下标，调用方必须持有锁
+func (mt *MerkleTree) indexOfKey(key any) (int, error) {
+	enc, err := DefaultKeyEncoder{}.AppendKey(nil, key)
+	if err != nil {
+		return 0, err
+	}
+	index, ok := mt.keyIndex[string(enc)]
+	if !ok {
+		return 0, fmt.Errorf("key %v not found", key)
+	}
+	return index, nil
+}
+
+// IndexOfKey 返回NewMerkleTreeFromKV构建的树中键所在的叶子下标
+func (mt *MerkleTree) IndexOfKey(key any) (int, bool) {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+
+	index, err := mt.indexOfKey(key)
+	return index, err == nil
+}
+
+// GetProofForKey 获取键所在数据块的完整性证明，验证时数据块为键值对的序列化结果
+func (mt *MerkleTree) GetProofForKey(key any) (*Proof, error) {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+
+	// 查找下标和生成证明在同一个读锁内，避免中间的InsertLeaf/DeleteLeaf移动下标
+	index, err := mt.indexOfKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return mt.getProofLocked(index)
+}
+
+// VerifyKV 验证键值对与树中该键所在的数据块一致，键不存在时返回false
+func (mt *MerkleTree) VerifyKV(key, value any) bool {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+
+	index, err := mt.indexOfKey(key)
+	if err != nil {
+		return false
+	}
+	return mt.leaves[index].hash == merkleLeafHash(mt.scheme, kvLeafData(key, value))
+}
+
+// VerifyKVProof 用GetProofForKey返回的证明验证键值对，按proof.Scheme计算哈希
+func VerifyKVProof(key, value any, proof *Proof, rootHash string) bool {
+	return VerifyProof(kvLeafData(key, value), proof, rootHash)
+}
+
+// shiftKeys 在下标index处插入（delta为1）或删除（delta为-1）叶子后调整键的下标，调用方必须持有写锁
+// 删除时被删除叶子的键不再可查
+func (mt *MerkleTree) shiftKeys(index, delta int) {
+	for enc, i := range mt.keyIndex {
+		switch {
+		case i == index && delta < 0:
+			delete(mt.keyIndex, enc)
+		case i >= index:
+			mt.keyIndex[enc] = i + delta
+		}
+	}
+}
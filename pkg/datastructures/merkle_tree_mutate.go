@@ -0,0 +1,154 @@
+package datastructures
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// merkleHeight 返回n个叶子的树中根节点所在的层，叶子为第0层
+func merkleHeight(n int) int {
+	if n <= 1 {
+		return 0
+	}
+	return bits.Len(uint(n - 1))
+}
+
+// merkleNodeAt 从根向下找到第level层的第i个节点，根位于第height层
+// 第level层的第i个节点覆盖叶子[i·2^level, (i+1)·2^level)，路径由i的二进制位决定
+func merkleNodeAt(root *MerkleNode, height, level, i int) *MerkleNode {
+	node := root
+	for lv := height; lv > level; lv-- {
+		node = node.children[(i>>(lv-1-level))&1]
+	}
+	return node
+}
+
+// rebuildFrom 在mt.leaves中下标start及之后的叶子发生变化后重建树
+// oldRoot、oldSize为变化之前的根和叶子数
+// 只覆盖start之前叶子的子树保持不变，第l层只重建下标不小于start>>l的节点，
+// 追加叶子时每层只新建一个节点，代价为O(log n)
+func (mt *MerkleTree) rebuildFrom(start int, oldRoot *MerkleNode, oldSize int) {
+	// 新节点由子节点的哈希计算，保留的子树中不能有失效的哈希
+	mt.flushHashes()
+	n := len(mt.leaves)
+	if n == 0 {
+		mt.root = nil
+		return
+	}
+
+	oldHeight := merkleHeight(oldSize)
+	cur := mt.leaves[start:] // 当前层从start开始的节点
+	for level, count := 0, n; count > 1; level, count = level+1, (count+1)/2 {
+		parentStart := start >> 1
+		next := make([]*MerkleNode, 0, (count+1)/2-parentStart)
+		for j := parentStart; 2*j < count; j++ {
+			// 左孩子在start之前时属于不变的子树，从旧树中取出
+			var left *MerkleNode
+			if 2*j >= start {
+				left = cur[2*j-start]
+			} else {
+				left = merkleNodeAt(oldRoot, oldHeight, level, 2*j)
+			}
+			// 奇数个节点，最后一个节点复制，与buildMerkleTree一致
+			right := left
+			if 2*j+1 < count {
+				right = cur[2*j+1-start]
+			}
+			next = append(next, newMerkleNode(mt.scheme, nil, left, right))
+		}
+		cur, start = next, parentStart
+	}
+
+	if len(cur) > 0 {
+		mt.root = cur[0]
+	} else {
+		// 删除末尾的叶子后剩余的叶子恰好构成旧树中一棵完整的子树
+		mt.root = merkleNodeAt(oldRoot, oldHeight, merkleHeight(n), 0)
+	}
+	mt.root.parent = nil
+}
+
+// newLeaf 为数据块创建叶子节点，不保留数据块时只保留哈希
+func (mt *MerkleTree) newLeaf(data []byte) *MerkleNode {
+	leaf := newMerkleNode(mt.scheme, data, nil, nil)
+	if mt.hashOnly {
+		leaf.data = nil
+	}
+	return leaf
+}
+
+// AppendLeaf 在末尾追加一个数据块，返回它的下标
+// 只新建从新叶子到根路径上的节点，代价为O(log n)，适合只追加的日志；
+// 结果与用全部数据调用NewMerkleTree构建的树相同
+func (mt *MerkleTree) AppendLeaf(data []byte) int {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+
+	index := len(mt.leaves)
+	oldRoot := mt.root
+	if !mt.hashOnly {
+		mt.data = append(mt.data, data)
+	}
+	mt.leaves = append(mt.leaves, mt.newLeaf(data))
+	mt.count++
+	mt.rebuildFrom(index, oldRoot, index)
+	return index
+}
+
+// InsertLeaf 在下标index处插入一个数据块，index等于Size()时相当于AppendLeaf
+// 树的形状只由叶子序列决定，插入后的树与用新序列调用NewMerkleTree构建的树相同：
+// - index之前的叶子下标不变，覆盖它们的完整子树原样保留
+// - index及之后的叶子下标加1，覆盖它们的节点全部重建，代价为O(n-index+log n)
+// - 根哈希改变，此前生成的证明都不能再对新根验证，需要重新获取
+// - index之前叶子的新证明中，低层的兄弟哈希与旧证明相同
+// - NewMerkleTreeFromKV记录的键随叶子一起移动，新插入的数据块没有对应的键
+func (mt *MerkleTree) InsertLeaf(index int, data []byte) error {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+
+	if index < 0 || index > len(mt.leaves) {
+		return fmt.Errorf("index out of range")
+	}
+
+	oldRoot, oldSize := mt.root, len(mt.leaves)
+	if !mt.hashOnly {
+		mt.data = append(mt.data, nil)
+		copy(mt.data[index+1:], mt.data[index:])
+		mt.data[index] = data
+	}
+	mt.leaves = append(mt.leaves, nil)
+	copy(mt.leaves[index+1:], mt.leaves[index:])
+	mt.leaves[index] = mt.newLeaf(data)
+	mt.count++
+	mt.shiftKeys(index, 1)
+	mt.rebuildFrom(index, oldRoot, oldSize)
+	return nil
+}
+
+// DeleteLeaf 删除下标index处的数据块
+// 与InsertLeaf对称：index之后的叶子下标减1，覆盖index及之后叶子的节点全部重建，
+// 代价为O(n-index+log n)；删除最后一个数据块后树为空，根哈希为""
+func (mt *MerkleTree) DeleteLeaf(index int) error {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+
+	if index < 0 || index >= len(mt.leaves) {
+		return fmt.Errorf("index out of range")
+	}
+
+	oldRoot, oldSize := mt.root, len(mt.leaves)
+	last := oldSize - 1
+	if !mt.hashOnly {
+		copy(mt.data[index:], mt.data[index+1:])
+		mt.data[last] = nil
+		mt.data = mt.data[:last]
+	}
+	mt.leaves[index].parent = nil
+	copy(mt.leaves[index:], mt.leaves[index+1:])
+	mt.leaves[last] = nil
+	mt.leaves = mt.leaves[:last]
+	mt.count--
+	mt.shiftKeys(index, -1)
+	mt.rebuildFrom(index, oldRoot, oldSize)
+	return nil
+}
@@ -0,0 +1,81 @@
+package datastructures
+
+import (
+	"runtime"
+	"sync"
+)
+
+// merkleParallelMinNodes 一层的节点数少于该值时串行处理，避免goroutine的调度开销超过哈希本身
+const merkleParallelMinNodes = 1024
+
+// WithBuildWorkers 构建树时用workers个goroutine并行计算叶子和每一层的哈希
+// workers <= 0时使用runtime.GOMAXPROCS(0)；不设置时串行构建。结果与串行构建完全相同，
+// 只影响NewMerkleTree等一次性构建，不影响之后的增量修改
+func WithBuildWorkers(workers int) MerkleTreeOption {
+	return func(mt *MerkleTree) {
+		if workers <= 0 {
+			workers = runtime.GOMAXPROCS(0)
+		}
+		mt.buildWorkers = workers
+	}
+}
+
+// parallelRange 把[0, n)均分给至多workers个goroutine执行fn，等待全部完成
+// n较小时直接在当前goroutine中执行
+func parallelRange(n, workers int, fn func(lo, hi int)) {
+	if workers <= 1 || n < merkleParallelMinNodes {
+		fn(0, n)
+		return
+	}
+	if workers > n {
+		workers = n
+	}
+
+	var wg sync.WaitGroup
+	chunk := (n + workers - 1) / workers
+	for lo := 0; lo < n; lo += chunk {
+		hi := lo + chunk
+		if hi > n {
+			hi = n
+		}
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			fn(lo, hi)
+		}(lo, hi)
+	}
+	wg.Wait()
+}
+
+// buildMerkleTreeParallel 并行构建树，返回叶子和根
+// 先并行哈希全部叶子，再逐层并行计算父节点；每个父节点只写自己的两个子节点的parent指针，
+// 不同goroutine之间没有共享的写入
+func buildMerkleTreeParallel(scheme MerkleHashScheme, data [][]byte, workers int) ([]*MerkleNode, *MerkleNode) {
+	leaves := make([]*MerkleNode, len(data))
+	parallelRange(len(data), workers, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			leaves[i] = newMerkleNode(scheme, data[i], nil, nil)
+		}
+	})
+	return leaves, buildLevelsParallel(scheme, leaves, workers)
+}
+
+// buildLevelsParallel 由已经创建的叶子逐层并行计算父节点，返回根
+func buildLevelsParallel(scheme MerkleHashScheme, leaves []*MerkleNode, workers int) *MerkleNode {
+	level := leaves
+	for len(level) > 1 {
+		next := make([]*MerkleNode, (len(level)+1)/2)
+		parallelRange(len(next), workers, func(lo, hi int) {
+			for j := lo; j < hi; j++ {
+				// 奇数个节点，最后一个节点复制，与buildMerkleTree一致
+				right := 2*j + 1
+				if right >= len(level) {
+					right = 2 * j
+				}
+				next[j] = newMerkleNode(scheme, nil, level[2*j], level[right])
+			}
+		})
+		level = next
+	}
+	return level[0]
+}
@@ -0,0 +1,196 @@
+package datastructures
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// merkleTreeMagic 默克尔树序列化格式的文件头
+const merkleTreeMagic = "MKT\x01"
+
+// merkleTreeHasData 标志位：数据块跟在叶子哈希之后
+const merkleTreeHasData = 1 << 0
+
+// 序列化格式：
+//
+//	magic(4字节) | scheme(uvarint) | flags(uvarint) | count(uvarint) | root(32字节，count为0时省略) |
+//	count × leafHash(32字节) | [count × (dataLen(uvarint) data)]
+//
+// 哈希按SHA-256原始字节写入；树的形状只由count决定，内部节点不保存，加载时由叶子哈希重新计算。
+// 不保留数据块的树不写入数据部分，flags中不设置merkleTreeHasData。
+
+// WriteTo 将叶子哈希、哈希方式和数据块（树保留数据块时）写入w，实现io.WriterTo
+func (mt *MerkleTree) WriteTo(w io.Writer) (int64, error) {
+	mt.mu.RLock()
+	defer mt.mu.RUnlock()
+	mt.flushHashes()
+
+	var flags uint64
+	if !mt.hashOnly {
+		flags |= merkleTreeHasData
+	}
+
+	bw := &binaryWriter{w: w}
+	bw.write([]byte(merkleTreeMagic))
+	bw.writeUvarint(uint64(mt.scheme))
+	bw.writeUvarint(flags)
+	bw.writeUvarint(uint64(len(mt.leaves)))
+	if mt.root != nil {
+		bw.writeHash(mt.root.hash)
+	}
+	for _, leaf := range mt.leaves {
+		bw.writeHash(leaf.hash)
+	}
+	if flags&merkleTreeHasData != 0 {
+		for _, d := range mt.data {
+			bw.writeBytes(d)
+		}
+	}
+
+	return bw.n, bw.err
+}
+
+// ReadFrom 从r读取WriteTo写入的数据并替换树的全部内容，实现io.ReaderFrom
+// 只由叶子哈希重新计算内部节点，数据块不重新哈希，代价与数据块的总大小无关；
+// 重新计算的根哈希必须与保存的根哈希一致，否则返回错误
+// 哈希方式和是否保留数据块都取自读取的数据；读取失败时树保持原有内容不变
+// NewMerkleTreeFromKV记录的键不保存，读取后不能再按键查找
+func (mt *MerkleTree) ReadFrom(r io.Reader) (int64, error) {
+	br := &binaryReader{r: r}
+	magic := make([]byte, len(merkleTreeMagic))
+	if _, err := br.read(magic); err != nil {
+		return br.n, err
+	}
+	if string(magic) != merkleTreeMagic {
+		return br.n, fmt.Errorf("invalid merkle tree header")
+	}
+
+	scheme, err := br.readUvarint()
+	if err != nil {
+		return br.n, err
+	}
+	if scheme != uint64(MerkleHashLegacy) && scheme != uint64(MerkleHashRFC6962) {
+		return br.n, fmt.Errorf("unknown hash scheme %d", scheme)
+	}
+	flags, err := br.readUvarint()
+	if err != nil {
+		return br.n, err
+	}
+	if flags&^merkleTreeHasData != 0 {
+		return br.n, fmt.Errorf("unknown flags %#x", flags)
+	}
+	count, err := br.readUvarint()
+	if err != nil {
+		return br.n, err
+	}
+
+	// 先完整读取并校验，再替换内容
+	var rootHash string
+	if count > 0 {
+		if rootHash, err = br.readHash(); err != nil {
+			return br.n, err
+		}
+	}
+	leaves := make([]*MerkleNode, 0, min(count, 1<<16))
+	for i := uint64(0); i < count; i++ {
+		hash, err := br.readHash()
+		if err != nil {
+			return br.n, err
+		}
+		leaves = append(leaves, leafFromHash(MerkleHashScheme(scheme), hash))
+	}
+	var data [][]byte
+	if flags&merkleTreeHasData != 0 {
+		data = make([][]byte, len(leaves))
+		for i, leaf := range leaves {
+			if data[i], err = br.readBytes(maxEncodedFieldLen); err != nil {
+				return br.n, err
+			}
+			leaf.data = data[i]
+		}
+	}
+
+	var root *MerkleNode
+	switch {
+	case len(leaves) == 0:
+	case mt.buildWorkers > 1:
+		root = buildLevelsParallel(MerkleHashScheme(scheme), leaves, mt.buildWorkers)
+	default:
+		root = buildMerkleTree(MerkleHashScheme(scheme), leaves)
+	}
+	if root != nil && root.hash != rootHash {
+		return br.n, fmt.Errorf("root hash mismatch: stored %s, computed %s", rootHash, root.hash)
+	}
+
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+
+	mt.scheme = MerkleHashScheme(scheme)
+	mt.hashOnly = flags&merkleTreeHasData == 0
+	mt.leaves = leaves
+	mt.data = data
+	if mt.data == nil {
+		mt.data = make([][]byte, 0)
+	}
+	mt.root = root
+	mt.count = int64(len(leaves))
+	mt.keyIndex = nil
+
+	return br.n, nil
+}
+
+// leafFromHash 由已知的哈希创建不带数据的叶子节点，不重新计算哈希
+func leafFromHash(scheme MerkleHashScheme, hash string) *MerkleNode {
+	return &MerkleNode{
+		hash:     hash,
+		children: make([]*MerkleNode, 0, 2),
+		isLeaf:   true,
+		scheme:   scheme,
+	}
+}
+
+// newMerkleTreeFromLeafHashes 由叶子哈希构建不保留数据块的树，不重新计算叶子哈希
+func newMerkleTreeFromLeafHashes(scheme MerkleHashScheme, hashes []string) *MerkleTree {
+	mt := &MerkleTree{
+		scheme:   scheme,
+		hashOnly: true,
+		leaves:   make([]*MerkleNode, len(hashes)),
+		data:     make([][]byte, 0),
+		count:    int64(len(hashes)),
+	}
+	for i, h := range hashes {
+		mt.leaves[i] = leafFromHash(scheme, h)
+	}
+	if len(mt.leaves) > 0 {
+		mt.root = buildMerkleTree(scheme, mt.leaves)
+	}
+	return mt
+}
+
+// writeHash 写入十六进制哈希对应的SHA-256原始字节
+func (bw *binaryWriter) writeHash(hash string) {
+	if bw.err != nil {
+		return
+	}
+	var raw [sha256.Size]byte
+	if len(hash) != 2*sha256.Size {
+		bw.err = fmt.Errorf("invalid hash length %d", len(hash))
+		return
+	}
+	if _, err := hex.Decode(raw[:], []byte(hash)); err != nil {
+		bw.err = err
+		return
+	}
+	bw.write(raw[:])
+}
+
+// readHash 读取SHA-256原始字节，返回十六进制表示
+func (br *binaryReader) readHash() (string, error) {
+	var raw [sha256.Size]byte
+	if _, err := br.read(raw[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw[:]), nil
+}
@@ -0,0 +1,47 @@
+package datastructures
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// NewMerkleTreeFromReader 把r的内容按chunkSize切分为数据块，边读取边构建默克尔树
+// 每个数据块读入后立即计算叶子哈希，数据块本身不保留：内存只与数据块数量成正比，与文件大小无关，
+// 适合对大文件构建树；最后一个数据块可以不足chunkSize，空的输入得到空树，chunkSize不是正数时返回错误
+// 得到的树总是只保留叶子哈希，与设置了WithHashOnly的树相同
+// opts: 可选配置，WithBuildWorkers只并行计算内部节点，叶子哈希随读取串行计算
+func NewMerkleTreeFromReader(r io.Reader, chunkSize int, opts ...MerkleTreeOption) (*MerkleTree, error) {
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("chunkSize must be > 0")
+	}
+
+	mt := &MerkleTree{hashOnly: true}
+	for _, opt := range opts {
+		opt(mt)
+	}
+
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			mt.leaves = append(mt.leaves, mt.newLeaf(buf[:n]))
+		}
+		if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read chunk %d: %w", len(mt.leaves), err)
+		}
+	}
+
+	mt.count = int64(len(mt.leaves))
+	switch {
+	case len(mt.leaves) == 0:
+	case mt.buildWorkers > 1:
+		mt.root = buildLevelsParallel(mt.scheme, mt.leaves, mt.buildWorkers)
+	default:
+		mt.root = buildMerkleTree(mt.scheme, mt.leaves)
+	}
+	return mt, nil
+}
@@ -0,0 +1,1182 @@
+package datastructures
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// merkleTestData 生成n个测试数据块
+func merkleTestData(n int) [][]byte {
+	data := make([][]byte, n)
+	for i := range data {
+		data[i] = []byte(fmt.Sprintf("chunk-%d", i))
+	}
+	return data
+}
+
+// checkMerkleParents 检查每个节点的父指针与子节点列表一致，根没有父节点
+func checkMerkleParents(t *testing.T, mt *MerkleTree) {
+	t.Helper()
+	if mt.root == nil {
+		return
+	}
+	if mt.root.parent != nil {
+		t.Fatal("根节点有父节点")
+	}
+	var walk func(n *MerkleNode)
+	walk = func(n *MerkleNode) {
+		for _, c := range n.children {
+			if c.parent != n {
+				t.Fatalf("节点 %s 的子节点 %s 的父节点为 %v", c.hash, n.hash, c.parent)
+			}
+			walk(c)
+		}
+	}
+	walk(mt.root)
+}
+
+// TestMerkleTreeAppendLeaf 测试逐个追加叶子与一次性构建的树一致
+func TestMerkleTreeAppendLeaf(t *testing.T) {
+	data := merkleTestData(70)
+	mt := NewMerkleTree(nil)
+	for i, d := range data {
+		if idx := mt.AppendLeaf(d); idx != i {
+			t.Fatalf("AppendLeaf 返回 %d, 期望 %d", idx, i)
+		}
+		want := NewMerkleTree(data[:i+1])
+		if mt.GetRootHash() != want.GetRootHash() {
+			t.Fatalf("追加 %d 次后根哈希 = %s, 期望 %s", i+1, mt.GetRootHash(), want.GetRootHash())
+		}
+		if mt.Height() != want.Height() {
+			t.Fatalf("追加 %d 次后高度 = %d, 期望 %d", i+1, mt.Height(), want.Height())
+		}
+		checkMerkleParents(t, mt)
+	}
+
+	if mt.Size() != int64(len(data)) {
+		t.Errorf("Size() = %d, 期望 %d", mt.Size(), len(data))
+	}
+	for i, d := range data {
+		if !mt.VerifyData(i, d) {
+			t.Fatalf("追加后 VerifyData(%d) 失败", i)
+		}
+	}
+
+	// 追加之后更新数据块，父指针必须指向新建的节点
+	if err := mt.UpdateData(5, []byte("changed")); err != nil {
+		t.Fatal(err)
+	}
+	data[5] = []byte("changed")
+	if mt.GetRootHash() != NewMerkleTree(data).GetRootHash() {
+		t.Error("AppendLeaf 之后 UpdateData 得到的根哈希与重新构建的树不同")
+	}
+}
+
+// TestMerkleTreeInsertDeleteLeaf 测试在任意位置插入和删除叶子
+func TestMerkleTreeInsertDeleteLeaf(t *testing.T) {
+	data := merkleTestData(37)
+	mt := NewMerkleTree(data)
+
+	check := func(op string) {
+		t.Helper()
+		want := NewMerkleTree(data)
+		if mt.GetRootHash() != want.GetRootHash() {
+			t.Fatalf("%s: 根哈希 = %s, 期望 %s", op, mt.GetRootHash(), want.GetRootHash())
+		}
+		if mt.Size() != int64(len(data)) {
+			t.Fatalf("%s: Size() = %d, 期望 %d", op, mt.Size(), len(data))
+		}
+		for i, d := range data {
+			if !mt.VerifyData(i, d) {
+				t.Fatalf("%s: VerifyData(%d) 失败", op, i)
+			}
+		}
+		checkMerkleParents(t, mt)
+	}
+
+	for _, index := range []int{0, 5, 16, 20, 39, 41} {
+		chunk := []byte(fmt.Sprintf("inserted-%d", index))
+		if err := mt.InsertLeaf(index, chunk); err != nil {
+			t.Fatalf("InsertLeaf(%d) 错误 = %v", index, err)
+		}
+		data = append(data[:index], append([][]byte{chunk}, data[index:]...)...)
+		check(fmt.Sprintf("InsertLeaf(%d)", index))
+	}
+
+	// 删除末尾的叶子使剩余叶子恰好为2的幂，根退回到旧树的子树
+	for len(data) > 32 {
+		if err := mt.DeleteLeaf(len(data) - 1); err != nil {
+			t.Fatal(err)
+		}
+		data = data[:len(data)-1]
+		check(fmt.Sprintf("DeleteLeaf(%d)", len(data)))
+	}
+	for _, index := range []int{0, 10, 15, 28} {
+		if err := mt.DeleteLeaf(index); err != nil {
+			t.Fatalf("DeleteLeaf(%d) 错误 = %v", index, err)
+		}
+		data = append(data[:index], data[index+1:]...)
+		check(fmt.Sprintf("DeleteLeaf(%d)", index))
+	}
+	for len(data) > 0 {
+		if err := mt.DeleteLeaf(0); err != nil {
+			t.Fatal(err)
+		}
+		data = data[1:]
+		check("DeleteLeaf(0)")
+	}
+	if mt.GetRootHash() != "" {
+		t.Errorf("空树的根哈希 = %q, 期望为空", mt.GetRootHash())
+	}
+
+	if err := mt.InsertLeaf(1, []byte("x")); err == nil {
+		t.Error("在末尾之后 InsertLeaf 应该返回错误")
+	}
+	if err := mt.DeleteLeaf(0); err == nil {
+		t.Error("空树 DeleteLeaf 应该返回错误")
+	}
+}
+
+// TestMerkleTreeProof 测试单个数据块的证明
+func TestMerkleTreeProof(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 5, 8, 13, 32, 33} {
+		data := merkleTestData(n)
+		mt := NewMerkleTree(data)
+		root := mt.GetRootHash()
+
+		for i, d := range data {
+			proof, err := mt.GetProof(i)
+			if err != nil {
+				t.Fatalf("n=%d GetProof(%d) 错误 = %v", n, i, err)
+			}
+			if len(proof.Steps) != merkleHeight(n) {
+				t.Fatalf("n=%d GetProof(%d) 有 %d 步, 期望 %d", n, i, len(proof.Steps), merkleHeight(n))
+			}
+			if !VerifyProof(d, proof, root) {
+				t.Fatalf("n=%d VerifyProof(%d) 失败", n, i)
+			}
+			if VerifyProof([]byte("forged"), proof, root) {
+				t.Fatalf("n=%d VerifyProof(%d) 接受了伪造的数据", n, i)
+			}
+
+			// 兄弟位置与下标不一致的证明被拒绝
+			if len(proof.Steps) > 0 {
+				flipped := *proof
+				flipped.Steps = append([]ProofStep(nil), proof.Steps...)
+				flipped.Steps[0].Side ^= 1
+				if VerifyProof(d, &flipped, root) {
+					t.Fatalf("n=%d VerifyProof(%d) 接受了兄弟位置翻转的证明", n, i)
+				}
+			}
+			if i > 0 {
+				moved := *proof
+				moved.LeafIndex = i - 1
+				if VerifyProof(d, &moved, root) && len(proof.Steps) > 0 {
+					t.Fatalf("n=%d 数据块 %d 的证明被当作下标 %d 验证通过", n, i, i-1)
+				}
+			}
+		}
+	}
+
+	mt := NewMerkleTree(merkleTestData(4))
+	if _, err := mt.GetProof(4); err == nil {
+		t.Error("越界的 GetProof 应该返回错误")
+	}
+	if VerifyProof([]byte("chunk-0"), nil, mt.GetRootHash()) {
+		t.Error("VerifyProof(nil) 应该失败")
+	}
+}
+
+// TestMerkleTreeMultiProof 测试多个数据块的批量证明
+func TestMerkleTreeMultiProof(t *testing.T) {
+	for _, n := range []int{1, 2, 7, 16, 37, 1024} {
+		data := merkleTestData(n)
+		mt := NewMerkleTree(data)
+		root := mt.GetRootHash()
+
+		sets := [][]int{{0}, {n - 1}, {n - 1, 0, 0}}
+		if n > 4 {
+			sets = append(sets, []int{1, 2, 3}, []int{0, n / 2, n - 2})
+		}
+		for _, indices := range sets {
+			proof, err := mt.GetMultiProof(indices)
+			if err != nil {
+				t.Fatalf("n=%d GetMultiProof(%v) 错误 = %v", n, indices, err)
+			}
+			chunks := make([][]byte, len(proof.Indices))
+			for i, idx := range proof.Indices {
+				chunks[i] = data[idx]
+			}
+			if !VerifyMultiProof(chunks, proof, root) {
+				t.Fatalf("n=%d VerifyMultiProof(%v) 失败", n, proof.Indices)
+			}
+			chunks[len(chunks)-1] = []byte("forged")
+			if VerifyMultiProof(chunks, proof, root) {
+				t.Fatalf("n=%d VerifyMultiProof(%v) 接受了伪造的数据", n, proof.Indices)
+			}
+		}
+	}
+
+	// 连续的数据块共享内部节点，证明远小于独立证明之和
+	data := merkleTestData(1024)
+	mt := NewMerkleTree(data)
+	indices := make([]int, 16)
+	for i := range indices {
+		indices[i] = 512 + i
+	}
+	proof, err := mt.GetMultiProof(indices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proof.Hashes) != 6 {
+		t.Errorf("1024 个叶子中对齐的 16 个数据块需要 %d 个哈希, 期望 6", len(proof.Hashes))
+	}
+	// 多余的哈希同样使证明无效
+	proof.Hashes = append(proof.Hashes, proof.Hashes[0])
+	if VerifyMultiProof(data[512:528], proof, mt.GetRootHash()) {
+		t.Error("VerifyMultiProof 接受了带有多余哈希的证明")
+	}
+
+	if _, err := mt.GetMultiProof(nil); err == nil {
+		t.Error("没有下标的 GetMultiProof 应该返回错误")
+	}
+	if _, err := mt.GetMultiProof([]int{1024}); err == nil {
+		t.Error("越界的 GetMultiProof 应该返回错误")
+	}
+}
+
+// TestMerkleTreeConsistencyProof 测试历史根哈希和一致性证明
+func TestMerkleTreeConsistencyProof(t *testing.T) {
+	const n = 45
+	data := merkleTestData(n)
+	mt := NewMerkleTree(nil)
+	roots := make([]string, n+1)
+	for i, d := range data {
+		mt.AppendLeaf(d)
+		roots[i+1] = mt.GetRootHash()
+	}
+
+	for size := 1; size <= n; size++ {
+		if got, err := mt.RootHashAt(size); err != nil || got != roots[size] {
+			t.Fatalf("RootHashAt(%d) = %s, %v, 期望 %s", size, got, err, roots[size])
+		}
+	}
+
+	for oldSize := 1; oldSize <= n; oldSize++ {
+		for newSize := oldSize; newSize <= n; newSize++ {
+			proof, err := mt.ConsistencyProof(oldSize, newSize)
+			if err != nil {
+				t.Fatalf("ConsistencyProof(%d, %d) 错误 = %v", oldSize, newSize, err)
+			}
+			if !VerifyConsistency(roots[oldSize], roots[newSize], proof) {
+				t.Fatalf("VerifyConsistency(%d, %d) 失败", oldSize, newSize)
+			}
+			if newSize > oldSize && VerifyConsistency(roots[oldSize], roots[newSize-1], proof) {
+				t.Fatalf("VerifyConsistency(%d, %d) 接受了错误的新根哈希", oldSize, newSize)
+			}
+		}
+	}
+
+	// 改写过历史的树无法与旧根一致
+	forked := NewMerkleTree(data)
+	if err := forked.UpdateData(3, []byte("rewritten")); err != nil {
+		t.Fatal(err)
+	}
+	proof, err := forked.ConsistencyProof(10, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if VerifyConsistency(roots[10], forked.GetRootHash(), proof) {
+		t.Error("VerifyConsistency 接受了前缀被改写的树")
+	}
+
+	for _, sizes := range [][2]int{{0, 5}, {6, 5}, {5, n + 1}} {
+		if _, err := mt.ConsistencyProof(sizes[0], sizes[1]); err == nil {
+			t.Errorf("ConsistencyProof(%d, %d) 应该返回错误", sizes[0], sizes[1])
+		}
+	}
+}
+
+// TestMerkleTreeHashScheme 测试RFC 6962域分隔的哈希方式
+func TestMerkleTreeHashScheme(t *testing.T) {
+	data := merkleTestData(4)
+	legacy := NewMerkleTree(data)
+	rfc := NewMerkleTree(data, WithMerkleHashScheme(MerkleHashRFC6962))
+	if legacy.HashScheme() != MerkleHashLegacy || rfc.HashScheme() != MerkleHashRFC6962 {
+		t.Fatal("HashScheme() 与选项不一致")
+	}
+	if legacy.GetRootHash() == rfc.GetRootHash() {
+		t.Fatal("域分隔应该改变根哈希")
+	}
+
+	// 旧方式下把两个叶子哈希拼接成一个数据块，得到根相同的另一棵树
+	forged := [][]byte{
+		[]byte(legacy.leaves[0].hash + legacy.leaves[1].hash),
+		[]byte(legacy.leaves[2].hash + legacy.leaves[3].hash),
+	}
+	if NewMerkleTree(forged).GetRootHash() != legacy.GetRootHash() {
+		t.Fatal("旧哈希方式应该受第二原像伪造影响")
+	}
+	rfcForged := [][]byte{
+		[]byte(rfc.leaves[0].hash + rfc.leaves[1].hash),
+		[]byte(rfc.leaves[2].hash + rfc.leaves[3].hash),
+	}
+	if NewMerkleTree(rfcForged, WithMerkleHashScheme(MerkleHashRFC6962)).GetRootHash() == rfc.GetRootHash() {
+		t.Fatal("RFC 6962 哈希方式不应该受第二原像伪造影响")
+	}
+
+	// 各种证明和增量修改都使用树的方式
+	data = merkleTestData(21)
+	mt := NewMerkleTree(nil, WithMerkleHashScheme(MerkleHashRFC6962))
+	for _, d := range data {
+		mt.AppendLeaf(d)
+	}
+	root := mt.GetRootHash()
+	if root != NewMerkleTree(data, WithMerkleHashScheme(MerkleHashRFC6962)).GetRootHash() {
+		t.Fatal("RFC 6962 哈希方式下 AppendLeaf 的结果与重新构建的树不同")
+	}
+	if !mt.VerifyData(3, data[3]) {
+		t.Error("RFC 6962 哈希方式下 VerifyData 失败")
+	}
+	proof, _ := mt.GetProof(13)
+	if proof.Scheme != MerkleHashRFC6962 || !VerifyProof(data[13], proof, root) {
+		t.Error("RFC 6962 哈希方式下单个证明验证失败")
+	}
+	downgraded := *proof
+	downgraded.Scheme = MerkleHashLegacy
+	if VerifyProof(data[13], &downgraded, root) {
+		t.Error("证明在错误的哈希方式下验证通过")
+	}
+	multi, _ := mt.GetMultiProof([]int{2, 3, 17})
+	if !VerifyMultiProof([][]byte{data[2], data[3], data[17]}, multi, root) {
+		t.Error("RFC 6962 哈希方式下批量证明验证失败")
+	}
+	old, _ := mt.RootHashAt(9)
+	consistency, _ := mt.ConsistencyProof(9, 21)
+	if !VerifyConsistency(old, root, consistency) {
+		t.Error("RFC 6962 哈希方式下一致性证明验证失败")
+	}
+}
+
+// TestMerkleTreeParallelBuild 测试并行构建与串行构建的结果一致
+func TestMerkleTreeParallelBuild(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 1000, 5000} {
+		data := merkleTestData(n)
+		for _, scheme := range []MerkleHashScheme{MerkleHashLegacy, MerkleHashRFC6962} {
+			want := NewMerkleTree(data, WithMerkleHashScheme(scheme))
+			got := NewMerkleTree(data, WithMerkleHashScheme(scheme), WithBuildWorkers(4))
+			if got.GetRootHash() != want.GetRootHash() || got.Height() != want.Height() || got.Size() != want.Size() {
+				t.Fatalf("n=%d scheme=%v: 并行构建的结果与串行构建不同", n, scheme)
+			}
+			checkMerkleParents(t, got)
+
+			// 并行构建的树可以继续增量修改
+			got.AppendLeaf([]byte("more"))
+			want.AppendLeaf([]byte("more"))
+			if got.GetRootHash() != want.GetRootHash() {
+				t.Fatalf("n=%d scheme=%v: 并行构建后 AppendLeaf 的结果不同", n, scheme)
+			}
+		}
+	}
+
+	if mt := NewMerkleTree(nil, WithBuildWorkers(0)); mt.buildWorkers < 1 {
+		t.Errorf("WithBuildWorkers(0) 设置了 %d 个工作者, 期望 GOMAXPROCS", mt.buildWorkers)
+	}
+}
+
+// failingReader 读取若干字节后返回错误
+type failingReader struct {
+	remaining int
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.remaining == 0 {
+		return 0, fmt.Errorf("disk failure")
+	}
+	n := len(p)
+	if n > r.remaining {
+		n = r.remaining
+	}
+	r.remaining -= n
+	return n, nil
+}
+
+// TestMerkleTreeFromReader 测试从io.Reader流式构建树
+func TestMerkleTreeFromReader(t *testing.T) {
+	content := make([]byte, 10000)
+	for i := range content {
+		content[i] = byte(i * 7)
+	}
+	const chunkSize = 256
+	var chunks [][]byte
+	for off := 0; off < len(content); off += chunkSize {
+		end := off + chunkSize
+		if end > len(content) {
+			end = len(content)
+		}
+		chunks = append(chunks, content[off:end])
+	}
+
+	for _, opts := range [][]MerkleTreeOption{nil, {WithBuildWorkers(4)}, {WithMerkleHashScheme(MerkleHashRFC6962)}} {
+		mt, err := NewMerkleTreeFromReader(bytes.NewReader(content), chunkSize, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := NewMerkleTree(chunks, opts...)
+		if mt.GetRootHash() != want.GetRootHash() || mt.Size() != int64(len(chunks)) {
+			t.Fatalf("流式构建的根哈希 = %s (%d 个数据块), 期望 %s (%d 个数据块)", mt.GetRootHash(), mt.Size(), want.GetRootHash(), len(chunks))
+		}
+		checkMerkleParents(t, mt)
+
+		// 数据块不保留，哈希和证明照常可用
+		for _, leaf := range mt.leaves {
+			if leaf.data != nil {
+				t.Fatal("流式构建的树保留了数据块")
+			}
+		}
+		if len(mt.GetAllData()) != 0 {
+			t.Error("不保留数据块时 GetAllData 应该为空")
+		}
+		if _, err := mt.RangeQuery(0, 1); err == nil {
+			t.Error("不保留数据块时 RangeQuery 应该返回错误")
+		}
+		last := len(chunks) - 1
+		proof, _ := mt.GetProof(last)
+		if !mt.VerifyData(last, chunks[last]) || !VerifyProof(chunks[last], proof, mt.GetRootHash()) {
+			t.Error("流式构建的树验证失败")
+		}
+
+		// 不保留数据的树仍然可以修改
+		if err := mt.UpdateData(3, []byte("patched")); err != nil {
+			t.Fatal(err)
+		}
+		mt.AppendLeaf([]byte("tail"))
+		mt.DeleteLeaf(0)
+		want.UpdateData(3, []byte("patched"))
+		want.AppendLeaf([]byte("tail"))
+		want.DeleteLeaf(0)
+		if mt.GetRootHash() != want.GetRootHash() {
+			t.Error("流式构建的树修改后与保留数据块的树不同")
+		}
+	}
+
+	empty, err := NewMerkleTreeFromReader(bytes.NewReader(nil), chunkSize)
+	if err != nil || empty.Size() != 0 || empty.GetRootHash() != "" {
+		t.Errorf("空输入: 错误 = %v", err)
+	}
+	if _, err := NewMerkleTreeFromReader(&failingReader{remaining: 1000}, chunkSize); err == nil {
+		t.Error("应该返回读取错误")
+	}
+	for _, size := range []int{0, -1} {
+		if _, err := NewMerkleTreeFromReader(bytes.NewReader([]byte("data")), size); err == nil {
+			t.Errorf("chunkSize %d 应该返回错误", size)
+		}
+	}
+}
+
+// TestMerkleTreeDiff 测试比较两棵树找出不同的数据块
+func TestMerkleTreeDiff(t *testing.T) {
+	data := merkleTestData(37)
+	base := NewMerkleTree(data)
+
+	changed := make([][]byte, len(data))
+	copy(changed, data)
+	for _, i := range []int{0, 17, 36} {
+		changed[i] = []byte("changed")
+	}
+	replica := NewMerkleTree(changed)
+
+	if got := base.Diff(replica); fmt.Sprint(got) != "[0 17 36]" {
+		t.Errorf("Diff = %v, 期望 [0 17 36]", got)
+	}
+	if got := base.Diff(NewMerkleTree(data)); len(got) != 0 {
+		t.Errorf("相同的树 Diff = %v, 期望为空", got)
+	}
+	if got := base.Diff(base); len(got) != 0 {
+		t.Errorf("与自身 Diff = %v, 期望为空", got)
+	}
+
+	// 大小不同时公共部分逐块比较，多出的数据块都算作不同
+	longer := NewMerkleTree(append(append([][]byte(nil), changed...), []byte("x"), []byte("y")))
+	if got := base.Diff(longer); fmt.Sprint(got) != "[0 17 36 37 38]" {
+		t.Errorf("与更长的树 Diff = %v, 期望 [0 17 36 37 38]", got)
+	}
+	if got := longer.Diff(base); fmt.Sprint(got) != "[0 17 36 37 38]" {
+		t.Errorf("更长的树 Diff = %v, 期望 [0 17 36 37 38]", got)
+	}
+	if got := NewMerkleTree(nil).Diff(NewMerkleTree(data[:2])); fmt.Sprint(got) != "[0 1]" {
+		t.Errorf("与空树 Diff = %v, 期望 [0 1]", got)
+	}
+
+	// 哈希方式不同时无法比较
+	rfc := NewMerkleTree(data, WithMerkleHashScheme(MerkleHashRFC6962))
+	if got := base.Diff(rfc); len(got) != len(data) {
+		t.Errorf("哈希方式不同时 Diff 返回 %d 个下标, 期望 %d", len(got), len(data))
+	}
+}
+
+// TestMerkleTreeSerialization 测试树的序列化与反序列化
+func TestMerkleTreeSerialization(t *testing.T) {
+	data := merkleTestData(45)
+	if !NewMerkleTree(data).Describe().Persistent {
+		t.Error("MerkleTree 支持 WriteTo/ReadFrom, Persistent 应该为 true")
+	}
+	for _, opts := range [][]MerkleTreeOption{nil, {WithMerkleHashScheme(MerkleHashRFC6962)}} {
+		mt := NewMerkleTree(data, opts...)
+		var buf bytes.Buffer
+		written, err := mt.WriteTo(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if written != int64(buf.Len()) {
+			t.Errorf("WriteTo() 返回 %d 字节, 实际写入 %d", written, buf.Len())
+		}
+		encoded := buf.Bytes()
+
+		restored := NewMerkleTree(merkleTestData(3))
+		read, err := restored.ReadFrom(bytes.NewReader(encoded))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if read != written {
+			t.Errorf("ReadFrom() 读取 %d 字节, 期望 %d", read, written)
+		}
+		if restored.GetRootHash() != mt.GetRootHash() || restored.Size() != mt.Size() || restored.HashScheme() != mt.HashScheme() {
+			t.Fatalf("恢复的树不同: 根哈希 %s 大小 %d 哈希方式 %v", restored.GetRootHash(), restored.Size(), restored.HashScheme())
+		}
+		checkMerkleParents(t, restored)
+		if got, _ := restored.RangeQuery(0, len(data)); len(got) != len(data) || !bytes.Equal(got[44], data[44]) {
+			t.Error("恢复的树丢失了数据块")
+		}
+		restored.AppendLeaf([]byte("more"))
+		mt.AppendLeaf([]byte("more"))
+		if restored.GetRootHash() != mt.GetRootHash() {
+			t.Error("恢复的树 AppendLeaf 的结果不同")
+		}
+
+		// 损坏的哈希使根哈希不一致，截断的数据返回错误，且不修改原有内容
+		corrupt := append([]byte(nil), encoded...)
+		corrupt[len(merkleTreeMagic)+3+sha256.Size] ^= 0xff
+		for _, bad := range [][]byte{corrupt, encoded[:len(encoded)/2], []byte("XXXX"), nil} {
+			if _, err := restored.ReadFrom(bytes.NewReader(bad)); err == nil {
+				t.Errorf("ReadFrom(%d 字节) 应该返回错误", len(bad))
+			}
+		}
+		if restored.Size() != int64(len(data)+1) {
+			t.Errorf("ReadFrom 失败后 Size() = %d, 期望 %d", restored.Size(), len(data)+1)
+		}
+	}
+
+	// 不保留数据块的树只写入哈希
+	var chunks bytes.Buffer
+	for _, d := range data {
+		chunks.Write(d)
+	}
+	streamed, _ := NewMerkleTreeFromReader(bytes.NewReader(chunks.Bytes()), 8)
+	var buf bytes.Buffer
+	if _, err := streamed.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	restored := NewMerkleTree(nil)
+	if _, err := restored.ReadFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if restored.GetRootHash() != streamed.GetRootHash() || !restored.hashOnly {
+		t.Error("只保留哈希的树序列化往返后不一致")
+	}
+	if _, err := restored.RangeQuery(0, 1); err == nil {
+		t.Error("恢复的只保留哈希的树 RangeQuery 应该返回错误")
+	}
+
+	// 空树
+	buf.Reset()
+	if _, err := NewMerkleTree(nil).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := restored.ReadFrom(&buf); err != nil || restored.Size() != 0 || restored.GetRootHash() != "" {
+		t.Errorf("空树序列化往返后不一致: 错误 = %v", err)
+	}
+}
+
+// TestMerkleProofEncoding 测试证明的二进制和JSON编码
+func TestMerkleProofEncoding(t *testing.T) {
+	data := merkleTestData(11)
+	for _, scheme := range []MerkleHashScheme{MerkleHashLegacy, MerkleHashRFC6962} {
+		mt := NewMerkleTree(data, WithMerkleHashScheme(scheme))
+		root := mt.GetRootHash()
+		for i := range data {
+			proof, err := mt.GetProof(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			bin, err := proof.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fromBin Proof
+			if err := fromBin.UnmarshalBinary(bin); err != nil {
+				t.Fatal(err)
+			}
+			js, err := json.Marshal(proof)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fromJSON Proof
+			if err := json.Unmarshal(js, &fromJSON); err != nil {
+				t.Fatal(err)
+			}
+			for name, p := range map[string]*Proof{"binary": &fromBin, "json": &fromJSON} {
+				if p.Scheme != scheme || p.LeafIndex != i || p.TreeSize != len(data) || !VerifyProof(data[i], p, root) {
+					t.Fatalf("证明 %[2]d (%[3]v) 经 %[1]s 往返后验证失败", name, i, scheme)
+				}
+			}
+		}
+	}
+
+	proof, _ := NewMerkleTree(data, WithMerkleHashScheme(MerkleHashRFC6962)).GetProof(2)
+	js, _ := json.Marshal(proof)
+	if !bytes.Contains(js, []byte(`"scheme":"rfc6962","leafIndex":2,"treeSize":11`)) || !bytes.Contains(js, []byte(`"side":"left"`)) {
+		t.Errorf("JSON 格式不符合预期: %s", js)
+	}
+
+	// 格式错误的数据返回错误
+	bin, _ := proof.MarshalBinary()
+	var p Proof
+	for _, bad := range [][]byte{nil, []byte("XXXX"), bin[:len(bin)-1], append(append([]byte(nil), bin...), 0)} {
+		if err := p.UnmarshalBinary(bad); err == nil {
+			t.Errorf("UnmarshalBinary(%d 字节) 应该返回错误", len(bad))
+		}
+	}
+	for _, bad := range []string{`{"scheme":"md5"}`, `{"steps":[{"side":"up"}]}`} {
+		if err := json.Unmarshal([]byte(bad), &p); err == nil {
+			t.Errorf("json.Unmarshal(%s) 应该返回错误", bad)
+		}
+	}
+}
+
+// TestMerkleTreeKeyLookup 测试按键查找叶子和生成证明
+func TestMerkleTreeKeyLookup(t *testing.T) {
+	kvs := make([]KeyValue, 20)
+	for i := range kvs {
+		kvs[i] = KeyValue{Key: fmt.Sprintf("key-%d", i), Value: i * 10}
+	}
+	mt := NewMerkleTreeFromKV(kvs)
+
+	for i, kv := range kvs {
+		if !mt.VerifyKV(kv.Key, kv.Value) {
+			t.Fatalf("VerifyKV(%v) 失败", kv.Key)
+		}
+		proof, err := mt.GetProofForKey(kv.Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if proof.LeafIndex != i || !VerifyKVProof(kv.Key, kv.Value, proof, mt.GetRootHash()) {
+			t.Fatalf("键 %v 的证明验证失败", kv.Key)
+		}
+	}
+	if mt.VerifyKV("key-3", 31) {
+		t.Error("VerifyKV 接受了错误的值")
+	}
+	if mt.VerifyKV("missing", 0) {
+		t.Error("VerifyKV 接受了不存在的键")
+	}
+	if _, err := mt.GetProofForKey("missing"); err == nil {
+		t.Error("不存在的键 GetProofForKey 应该返回错误")
+	}
+
+	// 插入和删除叶子后键随叶子移动，被删除的键不再可查
+	if err := mt.InsertLeaf(0, []byte("header")); err != nil {
+		t.Fatal(err)
+	}
+	if err := mt.DeleteLeaf(6); err != nil { // key-5
+		t.Fatal(err)
+	}
+	if i, ok := mt.IndexOfKey("key-9"); !ok || i != 9 {
+		t.Errorf("IndexOfKey(key-9) = %d, %v, 期望 9", i, ok)
+	}
+	if _, ok := mt.IndexOfKey("key-5"); ok {
+		t.Error("删除的键仍然在索引中")
+	}
+	for _, kv := range kvs {
+		if kv.Key != "key-5" && !mt.VerifyKV(kv.Key, kv.Value) {
+			t.Fatalf("插入和删除后 VerifyKV(%v) 失败", kv.Key)
+		}
+	}
+}
+
+// TestMerkleTreeHashOnly 测试只保留哈希的模式
+func TestMerkleTreeHashOnly(t *testing.T) {
+	data := merkleTestData(2000)
+	for _, opts := range [][]MerkleTreeOption{{WithHashOnly()}, {WithHashOnly(), WithBuildWorkers(4)}} {
+		mt := NewMerkleTree(data, opts...)
+		want := NewMerkleTree(data)
+		if !mt.HashOnly() || want.HashOnly() {
+			t.Fatal("HashOnly() 报告的模式错误")
+		}
+		if mt.GetRootHash() != want.GetRootHash() || mt.Size() != want.Size() {
+			t.Fatal("只保留哈希的树根哈希不同")
+		}
+		for _, leaf := range mt.leaves {
+			if leaf.data != nil {
+				t.Fatal("只保留哈希的树保留了数据块")
+			}
+		}
+		if len(mt.GetAllData()) != 0 {
+			t.Error("只保留哈希时 GetAllData 应该为空")
+		}
+		if _, err := mt.RangeQuery(0, 1); err == nil {
+			t.Error("只保留哈希时 RangeQuery 应该返回错误")
+		}
+
+		proof, _ := mt.GetProof(1234)
+		if !mt.VerifyData(1234, data[1234]) || !VerifyProof(data[1234], proof, mt.GetRootHash()) {
+			t.Error("只保留哈希时验证失败")
+		}
+		mt.UpdateData(7, []byte("patched"))
+		want.UpdateData(7, []byte("patched"))
+		if mt.GetRootHash() != want.GetRootHash() {
+			t.Error("只保留哈希时 UpdateData 的结果与保留数据块的树不同")
+		}
+	}
+}
+
+// TestFileManifest 测试文件清单的生成与校验
+func TestFileManifest(t *testing.T) {
+	content := make([]byte, 5000)
+	for i := range content {
+		content[i] = byte(i*13 + i>>9)
+	}
+	path := filepath.Join(t.TempDir(), "data.bin")
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := BuildFileManifest(path, 512, WithMerkleHashScheme(MerkleHashRFC6962))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := NewMerkleTreeFromReader(bytes.NewReader(content), 512, WithMerkleHashScheme(MerkleHashRFC6962))
+	if m.Root != want.GetRootHash() || m.FileSize != 5000 || len(m.Chunks) != 10 {
+		t.Fatalf("清单根哈希 %s 大小 %d 数据块数 %d", m.Root, m.FileSize, len(m.Chunks))
+	}
+	if last := m.Chunks[9]; last.Offset != 4608 || last.Size != 392 {
+		t.Errorf("最后一个数据块 = %+v, 期望偏移 4608 大小 392", last)
+	}
+
+	// JSON往返后仍然可以校验
+	js, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded FileManifest
+	if err := json.Unmarshal(js, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if bad, err := VerifyFileManifest(path, &decoded); err != nil || bad != nil {
+		t.Fatalf("文件未修改时 VerifyFileManifest = %v, %v", bad, err)
+	}
+
+	// 修改和截断文件后报告不符的数据块
+	content[1000] ^= 0xff
+	content[4999] ^= 0xff
+	os.WriteFile(path, content, 0o644)
+	if bad, err := VerifyFileManifest(path, m); err != nil || fmt.Sprint(bad) != "[1 9]" {
+		t.Errorf("修改后 VerifyFileManifest = %v, %v, 期望 [1 9]", bad, err)
+	}
+	os.WriteFile(path, content[:3000], 0o644)
+	if bad, err := VerifyFileManifest(path, m); err != nil || fmt.Sprint(bad) != "[1 5 6 7 8 9]" {
+		t.Errorf("截断后 VerifyFileManifest = %v, %v, 期望 [1 5 6 7 8 9]", bad, err)
+	}
+
+	// 不一致的清单返回错误
+	decoded.Chunks[3].Hash = decoded.Chunks[4].Hash
+	if _, err := VerifyFileManifest(path, &decoded); err == nil {
+		t.Error("数据块与根哈希不一致的清单应该返回错误")
+	}
+	if _, err := BuildFileManifest(filepath.Join(t.TempDir(), "missing"), 512); err == nil {
+		t.Error("文件不存在时应该返回错误")
+	}
+}
+
+// TestVersionedMerkleTree 测试带版本的默克尔树查询历史版本
+func TestVersionedMerkleTree(t *testing.T) {
+	data := merkleTestData(9)
+	vt := NewVersionedMerkleTree(data, WithMerkleHashScheme(MerkleHashRFC6962))
+
+	// 每个版本的数据块序列，用于对照
+	history := [][][]byte{append([][]byte(nil), data...)}
+	current := append([][]byte(nil), data...)
+	snapshot := func() { history = append(history, append([][]byte(nil), current...)) }
+
+	vt.AppendLeaf([]byte("a"))
+	current = append(current, []byte("a"))
+	snapshot()
+	if err := vt.UpdateData(3, []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	current[3] = []byte("b")
+	snapshot()
+	if err := vt.InsertLeaf(0, []byte("c")); err != nil {
+		t.Fatal(err)
+	}
+	current = append([][]byte{[]byte("c")}, current...)
+	snapshot()
+	if err := vt.DeleteLeaf(5); err != nil {
+		t.Fatal(err)
+	}
+	current = append(current[:5], current[6:]...)
+	snapshot()
+	if err := vt.DeleteLeaf(100); err == nil {
+		t.Fatal("越界的 DeleteLeaf 应该返回错误")
+	}
+
+	if d := vt.Describe(); d.Name != "VersionedMerkleTree" || !d.SupportsDelete || d.Persistent {
+		t.Errorf("Describe() = %+v", d)
+	}
+	if vt.Version() != len(history)-1 {
+		t.Fatalf("Version() = %d, 期望 %d", vt.Version(), len(history)-1)
+	}
+	for v, blocks := range history {
+		want := NewMerkleTree(blocks, WithMerkleHashScheme(MerkleHashRFC6962)).GetRootHash()
+		root, err := vt.RootAt(v)
+		if err != nil || root != want {
+			t.Fatalf("RootAt(%d) = %s, %v, 期望 %s", v, root, err, want)
+		}
+		for i, block := range blocks {
+			proof, err := vt.GetProofAt(v, i)
+			if err != nil || !VerifyProof(block, proof, root) {
+				t.Fatalf("版本 %[2]d 中数据块 %[1]d 的证明验证失败: %[3]v", i, v, err)
+			}
+		}
+	}
+	if latest, _ := vt.RootAt(vt.Version()); latest != vt.GetRootHash() {
+		t.Error("RootAt(Version()) 与当前根哈希不同")
+	}
+	if _, err := vt.RootAt(len(history)); err == nil {
+		t.Error("超出当前版本的 RootAt 应该返回错误")
+	}
+
+	old, _ := vt.TreeAt(0)
+	if got := old.Diff(NewMerkleTree(data, WithMerkleHashScheme(MerkleHashRFC6962))); got != nil {
+		t.Errorf("TreeAt(0) 与原始树在 %v 处不同", got)
+	}
+}
+
+// TestMerkleTreeAudit 测试完整性审计定位损坏的节点
+func TestMerkleTreeAudit(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 7, 37} {
+		mt := NewMerkleTree(merkleTestData(n))
+		if err := mt.Audit(); err != nil {
+			t.Fatalf("n=%d: 新建的树 Audit() = %v", n, err)
+		}
+		mt.AppendLeaf([]byte("x"))
+		mt.InsertLeaf(0, []byte("y"))
+		mt.DeleteLeaf(int(mt.Size() / 2))
+		mt.UpdateData(0, []byte("z"))
+		if err := mt.Audit(); err != nil {
+			t.Fatalf("n=%d: 修改后 Audit() = %v", n, err)
+		}
+	}
+
+	mt := NewMerkleTree(merkleTestData(8))
+	mt.root.children[1].children[0].hash = "corrupt"
+	if err := mt.Audit(); err == nil || !strings.Contains(err.Error(), "path RL") {
+		t.Errorf("内部节点损坏: Audit() = %v, 期望在路径 RL 处报错", err)
+	}
+
+	mt = NewMerkleTree(merkleTestData(8))
+	mt.data[5] = []byte("tampered")
+	if err := mt.Audit(); err == nil || !strings.Contains(err.Error(), "level 0 index 5 (path RLR)") {
+		t.Errorf("数据块被篡改: Audit() = %v, 期望在叶子 5 处报错", err)
+	}
+
+	mt = NewMerkleTree(merkleTestData(8), WithHashOnly())
+	mt.root.hash = "corrupt"
+	if err := mt.Audit(); err == nil || !strings.Contains(err.Error(), "(root)") {
+		t.Errorf("根节点损坏: Audit() = %v, 期望在根节点处报错", err)
+	}
+}
+
+// TestMerkleProofCompression 测试紧凑证明和证明合并
+func TestMerkleProofCompression(t *testing.T) {
+	data := merkleTestData(21)
+	mt := NewMerkleTree(data, WithMerkleHashScheme(MerkleHashRFC6962))
+	root := mt.GetRootHash()
+
+	proofs := make([]*Proof, 0, len(data))
+	for i := range data {
+		proof, _ := mt.GetProof(i)
+		proofs = append(proofs, proof)
+
+		full, _ := proof.MarshalBinary()
+		compact, err := proof.MarshalCompact()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(compact) >= len(full) {
+			t.Errorf("数据块 %d 的紧凑证明为 %d 字节, 二进制格式为 %d 字节", i, len(compact), len(full))
+		}
+		var decoded Proof
+		if err := decoded.UnmarshalBinary(compact); err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyProof(data[i], &decoded, root) {
+			t.Fatalf("数据块 %d 的紧凑证明验证失败", i)
+		}
+		if VerifyProof(data[(i+1)%len(data)], &decoded, root) {
+			t.Fatalf("数据块 %d 的紧凑证明验证通过了错误的数据块", i)
+		}
+	}
+
+	// 最后一个数据块在21个叶子的树的5层中有3层自配对，只剩两个哈希
+	compact, _ := proofs[20].MarshalCompact()
+	if want := len(merkleProofCompactMagic) + 3 + 2*sha256.Size; len(compact) != want {
+		t.Errorf("最后一个数据块的紧凑证明为 %d 字节, 期望 %d", len(compact), want)
+	}
+	var p Proof
+	if err := p.UnmarshalBinary(compact[:len(compact)-1]); err == nil {
+		t.Error("截断的紧凑证明应该返回错误")
+	}
+
+	// 合并单独的证明与直接生成的批量证明相同
+	picked := []*Proof{proofs[9], proofs[3], proofs[4], proofs[20], proofs[3]}
+	merged, err := MergeProofs(picked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	direct, _ := mt.GetMultiProof([]int{3, 4, 9, 20})
+	if fmt.Sprint(merged) != fmt.Sprint(direct) {
+		t.Errorf("MergeProofs() = %+v, 期望 %+v", merged, direct)
+	}
+	if !VerifyMultiProof([][]byte{data[3], data[4], data[9], data[20]}, merged, root) {
+		t.Error("合并的证明验证失败")
+	}
+	other, _ := NewMerkleTree(merkleTestData(5)).GetProof(0)
+	if _, err := MergeProofs([]*Proof{proofs[0], other}); err == nil {
+		t.Error("合并不同树的证明应该返回错误")
+	}
+}
+
+// TestBinaryMerkleTree 测试数组布局的默克尔树
+func TestBinaryMerkleTree(t *testing.T) {
+	if !(&BinaryMerkleTree{}).Describe().Persistent {
+		t.Error("BinaryMerkleTree 支持 MarshalBinary/UnmarshalBinary, Persistent 应该为 true")
+	}
+	for _, scheme := range []MerkleHashScheme{MerkleHashLegacy, MerkleHashRFC6962} {
+		for _, n := range []int{1, 2, 8, 64} {
+			data := make([][]byte, n)
+			for i := range data {
+				data[i] = []byte(fmt.Sprintf("block-%d", i))
+			}
+			bt, err := NewBinaryMerkleTree(data, WithMerkleHashScheme(scheme))
+			if err != nil {
+				t.Fatal(err)
+			}
+			mt := NewMerkleTree(data, WithMerkleHashScheme(scheme))
+			if bt.GetRootHash() != mt.GetRootHash() || bt.Height() != mt.Height() || bt.Size() != int64(n) {
+				t.Fatalf("scheme %v n=%d: 数组布局与指针布局的结果不同", scheme, n)
+			}
+			for i := range data {
+				proof, err := bt.GetProof(i)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !VerifyProof(data[i], proof, mt.GetRootHash()) {
+					t.Errorf("scheme %v n=%d: 数据块 %d 的证明验证失败", scheme, n, i)
+				}
+			}
+
+			update := []byte("updated")
+			if err := bt.UpdateData(n-1, update); err != nil {
+				t.Fatal(err)
+			}
+			mt.UpdateData(n-1, update)
+			if bt.GetRootHash() != mt.GetRootHash() || !bt.VerifyData(n-1, update) {
+				t.Errorf("scheme %v n=%d: 更新后根哈希不同", scheme, n)
+			}
+
+			bin, err := bt.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var loaded BinaryMerkleTree
+			if err := loaded.UnmarshalBinary(bin); err != nil {
+				t.Fatal(err)
+			}
+			if loaded.GetRootHash() != bt.GetRootHash() || loaded.Size() != bt.Size() {
+				t.Errorf("scheme %v n=%d: 序列化往返后树发生了变化", scheme, n)
+			}
+			bin[len(bin)-1] ^= 1
+			if n > 1 && loaded.UnmarshalBinary(bin) == nil {
+				t.Errorf("scheme %v n=%d: 损坏的叶子哈希应该返回错误", scheme, n)
+			}
+		}
+	}
+
+	if _, err := NewBinaryMerkleTree(make([][]byte, 3)); err == nil {
+		t.Error("数据块数不是2的幂时应该返回错误")
+	}
+	empty, _ := NewBinaryMerkleTree(nil)
+	if empty.GetRootHash() != "" || empty.Height() != 0 {
+		t.Error("空树不应该有根哈希")
+	}
+	if _, err := empty.GetProof(0); err == nil {
+		t.Error("空树 GetProof 应该返回错误")
+	}
+}
+
+// TestMerkleTreeDeferredHashing 测试延迟重新计算内部节点哈希
+func TestMerkleTreeDeferredHashing(t *testing.T) {
+	data := make([][]byte, 37)
+	for i := range data {
+		data[i] = []byte(fmt.Sprintf("block-%d", i))
+	}
+	eager := NewMerkleTree(data, WithMerkleHashScheme(MerkleHashRFC6962))
+	lazy := NewMerkleTree(data, WithMerkleHashScheme(MerkleHashRFC6962), WithDeferredHashing())
+	if !lazy.Deferred() || eager.Deferred() {
+		t.Fatal("Deferred() 与选项不一致")
+	}
+
+	for round := 0; round < 3; round++ {
+		for i := round; i < len(data); i += 2 {
+			update := []byte(fmt.Sprintf("round-%d-%d", round, i))
+			eager.UpdateData(i, update)
+			if err := lazy.UpdateData(i, update); err != nil {
+				t.Fatal(err)
+			}
+			if !lazy.VerifyData(i, update) {
+				t.Fatalf("叶子 %d 应该立即更新", i)
+			}
+		}
+		if lazy.root.hash != "" {
+			t.Fatal("读取之前根哈希应该处于失效状态")
+		}
+		if round == 1 {
+			lazy.Recompute()
+			if lazy.root.hash == "" {
+				t.Fatal("Recompute() 之后应该恢复根哈希")
+			}
+		}
+		if got, want := lazy.GetRootHash(), eager.GetRootHash(); got != want {
+			t.Fatalf("第 %d 轮: 根哈希 %s, 期望 %s", round, got, want)
+		}
+		if err := lazy.Audit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 结构修改前先补齐失效的哈希
+	lazy.UpdateData(3, []byte("x"))
+	eager.UpdateData(3, []byte("x"))
+	lazy.AppendLeaf([]byte("tail"))
+	eager.AppendLeaf([]byte("tail"))
+	proof, err := lazy.GetProof(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyProof([]byte("x"), proof, eager.GetRootHash()) {
+		t.Error("延迟计算的树生成的证明应该能用立即计算的根哈希验证")
+	}
+	if diff := lazy.Diff(eager); diff != nil {
+		t.Errorf("Diff = %v, 期望为空", diff)
+	}
+}
+
+// TestMerkleTreeExportDOT 测试导出Graphviz DOT格式
+func TestMerkleTreeExportDOT(t *testing.T) {
+	data := [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+	mt := NewMerkleTree(data)
+
+	var buf bytes.Buffer
+	if err := mt.ExportDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	dot := buf.String()
+	if !strings.HasPrefix(dot, "digraph MerkleTree {") || !strings.HasSuffix(dot, "}\n") {
+		t.Fatalf("DOT 输出不符合预期:\n%s", dot)
+	}
+	for _, want := range []string{
+		"leaf #2\\n" + mt.leaves[2].hash[:8],
+		"L2 #0\\n" + mt.GetRootHash()[:8],
+		"n2_0 -> n1_0;",
+		"n1_1 -> n0_2 [style=dashed", // 第3个叶子与自己配对
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT 输出缺少 %q:\n%s", want, dot)
+		}
+	}
+	if strings.Contains(dot, "n0_3") {
+		t.Error("DOT 输出不应该包含重复的叶子")
+	}
+
+	buf.Reset()
+	if err := NewMerkleTree(nil).ExportDOT(&buf); err != nil || buf.String() != "digraph MerkleTree {\n\tnode [shape=box, fontname=\"monospace\"];\n}\n" {
+		t.Errorf("空树 DOT = %q, %v", buf.String(), err)
+	}
+}
+
+// TestSignedRoot 测试根哈希的签名与验证
+func TestSignedRoot(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mt := NewMerkleTree([][]byte{[]byte("a"), []byte("b"), []byte("c")})
+	sr, err := mt.SignRoot(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sr.Root != mt.GetRootHash() || !VerifySignedRoot(pub, sr) {
+		t.Fatal("签名根应该验证通过")
+	}
+
+	bin, err := sr.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded SignedRoot
+	if err := decoded.UnmarshalBinary(bin); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Root != sr.Root || !decoded.Timestamp.Equal(sr.Timestamp) || !VerifySignedRoot(pub, &decoded) {
+		t.Error("二进制往返后签名根应该不变")
+	}
+	js, err := json.Marshal(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromJSON SignedRoot
+	if err := json.Unmarshal(js, &fromJSON); err != nil || !VerifySignedRoot(pub, &fromJSON) {
+		t.Errorf("JSON 往返后应该验证通过: %v", err)
+	}
+
+	// 篡改根哈希、时间戳或使用其他公钥都不能通过验证
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	tampered := *sr
+	tampered.Timestamp = sr.Timestamp.Add(time.Second)
+	if VerifySignedRoot(pub, &tampered) || VerifySignedRoot(otherPub, sr) {
+		t.Error("篡改时间戳或使用错误的公钥不应该验证通过")
+	}
+	mt.UpdateData(0, []byte("x"))
+	tampered = *sr
+	tampered.Root = mt.GetRootHash()
+	if VerifySignedRoot(pub, &tampered) {
+		t.Error("篡改根哈希不应该验证通过")
+	}
+
+	if _, err := NewMerkleTree(nil).SignRoot(priv); err == nil {
+		t.Error("对空树签名应该返回错误")
+	}
+	if err := decoded.UnmarshalBinary(bin[:len(bin)-1]); err == nil {
+		t.Error("截断的数据应该返回错误")
+	}
+}
@@ -0,0 +1,192 @@
+package datastructures
+
+import (
+	"fmt"
+	"sync"
+)
+
+// merkleOpKind 版本历史中记录的修改类型
+type merkleOpKind uint8
+
+const (
+	merkleOpUpdate merkleOpKind = iota
+	merkleOpInsert
+	merkleOpDelete
+)
+
+// merkleOp 一次修改：类型、位置和新叶子的哈希（删除时为空）
+type merkleOp struct {
+	kind  merkleOpKind
+	index int
+	hash  string
+}
+
+// VersionedMerkleTree 记录每次修改后根哈希的默克尔树
+// 特点：
+// - 创建时为版本0，每次UpdateData、AppendLeaf、InsertLeaf、DeleteLeaf成功后版本加1
+// - RootAt返回任意历史版本的根哈希，O(1)
+// - GetProofAt由版本0的叶子哈希重放修改记录得到历史版本的叶子并重建树，代价为O(n + 修改次数)，适合审计等低频场景
+// - 只保存叶子哈希和修改记录，不保存历史版本的数据块
+type VersionedMerkleTree struct {
+	mu     sync.RWMutex
+	tree   *MerkleTree // 当前版本的树
+	scheme MerkleHashScheme
+	base   []string   // 版本0的叶子哈希
+	ops    []merkleOp // 第v个元素把版本v变为版本v+1
+	roots  []string   // 第v个元素为版本v的根哈希
+}
+
+// NewVersionedMerkleTree 从数据块创建带版本历史的默克尔树
+// opts: 可选配置，与NewMerkleTree相同
+func NewVersionedMerkleTree(data [][]byte, opts ...MerkleTreeOption) *VersionedMerkleTree {
+	tree := NewMerkleTree(data, opts...)
+	vt := &VersionedMerkleTree{
+		tree:   tree,
+		scheme: tree.scheme,
+		base:   make([]string, len(tree.leaves)),
+		roots:  []string{tree.GetRootHash()},
+	}
+	for i, leaf := range tree.leaves {
+		vt.base[i] = leaf.hash
+	}
+	return vt
+}
+
+// record 在修改成功后记录修改和新的根哈希，调用方必须持有写锁
+func (vt *VersionedMerkleTree) record(op merkleOp) {
+	vt.ops = append(vt.ops, op)
+	vt.roots = append(vt.roots, vt.tree.GetRootHash())
+}
+
+// UpdateData 更新指定索引的数据块，成功后产生新版本
+func (vt *VersionedMerkleTree) UpdateData(index int, data []byte) error {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+
+	if err := vt.tree.UpdateData(index, data); err != nil {
+		return err
+	}
+	vt.record(merkleOp{kind: merkleOpUpdate, index: index, hash: merkleLeafHash(vt.scheme, data)})
+	return nil
+}
+
+// AppendLeaf 在末尾追加一个数据块，返回它的下标，产生新版本
+func (vt *VersionedMerkleTree) AppendLeaf(data []byte) int {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+
+	index := vt.tree.AppendLeaf(data)
+	vt.record(merkleOp{kind: merkleOpInsert, index: index, hash: merkleLeafHash(vt.scheme, data)})
+	return index
+}
+
+// InsertLeaf 在下标index处插入一个数据块，成功后产生新版本
+func (vt *VersionedMerkleTree) InsertLeaf(index int, data []byte) error {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+
+	if err := vt.tree.InsertLeaf(index, data); err != nil {
+		return err
+	}
+	vt.record(merkleOp{kind: merkleOpInsert, index: index, hash: merkleLeafHash(vt.scheme, data)})
+	return nil
+}
+
+// DeleteLeaf 删除下标index处的数据块，成功后产生新版本
+func (vt *VersionedMerkleTree) DeleteLeaf(index int) error {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+
+	if err := vt.tree.DeleteLeaf(index); err != nil {
+		return err
+	}
+	vt.record(merkleOp{kind: merkleOpDelete, index: index})
+	return nil
+}
+
+// Version 返回当前版本号
+func (vt *VersionedMerkleTree) Version() int {
+	vt.mu.RLock()
+	defer vt.mu.RUnlock()
+	return len(vt.ops)
+}
+
+// GetRootHash 返回当前版本的根哈希
+func (vt *VersionedMerkleTree) GetRootHash() string {
+	return vt.tree.GetRootHash()
+}
+
+// GetProof 获取当前版本中数据块的完整性证明
+func (vt *VersionedMerkleTree) GetProof(index int) (*Proof, error) {
+	return vt.tree.GetProof(index)
+}
+
+// Size 返回当前版本的数据块数量
+func (vt *VersionedMerkleTree) Size() int64 {
+	return vt.tree.Size()
+}
+
+// RootAt 返回版本version的根哈希，空树的根哈希为""
+func (vt *VersionedMerkleTree) RootAt(version int) (string, error) {
+	vt.mu.RLock()
+	defer vt.mu.RUnlock()
+
+	if version < 0 || version >= len(vt.roots) {
+		return "", fmt.Errorf("version %d out of range [0, %d]", version, len(vt.roots)-1)
+	}
+	return vt.roots[version], nil
+}
+
+// leavesAt 由版本0的叶子哈希重放修改记录，返回版本version的叶子哈希，调用方必须持有读锁
+func (vt *VersionedMerkleTree) leavesAt(version int) []string {
+	leaves := append([]string(nil), vt.base...)
+	for _, op := range vt.ops[:version] {
+		switch op.kind {
+		case merkleOpUpdate:
+			leaves[op.index] = op.hash
+		case merkleOpInsert:
+			leaves = insertAt(leaves, op.index, op.hash)
+		case merkleOpDelete:
+			leaves = append(leaves[:op.index], leaves[op.index+1:]...)
+		}
+	}
+	return leaves
+}
+
+// TreeAt 重建版本version的树，只包含叶子哈希，不包含数据块
+// 返回的树与当前树相互独立，可以用于生成多个证明或与其他版本Diff
+func (vt *VersionedMerkleTree) TreeAt(version int) (*MerkleTree, error) {
+	vt.mu.RLock()
+	defer vt.mu.RUnlock()
+
+	if version < 0 || version >= len(vt.roots) {
+		return nil, fmt.Errorf("version %d out of range [0, %d]", version, len(vt.roots)-1)
+	}
+
+	return newMerkleTreeFromLeafHashes(vt.scheme, vt.leavesAt(version)), nil
+}
+
+// GetProofAt 获取数据块在版本version中的完整性证明，可以对RootAt(version)验证
+func (vt *VersionedMerkleTree) GetProofAt(version, index int) (*Proof, error) {
+	mt, err := vt.TreeAt(version)
+	if err != nil {
+		return nil, err
+	}
+	return mt.GetProof(index)
+}
+
+// Describe 返回带版本默克尔树的能力描述
+func (vt *VersionedMerkleTree) Describe() Descriptor {
+	return Descriptor{
+		Name:           "VersionedMerkleTree",
+		Ordered:        true,
+		SupportsDelete: true,
+		Concurrency:    ConcurrencyRWMutex,
+		Complexity: Complexity{
+			Insert: "O(log n) 追加，O(n-i) 在位置i插入",
+			Search: "O(1) 按版本取根哈希",
+			Delete: "O(n-i) 删除位置i",
+		},
+		Notes: "记录每次修改后的根哈希；历史版本的证明由版本0重放修改记录重建，代价为O(n + 修改次数)；不保存历史数据块",
+	}
+}
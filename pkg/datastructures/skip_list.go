@@ -1,28 +1,58 @@
 package datastructures
 
 import (
+	"bufio"
 	"fmt"
-	"math/rand"
+	"io"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // SkipNode 跳表节点
+// 前向指针和值是原子的：写入者在互斥锁内修改，Search和RangeQuery不加锁读取
 type SkipNode struct {
-	key     any    // 键
-	value   any    // 值
-	forward []*SkipNode    // 前向指针数组，每一层的下一个节点
+	key     any                  // 键（发布后不再修改）
+	value   atomic.Pointer[any]  // 值
+	forward []atomic.Pointer[SkipNode] // 前向指针数组，每一层的下一个节点
+	span    []int64        // 每一层前向指针跨越的第0层节点数，用于按排名定位
+	backward *SkipNode     // 第0层的后向指针，第一个节点为nil
 	height  int            // 节点高度（层数）
+	epoch   uint64         // 创建时跳表的纪元，快照据此跳过之后插入的节点
 }
 
 // NewSkipNode 创建新的跳表节点
 func NewSkipNode(key, value any, height int) *SkipNode {
-	return &SkipNode{
+	n := &SkipNode{
 		key:     key,
-		value:   value,
-		forward: make([]*SkipNode, height),
+		forward: make([]atomic.Pointer[SkipNode], height),
+		span:    make([]int64, height),
 		height:  height,
 	}
+	n.value.Store(&value)
+	return n
+}
+
+// next 返回第i层的下一个节点
+func (n *SkipNode) next(i int) *SkipNode {
+	return n.forward[i].Load()
+}
+
+// setNext 设置第i层的下一个节点，调用方必须持有写锁
+func (n *SkipNode) setNext(i int, x *SkipNode) {
+	n.forward[i].Store(x)
+}
+
+// loadValue 读取值
+func (n *SkipNode) loadValue() any {
+	return *n.value.Load()
+}
+
+// storeValue 替换值，调用方必须持有写锁
+func (n *SkipNode) storeValue(v any) {
+	n.value.Store(&v)
 }
 
 // SkipList 跳表结构
@@ -31,21 +61,88 @@ func NewSkipNode(key, value any, height int) *SkipNode {
 // - 有序结构，支持范围查询
 // - O(log n)时间复杂度的操作
 // - 实现简单，适合内存场景
+// - 写操作持有互斥锁，Search和RangeQuery通过原子指针无锁读取
 type SkipList struct {
 	head     *SkipNode    // 头节点
+	tail     *SkipNode    // 尾节点（最大键），空表为nil
 	comparator Comparator // 比较函数
 	mu       sync.RWMutex // 读写锁
 	maxLevel int          // 最大层数
 	level    int          // 当前最大层数
 	prob     float64      // 随机层数的概率因子 (0 < prob < 1)
 	count    int64        // 元素总数
+	levelGen LevelGenerator // 层数生成策略，仅在写锁内使用
+	keyCodec   Codec      // 键编解码器，用于WriteTo/ReadFrom
+	valueCodec Codec      // 值编解码器，用于WriteTo/ReadFrom
+	arena    *skipNodeArena // 节点块分配器，nil表示直接从堆分配
+	duplicates      bool       // 多重映射模式，允许重复键
+	valueComparator Comparator // 多重映射模式下相等键之间按值排序，nil表示按插入顺序
+	finger       bool        // 是否启用finger（缓存上一次插入路径）
+	fingerValid  bool        // 缓存的插入路径是否有效，删除操作会使其失效
+	fingerUpdate []*SkipNode // 上一次插入的每层前驱
+	fingerRank   []int64     // 上一次插入的每层前驱的排名
+	removals     atomic.Uint64 // 摘除节点的次数，SkipListFinger据此判断缓存的路径是否仍然可用
+	epoch        uint64                    // 快照纪元，每创建一个快照加一，仅在写锁内修改
+	snapshots    map[*skipSnapshot]struct{} // 活跃的快照，仅在写锁内修改
+	snapMu       sync.Mutex                // 保护快照登记的内容，快照迭代器每前进一步持有一次
+}
+
+// SkipListOption 跳表可选配置
+type SkipListOption func(*SkipList)
+
+// WithSkipListSeed 使用固定种子生成随机层数，相同的插入序列会得到相同的跳表结构
+// 主要用于可复现的测试和基准测试
+func WithSkipListSeed(seed int64) SkipListOption {
+	return func(s *SkipList) {
+		s.levelGen = NewGeometricLevelGenerator(s.prob, seed)
+	}
+}
+
+// WithSkipListCodec 设置序列化时使用的键、值编解码器
+func WithSkipListCodec(keyCodec, valueCodec Codec) SkipListOption {
+	return func(s *SkipList) {
+		s.keyCodec = keyCodec
+		s.valueCodec = valueCodec
+	}
+}
+
+// WithDuplicates 启用多重映射模式：允许插入重复键，相等的键按插入顺序排列
+// 该模式下Search和Delete作用于键的第一个值，SearchAll返回键的全部值
+func WithDuplicates() SkipListOption {
+	return func(s *SkipList) {
+		s.duplicates = true
+	}
+}
+
+// WithValueComparator 启用多重映射模式，相等的键之间按值排序
+// 同一个(键, 值)对只保存一份，重复插入时替换原有的值
+func WithValueComparator(valueComparator Comparator) SkipListOption {
+	return func(s *SkipList) {
+		s.duplicates = true
+		s.valueComparator = valueComparator
+	}
+}
+
+// WithFinger 启用finger优化：缓存上一次插入的查找路径
+// 下一次插入时从缓存路径的第0层向上，找到第一层仍能覆盖新位置的前驱，只重新查找它下面的层，
+// 与上一次插入相距d个元素时期望代价为O(log d)而不是O(log n)，新位置在缓存位置之前或之后都适用，
+// 适合时间序列追加和局部集中的写入。删除操作会使缓存失效，下一次插入从头节点开始。
+// 写入路径只有一条，读取使用各自的SkipListFinger（见NewFinger）。
+func WithFinger() SkipListOption {
+	return func(s *SkipList) {
+		s.finger = true
+		s.fingerUpdate = make([]*SkipNode, s.maxLevel)
+		s.fingerRank = make([]int64, s.maxLevel)
+	}
 }
 
 // NewSkipList 创建新的跳表
 // maxLevel: 最大层数，建议16-32
 // prob: 升层概率，建议0.5
 // comparator: 比较函数
-func NewSkipList(maxLevel int, prob float64, comparator Comparator) *SkipList {
+// 默认每个跳表使用以当前时间为种子的独立几何分布生成器，
+// 可通过WithSkipListSeed指定种子，或通过WithLevelGenerator替换生成策略
+func NewSkipList(maxLevel int, prob float64, comparator Comparator, opts ...SkipListOption) *SkipList {
 	if maxLevel < 1 {
 		panic("maxLevel must be >= 1")
 	}
@@ -56,7 +153,7 @@ func NewSkipList(maxLevel int, prob float64, comparator Comparator) *SkipList {
 		panic("comparator is required")
 	}
 
-	return &SkipList{
+	s := &SkipList{
 		head:       NewSkipNode(nil, nil, maxLevel),
 		comparator: comparator,
 		maxLevel:   maxLevel,
@@ -64,16 +161,20 @@ func NewSkipList(maxLevel int, prob float64, comparator Comparator) *SkipList {
 		prob:       prob,
 		count:      0,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.levelGen == nil {
+		s.levelGen = NewGeometricLevelGenerator(prob, time.Now().UnixNano())
+	}
+
+	return s
 }
 
-// randomLevel 生成随机层数
-// 使用几何分布，概率为p的节点有第k层
+// randomLevel 生成新节点的层数，限制在[1, maxLevel]内
+// 调用方必须持有写锁（生成器不要求并发安全）
 func (s *SkipList) randomLevel() int {
-	level := 1
-	for level < s.maxLevel && rand.Float64() < s.prob {
-		level++
-	}
-	return level
+	return max(1, min(s.levelGen.NextLevel(s.maxLevel), s.maxLevel))
 }
 
 // Insert 插入键值对
@@ -86,21 +187,156 @@ func (s *SkipList) Insert(key any, value any) error {
 	}
 
 	// 查找插入位置和更新指针
+	update, rank := s.insertPath(key, value)
+	s.insertAt(key, value, update, rank)
+	return nil
+}
+
+// insertPath 查找(key, value)的插入路径，返回每层的前驱update及其排名rank（头节点为0）
+// 启用finger且缓存有效时，从缓存的路径开始finger查找
+// 调用方必须持有写锁
+func (s *SkipList) insertPath(key, value any) ([]*SkipNode, []int64) {
+	if !s.finger {
+		update := make([]*SkipNode, s.maxLevel)
+		rank := make([]int64, s.maxLevel)
+		s.findUpdatePath(key, value, update, rank, false)
+		return update, rank
+	}
+
+	update, rank := s.fingerUpdate, s.fingerRank
+	s.findUpdatePath(key, value, update, rank, s.fingerValid)
+	// insertAt之后路径仍然有效，可供下一次插入使用
+	s.fingerValid = true
+	return update, rank
+}
+
+// GetOrInsert 键已存在时返回已有的值和true，否则插入value并返回value和false
+// 检查和插入在同一次写锁内完成，避免Search后再Insert的竞态
+// 多重映射模式下返回键的第一个值；key为nil时不插入，返回nil和false
+func (s *SkipList) GetOrInsert(key, value any) (any, bool) {
+	if key == nil {
+		return nil, false
+	}
+
+	// 已存在时走无锁读路径，不与写入者竞争
+	if x := s.seekUnlocked(key); x != nil && s.comparator(x.key, key) == 0 {
+		return x.loadValue(), true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 加锁后重新检查，期间可能有其他写入者插入了该键
+	if x := s.seekUnlocked(key); x != nil && s.comparator(x.key, key) == 0 {
+		return x.loadValue(), true
+	}
+
+	update, rank := s.insertPath(key, value)
+	s.insertAt(key, value, update, rank)
+	return value, false
+}
+
+// InsertBatch 批量插入键值对，只获取一次写锁
+// 输入先按键排序，之后每次插入都从上一次的插入路径继续向后查找，
+// 而不是从头节点重新下降，适合批量加载。同一批中的重复键以最后一个为准。
+// 任意键为nil时返回错误，且不插入任何数据。
+func (s *SkipList) InsertBatch(kvs []KeyValue) error {
+	for _, kv := range kvs {
+		if kv.Key == nil {
+			return fmt.Errorf("key cannot be nil")
+		}
+	}
+
+	// 键相同时按原始位置排序，保证重复键中最后一个最后写入
+	// （sort.SliceStable的比较次数为O(n log² n)，批量较大时比这种方式慢得多）
+	order := make([]int, len(kvs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := kvs[order[i]], kvs[order[j]]
+		if c := s.comparator(a.Key, b.Key); c != 0 {
+			return c < 0
+		}
+		if s.valueComparator != nil {
+			if c := s.valueComparator(a.Value, b.Value); c != 0 {
+				return c < 0
+			}
+		}
+		return order[i] < order[j]
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 批量插入使用自己的路径，结束后finger缓存的排名已过期
+	s.fingerValid = false
+
 	update := make([]*SkipNode, s.maxLevel)
-	x := s.head
+	rank := make([]int64, s.maxLevel)
+	for i, idx := range order {
+		kv := kvs[idx]
+		s.findUpdatePath(kv.Key, kv.Value, update, rank, i > 0)
+		s.insertAt(kv.Key, kv.Value, update, rank)
+	}
 
-	// 从最高层开始查找，找到每层的插入位置
-	for i := s.level - 1; i >= 0; i-- {
-		for x.forward[i] != nil && s.comparator(x.forward[i].key, key) < 0 {
-			x = x.forward[i]
+	return nil
+}
+
+// precedes 判断节点x是否应该排在待插入的(key, value)之前
+func (s *SkipList) precedes(x *SkipNode, key, value any) bool {
+	c := s.comparator(x.key, key)
+	if c != 0 || !s.duplicates {
+		return c < 0
+	}
+	if s.valueComparator != nil {
+		return s.valueComparator(x.loadValue(), value) < 0
+	}
+	return true // 按插入顺序：新元素排在所有相等的键之后
+}
+
+// findUpdatePath 查找每一层中最后一个排在(key, value)之前的节点及其排名，填入update和rank
+// 非多重映射模式下即最后一个小于key的节点
+// resume为true时update和rank中保存的是上一次insertAt之后仍然有效的插入路径（新键可以在它之前或之后），
+// 由fingerLevel找到第一层仍然有效的路径，这一层及以上保持不变，只重新查找下面的层，
+// 每一层从上一层的结果和缓存路径中更靠后且排在新元素之前的节点出发
+// 调用方必须持有写锁
+func (s *SkipList) findUpdatePath(key, value any, update []*SkipNode, rank []int64, resume bool) *SkipNode {
+	before := func(x *SkipNode) bool { return s.precedes(x, key, value) }
+	x, r, top := s.head, int64(0), s.level
+	if resume {
+		top = fingerLevel(s.head, update, s.level, before)
+		if top < s.level {
+			x, r = update[top], rank[top]
+		}
+	}
+
+	// 从top的下一层开始查找，找到每层的插入位置
+	for i := top - 1; i >= 0; i-- {
+		if resume && rank[i] > r && before(update[i]) {
+			x, r = update[i], rank[i]
+		}
+		for next := x.next(i); next != nil && before(next); next = x.next(i) {
+			r += x.span[i]
+			x = next
 		}
 		update[i] = x
+		rank[i] = r
 	}
 
-	// 如果键已存在，更新值
-	if x.forward[0] != nil && s.comparator(x.forward[0].key, key) == 0 {
-		x.forward[0].value = value
-		return nil
+	return x
+}
+
+// insertAt 按findUpdatePath得到的路径插入键值对，键已存在时更新值
+// 插入后update和rank仍然是该键的有效插入路径，可供下一次resume使用
+// 调用方必须持有写锁
+func (s *SkipList) insertAt(key, value any, update []*SkipNode, rank []int64) {
+	// 如果键已存在，更新值（多重映射模式下只有按值排序且值相等时才更新）
+	if next := update[0].next(0); next != nil && s.comparator(next.key, key) == 0 &&
+		(!s.duplicates || (s.valueComparator != nil && s.valueComparator(next.loadValue(), value) == 0)) {
+		s.preserveValue(next)
+		next.storeValue(value)
+		return
 	}
 
 	// 生成随机层数
@@ -108,54 +344,75 @@ func (s *SkipList) Insert(key any, value any) error {
 	if newLevel > s.level {
 		// 如果新层数超过当前最大层数，补充update数组
 		for i := s.level; i < newLevel; i++ {
+			rank[i] = 0
 			update[i] = s.head
+			s.head.span[i] = s.count
 		}
 		s.level = newLevel
 	}
 
 	// 创建新节点
-	newNode := NewSkipNode(key, value, newLevel)
+	newNode := s.newNode(key, value, newLevel)
+	newNode.epoch = s.epoch
 
-	// 更新指针
+	// 更新指针和跨度
 	for i := 0; i < newLevel; i++ {
-		newNode.forward[i] = update[i].forward[i]
-		update[i].forward[i] = newNode
+		newNode.setNext(i, update[i].next(i))
+		update[i].setNext(i, newNode)
+		newNode.span[i] = update[i].span[i] - (rank[0] - rank[i])
+		update[i].span[i] = rank[0] - rank[i] + 1
+	}
+	// 更高的层没有指向新节点，但跨越了它
+	for i := newLevel; i < s.level; i++ {
+		update[i].span[i]++
+	}
+
+	// 维护第0层的后向指针
+	if update[0] != s.head {
+		newNode.backward = update[0]
+	}
+	if newNode.next(0) != nil {
+		newNode.next(0).backward = newNode
+	} else {
+		s.tail = newNode
 	}
 
 	s.count++
-	return nil
 }
 
-// Search 查找值
-func (s *SkipList) Search(key any) (any, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// seekUnlocked 不加锁地查找第一个大于等于key的节点
+// 每个指针只读取一次：写入者可能在两次读取之间修改它
+func (s *SkipList) seekUnlocked(key any) *SkipNode {
+	x := s.head
 
-	if key == nil {
-		return nil, false
+	// 当前层数可能被并发修改，从最大层数开始查找，空的高层会被直接跳过
+	for i := s.maxLevel - 1; i >= 0; i-- {
+		for next := x.next(i); next != nil && s.comparator(next.key, key) < 0; next = x.next(i) {
+			x = next
+		}
 	}
 
-	x := s.head
+	return x.next(0)
+}
 
-	// 从最高层开始查找
-	for i := s.level - 1; i >= 0; i-- {
-		for x.forward[i] != nil && s.comparator(x.forward[i].key, key) < 0 {
-			x = x.forward[i]
-		}
+// Search 查找值
+// 不获取读锁，只通过原子指针遍历，不会被写入者阻塞
+func (s *SkipList) Search(key any) (any, bool) {
+	if key == nil {
+		return nil, false
 	}
 
-	// 前进到第一层的下一个节点
-	x = x.forward[0]
+	x := s.seekUnlocked(key)
 
 	// 检查是否找到
 	if x != nil && s.comparator(x.key, key) == 0 {
-		return x.value, true
+		return x.loadValue(), true
 	}
 
 	return nil, false
 }
 
-// Delete 删除键值对
+// Delete 删除键值对，多重映射模式下删除该键的第一个值
 func (s *SkipList) Delete(key any) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -164,70 +421,385 @@ func (s *SkipList) Delete(key any) bool {
 		return false
 	}
 
+	return s.deleteFirst(key)
+}
+
+// deleteFirst 删除键的第一个节点，调用方必须持有写锁
+func (s *SkipList) deleteFirst(key any) bool {
 	update := make([]*SkipNode, s.maxLevel)
 	x := s.head
 
 	// 查找要删除的节点和更新指针
 	for i := s.level - 1; i >= 0; i-- {
-		for x.forward[i] != nil && s.comparator(x.forward[i].key, key) < 0 {
-			x = x.forward[i]
+		for x.next(i) != nil && s.comparator(x.next(i).key, key) < 0 {
+			x = x.next(i)
 		}
 		update[i] = x
 	}
 
-	x = x.forward[0]
+	x = x.next(0)
 
 	// 如果找到要删除的节点
 	if x != nil && s.comparator(x.key, key) == 0 {
-		// 更新指针
-		for i := 0; i < s.level; i++ {
-			if update[i].forward[i] != x {
-				break
-			}
-			update[i].forward[i] = x.forward[i]
+		s.unlink(x, update)
+		return true
+	}
+
+	return false
+}
+
+// unlink 从跳表中摘除节点x，update[i]为x在第i层的前驱（或跨越x的节点）
+// 调用方必须持有写锁
+func (s *SkipList) unlink(x *SkipNode, update []*SkipNode) {
+	s.fingerValid = false // 缓存的前驱可能正是被删除的节点
+	s.removals.Add(1)
+
+	// 有活跃快照时，登记和修改第0层指针对快照迭代器是一步完成的
+	if len(s.snapshots) > 0 {
+		s.snapMu.Lock()
+		defer s.snapMu.Unlock()
+		s.preserveRemoved(update[0], x)
+	}
+
+	// 更新指针和跨度
+	for i := 0; i < s.level; i++ {
+		if update[i].next(i) == x {
+			update[i].span[i] += x.span[i] - 1
+			update[i].setNext(i, x.next(i))
+		} else {
+			update[i].span[i]--
+		}
+	}
+	if x.next(0) != nil {
+		x.next(0).backward = x.backward
+	} else {
+		s.tail = x.backward
+	}
+
+	// 移除最高层为空的头指针
+	for s.level > 1 && s.head.next(s.level-1) == nil {
+		s.level--
+	}
+
+	s.count--
+}
+
+// SearchAll 返回键的全部值，多重映射模式下按相等键的排列顺序返回
+// 与Search一样不获取读锁
+func (s *SkipList) SearchAll(key any) []any {
+	if key == nil {
+		return nil
+	}
+
+	var values []any
+	for x := s.seekUnlocked(key); x != nil && s.comparator(x.key, key) == 0; x = x.next(0) {
+		values = append(values, x.loadValue())
+	}
+	return values
+}
+
+// DeleteValue 删除指定的(键, 值)对，返回是否找到
+// 设置了WithValueComparator时用它判断值是否相等，否则使用==（值必须是可比较类型）
+func (s *SkipList) DeleteValue(key, value any) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key == nil {
+		return false
+	}
+
+	// 找到第一个相等键的前驱及其排名
+	x := s.head
+	var rank int64
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next(i) != nil && s.comparator(x.next(i).key, key) < 0 {
+			rank += x.span[i]
+			x = x.next(i)
 		}
+	}
 
-		// 移除最高层为空的头指针
-		for s.level > 1 && s.head.forward[s.level-1] == nil {
-			s.level--
+	// 在相等的键中按值查找目标节点
+	for target := x.next(0); target != nil && s.comparator(target.key, key) == 0; target = target.next(0) {
+		rank++
+		if !s.valueEqual(target.loadValue(), value) {
+			continue
 		}
 
-		s.count--
+		// 按排名重新定位目标节点在每一层的前驱
+		update := make([]*SkipNode, s.maxLevel)
+		y := s.head
+		var traversed int64
+		for i := s.level - 1; i >= 0; i-- {
+			for y.next(i) != nil && traversed+y.span[i] < rank {
+				traversed += y.span[i]
+				y = y.next(i)
+			}
+			update[i] = y
+		}
+		s.unlink(target, update)
 		return true
 	}
 
 	return false
 }
 
+// DeleteAll 删除键的全部值，返回删除的数量
+func (s *SkipList) DeleteAll(key any) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key == nil {
+		return 0
+	}
+
+	n := 0
+	for s.deleteFirst(key) {
+		n++
+	}
+	return n
+}
+
+// valueEqual 判断两个值是否相等
+func (s *SkipList) valueEqual(a, b any) bool {
+	if s.valueComparator != nil {
+		return s.valueComparator(a, b) == 0
+	}
+	return a == b
+}
+
 // RangeQuery 范围查询 [start, end)
 func (s *SkipList) RangeQuery(start, end any) ([]KeyValue, error) {
+	return s.RangeQueryLimit(start, end, 0)
+}
+
+// RangeQueryLimit 范围查询 [start, end)，最多返回limit个键值对
+// limit <= 0 表示不限制数量
+func (s *SkipList) RangeQueryLimit(start, end any, limit int) ([]KeyValue, error) {
+	var result []KeyValue
+	if limit > 0 {
+		result = make([]KeyValue, 0, min(limit, 64))
+	}
+
+	err := s.RangeFunc(start, end, func(key, value any) bool {
+		result = append(result, KeyValue{Key: key, Value: value})
+		return limit <= 0 || len(result) < limit
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RangeFunc 按键升序对 [start, end) 中的每个键值对调用fn，fn返回false时停止遍历
+// 不获取读锁，遍历为弱一致性：可能看到也可能看不到遍历期间的并发修改
+func (s *SkipList) RangeFunc(start, end any, fn func(key, value any) bool) error {
+	if start == nil || end == nil {
+		return fmt.Errorf("start and end cannot be nil")
+	}
+
+	if s.comparator(start, end) >= 0 {
+		return fmt.Errorf("start must be less than end")
+	}
+
+	// 找到起始节点，遍历直到达到结束条件
+	x := s.seekUnlocked(start)
+	for x != nil && s.comparator(x.key, end) < 0 {
+		if !fn(x.key, x.loadValue()) {
+			break
+		}
+		x = x.next(0)
+	}
+
+	return nil
+}
+
+// Min 返回最小的键值对，O(1)
+func (s *SkipList) Min() (KeyValue, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if x := s.head.next(0); x != nil {
+		return KeyValue{Key: x.key, Value: x.loadValue()}, true
+	}
+	return KeyValue{}, false
+}
+
+// Max 返回最大的键值对，通过尾指针O(1)获取
+func (s *SkipList) Max() (KeyValue, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.tail != nil {
+		return KeyValue{Key: s.tail.key, Value: s.tail.loadValue()}, true
+	}
+	return KeyValue{}, false
+}
+
+// Floor 返回小于等于key的最大键值对
+func (s *SkipList) Floor(key any) (KeyValue, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if key == nil {
+		return KeyValue{}, false
+	}
+
+	// 找到最后一个小于key的节点，它的后继可能正好等于key
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next(i) != nil && s.comparator(x.next(i).key, key) < 0 {
+			x = x.next(i)
+		}
+	}
+
+	if next := x.next(0); next != nil && s.comparator(next.key, key) == 0 {
+		return KeyValue{Key: next.key, Value: next.loadValue()}, true
+	}
+	if x == s.head {
+		return KeyValue{}, false
+	}
+	return KeyValue{Key: x.key, Value: x.loadValue()}, true
+}
+
+// Ceiling 返回大于等于key的最小键值对
+func (s *SkipList) Ceiling(key any) (KeyValue, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if key == nil {
+		return KeyValue{}, false
+	}
+
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next(i) != nil && s.comparator(x.next(i).key, key) < 0 {
+			x = x.next(i)
+		}
+	}
+
+	if next := x.next(0); next != nil {
+		return KeyValue{Key: next.key, Value: next.loadValue()}, true
+	}
+	return KeyValue{}, false
+}
+
+// Rank 返回键的排名（从0开始，即比它小的键的个数），键不存在时返回false
+func (s *SkipList) Rank(key any) (int64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if key == nil {
+		return 0, false
+	}
+
+	// 累加所有小于key的节点数，多重映射模式下返回第一个相等键的排名
+	var rank int64
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next(i) != nil && s.comparator(x.next(i).key, key) < 0 {
+			rank += x.span[i]
+			x = x.next(i)
+		}
+	}
+
+	if next := x.next(0); next != nil && s.comparator(next.key, key) == 0 {
+		return rank, true
+	}
+	return 0, false
+}
+
+// CountRange 返回 [start, end) 中的键数量，O(log n)
+// 利用跨度分别计算start和end的排名，不遍历区间内的节点
+func (s *SkipList) CountRange(start, end any) (int64, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	if start == nil || end == nil {
-		return nil, fmt.Errorf("start and end cannot be nil")
+		return 0, fmt.Errorf("start and end cannot be nil")
 	}
 
 	if s.comparator(start, end) >= 0 {
-		return nil, fmt.Errorf("start must be less than end")
+		return 0, fmt.Errorf("start must be less than end")
 	}
 
-	var result []KeyValue
+	return s.countLess(end) - s.countLess(start), nil
+}
+
+// countLess 返回小于key的节点数，调用方必须持有锁
+func (s *SkipList) countLess(key any) int64 {
+	var rank int64
 	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next(i) != nil && s.comparator(x.next(i).key, key) < 0 {
+			rank += x.span[i]
+			x = x.next(i)
+		}
+	}
+	return rank
+}
 
-	// 找到起始节点
+// GetByRank 返回排名为rank（从0开始）的键值对，O(log n)
+func (s *SkipList) GetByRank(rank int64) (KeyValue, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if x := s.nodeByRank(rank); x != nil {
+		return KeyValue{Key: x.key, Value: x.loadValue()}, true
+	}
+	return KeyValue{}, false
+}
+
+// nodeByRank 沿跨度定位排名为rank（从0开始）的节点，调用方必须持有锁
+func (s *SkipList) nodeByRank(rank int64) *SkipNode {
+	if rank < 0 || rank >= s.count {
+		return nil
+	}
+
+	target := rank + 1 // 头节点排名为0，第一个元素排名为1
+	var traversed int64
+	x := s.head
 	for i := s.level - 1; i >= 0; i-- {
-		for x.forward[i] != nil && s.comparator(x.forward[i].key, start) < 0 {
-			x = x.forward[i]
+		for x.next(i) != nil && traversed+x.span[i] <= target {
+			traversed += x.span[i]
+			x = x.next(i)
+		}
+		if traversed == target {
+			return x
 		}
 	}
 
-	x = x.forward[0]
+	return nil
+}
 
-	// 遍历直到达到结束条件
-	for x != nil && s.comparator(x.key, end) < 0 {
-		result = append(result, KeyValue{Key: x.key, Value: x.value})
-		x = x.forward[0]
+// RangeQueryDesc 降序范围查询 [start, end)，结果按键从大到小排列
+// 从end处沿后向指针回溯，适合"前N名"之类的倒序读取
+func (s *SkipList) RangeQueryDesc(start, end any) ([]KeyValue, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if start == nil || end == nil {
+		return nil, fmt.Errorf("start and end cannot be nil")
+	}
+
+	if s.comparator(start, end) >= 0 {
+		return nil, fmt.Errorf("start must be less than end")
+	}
+
+	// 找到最后一个小于end的节点
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next(i) != nil && s.comparator(x.next(i).key, end) < 0 {
+			x = x.next(i)
+		}
+	}
+	if x == s.head {
+		return nil, nil
+	}
+
+	var result []KeyValue
+	for ; x != nil && s.comparator(x.key, start) >= 0; x = x.backward {
+		result = append(result, KeyValue{Key: x.key, Value: x.loadValue()})
 	}
 
 	return result, nil
@@ -239,16 +811,51 @@ func (s *SkipList) ScanAll() []KeyValue {
 	defer s.mu.RUnlock()
 
 	var result []KeyValue
-	x := s.head.forward[0]
+	x := s.head.next(0)
 
 	for x != nil {
-		result = append(result, KeyValue{Key: x.key, Value: x.value})
-		x = x.forward[0]
+		result = append(result, KeyValue{Key: x.key, Value: x.loadValue()})
+		x = x.next(0)
 	}
 
 	return result
 }
 
+// Keys 返回 [start, end) 中的所有键，不复制值
+func (s *SkipList) Keys(start, end any) ([]any, error) {
+	var result []any
+	err := s.RangeFunc(start, end, func(key, _ any) bool {
+		result = append(result, key)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// KeysAll 按顺序返回所有键
+func (s *SkipList) KeysAll() []any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]any, 0, s.count)
+	for x := s.head.next(0); x != nil; x = x.next(0) {
+		result = append(result, x.key)
+	}
+	return result
+}
+
+// ScanFunc 按键升序对每个键值对调用fn，fn返回false时停止遍历
+// 不获取读锁也不复制数据，适合增量处理大量元素；遍历为弱一致性，fn中可以修改跳表
+func (s *SkipList) ScanFunc(fn func(key, value any) bool) {
+	for x := s.head.next(0); x != nil; x = x.next(0) {
+		if !fn(x.key, x.loadValue()) {
+			return
+		}
+	}
+}
+
 // Size 返回元素数量
 func (s *SkipList) Size() int64 {
 	s.mu.RLock()
@@ -277,33 +884,58 @@ func (s *SkipList) Height() int {
 
 	height := 0
 	x := s.head
-	for x.forward[0] != nil {
+	for x.next(0) != nil {
 		height++
-		x = x.forward[0]
+		x = x.next(0)
 	}
 	return height
 }
 
+// defaultDumpNodesPerLevel String()每层最多显示的节点数
+const defaultDumpNodesPerLevel = 16
+
 // String 返回跳表的字符串表示（用于调试）
+// 每层最多显示16个节点，完整输出请使用Dump(w, 0)
 func (s *SkipList) String() string {
+	var sb strings.Builder
+	s.Dump(&sb, defaultDumpNodesPerLevel)
+	return sb.String()
+}
+
+// Dump 将跳表结构写入w（用于调试）
+// 第一行为概要信息，之后每层一行，包含该层的节点总数和前maxNodesPerLevel个键，
+// 超出部分以"... (+N more)"截断；maxNodesPerLevel <= 0 表示输出全部节点
+func (s *SkipList) Dump(w io.Writer, maxNodesPerLevel int) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var result string
-	result += fmt.Sprintf("SkipList(level=%d, count=%d):\n", s.level, s.count)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "SkipList(level=%d, maxLevel=%d, prob=%.2f, count=%d):\n",
+		s.level, s.maxLevel, s.prob, s.count)
 
 	// 显示每一层的节点
 	for i := s.level - 1; i >= 0; i-- {
-		result += fmt.Sprintf("Level %d: ", i)
-		x := s.head
-		for x.forward[i] != nil {
-			result += fmt.Sprintf("%v -> ", x.forward[i].key)
-			x = x.forward[i]
+		n := 0
+		for x := s.head.next(i); x != nil; x = x.next(i) {
+			n++
+		}
+
+		fmt.Fprintf(bw, "Level %d (%d nodes): ", i, n)
+		shown := 0
+		for x := s.head.next(i); x != nil; x = x.next(i) {
+			if maxNodesPerLevel > 0 && shown == maxNodesPerLevel {
+				fmt.Fprintf(bw, "... (+%d more)\n", n-shown)
+				break
+			}
+			fmt.Fprintf(bw, "%v -> ", x.key)
+			shown++
+		}
+		if shown == n {
+			bw.WriteString("nil\n")
 		}
-		result += "nil\n"
 	}
 
-	return result
+	return bw.Flush()
 }
 
 // NewDefaultSkipList 创建默认配置的跳表
@@ -311,8 +943,25 @@ func (s *SkipList) String() string {
 // - maxLevel: 16
 // - prob: 0.5
 // 适用于大多数场景
-func NewDefaultSkipList(comparator Comparator) *SkipList {
-	// 使用随机种子
-	rand.Seed(time.Now().UnixNano())
-	return NewSkipList(16, 0.5, comparator)
+func NewDefaultSkipList(comparator Comparator, opts ...SkipListOption) *SkipList {
+	return NewSkipList(16, 0.5, comparator, opts...)
+}
+
+// Describe 返回跳表的能力描述
+func (s *SkipList) Describe() Descriptor {
+	return Descriptor{
+		Name:           "SkipList",
+		Ordered:        true,
+		SupportsDelete: true,
+		SupportsRange:  true,
+		Persistent:     true,
+		Concurrency:    ConcurrencyLockFreeReads,
+		Complexity: Complexity{
+			Insert: "O(log n) 期望",
+			Search: "O(log n) 期望",
+			Delete: "O(log n) 期望",
+			Range:  "O(log n + k) 期望",
+		},
+		Notes: "随机化平衡，实现简单，适合内存场景；Search、SearchAll和RangeQuery不加锁，写入互斥，读取不会被写入阻塞",
+	}
 }
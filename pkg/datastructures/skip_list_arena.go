@@ -0,0 +1,55 @@
+package datastructures
+
+import "sync/atomic"
+
+// skipNodeArena 跳表节点的块分配器
+// 节点、前向指针数组和跨度数组分别从三个板分配器中切分，
+// 每插入一个节点从3次堆分配降为均摊远小于1次。
+// 只在跳表写锁内使用，因此不需要额外加锁。
+type skipNodeArena struct {
+	chunkSize int // 每块容纳的节点数量
+	nodes     slab[SkipNode]
+	forwards  slab[atomic.Pointer[SkipNode]]
+	spans     slab[int64]
+}
+
+// newNode 从内存块中分配节点
+func (a *skipNodeArena) newNode(key, value any, height int) *SkipNode {
+	node := &a.nodes.alloc(1, a.chunkSize)[0]
+	node.key = key
+	node.value.Store(&value)
+	// 期望层数约为2，按每节点4层预留指针块
+	node.forward = a.forwards.alloc(height, a.chunkSize*4)
+	node.span = a.spans.alloc(height, a.chunkSize*4)
+	node.height = height
+	return node
+}
+
+// release 丢弃所有内存块，由GC在无锁读者不再引用后整体回收
+// 不能原地复用内存块：Search和RangeQuery可能仍在不加锁地遍历旧节点
+func (a *skipNodeArena) release() {
+	a.nodes = slab[SkipNode]{}
+	a.forwards = slab[atomic.Pointer[SkipNode]]{}
+	a.spans = slab[int64]{}
+}
+
+// WithSkipListArena 从连续内存块中分配节点（arena模式）
+// chunkSize: 每个内存块容纳的节点数量，建议值：1024-65536
+// 适合千万级元素的跳表：节点不再是独立的堆对象，GC需要跟踪的对象数量大幅减少。
+// 被删除节点占用的空间在ReadFrom整体替换内容之前不会回收，适合以插入为主的场景。
+func WithSkipListArena(chunkSize int) SkipListOption {
+	if chunkSize <= 0 {
+		panic("chunkSize must be > 0")
+	}
+	return func(s *SkipList) {
+		s.arena = &skipNodeArena{chunkSize: chunkSize}
+	}
+}
+
+// newNode 创建节点，arena模式下从内存块分配
+func (s *SkipList) newNode(key, value any, height int) *SkipNode {
+	if s.arena != nil {
+		return s.arena.newNode(key, value, height)
+	}
+	return NewSkipNode(key, value, height)
+}
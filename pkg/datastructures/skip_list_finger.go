@@ -0,0 +1,104 @@
+package datastructures
+
+import "fmt"
+
+// fingerLevel 返回缓存路径中最低的一层t：path[t]是头节点或排在目标之前，且它在第t层的后继不排在目标之前
+// 这一层的路径不需要改变，从它向下查找即可；before判断节点是否排在目标之前，没有这样的层时返回level
+// 有效性自下而上单调：层越高，path中的前驱越靠前、后继越靠后，因此t以上的层也都有效
+// 目标与缓存位置相距d个元素时，期望在第O(log d)层停下
+func fingerLevel(head *SkipNode, path []*SkipNode, level int, before func(*SkipNode) bool) int {
+	for t := 0; t < level; t++ {
+		x := path[t]
+		if x != head && !before(x) {
+			continue
+		}
+		if next := x.next(t); next == nil || !before(next) {
+			return t
+		}
+	}
+	return level
+}
+
+// SkipListFinger 跳表的读取端finger，缓存上一次查找的每层前驱
+// 特点：
+// - 与上一次查找的键相距d个元素时，Search和RangeFunc的定位代价期望为O(log d)，适合局部集中的查找和扫描
+// - 与SkipList.Search一样不加锁，只通过原子指针遍历，不会被写入者阻塞
+// - 插入不影响缓存的路径；有节点被摘除后路径中可能有已删除的节点，下一次查找从头节点开始并重新缓存
+// - 一个finger只能由一个goroutine使用，多个goroutine各自调用NewFinger
+type SkipListFinger struct {
+	s        *SkipList
+	path     []*SkipNode // 上一次查找的每层前驱，nil表示没有缓存
+	removals uint64      // 缓存路径时跳表的摘除次数
+}
+
+// NewFinger 创建读取端finger，第一次查找从头节点开始
+func (s *SkipList) NewFinger() *SkipListFinger {
+	return &SkipListFinger{s: s}
+}
+
+// seek 查找第一个大于等于key的节点，并把查找路径缓存下来
+func (f *SkipListFinger) seek(key any) *SkipNode {
+	s := f.s
+	before := func(x *SkipNode) bool { return s.comparator(x.key, key) < 0 }
+
+	// 先读取摘除次数再遍历：遍历期间发生的摘除会使下一次查找放弃这次缓存的路径
+	removals := s.removals.Load()
+	x, top := s.head, s.maxLevel
+	if f.path != nil && f.removals == removals {
+		top = fingerLevel(s.head, f.path, s.maxLevel, before)
+		if top < s.maxLevel {
+			x = f.path[top]
+		}
+	} else {
+		f.path = make([]*SkipNode, s.maxLevel)
+		for i := range f.path {
+			f.path[i] = s.head
+		}
+	}
+
+	for i := top - 1; i >= 0; i-- {
+		// 缓存路径中这一层的前驱比上一层的结果更靠后时直接从它出发
+		if p := f.path[i]; p != s.head && before(p) && (x == s.head || s.comparator(p.key, x.key) > 0) {
+			x = p
+		}
+		for next := x.next(i); next != nil && before(next); next = x.next(i) {
+			x = next
+		}
+		f.path[i] = x
+	}
+	f.removals = removals
+
+	return x.next(0)
+}
+
+// Search 查找值，语义与SkipList.Search相同
+func (f *SkipListFinger) Search(key any) (any, bool) {
+	if key == nil {
+		return nil, false
+	}
+
+	x := f.seek(key)
+	if x != nil && f.s.comparator(x.key, key) == 0 {
+		return x.loadValue(), true
+	}
+	return nil, false
+}
+
+// RangeFunc 按键的顺序对[start, end)中的每个键值对调用fn，fn返回false时停止，语义与SkipList.RangeFunc相同
+// 起点由finger定位，连续扫描相邻的区间时只需O(log d)即可找到起点
+func (f *SkipListFinger) RangeFunc(start, end any, fn func(key, value any) bool) error {
+	s := f.s
+	if start == nil || end == nil {
+		return fmt.Errorf("start and end cannot be nil")
+	}
+	if s.comparator(start, end) >= 0 {
+		return fmt.Errorf("start must be less than end")
+	}
+
+	for x := f.seek(start); x != nil && s.comparator(x.key, end) < 0; x = x.next(0) {
+		if !fn(x.key, x.loadValue()) {
+			break
+		}
+	}
+	return nil
+}
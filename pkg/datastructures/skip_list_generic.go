@@ -0,0 +1,225 @@
+package datastructures
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+// Pair 泛型键值对
+type Pair[K any, V any] struct {
+	Key   K
+	Value V
+}
+
+// genericSkipNode 泛型跳表节点
+type genericSkipNode[K any, V any] struct {
+	key     K
+	value   V
+	forward []*genericSkipNode[K, V]
+}
+
+// GenericSkipList 泛型跳表
+// 特点：
+// - 键值类型在编译期确定，热路径上没有接口装箱和类型断言
+// - 使用强类型比较函数，API编译期类型安全
+// - 其余行为与SkipList一致：有序、支持范围查询、并发安全
+type GenericSkipList[K any, V any] struct {
+	head     *genericSkipNode[K, V] // 头节点
+	compare  func(a, b K) int       // 比较函数
+	mu       sync.RWMutex           // 读写锁
+	maxLevel int                    // 最大层数
+	level    int                    // 当前最大层数
+	prob     float64                // 随机层数的概率因子 (0 < prob < 1)
+	count    int64                  // 元素总数
+}
+
+// NewGenericSkipList 创建新的泛型跳表
+// maxLevel: 最大层数，建议16-32
+// prob: 升层概率，建议0.5
+// compare: 比较函数，返回值：负数(小于)、0(等于)、正数(大于)
+func NewGenericSkipList[K any, V any](maxLevel int, prob float64, compare func(a, b K) int) *GenericSkipList[K, V] {
+	if maxLevel < 1 {
+		panic("maxLevel must be >= 1")
+	}
+	if prob <= 0 || prob >= 1 {
+		panic("prob must be in (0, 1)")
+	}
+	if compare == nil {
+		panic("compare is required")
+	}
+
+	return &GenericSkipList[K, V]{
+		head:     &genericSkipNode[K, V]{forward: make([]*genericSkipNode[K, V], maxLevel)},
+		compare:  compare,
+		maxLevel: maxLevel,
+		level:    1,
+		prob:     prob,
+	}
+}
+
+// NewOrderedSkipList 创建以可排序类型为键的默认配置泛型跳表
+// 使用cmp.Compare作为比较函数，maxLevel为16，prob为0.5
+func NewOrderedSkipList[K cmp.Ordered, V any]() *GenericSkipList[K, V] {
+	return NewGenericSkipList[K, V](16, 0.5, cmp.Compare[K])
+}
+
+// randomLevel 生成随机层数
+func (s *GenericSkipList[K, V]) randomLevel() int {
+	level := 1
+	for level < s.maxLevel && rand.Float64() < s.prob {
+		level++
+	}
+	return level
+}
+
+// findPath 查找每一层中最后一个小于key的节点
+func (s *GenericSkipList[K, V]) findPath(key K, update []*genericSkipNode[K, V]) *genericSkipNode[K, V] {
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.forward[i] != nil && s.compare(x.forward[i].key, key) < 0 {
+			x = x.forward[i]
+		}
+		if update != nil {
+			update[i] = x
+		}
+	}
+	return x
+}
+
+// Insert 插入键值对，键已存在时更新值
+func (s *GenericSkipList[K, V]) Insert(key K, value V) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update := make([]*genericSkipNode[K, V], s.maxLevel)
+	x := s.findPath(key, update)
+
+	if next := x.forward[0]; next != nil && s.compare(next.key, key) == 0 {
+		next.value = value
+		return
+	}
+
+	newLevel := s.randomLevel()
+	if newLevel > s.level {
+		for i := s.level; i < newLevel; i++ {
+			update[i] = s.head
+		}
+		s.level = newLevel
+	}
+
+	node := &genericSkipNode[K, V]{
+		key:     key,
+		value:   value,
+		forward: make([]*genericSkipNode[K, V], newLevel),
+	}
+	for i := 0; i < newLevel; i++ {
+		node.forward[i] = update[i].forward[i]
+		update[i].forward[i] = node
+	}
+
+	s.count++
+}
+
+// Search 查找值
+func (s *GenericSkipList[K, V]) Search(key K) (V, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	x := s.findPath(key, nil).forward[0]
+	if x != nil && s.compare(x.key, key) == 0 {
+		return x.value, true
+	}
+
+	var zero V
+	return zero, false
+}
+
+// Delete 删除键值对
+func (s *GenericSkipList[K, V]) Delete(key K) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update := make([]*genericSkipNode[K, V], s.maxLevel)
+	x := s.findPath(key, update).forward[0]
+	if x == nil || s.compare(x.key, key) != 0 {
+		return false
+	}
+
+	for i := 0; i < s.level; i++ {
+		if update[i].forward[i] != x {
+			break
+		}
+		update[i].forward[i] = x.forward[i]
+	}
+
+	for s.level > 1 && s.head.forward[s.level-1] == nil {
+		s.level--
+	}
+
+	s.count--
+	return true
+}
+
+// RangeQuery 范围查询 [start, end)
+func (s *GenericSkipList[K, V]) RangeQuery(start, end K) ([]Pair[K, V], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.compare(start, end) >= 0 {
+		return nil, fmt.Errorf("start must be less than end")
+	}
+
+	var result []Pair[K, V]
+	for x := s.findPath(start, nil).forward[0]; x != nil && s.compare(x.key, end) < 0; x = x.forward[0] {
+		result = append(result, Pair[K, V]{Key: x.key, Value: x.value})
+	}
+
+	return result, nil
+}
+
+// ScanAll 顺序遍历所有键值对
+func (s *GenericSkipList[K, V]) ScanAll() []Pair[K, V] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]Pair[K, V], 0, s.count)
+	for x := s.head.forward[0]; x != nil; x = x.forward[0] {
+		result = append(result, Pair[K, V]{Key: x.key, Value: x.value})
+	}
+
+	return result
+}
+
+// Size 返回元素数量
+func (s *GenericSkipList[K, V]) Size() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.count
+}
+
+// Level 返回当前最大层数
+func (s *GenericSkipList[K, V]) Level() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.level
+}
+
+// Describe 返回泛型跳表的能力描述
+func (s *GenericSkipList[K, V]) Describe() Descriptor {
+	return Descriptor{
+		Name:           "GenericSkipList",
+		Ordered:        true,
+		SupportsDelete: true,
+		SupportsRange:  true,
+		Concurrency:    ConcurrencyRWMutex,
+		Complexity: Complexity{
+			Insert: "O(log n) 期望",
+			Search: "O(log n) 期望",
+			Delete: "O(log n) 期望",
+			Range:  "O(log n + k) 期望",
+		},
+		Notes: "键值类型编译期确定，无接口装箱",
+	}
+}
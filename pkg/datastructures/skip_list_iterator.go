@@ -0,0 +1,128 @@
+package datastructures
+
+// SkipListIterator 跳表游标
+// 特点：
+// - 支持按键升序(Next)和降序(Prev)遍历，不需要像RangeQuery那样一次性生成结果切片
+// - 每次移动只短暂持有读锁，遍历期间不阻塞写入者
+// - 可以通过Seek从任意位置恢复扫描，适合分页
+// - 弱一致性：遍历期间的并发插入可能看到也可能看不到
+type SkipListIterator struct {
+	list  *SkipList
+	node  *SkipNode // 当前节点，nil表示迭代结束
+	key   any       // 定位时读取的键
+	value any       // 定位时读取的值
+}
+
+// Iterator 创建跳表游标，游标初始位于第一个键值对
+func (s *SkipList) Iterator() *SkipListIterator {
+	it := &SkipListIterator{list: s}
+	it.SeekToFirst()
+	return it
+}
+
+// setNode 在持有读锁时定位到节点并读取键值
+func (it *SkipListIterator) setNode(x *SkipNode) {
+	it.node = x
+	if x != nil {
+		it.key, it.value = x.key, x.loadValue()
+	} else {
+		it.key, it.value = nil, nil
+	}
+}
+
+// SeekToFirst 定位到第一个键值对
+func (it *SkipListIterator) SeekToFirst() {
+	s := it.list
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	it.setNode(s.head.next(0))
+}
+
+// Seek 定位到第一个大于等于key的键值对
+func (it *SkipListIterator) Seek(key any) {
+	s := it.list
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if key == nil {
+		it.setNode(nil)
+		return
+	}
+
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next(i) != nil && s.comparator(x.next(i).key, key) < 0 {
+			x = x.next(i)
+		}
+	}
+	it.setNode(x.next(0))
+}
+
+// Valid 游标是否指向有效的键值对
+func (it *SkipListIterator) Valid() bool {
+	return it.node != nil
+}
+
+// Next 移动到下一个键值对
+// 当前节点被并发删除时，仍会沿着它删除时的后继继续前进
+func (it *SkipListIterator) Next() {
+	if it.node == nil {
+		return
+	}
+	s := it.list
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	it.setNode(it.node.next(0))
+}
+
+// SeekToLast 定位到最后一个键值对
+func (it *SkipListIterator) SeekToLast() {
+	s := it.list
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	it.setNode(s.tail)
+}
+
+// SeekForPrev 定位到最后一个小于等于key的键值对
+func (it *SkipListIterator) SeekForPrev(key any) {
+	s := it.list
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if key == nil {
+		it.setNode(nil)
+		return
+	}
+
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next(i) != nil && s.comparator(x.next(i).key, key) <= 0 {
+			x = x.next(i)
+		}
+	}
+	if x == s.head {
+		x = nil
+	}
+	it.setNode(x)
+}
+
+// Prev 移动到上一个键值对
+func (it *SkipListIterator) Prev() {
+	if it.node == nil {
+		return
+	}
+	s := it.list
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	it.setNode(it.node.backward)
+}
+
+// Key 返回当前键
+func (it *SkipListIterator) Key() any {
+	return it.key
+}
+
+// Value 返回当前值
+func (it *SkipListIterator) Value() any {
+	return it.value
+}
@@ -0,0 +1,91 @@
+package datastructures
+
+import "math/rand"
+
+// LevelGenerator 跳表新节点的层数生成策略
+// 实现只会在跳表写锁内被调用，不需要自身保证并发安全
+type LevelGenerator interface {
+	// NextLevel 返回新节点的层数，结果会被限制在[1, maxLevel]内
+	NextLevel(maxLevel int) int
+}
+
+// GeometricLevelGenerator 几何分布层数生成器（默认策略）
+// 节点拥有第k层的概率为prob^(k-1)
+type GeometricLevelGenerator struct {
+	prob float64
+	rng  *rand.Rand
+}
+
+// NewGeometricLevelGenerator 创建几何分布层数生成器
+// prob: 升层概率，取值范围(0, 1)
+// seed: 随机种子，相同种子生成相同的层数序列
+func NewGeometricLevelGenerator(prob float64, seed int64) *GeometricLevelGenerator {
+	if prob <= 0 || prob >= 1 {
+		panic("prob must be in (0, 1)")
+	}
+	return &GeometricLevelGenerator{prob: prob, rng: rand.New(rand.NewSource(seed))}
+}
+
+// NextLevel 生成随机层数
+func (g *GeometricLevelGenerator) NextLevel(maxLevel int) int {
+	level := 1
+	for level < maxLevel && g.rng.Float64() < g.prob {
+		level++
+	}
+	return level
+}
+
+// CappedLevelGenerator 限制最大层数的生成器
+// 较低的层数上限让节点的前向指针数组更小、更紧凑，对缓存更友好，
+// 代价是元素很多时高层的跨度变大，查找路径变长
+type CappedLevelGenerator struct {
+	inner LevelGenerator
+	cap   int
+}
+
+// NewCappedLevelGenerator 创建限制层数上限为cap的生成器
+func NewCappedLevelGenerator(inner LevelGenerator, cap int) *CappedLevelGenerator {
+	if inner == nil {
+		panic("inner generator is required")
+	}
+	if cap < 1 {
+		panic("cap must be >= 1")
+	}
+	return &CappedLevelGenerator{inner: inner, cap: cap}
+}
+
+// NextLevel 生成不超过上限的层数
+func (g *CappedLevelGenerator) NextLevel(maxLevel int) int {
+	return g.inner.NextLevel(min(maxLevel, g.cap))
+}
+
+// SequenceLevelGenerator 按给定序列循环返回层数的确定性生成器
+// 用于精确复现某个跳表结构，例如复现问题报告中的插入序列
+type SequenceLevelGenerator struct {
+	levels []int
+	pos    int
+}
+
+// NewSequenceLevelGenerator 创建按levels循环生成层数的生成器
+func NewSequenceLevelGenerator(levels ...int) *SequenceLevelGenerator {
+	if len(levels) == 0 {
+		panic("levels cannot be empty")
+	}
+	return &SequenceLevelGenerator{levels: append([]int(nil), levels...)}
+}
+
+// NextLevel 返回序列中的下一个层数
+func (g *SequenceLevelGenerator) NextLevel(maxLevel int) int {
+	level := g.levels[g.pos]
+	g.pos = (g.pos + 1) % len(g.levels)
+	return level
+}
+
+// WithLevelGenerator 使用自定义的层数生成策略
+func WithLevelGenerator(g LevelGenerator) SkipListOption {
+	return func(s *SkipList) {
+		if g != nil {
+			s.levelGen = g
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package datastructures
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+)
+
+// markedRef 带删除标记的指针（不可变）
+// Go没有可以原子修改的"指针+标记位"，因此每次修改都替换整个引用对象
+type markedRef struct {
+	node   *lockFreeNode
+	marked bool // 持有该引用的节点在这一层已被逻辑删除
+}
+
+// lockFreeNode 无锁跳表节点
+type lockFreeNode struct {
+	key      any
+	value    atomic.Pointer[any]
+	next     []atomic.Pointer[markedRef]
+	topLevel int
+}
+
+// newLockFreeNode 创建无锁跳表节点
+func newLockFreeNode(key, value any, height int) *lockFreeNode {
+	n := &lockFreeNode{
+		key:      key,
+		next:     make([]atomic.Pointer[markedRef], height),
+		topLevel: height,
+	}
+	n.value.Store(&value)
+	return n
+}
+
+// LockFreeSkipList 无锁并发跳表（Herlihy-Shavit算法）
+// 特点：
+// - 基于CAS实现，没有全局锁，写入者之间互不阻塞
+// - Insert/Delete/Search可线性化，Contains为无等待（wait-free）操作
+// - 删除分两步：先在各层标记（逻辑删除），再由后续遍历摘除（物理删除）
+// - 范围查询和遍历为弱一致性，不保证看到遍历期间的并发修改
+type LockFreeSkipList struct {
+	head       *lockFreeNode // 头节点（哨兵，键不参与比较）
+	comparator Comparator    // 比较函数
+	maxLevel   int           // 最大层数
+	prob       float64       // 随机层数的概率因子 (0 < prob < 1)
+	count      atomic.Int64  // 元素总数
+}
+
+// NewLockFreeSkipList 创建无锁并发跳表
+// maxLevel: 最大层数，建议16-32
+// prob: 升层概率，建议0.5
+// comparator: 比较函数
+func NewLockFreeSkipList(maxLevel int, prob float64, comparator Comparator) *LockFreeSkipList {
+	if maxLevel < 1 {
+		panic("maxLevel must be >= 1")
+	}
+	if prob <= 0 || prob >= 1 {
+		panic("prob must be in (0, 1)")
+	}
+	if comparator == nil {
+		panic("comparator is required")
+	}
+
+	head := newLockFreeNode(nil, nil, maxLevel)
+	for i := range head.next {
+		head.next[i].Store(&markedRef{})
+	}
+
+	return &LockFreeSkipList{
+		head:       head,
+		comparator: comparator,
+		maxLevel:   maxLevel,
+		prob:       prob,
+	}
+}
+
+// NewDefaultLockFreeSkipList 创建默认配置（maxLevel=16, prob=0.5）的无锁跳表
+func NewDefaultLockFreeSkipList(comparator Comparator) *LockFreeSkipList {
+	return NewLockFreeSkipList(16, 0.5, comparator)
+}
+
+// randomLevel 生成随机层数（全局随机源是并发安全的）
+func (s *LockFreeSkipList) randomLevel() int {
+	level := 1
+	for level < s.maxLevel && rand.Float64() < s.prob {
+		level++
+	}
+	return level
+}
+
+// find 查找每一层的前驱和后继，途中摘除已标记删除的节点
+// 返回key是否存在于最底层
+func (s *LockFreeSkipList) find(key any, preds, succs []*lockFreeNode) bool {
+retry:
+	for {
+		pred := s.head
+		var curr *lockFreeNode
+		for level := s.maxLevel - 1; level >= 0; level-- {
+			curr = pred.next[level].Load().node
+			for curr != nil {
+				ref := curr.next[level].Load()
+				for ref.marked {
+					// curr已被逻辑删除，尝试把它从pred之后摘除
+					expected := pred.next[level].Load()
+					if expected.node != curr || expected.marked {
+						continue retry
+					}
+					if !pred.next[level].CompareAndSwap(expected, &markedRef{node: ref.node}) {
+						continue retry
+					}
+					curr = ref.node
+					if curr == nil {
+						break
+					}
+					ref = curr.next[level].Load()
+				}
+				if curr == nil || s.comparator(curr.key, key) >= 0 {
+					break
+				}
+				pred = curr
+				curr = ref.node
+			}
+			preds[level] = pred
+			succs[level] = curr
+		}
+		return curr != nil && s.comparator(curr.key, key) == 0
+	}
+}
+
+// Insert 插入键值对，键已存在时原子地更新值
+func (s *LockFreeSkipList) Insert(key any, value any) error {
+	if key == nil {
+		return fmt.Errorf("key cannot be nil")
+	}
+
+	preds := make([]*lockFreeNode, s.maxLevel)
+	succs := make([]*lockFreeNode, s.maxLevel)
+	topLevel := s.randomLevel()
+
+	for {
+		if s.find(key, preds, succs) {
+			succs[0].value.Store(&value)
+			return nil
+		}
+
+		node := newLockFreeNode(key, value, topLevel)
+		for level := 0; level < topLevel; level++ {
+			node.next[level].Store(&markedRef{node: succs[level]})
+		}
+
+		// 在最底层链接即为插入的线性化点
+		expected := preds[0].next[0].Load()
+		if expected.node != succs[0] || expected.marked {
+			continue
+		}
+		if !preds[0].next[0].CompareAndSwap(expected, &markedRef{node: node}) {
+			continue
+		}
+		s.count.Add(1)
+
+		// 逐层向上链接
+		for level := 1; level < topLevel; level++ {
+			for {
+				own := node.next[level].Load()
+				if own.marked {
+					return nil // 新节点已被并发删除，无需继续链接
+				}
+				if own.node != succs[level] &&
+					!node.next[level].CompareAndSwap(own, &markedRef{node: succs[level]}) {
+					continue
+				}
+
+				expected := preds[level].next[level].Load()
+				if expected.node == succs[level] && !expected.marked &&
+					preds[level].next[level].CompareAndSwap(expected, &markedRef{node: node}) {
+					break
+				}
+				s.find(key, preds, succs)
+			}
+		}
+		return nil
+	}
+}
+
+// Delete 删除键值对
+func (s *LockFreeSkipList) Delete(key any) bool {
+	if key == nil {
+		return false
+	}
+
+	preds := make([]*lockFreeNode, s.maxLevel)
+	succs := make([]*lockFreeNode, s.maxLevel)
+
+	if !s.find(key, preds, succs) {
+		return false
+	}
+	victim := succs[0]
+
+	// 自顶向下标记除最底层外的各层
+	for level := victim.topLevel - 1; level >= 1; level-- {
+		ref := victim.next[level].Load()
+		for !ref.marked {
+			victim.next[level].CompareAndSwap(ref, &markedRef{node: ref.node, marked: true})
+			ref = victim.next[level].Load()
+		}
+	}
+
+	// 标记最底层即为删除的线性化点，只有成功标记的线程返回true
+	ref := victim.next[0].Load()
+	for {
+		if ref.marked {
+			return false
+		}
+		if victim.next[0].CompareAndSwap(ref, &markedRef{node: ref.node, marked: true}) {
+			s.count.Add(-1)
+			s.find(key, preds, succs) // 物理摘除
+			return true
+		}
+		ref = victim.next[0].Load()
+	}
+}
+
+// locate 无等待查找：只读遍历，跳过已标记节点但不摘除
+func (s *LockFreeSkipList) locate(key any) *lockFreeNode {
+	pred := s.head
+	var curr *lockFreeNode
+	for level := s.maxLevel - 1; level >= 0; level-- {
+		curr = pred.next[level].Load().node
+		for curr != nil {
+			ref := curr.next[level].Load()
+			for ref.marked {
+				curr = ref.node
+				if curr == nil {
+					break
+				}
+				ref = curr.next[level].Load()
+			}
+			if curr == nil || s.comparator(curr.key, key) >= 0 {
+				break
+			}
+			pred = curr
+			curr = ref.node
+		}
+	}
+
+	if curr != nil && s.comparator(curr.key, key) == 0 && !curr.next[0].Load().marked {
+		return curr
+	}
+	return nil
+}
+
+// Contains 检查键是否存在（无等待）
+func (s *LockFreeSkipList) Contains(key any) bool {
+	if key == nil {
+		return false
+	}
+	return s.locate(key) != nil
+}
+
+// Search 查找值
+func (s *LockFreeSkipList) Search(key any) (any, bool) {
+	if key == nil {
+		return nil, false
+	}
+	if node := s.locate(key); node != nil {
+		return *node.value.Load(), true
+	}
+	return nil, false
+}
+
+// RangeQuery 范围查询 [start, end)，弱一致性
+func (s *LockFreeSkipList) RangeQuery(start, end any) ([]KeyValue, error) {
+	if start == nil || end == nil {
+		return nil, fmt.Errorf("start and end cannot be nil")
+	}
+	if s.comparator(start, end) >= 0 {
+		return nil, fmt.Errorf("start must be less than end")
+	}
+
+	preds := make([]*lockFreeNode, s.maxLevel)
+	succs := make([]*lockFreeNode, s.maxLevel)
+	s.find(start, preds, succs)
+
+	var result []KeyValue
+	for x := succs[0]; x != nil && s.comparator(x.key, end) < 0; {
+		ref := x.next[0].Load()
+		if !ref.marked {
+			result = append(result, KeyValue{Key: x.key, Value: *x.value.Load()})
+		}
+		x = ref.node
+	}
+
+	return result, nil
+}
+
+// ScanAll 顺序遍历所有未删除的键值对，弱一致性
+func (s *LockFreeSkipList) ScanAll() []KeyValue {
+	var result []KeyValue
+	for x := s.head.next[0].Load().node; x != nil; {
+		ref := x.next[0].Load()
+		if !ref.marked {
+			result = append(result, KeyValue{Key: x.key, Value: *x.value.Load()})
+		}
+		x = ref.node
+	}
+	return result
+}
+
+// Size 返回元素数量（并发修改期间为近似值）
+func (s *LockFreeSkipList) Size() int64 {
+	return s.count.Load()
+}
+
+// Describe 返回无锁跳表的能力描述
+func (s *LockFreeSkipList) Describe() Descriptor {
+	return Descriptor{
+		Name:           "LockFreeSkipList",
+		Ordered:        true,
+		SupportsDelete: true,
+		SupportsRange:  true,
+		Concurrency:    ConcurrencyLockFree,
+		Complexity: Complexity{
+			Insert: "O(log n) 期望",
+			Search: "O(log n) 期望",
+			Delete: "O(log n) 期望",
+			Range:  "O(log n + k) 期望",
+		},
+		Notes: "Insert/Delete/Search可线性化，Contains无等待；范围查询为弱一致性",
+	}
+}
@@ -0,0 +1,120 @@
+package datastructures
+
+import (
+	"fmt"
+	"io"
+)
+
+// skipListMagic 跳表序列化格式的文件头
+const skipListMagic = "SKL\x01"
+
+// 序列化格式：
+//
+//	magic(4字节) | count(uvarint) | count × (keyLen(uvarint) key valueLen(uvarint) value)
+//
+// 键值对按键升序写入，层级结构不保存，加载时重新随机生成。
+
+// WriteTo 将跳表中的所有键值对写入w，实现io.WriterTo
+// 需要先通过WithSkipListCodec设置键、值编解码器
+func (s *SkipList) WriteTo(w io.Writer) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.keyCodec == nil || s.valueCodec == nil {
+		return 0, fmt.Errorf("key and value codecs are required for serialization")
+	}
+
+	bw := &binaryWriter{w: w}
+	bw.write([]byte(skipListMagic))
+	bw.writeUvarint(uint64(s.count))
+	for x := s.head.next(0); x != nil && bw.err == nil; x = x.next(0) {
+		bw.writeEncoded(s.keyCodec, x.key)
+		bw.writeEncoded(s.valueCodec, x.loadValue())
+	}
+
+	return bw.n, bw.err
+}
+
+// ReadFrom 从r读取WriteTo写入的数据并替换跳表的全部内容，实现io.ReaderFrom
+// 读取失败时跳表保持原有内容不变
+func (s *SkipList) ReadFrom(r io.Reader) (int64, error) {
+	if s.keyCodec == nil || s.valueCodec == nil {
+		return 0, fmt.Errorf("key and value codecs are required for serialization")
+	}
+
+	br := &binaryReader{r: r}
+	magic := make([]byte, len(skipListMagic))
+	if _, err := br.read(magic); err != nil {
+		return br.n, err
+	}
+	if string(magic) != skipListMagic {
+		return br.n, fmt.Errorf("invalid skip list header")
+	}
+
+	count, err := br.readUvarint()
+	if err != nil {
+		return br.n, err
+	}
+
+	// 先完整读取并校验，再替换内容
+	kvs := make([]KeyValue, 0, min(count, 1<<16))
+	for i := uint64(0); i < count; i++ {
+		key, err := br.readDecoded(s.keyCodec, maxEncodedFieldLen)
+		if err != nil {
+			return br.n, err
+		}
+		value, err := br.readDecoded(s.valueCodec, maxEncodedFieldLen)
+		if err != nil {
+			return br.n, err
+		}
+		if key == nil {
+			return br.n, fmt.Errorf("key cannot be nil")
+		}
+		if len(kvs) > 0 {
+			if c := s.comparator(kvs[len(kvs)-1].Key, key); c > 0 || (c == 0 && !s.duplicates) {
+				return br.n, fmt.Errorf("keys are not in strictly ascending order")
+			}
+		}
+		kvs = append(kvs, KeyValue{Key: key, Value: value})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reset()
+	update := make([]*SkipNode, s.maxLevel)
+	rank := make([]int64, s.maxLevel)
+	for i, kv := range kvs {
+		s.findUpdatePath(kv.Key, kv.Value, update, rank, i > 0)
+		s.insertAt(kv.Key, kv.Value, update, rank)
+	}
+
+	return br.n, nil
+}
+
+// reset 清空跳表，调用方必须持有写锁
+// arena模式下同时丢弃全部节点内存块
+func (s *SkipList) reset() {
+	if s.arena != nil {
+		s.arena.release()
+	}
+	// 全部节点都被摘除，为活跃快照按顺序登记在头节点之下
+	if len(s.snapshots) > 0 {
+		s.snapMu.Lock()
+		defer s.snapMu.Unlock()
+		for x := s.head.next(0); x != nil; x = x.next(0) {
+			s.preserveRemoved(s.head, x)
+		}
+	}
+
+	// 头节点不替换，只清空指针，不加锁的读者始终从同一个头节点开始遍历
+	for i := range s.head.forward {
+		s.head.setNext(i, nil)
+		s.head.span[i] = 0
+	}
+	s.tail = nil
+	s.level = 1
+	s.count = 0
+	s.fingerValid = false
+	s.removals.Add(1)
+}
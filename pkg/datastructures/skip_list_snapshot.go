@@ -0,0 +1,176 @@
+package datastructures
+
+// skipSnapshot 跳表的一个时间点快照
+// 创建快照时只记录纪元（O(1)），不复制节点；之后写入者在摘除节点或替换值之前，
+// 把快照仍然需要的内容登记到所有活跃快照中（写时复制，与bplusSnapshot相同）：
+// - 被摘除的节点按摘除顺序登记在它当时的第0层前驱之下。节点摘除后前向指针保持不变，
+//   迭代器遍历到前驱时先遍历登记在它之下的节点，再沿第0层指针继续，因此不会遗漏或重复
+// - 被替换值的节点只登记第一次替换前的值
+type skipSnapshot struct {
+	epoch   uint64                    // 快照纪元，纪元小于它的节点属于该快照
+	count   int64                     // 快照时刻的元素数量
+	removed map[*SkipNode][]*SkipNode // 快照之后被摘除的节点，键为摘除时的第0层前驱
+	values  map[*SkipNode]*any        // 快照之后被替换过值的节点的原始值指针
+}
+
+// preserveRemoved 在摘除节点x之前调用，pred为x在第0层的前驱
+// 调用方必须持有写锁和snapMu，并在释放snapMu之前完成第0层指针的修改
+func (s *SkipList) preserveRemoved(pred, x *SkipNode) {
+	for snap := range s.snapshots {
+		snap.removed[pred] = append(snap.removed[pred], x)
+	}
+}
+
+// preserveValue 在替换节点的值之前调用，为所有活跃快照保存原始值
+// 调用方必须持有写锁
+func (s *SkipList) preserveValue(x *SkipNode) {
+	if len(s.snapshots) == 0 {
+		return
+	}
+	s.snapMu.Lock()
+	defer s.snapMu.Unlock()
+	for snap := range s.snapshots {
+		if x.epoch >= snap.epoch {
+			continue // 快照之后插入的节点不属于该快照
+		}
+		if _, ok := snap.values[x]; !ok {
+			snap.values[x] = x.value.Load()
+		}
+	}
+}
+
+// skipSnapshotFrame 迭代器中一个尚未遍历完的被摘除节点列表
+type skipSnapshotFrame struct {
+	owner *SkipNode // 列表登记在它之下
+	idx   int       // 下一个要遍历的位置
+}
+
+// SkipListSnapshotIterator 跳表快照迭代器
+// 特点：
+// - 看到的是创建迭代器那一刻已完整链接的键值对，之后的插入、删除和更新都不可见
+// - 创建时只在写锁内记录纪元，O(1)；遍历时不加锁地沿第0层指针前进，每一步只短暂持有snapMu，
+//   长时间导出不会阻塞写入者，写入者只在删除和更新时为快照登记原始内容
+// - 使用完毕后必须调用Close，否则写入者会持续为该快照登记；迭代结束时会自动调用
+type SkipListSnapshotIterator struct {
+	s     *SkipList
+	snap  *skipSnapshot
+	chain *SkipNode           // 最近一个沿第0层指针到达的节点
+	stack []skipSnapshotFrame // 待遍历的被摘除节点列表，栈底属于chain
+	node  *SkipNode           // 当前节点，nil表示迭代结束
+	value *any                // 当前节点在快照时刻的值
+	count int64               // 快照中的元素数量
+}
+
+// SnapshotIter 创建快照迭代器，迭代器初始位于第一个键值对
+func (s *SkipList) SnapshotIter() *SkipListSnapshotIterator {
+	s.mu.Lock()
+	s.epoch++
+	snap := &skipSnapshot{
+		epoch:   s.epoch,
+		count:   s.count,
+		removed: make(map[*SkipNode][]*SkipNode),
+		values:  make(map[*SkipNode]*any),
+	}
+	if s.snapshots == nil {
+		s.snapshots = make(map[*skipSnapshot]struct{})
+	}
+	s.snapshots[snap] = struct{}{}
+	s.mu.Unlock()
+
+	it := &SkipListSnapshotIterator{
+		s:     s,
+		snap:  snap,
+		chain: s.head,
+		stack: []skipSnapshotFrame{{owner: s.head}},
+		count: snap.count,
+	}
+	it.Next()
+	return it
+}
+
+// advance 移动到快照中的下一个节点，没有更多节点时返回false
+// 先遍历登记在已到达节点之下的被摘除节点，再沿chain的第0层指针前进；快照之后插入的节点不输出，
+// 但登记在它们之下的被摘除节点仍要遍历
+func (it *SkipListSnapshotIterator) advance() bool {
+	it.s.snapMu.Lock()
+	defer it.s.snapMu.Unlock()
+
+	for {
+		var x *SkipNode
+		if n := len(it.stack); n > 0 {
+			f := &it.stack[n-1]
+			list := it.snap.removed[f.owner]
+			if f.idx == len(list) {
+				it.stack = it.stack[:n-1]
+				continue
+			}
+			x = list[f.idx]
+			f.idx++
+		} else {
+			// chain之下登记的节点都已遍历，此时读取它的后继与登记是互斥的
+			if x = it.chain.next(0); x == nil {
+				return false
+			}
+			it.chain = x
+		}
+		it.stack = append(it.stack, skipSnapshotFrame{owner: x})
+
+		if x.epoch < it.snap.epoch {
+			it.node = x
+			if v, ok := it.snap.values[x]; ok {
+				it.value = v
+			} else {
+				it.value = x.value.Load()
+			}
+			return true
+		}
+	}
+}
+
+// Valid 迭代器是否指向有效的键值对
+func (it *SkipListSnapshotIterator) Valid() bool {
+	return it.node != nil
+}
+
+// Next 移动到下一个键值对
+func (it *SkipListSnapshotIterator) Next() {
+	if it.snap == nil {
+		return
+	}
+	if !it.advance() {
+		it.Close()
+	}
+}
+
+// Key 返回当前键
+func (it *SkipListSnapshotIterator) Key() any {
+	if !it.Valid() {
+		return nil
+	}
+	return it.node.key
+}
+
+// Value 返回当前键在快照时刻的值
+func (it *SkipListSnapshotIterator) Value() any {
+	if !it.Valid() {
+		return nil
+	}
+	return *it.value
+}
+
+// Len 返回快照中的键值对数量
+func (it *SkipListSnapshotIterator) Len() int {
+	return int(it.count)
+}
+
+// Close 释放快照，之后写入者不再为它登记，迭代器变为无效，可重复调用
+func (it *SkipListSnapshotIterator) Close() {
+	it.node, it.value, it.stack = nil, nil, nil
+	if it.snap == nil {
+		return
+	}
+	it.s.mu.Lock()
+	delete(it.s.snapshots, it.snap)
+	it.s.mu.Unlock()
+	it.snap = nil
+}
@@ -0,0 +1,119 @@
+package datastructures
+
+import (
+	"math"
+	"reflect"
+	"sync/atomic"
+	"unsafe"
+)
+
+// statsSampleSize 统计平均查找路径时采样的键数量
+const statsSampleSize = 256
+
+// SkipListStats 跳表的层级分布统计，用于调整maxLevel和prob
+type SkipListStats struct {
+	Count              int64   // 元素数量
+	Level              int     // 当前最大层数
+	MaxLevel           int     // 配置的最大层数
+	Prob               float64 // 配置的升层概率
+	NodesPerLevel      []int64 // NodesPerLevel[i]为拥有第i层指针的节点数
+	AvgSearchPath      float64 // 采样得到的平均查找路径长度（经过的指针数）
+	ExpectedSearchPath float64 // 理论期望查找路径长度 log_{1/p}(n)/p
+	EstimatedBytes     int64   // 节点结构占用的内存估算（字节），不含键值本身引用的数据
+}
+
+// Stats 返回跳表的层级分布统计
+// 遍历全部节点统计层级分布，并均匀采样最多256个键测量实际查找路径长度
+func (s *SkipList) Stats() SkipListStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := SkipListStats{
+		Count:         s.count,
+		Level:         s.level,
+		MaxLevel:      s.maxLevel,
+		Prob:          s.prob,
+		NodesPerLevel: make([]int64, s.level),
+	}
+
+	stats.EstimatedBytes = skipNodeBytes(s.head)
+
+	step := max(s.count/statsSampleSize, 1)
+	var sampled, pathTotal int64
+	var i int64
+	for x := s.head.next(0); x != nil; x = x.next(0) {
+		for l := 0; l < x.height; l++ {
+			stats.NodesPerLevel[l]++
+		}
+		stats.EstimatedBytes += skipNodeBytes(x)
+
+		if i%step == 0 && sampled < statsSampleSize {
+			pathTotal += int64(s.searchPathLen(x.key))
+			sampled++
+		}
+		i++
+	}
+
+	if sampled > 0 {
+		stats.AvgSearchPath = float64(pathTotal) / float64(sampled)
+	}
+	if s.count > 1 {
+		stats.ExpectedSearchPath = math.Log(float64(s.count)) / math.Log(1/s.prob) / s.prob
+	}
+
+	return stats
+}
+
+// searchPathLen 返回查找key时经过的指针数（水平前进和向下一层各计一次）
+// 调用方必须持有锁
+func (s *SkipList) searchPathLen(key any) int {
+	steps := 0
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next(i) != nil && s.comparator(x.next(i).key, key) < 0 {
+			x = x.next(i)
+			steps++
+		}
+		steps++
+	}
+	return steps
+}
+
+// skipNodeBytes 估算节点结构自身占用的字节数：节点、前向指针数组、跨度数组和值的装箱
+func skipNodeBytes(x *SkipNode) int64 {
+	const nodeSize = int64(unsafe.Sizeof(SkipNode{}))
+	const slotSize = int64(unsafe.Sizeof(atomic.Pointer[SkipNode]{}) + unsafe.Sizeof(int64(0)))
+	const valueBoxSize = int64(unsafe.Sizeof(any(nil)))
+	return nodeSize + int64(x.height)*slotSize + valueBoxSize
+}
+
+// MemoryUsage 估算跳表占用的总字节数
+// 包括节点、前向指针数组、跨度数组，以及接口中装箱的键和值。
+// 键值大小按常见类型估算（字符串和字节切片计入内容长度），
+// 其他类型按其静态大小计算，不跟踪内部指针引用的数据。
+func (s *SkipList) MemoryUsage() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	total := int64(unsafe.Sizeof(SkipList{})) + skipNodeBytes(s.head)
+	for x := s.head.next(0); x != nil; x = x.next(0) {
+		total += skipNodeBytes(x) + boxedSize(x.key) + boxedSize(x.loadValue())
+	}
+	return total
+}
+
+// boxedSize 估算接口值装箱数据占用的字节数（不含接口头本身）
+func boxedSize(v any) int64 {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case string:
+		return int64(unsafe.Sizeof(v)) + int64(len(v))
+	case []byte:
+		return int64(unsafe.Sizeof(v)) + int64(cap(v))
+	case bool, int8, uint8:
+		return 0 // 单字节值使用运行时的静态表，不分配
+	default:
+		return int64(reflect.TypeOf(v).Size())
+	}
+}
@@ -0,0 +1,1202 @@
+package datastructures
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+)
+
+// TestSkipListGeneric 测试泛型跳表的基本操作
+func TestSkipListGeneric(t *testing.T) {
+	sl := NewOrderedSkipList[int, string]()
+
+	keys := []int{5, 3, 7, 1, 9, 4, 6, 8, 2}
+	for _, k := range keys {
+		sl.Insert(k, fmt.Sprintf("value%d", k))
+	}
+	sl.Insert(5, "newValue5")
+
+	if sl.Size() != int64(len(keys)) {
+		t.Errorf("Size() = %d, 期望 %d", sl.Size(), len(keys))
+	}
+	if v, ok := sl.Search(5); !ok || v != "newValue5" {
+		t.Errorf("Search(5) = %q, %v, 期望 newValue5, true", v, ok)
+	}
+	if _, ok := sl.Search(100); ok {
+		t.Error("Search(100) 应该返回 false")
+	}
+
+	result, err := sl.RangeQuery(3, 7)
+	if err != nil {
+		t.Fatalf("RangeQuery() 错误 = %v", err)
+	}
+	if len(result) != 4 || result[0].Key != 3 || result[3].Key != 6 {
+		t.Errorf("RangeQuery(3, 7) = %v", result)
+	}
+	if _, err := sl.RangeQuery(7, 3); err == nil {
+		t.Error("start >= end 应该返回错误")
+	}
+
+	if !sl.Delete(1) || sl.Delete(1) {
+		t.Error("Delete() 返回值不正确")
+	}
+	all := sl.ScanAll()
+	for i := 1; i < len(all); i++ {
+		if all[i-1].Key >= all[i].Key {
+			t.Fatalf("ScanAll() 结果无序: %v", all)
+		}
+	}
+	if len(all) != len(keys)-1 {
+		t.Errorf("ScanAll() 返回 %d 个元素, 期望 %d", len(all), len(keys)-1)
+	}
+}
+
+// TestSkipListLockFree 测试无锁跳表的基本操作
+func TestSkipListLockFree(t *testing.T) {
+	sl := NewDefaultLockFreeSkipList(intComparator)
+
+	for _, k := range []int{5, 3, 7, 1, 9} {
+		if err := sl.Insert(k, k*10); err != nil {
+			t.Fatalf("Insert(%d) 错误 = %v", k, err)
+		}
+	}
+	sl.Insert(5, 500)
+
+	if v, ok := sl.Search(5); !ok || v != 500 {
+		t.Errorf("Search(5) = %v, %v, 期望 500, true", v, ok)
+	}
+	if sl.Contains(4) {
+		t.Error("Contains(4) 应该返回 false")
+	}
+	if sl.Insert(nil, 1) == nil {
+		t.Error("插入 nil 键应该返回错误")
+	}
+	if !sl.Delete(3) || sl.Delete(3) {
+		t.Error("Delete(3) 返回值不正确")
+	}
+
+	result, _ := sl.RangeQuery(1, 8)
+	if len(result) != 3 || result[0].Key != 1 || result[2].Key != 7 {
+		t.Errorf("RangeQuery(1, 8) = %v", result)
+	}
+	if sl.Size() != 4 {
+		t.Errorf("Size() = %d, 期望 4", sl.Size())
+	}
+}
+
+// TestSkipListLockFreeConcurrent 并发压力测试，建议配合 -race 运行
+func TestSkipListLockFreeConcurrent(t *testing.T) {
+	sl := NewDefaultLockFreeSkipList(intComparator)
+	const workers = 8
+	const perWorker = 2000
+
+	var wg sync.WaitGroup
+
+	// 每个写入者负责独立的键区间：全部插入，再删除其中的偶数键
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(base int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				sl.Insert(base+i, base+i)
+			}
+			for i := 0; i < perWorker; i += 2 {
+				if !sl.Delete(base + i) {
+					t.Errorf("Delete(%d) 应该返回 true", base+i)
+				}
+			}
+		}(w * perWorker)
+	}
+
+	// 所有写入者在共享的小区间上竞争
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			for i := 0; i < perWorker; i++ {
+				key := -1 - r.Intn(64)
+				switch r.Intn(3) {
+				case 0:
+					sl.Insert(key, i)
+				case 1:
+					sl.Delete(key)
+				default:
+					sl.Contains(key)
+				}
+			}
+		}(int64(w))
+	}
+	wg.Wait()
+
+	for k := 0; k < workers*perWorker; k++ {
+		if sl.Contains(k) != (k%2 == 1) {
+			t.Fatalf("Contains(%d) = %v", k, sl.Contains(k))
+		}
+	}
+
+	all := sl.ScanAll()
+	for i := 1; i < len(all); i++ {
+		if intComparator(all[i-1].Key, all[i].Key) >= 0 {
+			t.Fatalf("ScanAll() 结果无序或重复: %v, %v", all[i-1].Key, all[i].Key)
+		}
+	}
+	if int64(len(all)) != sl.Size() {
+		t.Errorf("ScanAll() 返回 %d 个元素, Size() = %d", len(all), sl.Size())
+	}
+}
+
+// TestSkipListSeed 测试固定种子时跳表结构可复现
+func TestSkipListSeed(t *testing.T) {
+	build := func(seed int64) string {
+		sl := NewDefaultSkipList(intComparator, WithSkipListSeed(seed))
+		for i := 0; i < 200; i++ {
+			sl.Insert(i, i)
+		}
+		return dumpSkipList(sl)
+	}
+
+	if build(42) != build(42) {
+		t.Error("相同种子应该生成相同的跳表结构")
+	}
+	if build(1) == build(2) {
+		t.Error("不同种子生成了完全相同的跳表结构")
+	}
+}
+
+// TestSkipListIterator 测试跳表游标的遍历和定位
+func TestSkipListIterator(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator)
+	for i := 0; i < 100; i += 2 {
+		sl.Insert(i, i*10)
+	}
+
+	var keys []int
+	for it := sl.Iterator(); it.Valid(); it.Next() {
+		if it.Value() != it.Key().(int)*10 {
+			t.Fatalf("Key %v 的 Value = %v", it.Key(), it.Value())
+		}
+		keys = append(keys, it.Key().(int))
+	}
+	if len(keys) != 50 || keys[0] != 0 || keys[49] != 98 {
+		t.Errorf("完整遍历得到 %d 个键", len(keys))
+	}
+
+	// 分页：每页3个，从上一页最后一个键之后继续
+	it := sl.Iterator()
+	it.Seek(31)
+	if !it.Valid() || it.Key() != 32 {
+		t.Fatalf("Seek(31) 定位到 %v, 期望 32", it.Key())
+	}
+	var page []any
+	for ; it.Valid() && len(page) < 3; it.Next() {
+		page = append(page, it.Key())
+	}
+	it.Seek(page[len(page)-1].(int) + 1)
+	if it.Key() != 38 {
+		t.Errorf("恢复扫描定位到 %v, 期望 38", it.Key())
+	}
+
+	// 遍历期间删除当前节点，游标仍能继续前进
+	it.Seek(50)
+	sl.Delete(50)
+	it.Next()
+	if it.Key() != 52 {
+		t.Errorf("删除当前节点后 Next() 得到 %v, 期望 52", it.Key())
+	}
+
+	it.Seek(1000)
+	if it.Valid() {
+		t.Error("Seek 超过最大键后游标应该无效")
+	}
+	it.Next()
+	it.SeekToFirst()
+	if !it.Valid() || it.Key() != 0 {
+		t.Errorf("SeekToFirst() 定位到 %v", it.Key())
+	}
+}
+
+// TestSkipListBackward 测试跳表的降序范围查询和反向遍历
+func TestSkipListBackward(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator, WithSkipListSeed(7))
+	perm := rand.New(rand.NewSource(7)).Perm(100)
+	for _, k := range perm {
+		sl.Insert(k, k)
+	}
+	for k := 0; k < 100; k += 3 {
+		sl.Delete(k)
+	}
+
+	result, err := sl.RangeQueryDesc(10, 20)
+	if err != nil {
+		t.Fatalf("RangeQueryDesc() 错误 = %v", err)
+	}
+	expected := []int{19, 17, 16, 14, 13, 11, 10}
+	if len(result) != len(expected) {
+		t.Fatalf("RangeQueryDesc(10, 20) = %v, 期望 %v", result, expected)
+	}
+	for i, kv := range result {
+		if kv.Key != expected[i] {
+			t.Fatalf("RangeQueryDesc(10, 20) = %v, 期望 %v", result, expected)
+		}
+	}
+	if result, _ := sl.RangeQueryDesc(-10, 0); len(result) != 0 {
+		t.Errorf("RangeQueryDesc(-10, 0) = %v, 期望空", result)
+	}
+	if _, err := sl.RangeQueryDesc(5, 5); err == nil {
+		t.Error("start >= end 应该返回错误")
+	}
+
+	// 反向完整遍历应该与正向遍历互为逆序
+	forward := sl.ScanAll()
+	it := sl.Iterator()
+	i := len(forward) - 1
+	for it.SeekToLast(); it.Valid(); it.Prev() {
+		if i < 0 || it.Key() != forward[i].Key {
+			t.Fatalf("反向遍历在位置 %d 得到 %v", i, it.Key())
+		}
+		i--
+	}
+	if i != -1 {
+		t.Errorf("反向遍历少了 %d 个元素", i+1)
+	}
+
+	it.SeekForPrev(30)
+	if it.Key() != 29 {
+		t.Errorf("SeekForPrev(30) 定位到 %v, 期望 29", it.Key())
+	}
+	it.Prev()
+	it.Next()
+	if it.Key() != 29 {
+		t.Errorf("Prev() 后 Next() 定位到 %v, 期望 29", it.Key())
+	}
+	it.SeekForPrev(0)
+	if it.Valid() {
+		t.Error("SeekForPrev 小于最小键时游标应该无效")
+	}
+
+	// 删除最大键后尾节点应该前移
+	sl.Delete(98)
+	sl.Delete(99)
+	it.SeekToLast()
+	if it.Key() != 97 {
+		t.Errorf("删除尾部后 SeekToLast() 定位到 %v, 期望 97", it.Key())
+	}
+}
+
+// TestSkipListRank 测试跳表的排名查询
+func TestSkipListRank(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator, WithSkipListSeed(3))
+	r := rand.New(rand.NewSource(3))
+	present := make(map[int]bool)
+
+	check := func() {
+		t.Helper()
+		all := sl.ScanAll()
+		for i, kv := range all {
+			if rank, ok := sl.Rank(kv.Key); !ok || rank != int64(i) {
+				t.Fatalf("Rank(%v) = %d, %v, 期望 %d", kv.Key, rank, ok, i)
+			}
+			if got, ok := sl.GetByRank(int64(i)); !ok || got.Key != kv.Key {
+				t.Fatalf("GetByRank(%d) = %v, %v, 期望 %v", i, got.Key, ok, kv.Key)
+			}
+		}
+		if _, ok := sl.GetByRank(int64(len(all))); ok {
+			t.Fatal("GetByRank 越界应该返回 false")
+		}
+	}
+
+	for round := 0; round < 20; round++ {
+		for i := 0; i < 50; i++ {
+			k := r.Intn(200)
+			if r.Intn(3) == 0 {
+				sl.Delete(k)
+				delete(present, k)
+			} else {
+				sl.Insert(k, k)
+				present[k] = true
+			}
+		}
+		check()
+	}
+
+	for k := 0; k < 200; k++ {
+		if _, ok := sl.Rank(k); ok != present[k] {
+			t.Errorf("Rank(%d) 存在性 = %v, 期望 %v", k, ok, present[k])
+		}
+	}
+	if _, ok := sl.GetByRank(-1); ok {
+		t.Error("GetByRank(-1) 应该返回 false")
+	}
+}
+
+// TestSkipListFloorCeiling 测试跳表的Floor和Ceiling查询
+func TestSkipListFloorCeiling(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator)
+	for _, k := range []int{10, 20, 30, 40} {
+		sl.Insert(k, k*10)
+	}
+
+	tests := []struct {
+		key         int
+		floor, ceil any
+		hasFloor    bool
+		hasCeiling  bool
+	}{
+		{5, nil, 10, false, true},
+		{10, 10, 10, true, true},
+		{15, 10, 20, true, true},
+		{40, 40, 40, true, true},
+		{45, 40, nil, true, false},
+	}
+
+	for _, tt := range tests {
+		kv, ok := sl.Floor(tt.key)
+		if ok != tt.hasFloor || (ok && kv.Key != tt.floor) {
+			t.Errorf("Floor(%d) = %v, %v, 期望 %v, %v", tt.key, kv.Key, ok, tt.floor, tt.hasFloor)
+		}
+		if ok && kv.Value != kv.Key.(int)*10 {
+			t.Errorf("Floor(%d) 的值 = %v", tt.key, kv.Value)
+		}
+		kv, ok = sl.Ceiling(tt.key)
+		if ok != tt.hasCeiling || (ok && kv.Key != tt.ceil) {
+			t.Errorf("Ceiling(%d) = %v, %v, 期望 %v, %v", tt.key, kv.Key, ok, tt.ceil, tt.hasCeiling)
+		}
+	}
+
+	if _, ok := NewDefaultSkipList(intComparator).Floor(1); ok {
+		t.Error("空跳表的 Floor() 应该返回 false")
+	}
+}
+
+// TestSkipListRangeLimit 测试跳表的流式和限量范围查询
+func TestSkipListRangeLimit(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator)
+	for i := 0; i < 1000; i++ {
+		sl.Insert(i, i)
+	}
+
+	result, err := sl.RangeQueryLimit(100, 900, 50)
+	if err != nil {
+		t.Fatalf("RangeQueryLimit() 错误 = %v", err)
+	}
+	if len(result) != 50 || result[0].Key != 100 || result[49].Key != 149 {
+		t.Errorf("RangeQueryLimit(100, 900, 50) 返回 %d 个元素", len(result))
+	}
+	if result, _ := sl.RangeQueryLimit(990, 2000, 50); len(result) != 10 {
+		t.Errorf("RangeQueryLimit(990, 2000, 50) 返回 %d 个元素, 期望 10", len(result))
+	}
+	if result, _ := sl.RangeQueryLimit(0, 1000, 0); len(result) != 1000 {
+		t.Errorf("limit=0 应该不限制数量, 返回 %d 个元素", len(result))
+	}
+
+	sum, calls := 0, 0
+	err = sl.RangeFunc(10, 20, func(key, value any) bool {
+		calls++
+		sum += value.(int)
+		return key.(int) < 14
+	})
+	if err != nil {
+		t.Fatalf("RangeFunc() 错误 = %v", err)
+	}
+	if calls != 5 || sum != 10+11+12+13+14 {
+		t.Errorf("RangeFunc 提前停止失败: calls=%d, sum=%d", calls, sum)
+	}
+	if err := sl.RangeFunc(20, 10, func(any, any) bool { return true }); err == nil {
+		t.Error("start >= end 应该返回错误")
+	}
+}
+
+// TestSkipListInsertBatch 测试跳表的批量插入
+func TestSkipListInsertBatch(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator, WithSkipListSeed(11))
+	for i := 0; i < 100; i += 10 {
+		sl.Insert(i, -1)
+	}
+
+	r := rand.New(rand.NewSource(11))
+	batch := make([]KeyValue, 0, 300)
+	for _, k := range r.Perm(150) {
+		batch = append(batch, KeyValue{Key: k, Value: k})
+	}
+	batch = append(batch, KeyValue{Key: 5, Value: "first"}, KeyValue{Key: 5, Value: "last"})
+
+	if err := sl.InsertBatch(batch); err != nil {
+		t.Fatalf("InsertBatch() 错误 = %v", err)
+	}
+	if sl.Size() != 150 {
+		t.Errorf("Size() = %d, 期望 150", sl.Size())
+	}
+	if v, _ := sl.Search(5); v != "last" {
+		t.Errorf("重复键应该以最后一个为准, Search(5) = %v", v)
+	}
+	if v, _ := sl.Search(20); v != 20 {
+		t.Errorf("已存在的键应该被更新, Search(20) = %v", v)
+	}
+
+	all := sl.ScanAll()
+	for i, kv := range all {
+		if kv.Key != i {
+			t.Fatalf("ScanAll()[%d] = %v", i, kv.Key)
+		}
+		if rank, ok := sl.Rank(i); !ok || rank != int64(i) {
+			t.Fatalf("Rank(%d) = %d, %v", i, rank, ok)
+		}
+	}
+
+	if err := sl.InsertBatch([]KeyValue{{Key: 1000, Value: 1}, {Key: nil}}); err == nil {
+		t.Error("包含 nil 键的批量插入应该返回错误")
+	}
+	if _, ok := sl.Search(1000); ok {
+		t.Error("失败的批量插入不应该插入任何数据")
+	}
+}
+
+// TestSkipListSerialization 测试跳表的序列化和恢复
+func TestSkipListSerialization(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator, WithSkipListCodec(IntCodec{}, StringCodec{}))
+	for i := 0; i < 500; i++ {
+		sl.Insert(i*3, fmt.Sprintf("value%d", i))
+	}
+
+	var buf bytes.Buffer
+	n, err := sl.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo() 错误 = %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo() 返回 %d 字节, 实际写入 %d", n, buf.Len())
+	}
+	data := buf.Bytes()
+
+	restored := NewDefaultSkipList(intComparator, WithSkipListCodec(IntCodec{}, StringCodec{}))
+	restored.Insert(-1, "stale")
+	if _, err := restored.ReadFrom(bytes.NewReader(data)); err != nil {
+		t.Fatalf("ReadFrom() 错误 = %v", err)
+	}
+	if restored.Size() != 500 {
+		t.Errorf("恢复后 Size() = %d, 期望 500", restored.Size())
+	}
+	if _, ok := restored.Search(-1); ok {
+		t.Error("ReadFrom() 应该替换原有内容")
+	}
+	for i := 0; i < 500; i++ {
+		if v, ok := restored.Search(i * 3); !ok || v != fmt.Sprintf("value%d", i) {
+			t.Fatalf("Search(%d) = %v, %v", i*3, v, ok)
+		}
+		if rank, _ := restored.Rank(i * 3); rank != int64(i) {
+			t.Fatalf("Rank(%d) = %d, 期望 %d", i*3, rank, i)
+		}
+	}
+	if kv, ok := restored.Floor(1000000); !ok || kv.Key != 499*3 {
+		t.Errorf("恢复后尾节点不正确: %v", kv.Key)
+	}
+
+	// 截断的数据应该返回错误，且不修改原有内容
+	if _, err := restored.ReadFrom(bytes.NewReader(data[:len(data)/2])); err == nil {
+		t.Error("截断的数据应该返回错误")
+	}
+	if restored.Size() != 500 {
+		t.Errorf("读取失败后 Size() = %d, 期望 500", restored.Size())
+	}
+	if _, err := restored.ReadFrom(bytes.NewReader([]byte("garbage"))); err == nil {
+		t.Error("错误的文件头应该返回错误")
+	}
+
+	if _, err := NewDefaultSkipList(intComparator).WriteTo(&buf); err == nil {
+		t.Error("未设置编解码器时 WriteTo() 应该返回错误")
+	}
+	bad := NewDefaultSkipList(intComparator, WithSkipListCodec(IntCodec{}, IntCodec{}))
+	bad.Insert(1, "not an int")
+	if _, err := bad.WriteTo(&buf); err == nil {
+		t.Error("值类型与编解码器不匹配时应该返回错误")
+	}
+}
+
+// TestSkipListArena 测试arena模式下跳表的正确性
+func TestSkipListArena(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator, WithSkipListArena(64), WithSkipListSeed(5),
+		WithSkipListCodec(IntCodec{}, IntCodec{}))
+	r := rand.New(rand.NewSource(5))
+	expected := make(map[int]int)
+
+	for i := 0; i < 5000; i++ {
+		k := r.Intn(2000)
+		if r.Intn(4) == 0 {
+			sl.Delete(k)
+			delete(expected, k)
+		} else {
+			sl.Insert(k, i)
+			expected[k] = i
+		}
+	}
+
+	if sl.Size() != int64(len(expected)) {
+		t.Fatalf("Size() = %d, 期望 %d", sl.Size(), len(expected))
+	}
+	for k, v := range expected {
+		if got, ok := sl.Search(k); !ok || got != v {
+			t.Fatalf("Search(%d) = %v, %v, 期望 %d", k, got, ok, v)
+		}
+	}
+
+	// ReadFrom会整体回收arena，恢复后的数据应该完整
+	var buf bytes.Buffer
+	if _, err := sl.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() 错误 = %v", err)
+	}
+	if _, err := sl.ReadFrom(&buf); err != nil {
+		t.Fatalf("ReadFrom() 错误 = %v", err)
+	}
+	for k, v := range expected {
+		if got, ok := sl.Search(k); !ok || got != v {
+			t.Fatalf("ReadFrom 后 Search(%d) = %v, %v, 期望 %d", k, got, ok, v)
+		}
+	}
+}
+
+// TestSkipListStats 测试跳表的层级分布统计
+func TestSkipListStats(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator, WithSkipListSeed(9))
+	for i := 0; i < 10000; i++ {
+		sl.Insert(i, i)
+	}
+
+	stats := sl.Stats()
+	if stats.Count != 10000 || stats.Level != sl.Level() || stats.MaxLevel != 16 {
+		t.Errorf("Stats() 基本信息不正确: %+v", stats)
+	}
+	if stats.NodesPerLevel[0] != 10000 {
+		t.Errorf("第0层节点数 = %d, 期望 10000", stats.NodesPerLevel[0])
+	}
+	for i := 1; i < len(stats.NodesPerLevel); i++ {
+		if stats.NodesPerLevel[i] > stats.NodesPerLevel[i-1] {
+			t.Fatalf("第%d层节点数多于第%d层: %v", i, i-1, stats.NodesPerLevel)
+		}
+	}
+	// prob=0.5时第1层约有一半节点
+	if ratio := float64(stats.NodesPerLevel[1]) / 10000; ratio < 0.45 || ratio > 0.55 {
+		t.Errorf("第1层节点比例 = %.3f, 期望约 0.5", ratio)
+	}
+	if stats.AvgSearchPath <= 0 || stats.AvgSearchPath > 3*stats.ExpectedSearchPath {
+		t.Errorf("平均查找路径 = %.1f, 理论值 = %.1f", stats.AvgSearchPath, stats.ExpectedSearchPath)
+	}
+	if stats.EstimatedBytes <= 10000*int64(unsafe.Sizeof(SkipNode{})) {
+		t.Errorf("内存估算 = %d 字节, 过小", stats.EstimatedBytes)
+	}
+
+	if empty := NewDefaultSkipList(intComparator).Stats(); empty.Count != 0 || empty.AvgSearchPath != 0 {
+		t.Errorf("空跳表的 Stats() = %+v", empty)
+	}
+}
+
+// TestSkipListConcurrentReaders 测试写入者持锁修改时无锁读路径的正确性（配合-race运行）
+func TestSkipListConcurrentReaders(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator)
+	if got := sl.Describe().Concurrency; got != ConcurrencyLockFreeReads {
+		t.Errorf("Describe().Concurrency = %q, 期望 %q", got, ConcurrencyLockFreeReads)
+	}
+	const n = 2000
+	// 偶数键始终存在，奇数键被反复插入和删除
+	for i := 0; i < n; i += 2 {
+		sl.Insert(i, i)
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for w := 0; w < 2; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				k := r.Intn(n/2)*2 + 1
+				if r.Intn(2) == 0 {
+					sl.Insert(k, k)
+				} else {
+					sl.Delete(k)
+				}
+				sl.Insert(k-1, k-1) // 更新已有偶数键的值
+			}
+		}(int64(w))
+	}
+
+	errs := make(chan string, 4)
+	var readers sync.WaitGroup
+	for rdr := 0; rdr < 4; rdr++ {
+		readers.Add(1)
+		go func(seed int64) {
+			defer readers.Done()
+			r := rand.New(rand.NewSource(seed))
+			// 一半的读者通过各自的finger读取
+			search, rangeFunc := sl.Search, sl.RangeFunc
+			if seed%2 == 1 {
+				f := sl.NewFinger()
+				search, rangeFunc = f.Search, f.RangeFunc
+			}
+			for i := 0; i < 2000; i++ {
+				k := r.Intn(n/2) * 2
+				if v, ok := search(k); !ok || v != k {
+					errs <- fmt.Sprintf("Search(%d) = %v, %v", k, v, ok)
+					return
+				}
+				prev := -1
+				evens := 0
+				rangeFunc(k, k+40, func(key, value any) bool {
+					if key.(int) <= prev {
+						errs <- fmt.Sprintf("RangeFunc 结果无序: %d 在 %d 之后", key, prev)
+					}
+					if key.(int)%2 == 0 {
+						evens++
+					}
+					prev = key.(int)
+					return true
+				})
+				if want := min(20, (n-k)/2); evens != want {
+					errs <- fmt.Sprintf("RangeFunc(%d, %d) 得到 %d 个偶数键, 期望 %d", k, k+40, evens, want)
+					return
+				}
+			}
+		}(int64(rdr))
+	}
+
+	readers.Wait()
+	close(stop)
+	wg.Wait()
+	close(errs)
+	for e := range errs {
+		t.Error(e)
+	}
+}
+
+// TestSkipListSnapshotIter 测试跳表快照迭代器的一致性
+func TestSkipListSnapshotIter(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator)
+	for i := 0; i < 100; i++ {
+		sl.Insert(i, i)
+	}
+
+	it := sl.SnapshotIter()
+	defer it.Close()
+
+	// 快照之后的修改对迭代器不可见
+	for i := 0; i < 100; i += 2 {
+		sl.Delete(i)
+	}
+	for i := 1; i < 100; i += 2 {
+		sl.Insert(i, -i)
+	}
+	sl.Insert(1000, 1000)
+
+	expected := 0
+	for ; it.Valid(); it.Next() {
+		if it.Key() != expected || it.Value() != expected {
+			t.Fatalf("快照迭代得到 %v=%v, 期望 %d=%d", it.Key(), it.Value(), expected, expected)
+		}
+		expected++
+	}
+	if expected != 100 || it.Len() != 100 {
+		t.Errorf("快照迭代了 %d 个元素, 期望 100", expected)
+	}
+
+	it.Close()
+	if it.Valid() || it.Key() != nil {
+		t.Error("Close() 后迭代器应该无效")
+	}
+}
+
+// TestSkipListSnapshotIterInterleaved 测试迭代过程中穿插的删除、插入和更新对快照不可见
+func TestSkipListSnapshotIterInterleaved(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator)
+	want := make(map[int]int)
+	for i := 0; i < 2000; i += 2 {
+		sl.Insert(i, i)
+		want[i] = i
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	it := sl.SnapshotIter()
+	defer it.Close()
+
+	// 每前进一步做若干次随机修改，被删除的节点可能正是迭代器所在的节点或它的前驱
+	prev, seen := -1, 0
+	for ; it.Valid(); it.Next() {
+		k, v := it.Key().(int), it.Value().(int)
+		if k <= prev || want[k] != v {
+			t.Fatalf("快照迭代得到 %d=%d, 上一个键 %d, 期望值 %d", k, v, prev, want[k])
+		}
+		prev = k
+		seen++
+		for j := 0; j < 4; j++ {
+			key := rng.Intn(2000)
+			switch rng.Intn(3) {
+			case 0:
+				sl.Delete(key)
+			case 1:
+				sl.Insert(key, -key)
+			default:
+				sl.Delete(key)
+				sl.Insert(key, -key-1)
+			}
+		}
+	}
+	if seen != len(want) || it.Len() != len(want) {
+		t.Errorf("快照迭代了 %d 个元素, Len() = %d, 期望 %d", seen, it.Len(), len(want))
+	}
+	if len(sl.snapshots) != 0 {
+		t.Errorf("迭代结束后仍有 %d 个活跃快照", len(sl.snapshots))
+	}
+
+	// 并发写入时迭代器同样只看到快照时刻的内容
+	sl = NewDefaultSkipList(intComparator)
+	for i := 0; i < 1000; i++ {
+		sl.Insert(i, i)
+	}
+	it = sl.SnapshotIter()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 5000; i++ {
+			key := (i * 7) % 1200
+			if i%2 == 0 {
+				sl.Delete(key)
+			} else {
+				sl.Insert(key, -key)
+			}
+		}
+	}()
+	expected := 0
+	for ; it.Valid(); it.Next() {
+		if it.Key() != expected || it.Value() != expected {
+			t.Fatalf("快照迭代得到 %v=%v, 期望 %d=%d", it.Key(), it.Value(), expected, expected)
+		}
+		expected++
+	}
+	<-done
+	if expected != 1000 {
+		t.Errorf("快照迭代了 %d 个元素, 期望 1000", expected)
+	}
+}
+
+// TestSkipListDuplicates 测试跳表的多重映射模式
+func TestSkipListDuplicates(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator, WithDuplicates())
+	for _, kv := range []KeyValue{{2, "b1"}, {1, "a"}, {2, "b2"}, {3, "c"}, {2, "b3"}} {
+		sl.Insert(kv.Key, kv.Value)
+	}
+
+	if sl.Size() != 5 {
+		t.Errorf("Size() = %d, 期望 5", sl.Size())
+	}
+	if got := fmt.Sprint(sl.SearchAll(2)); got != "[b1 b2 b3]" {
+		t.Errorf("SearchAll(2) = %s, 期望按插入顺序 [b1 b2 b3]", got)
+	}
+	if v, _ := sl.Search(2); v != "b1" {
+		t.Errorf("Search(2) = %v, 期望第一个值 b1", v)
+	}
+	if rank, _ := sl.Rank(3); rank != 4 {
+		t.Errorf("Rank(3) = %d, 期望 4", rank)
+	}
+
+	if !sl.DeleteValue(2, "b2") || sl.DeleteValue(2, "b2") {
+		t.Error("DeleteValue(2, b2) 返回值不正确")
+	}
+	if got := fmt.Sprint(sl.SearchAll(2)); got != "[b1 b3]" {
+		t.Errorf("DeleteValue 后 SearchAll(2) = %s", got)
+	}
+	if rank, _ := sl.Rank(2); rank != 1 {
+		t.Errorf("Rank(2) = %d, 期望第一个相等键的排名 1", rank)
+	}
+	if kv, _ := sl.GetByRank(2); kv.Value != "b3" {
+		t.Errorf("GetByRank(2) = %v, 期望 b3", kv.Value)
+	}
+
+	if n := sl.DeleteAll(2); n != 2 {
+		t.Errorf("DeleteAll(2) = %d, 期望 2", n)
+	}
+	if len(sl.SearchAll(2)) != 0 || sl.Size() != 2 {
+		t.Errorf("DeleteAll 后仍有剩余: %v", sl.ScanAll())
+	}
+
+	// 按值排序的多重映射，同一(键, 值)对只保存一份
+	byValue := NewDefaultSkipList(intComparator, WithValueComparator(intComparator))
+	byValue.InsertBatch([]KeyValue{{1, 30}, {1, 10}, {2, 5}, {1, 20}, {1, 10}})
+	byValue.Insert(1, 15)
+	if got := fmt.Sprint(byValue.SearchAll(1)); got != "[10 15 20 30]" {
+		t.Errorf("按值排序 SearchAll(1) = %s, 期望 [10 15 20 30]", got)
+	}
+	if !byValue.DeleteValue(1, 20) || byValue.Size() != 4 {
+		t.Errorf("DeleteValue(1, 20) 后 Size() = %d", byValue.Size())
+	}
+	all := byValue.ScanAll()
+	for i, kv := range all {
+		if got, _ := byValue.GetByRank(int64(i)); got != kv {
+			t.Fatalf("GetByRank(%d) = %v, 期望 %v", i, got, kv)
+		}
+	}
+}
+
+// TestSkipListLevelGenerator 测试可插拔的层数生成策略
+func TestSkipListLevelGenerator(t *testing.T) {
+	// 确定性序列：相同序列得到相同结构
+	build := func() *SkipList {
+		sl := NewDefaultSkipList(intComparator, WithLevelGenerator(NewSequenceLevelGenerator(1, 3, 2, 20, 0)))
+		for i := 0; i < 50; i++ {
+			sl.Insert(i, i)
+		}
+		return sl
+	}
+	a, b := build(), build()
+	if dumpSkipList(a) != dumpSkipList(b) {
+		t.Error("相同的层数序列应该生成相同的跳表结构")
+	}
+	stats := a.Stats()
+	// 序列中的20被限制为maxLevel(16)，0被限制为1
+	if stats.Level != 16 || stats.NodesPerLevel[15] != 10 || stats.NodesPerLevel[0] != 50 {
+		t.Errorf("层数分布不符合序列: level=%d, %v", stats.Level, stats.NodesPerLevel)
+	}
+
+	capped := NewDefaultSkipList(intComparator,
+		WithLevelGenerator(NewCappedLevelGenerator(NewGeometricLevelGenerator(0.9, 1), 4)))
+	for i := 0; i < 1000; i++ {
+		capped.Insert(i, i)
+	}
+	if capped.Level() != 4 {
+		t.Errorf("限制层数后 Level() = %d, 期望 4", capped.Level())
+	}
+	for i := 0; i < 1000; i++ {
+		if v, ok := capped.Search(i); !ok || v != i {
+			t.Fatalf("Search(%d) = %v, %v", i, v, ok)
+		}
+	}
+}
+
+// TestSkipListGetOrInsert 测试GetOrInsert的原子性
+func TestSkipListGetOrInsert(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator)
+	if v, loaded := sl.GetOrInsert(1, "a"); loaded || v != "a" {
+		t.Errorf("首次 GetOrInsert(1) = %v, %v, 期望 a, false", v, loaded)
+	}
+	if v, loaded := sl.GetOrInsert(1, "b"); !loaded || v != "a" {
+		t.Errorf("再次 GetOrInsert(1) = %v, %v, 期望 a, true", v, loaded)
+	}
+	if _, loaded := sl.GetOrInsert(nil, "x"); loaded || sl.Size() != 1 {
+		t.Error("nil 键不应该被插入")
+	}
+
+	// 并发去重：每个键只有一个goroutine插入成功
+	var wg sync.WaitGroup
+	var inserted atomic.Int64
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for k := 100; k < 600; k++ {
+				if _, loaded := sl.GetOrInsert(k, g); !loaded {
+					inserted.Add(1)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if inserted.Load() != 500 || sl.Size() != 501 {
+		t.Errorf("并发 GetOrInsert 插入了 %d 次, Size() = %d, 期望 500 和 501", inserted.Load(), sl.Size())
+	}
+}
+
+// dumpSkipList 返回跳表的完整结构（不截断）
+func dumpSkipList(sl *SkipList) string {
+	var sb strings.Builder
+	sl.Dump(&sb, 0)
+	return sb.String()
+}
+
+// TestSkipListDump 测试跳表调试输出的截断
+func TestSkipListDump(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator, WithLevelGenerator(NewSequenceLevelGenerator(1, 2)))
+	for i := 0; i < 100; i++ {
+		sl.Insert(i, i)
+	}
+
+	var buf bytes.Buffer
+	if err := sl.Dump(&buf, 3); err != nil {
+		t.Fatalf("Dump() 错误 = %v", err)
+	}
+	expected := "SkipList(level=2, maxLevel=16, prob=0.50, count=100):\n" +
+		"Level 1 (50 nodes): 1 -> 3 -> 5 -> ... (+47 more)\n" +
+		"Level 0 (100 nodes): 0 -> 1 -> 2 -> ... (+97 more)\n"
+	if buf.String() != expected {
+		t.Errorf("Dump(3) =\n%s\n期望\n%s", buf.String(), expected)
+	}
+
+	full := dumpSkipList(sl)
+	if !strings.Contains(full, "98 -> 99 -> nil") || strings.Contains(full, "more") {
+		t.Errorf("Dump(0) 应该输出全部节点:\n%s", full)
+	}
+	if lines := strings.Count(sl.String(), "\n"); lines != 3 || len(sl.String()) > 400 {
+		t.Errorf("String() 应该被截断, 得到 %d 行 %d 字节", lines, len(sl.String()))
+	}
+}
+
+// TestSkipListMemoryUsage 测试跳表内存估算
+func TestSkipListMemoryUsage(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator)
+	empty := sl.MemoryUsage()
+	for i := 0; i < 1000; i++ {
+		sl.Insert(i, strings.Repeat("x", 100))
+	}
+
+	usage := sl.MemoryUsage()
+	// 每个元素至少包含100字节的值内容和8字节的键
+	if usage-empty < 1000*108 {
+		t.Errorf("MemoryUsage() = %d, 增长过小", usage)
+	}
+	if usage <= sl.Stats().EstimatedBytes {
+		t.Errorf("MemoryUsage() = %d 应该大于不含键值的估算 %d", usage, sl.Stats().EstimatedBytes)
+	}
+
+	sl.InsertBatch([]KeyValue{{Key: 5000, Value: []byte("abc")}, {Key: 5001, Value: nil}})
+	if sl.MemoryUsage() <= usage {
+		t.Error("插入后 MemoryUsage() 应该增长")
+	}
+}
+
+// TestSkipListCountRange 测试跳表的区间计数
+func TestSkipListCountRange(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator)
+	for i := 0; i < 1000; i += 5 {
+		sl.Insert(i, i)
+	}
+
+	tests := []struct {
+		start, end int
+		expected   int64
+	}{
+		{0, 1000, 200},
+		{0, 5, 1},
+		{1, 5, 0},
+		{-100, 0, 0},
+		{12, 38, 5},
+		{995, 2000, 1},
+	}
+	for _, tt := range tests {
+		count, err := sl.CountRange(tt.start, tt.end)
+		if err != nil {
+			t.Fatalf("CountRange(%d, %d) 错误 = %v", tt.start, tt.end, err)
+		}
+		kvs, _ := sl.RangeQuery(tt.start, tt.end)
+		if count != tt.expected || count != int64(len(kvs)) {
+			t.Errorf("CountRange(%d, %d) = %d, 期望 %d", tt.start, tt.end, count, tt.expected)
+		}
+	}
+	if _, err := sl.CountRange(10, 10); err == nil {
+		t.Error("start >= end 应该返回错误")
+	}
+}
+
+// TestSkipListFinger 测试finger优化下插入和删除的正确性
+func TestSkipListFinger(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator, WithFinger(), WithSkipListSeed(21))
+	r := rand.New(rand.NewSource(21))
+	expected := make(map[int]int)
+
+	// 顺序追加、局部随机插入和删除交替进行
+	next := 0
+	for i := 0; i < 3000; i++ {
+		switch r.Intn(4) {
+		case 0:
+			k := r.Intn(next + 1)
+			sl.Delete(k)
+			delete(expected, k)
+		case 1:
+			k := max(0, next-r.Intn(20))
+			sl.Insert(k, i)
+			expected[k] = i
+		default:
+			sl.Insert(next, i)
+			expected[next] = i
+			next++
+		}
+		if i%500 == 0 {
+			sl.InsertBatch([]KeyValue{{Key: next + 5, Value: i}})
+			expected[next+5] = i
+		}
+	}
+
+	if sl.Size() != int64(len(expected)) {
+		t.Fatalf("Size() = %d, 期望 %d", sl.Size(), len(expected))
+	}
+	for i, kv := range sl.ScanAll() {
+		if expected[kv.Key.(int)] != kv.Value {
+			t.Fatalf("键 %v 的值 = %v, 期望 %v", kv.Key, kv.Value, expected[kv.Key.(int)])
+		}
+		if rank, ok := sl.Rank(kv.Key); !ok || rank != int64(i) {
+			t.Fatalf("Rank(%v) = %d, 期望 %d", kv.Key, rank, i)
+		}
+	}
+
+	// 读取端finger：前后跳动的查找和删除之后的查找都与Search一致
+	f := sl.NewFinger()
+	for i := 0; i < 2000; i++ {
+		k := r.Intn(next + 10)
+		if i%100 == 0 {
+			sl.Delete(k)
+			delete(expected, k)
+		}
+		v, ok := f.Search(k)
+		if want, exists := expected[k]; ok != exists || (ok && v != want) {
+			t.Fatalf("finger Search(%d) = %v, %v, 期望 %v, %v", k, v, ok, want, exists)
+		}
+	}
+	var scanned []int
+	f.RangeFunc(100, 120, func(key, value any) bool {
+		scanned = append(scanned, key.(int))
+		return true
+	})
+	var want []int
+	for k := 100; k < 120; k++ {
+		if _, ok := expected[k]; ok {
+			want = append(want, k)
+		}
+	}
+	if fmt.Sprint(scanned) != fmt.Sprint(want) {
+		t.Errorf("finger RangeFunc = %v, 期望 %v", scanned, want)
+	}
+}
+
+// TestSkipListFingerCost 测试finger查找只访问与距离相关的层：相邻操作的比较次数与表的大小无关
+func TestSkipListFingerCost(t *testing.T) {
+	var calls int
+	counting := func(a, b any) int {
+		calls++
+		return intComparator(a, b)
+	}
+	const n = 1 << 16
+	sl := NewDefaultSkipList(counting, WithFinger(), WithSkipListSeed(5))
+	for i := 0; i < n; i += 2 {
+		sl.Insert(i, i)
+	}
+
+	// 顺序追加
+	calls = 0
+	for i := n; i < n+1000; i++ {
+		sl.Insert(i, i)
+	}
+	if avg := float64(calls) / 1000; avg > 8 {
+		t.Errorf("顺序追加平均比较 %.1f 次，期望与表的大小无关", avg)
+	}
+
+	// 在缓存位置之前的相邻插入
+	calls = 0
+	for i := n/2 + 999; i > n/2; i -= 2 {
+		sl.Insert(i, i)
+	}
+	if avg := float64(calls) / 500; avg > 16 {
+		t.Errorf("逆序相邻插入平均比较 %.1f 次，期望与表的大小无关", avg)
+	}
+
+	// 读取端finger的相邻查找
+	f := sl.NewFinger()
+	f.Search(1000)
+	calls = 0
+	for i := 1000; i < 3000; i++ {
+		if _, ok := f.Search(i); !ok != (i%2 == 1) {
+			t.Fatalf("finger Search(%d) 结果错误", i)
+		}
+	}
+	if avg := float64(calls) / 2000; avg > 16 {
+		t.Errorf("finger相邻查找平均比较 %.1f 次，期望与表的大小无关", avg)
+	}
+}
+
+// TestSkipListScanFunc 测试跳表的回调式遍历
+func TestSkipListScanFunc(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator)
+	for i := 0; i < 100; i++ {
+		sl.Insert(i, i*2)
+	}
+
+	sum, visited := 0, 0
+	sl.ScanFunc(func(key, value any) bool {
+		if value != key.(int)*2 {
+			t.Fatalf("键 %v 的值 = %v", key, value)
+		}
+		sum += key.(int)
+		visited++
+		return true
+	})
+	if visited != 100 || sum != 4950 {
+		t.Errorf("ScanFunc 访问了 %d 个元素, 键之和 = %d", visited, sum)
+	}
+
+	// 提前停止，并在回调中删除元素
+	visited = 0
+	sl.ScanFunc(func(key, value any) bool {
+		sl.Delete(key)
+		visited++
+		return visited < 10
+	})
+	if visited != 10 || sl.Size() != 90 {
+		t.Errorf("提前停止后 visited = %d, Size() = %d", visited, sl.Size())
+	}
+}
+
+// TestSkipListMinMax 测试跳表的最小、最大键值对
+func TestSkipListMinMax(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator)
+	if _, ok := sl.Min(); ok {
+		t.Error("空跳表的 Min() 应该返回 false")
+	}
+	if _, ok := sl.Max(); ok {
+		t.Error("空跳表的 Max() 应该返回 false")
+	}
+
+	for _, k := range []int{50, 10, 90, 30} {
+		sl.Insert(k, k*10)
+	}
+	if kv, ok := sl.Min(); !ok || kv.Key != 10 || kv.Value != 100 {
+		t.Errorf("Min() = %v, %v, 期望 10=100", kv, ok)
+	}
+	if kv, ok := sl.Max(); !ok || kv.Key != 90 || kv.Value != 900 {
+		t.Errorf("Max() = %v, %v, 期望 90=900", kv, ok)
+	}
+
+	sl.Delete(90)
+	sl.Delete(10)
+	if kv, _ := sl.Min(); kv.Key != 30 {
+		t.Errorf("删除后 Min() = %v, 期望 30", kv.Key)
+	}
+	if kv, _ := sl.Max(); kv.Key != 50 {
+		t.Errorf("删除后 Max() = %v, 期望 50", kv.Key)
+	}
+}
+
+// TestSkipListKeys 测试跳表的只返回键的查询
+func TestSkipListKeys(t *testing.T) {
+	sl := NewDefaultSkipList(intComparator)
+	for _, k := range []int{5, 1, 9, 3, 7} {
+		sl.Insert(k, k)
+	}
+
+	if got := fmt.Sprint(sl.KeysAll()); got != "[1 3 5 7 9]" {
+		t.Errorf("KeysAll() = %s", got)
+	}
+	keys, err := sl.Keys(3, 8)
+	if err != nil || fmt.Sprint(keys) != "[3 5 7]" {
+		t.Errorf("Keys(3, 8) = %v, %v", keys, err)
+	}
+	if _, err := sl.Keys(8, 3); err == nil {
+		t.Error("start >= end 应该返回错误")
+	}
+}
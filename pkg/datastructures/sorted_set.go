@@ -0,0 +1,208 @@
+package datastructures
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+// ScoredMember 有序集合中的成员及其分数
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
+// sortedSetKey 有序集合在跳表中的复合键：先按分数排序，分数相同按成员排序
+type sortedSetKey struct {
+	score  float64
+	member string
+}
+
+// sortedSetComparator 复合键比较函数
+func sortedSetComparator(a, b any) int {
+	ka, kb := a.(sortedSetKey), b.(sortedSetKey)
+	if c := cmp.Compare(ka.score, kb.score); c != 0 {
+		return c
+	}
+	return strings.Compare(ka.member, kb.member)
+}
+
+// SortedSet 有序集合（类似Redis ZSET）
+// 特点：
+// - 跳表按(分数, 成员)排序，支持按分数和按排名的范围查询
+// - 辅助哈希表保存成员到分数的映射，按成员查分数为O(1)
+// - 排名基于跳表跨度计算，O(log n)
+// - 修改分数时先删除旧的(分数, 成员)再插入新的
+type SortedSet struct {
+	list   *SkipList          // 按(分数, 成员)排序的跳表
+	scores map[string]float64 // 成员到分数的映射
+	mu     sync.RWMutex       // 读写锁，保证跳表和哈希表的一致性
+}
+
+// NewSortedSet 创建新的有序集合
+func NewSortedSet() *SortedSet {
+	return &SortedSet{
+		list:   NewDefaultSkipList(sortedSetComparator),
+		scores: make(map[string]float64),
+	}
+}
+
+// Add 添加成员或更新已有成员的分数，返回成员是否为新添加
+func (z *SortedSet) Add(member string, score float64) (bool, error) {
+	if math.IsNaN(score) {
+		return false, fmt.Errorf("score cannot be NaN")
+	}
+
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	old, exists := z.scores[member]
+	if exists {
+		if old == score {
+			return false, nil
+		}
+		z.list.Delete(sortedSetKey{score: old, member: member})
+	}
+
+	z.scores[member] = score
+	z.list.Insert(sortedSetKey{score: score, member: member}, nil)
+	return !exists, nil
+}
+
+// IncrBy 为成员的分数加上delta，成员不存在时以delta为初始分数添加
+// 返回更新后的分数
+func (z *SortedSet) IncrBy(member string, delta float64) (float64, error) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	old, exists := z.scores[member]
+	score := old + delta
+	if math.IsNaN(score) {
+		return old, fmt.Errorf("resulting score is NaN")
+	}
+
+	if exists {
+		z.list.Delete(sortedSetKey{score: old, member: member})
+	}
+	z.scores[member] = score
+	z.list.Insert(sortedSetKey{score: score, member: member}, nil)
+	return score, nil
+}
+
+// Remove 删除成员
+func (z *SortedSet) Remove(member string) bool {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	score, ok := z.scores[member]
+	if !ok {
+		return false
+	}
+	delete(z.scores, member)
+	return z.list.Delete(sortedSetKey{score: score, member: member})
+}
+
+// Score 查询成员的分数
+func (z *SortedSet) Score(member string) (float64, bool) {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+	score, ok := z.scores[member]
+	return score, ok
+}
+
+// Rank 返回成员按分数升序的排名（从0开始）
+func (z *SortedSet) Rank(member string) (int64, bool) {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+
+	score, ok := z.scores[member]
+	if !ok {
+		return 0, false
+	}
+	return z.list.Rank(sortedSetKey{score: score, member: member})
+}
+
+// RangeByScore 返回分数在[min, max]内的成员，按分数升序排列
+func (z *SortedSet) RangeByScore(min, max float64) ([]ScoredMember, error) {
+	if math.IsNaN(min) || math.IsNaN(max) {
+		return nil, fmt.Errorf("min and max cannot be NaN")
+	}
+	if min > max {
+		return nil, fmt.Errorf("min must be less than or equal to max")
+	}
+
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+
+	var result []ScoredMember
+	it := z.list.Iterator()
+	for it.Seek(sortedSetKey{score: min}); it.Valid(); it.Next() {
+		k := it.Key().(sortedSetKey)
+		if k.score > max {
+			break
+		}
+		result = append(result, ScoredMember{Member: k.member, Score: k.score})
+	}
+
+	return result, nil
+}
+
+// RangeByRank 返回排名在[start, stop]内的成员，按分数升序排列
+// 与Redis一致，负数表示从末尾倒数，-1为最后一个成员
+func (z *SortedSet) RangeByRank(start, stop int64) []ScoredMember {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+
+	n := int64(len(z.scores))
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if stop < 0 {
+		stop = n + stop
+	}
+	stop = min(stop, n-1)
+	if start > stop {
+		return nil
+	}
+
+	first, ok := z.list.GetByRank(start)
+	if !ok {
+		return nil
+	}
+
+	result := make([]ScoredMember, 0, stop-start+1)
+	it := z.list.Iterator()
+	for it.Seek(first.Key); it.Valid() && int64(len(result)) <= stop-start; it.Next() {
+		k := it.Key().(sortedSetKey)
+		result = append(result, ScoredMember{Member: k.member, Score: k.score})
+	}
+
+	return result
+}
+
+// Card 返回成员数量
+func (z *SortedSet) Card() int64 {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+	return int64(len(z.scores))
+}
+
+// Describe 返回有序集合的能力描述
+func (z *SortedSet) Describe() Descriptor {
+	return Descriptor{
+		Name:           "SortedSet",
+		Ordered:        true,
+		SupportsDelete: true,
+		SupportsRange:  true,
+		Concurrency:    ConcurrencyRWMutex,
+		Complexity: Complexity{
+			Insert: "O(log n) 期望",
+			Search: "O(1) 按成员",
+			Delete: "O(log n) 期望",
+			Range:  "O(log n + k) 按分数或排名",
+		},
+		Notes: "跳表加哈希表，成员按(分数, 成员)排序，支持O(log n)排名查询",
+	}
+}
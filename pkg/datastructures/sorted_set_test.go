@@ -0,0 +1,81 @@
+package datastructures
+
+import (
+	"math"
+	"testing"
+)
+
+// TestSortedSet 测试有序集合的基本操作
+func TestSortedSet(t *testing.T) {
+	z := NewSortedSet()
+	for _, m := range []ScoredMember{{"alice", 30}, {"bob", 10}, {"carol", 20}, {"dave", 20}, {"eve", 50}} {
+		if added, err := z.Add(m.Member, m.Score); err != nil || !added {
+			t.Fatalf("Add(%s) = %v, %v", m.Member, added, err)
+		}
+	}
+	if added, _ := z.Add("bob", 40); added {
+		t.Error("更新已有成员时 Add() 应该返回 false")
+	}
+	if _, err := z.Add("nan", math.NaN()); err == nil {
+		t.Error("NaN 分数应该返回错误")
+	}
+
+	members := func(ms []ScoredMember) []string {
+		var names []string
+		for _, m := range ms {
+			names = append(names, m.Member)
+		}
+		return names
+	}
+	assertMembers := func(name string, got []ScoredMember, expected ...string) {
+		t.Helper()
+		names := members(got)
+		if len(names) != len(expected) {
+			t.Fatalf("%s = %v, 期望 %v", name, names, expected)
+		}
+		for i := range names {
+			if names[i] != expected[i] {
+				t.Fatalf("%s = %v, 期望 %v", name, names, expected)
+			}
+		}
+	}
+
+	// 当前分数：carol=20, dave=20, alice=30, bob=40, eve=50
+	all := z.RangeByRank(0, -1)
+	assertMembers("RangeByRank(0, -1)", all, "carol", "dave", "alice", "bob", "eve")
+	assertMembers("RangeByRank(-2, -1)", z.RangeByRank(-2, -1), "bob", "eve")
+	assertMembers("RangeByRank(1, 100)", z.RangeByRank(1, 100), "dave", "alice", "bob", "eve")
+	if r := z.RangeByRank(3, 1); len(r) != 0 {
+		t.Errorf("RangeByRank(3, 1) = %v, 期望空", r)
+	}
+
+	byScore, err := z.RangeByScore(20, 40)
+	if err != nil {
+		t.Fatalf("RangeByScore() 错误 = %v", err)
+	}
+	assertMembers("RangeByScore(20, 40)", byScore, "carol", "dave", "alice", "bob")
+	if _, err := z.RangeByScore(40, 20); err == nil {
+		t.Error("min > max 应该返回错误")
+	}
+
+	if score, err := z.IncrBy("carol", 25); err != nil || score != 45 {
+		t.Errorf("IncrBy(carol, 25) = %v, %v, 期望 45", score, err)
+	}
+	if score, _ := z.IncrBy("frank", 5); score != 5 {
+		t.Errorf("IncrBy(frank, 5) = %v, 期望 5", score)
+	}
+	if rank, ok := z.Rank("carol"); !ok || rank != 4 {
+		t.Errorf("Rank(carol) = %d, %v, 期望 4", rank, ok)
+	}
+	if score, ok := z.Score("carol"); !ok || score != 45 {
+		t.Errorf("Score(carol) = %v, %v", score, ok)
+	}
+
+	if !z.Remove("alice") || z.Remove("alice") {
+		t.Error("Remove() 返回值不正确")
+	}
+	if z.Card() != 5 {
+		t.Errorf("Card() = %d, 期望 5", z.Card())
+	}
+	assertMembers("删除后 RangeByRank(0, -1)", z.RangeByRank(0, -1), "frank", "dave", "bob", "carol", "eve")
+}